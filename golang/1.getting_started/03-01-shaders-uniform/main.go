@@ -76,50 +76,17 @@ void main()
 		0.0, 0.5, 0.0, // top
 	}
 
-	// 创建顶点数组对象(VAO)
-	var vbo, vao uint32
-	gl.GenVertexArrays(1, &vao)
-	gl.GenBuffers(1, &vbo)
-	// 首先绑定顶点数组对象，然后绑定并设置顶点缓冲区，最后配置顶点属性
-	gl.BindVertexArray(vao)
-
-	// 绑定顶点缓冲类型
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	// 把之前定义的顶点数据复制到缓冲的内存中
-	gl.BufferData(gl.ARRAY_BUFFER,
-		// float32 每个元素占用 4 字节
-		len(vertices)*4,
-		gl.Ptr(vertices),
-		// GL_STATIC_DRAW ：数据不会或几乎不会改变
-		// GL_DYNAMIC_DRAW：数据会被改变很多
-		// GL_STREAM_DRAW ：数据每次绘制时都会改变
-		gl.STATIC_DRAW,
-	)
-
-	// 设置顶点属性指针
-	gl.VertexAttribPointer(
-		// 要配置的顶点属性,layout(location = 0)
-		0,
-		// 顶点属性的大小, vec3
-		3,
-		// 数据的类型
-		gl.FLOAT,
-		// 是否希望数据被标准化,
-		// 如果设置为 true，所有数据都会被映射到 0（或 -1 用于符号数据）到 1 之间
-		false,
-		// 步长设置为3 * sizeof(float)
-		3*4,
+	// 创建 VAO 和 VBO 并设置顶点属性, 位置属性 layout(location = 0), vec3
+	triangle, err := common.NewMesh(
+		common.NewVertexLayout(
+			common.Float("aPos", 3),
+		),
+		vertices,
 		nil,
 	)
-	gl.EnableVertexAttribArray(0)
-
-	// 之后您可以取消绑定 VAO，这样其他 VAO 调用就不会意外修改此 VAO，但这种情况很少发生。修改其他
-	// 无论如何，VAO 都需要调用 glBindVertexArray，因此当不是直接需要时，我们通常不会取消绑定 VAO（或 VBO）
-	// gl.BindVertexArray(0);
-
-	// 绑定 VAO（它已经绑定，但只是为了演示）：因为我们只有一个 VAO，所以我们可以
-	// 只需在渲染相应三角形之前预先绑定它即可；这是另一种方法。
-	gl.BindVertexArray(vao)
+	if err != nil {
+		return err
+	}
 
 	for !window.ShouldClose() {
 		// 处理所有输入：查询GLFW是否按下-释放此帧相关按键并做出相应反应
@@ -139,7 +106,7 @@ void main()
 		sd.SetFloat("ourColor", 0.0, greenVal, 0.0, 1.0)
 
 		// 渲染三角形
-		gl.DrawArrays(gl.TRIANGLES, 0, 3)
+		triangle.Draw()
 
 		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
 		window.SwapBuffers()
@@ -148,8 +115,7 @@ void main()
 
 	// 可选：一旦超出其用途，就取消分配所有资源
 	// ------------------------------------------------------------------------
-	gl.DeleteVertexArrays(1, &vao)
-	gl.DeleteBuffers(1, &vbo)
+	triangle.Delete()
 	sd.Del()
 
 	return nil
@@ -85,64 +85,18 @@ void main()
 		0.0, 0.5, 0.0, 0.0, 0.0, 1.0, // top
 	}
 
-	// 创建顶点数组对象(VAO)
-	var vbo, vao uint32
-	gl.GenVertexArrays(1, &vao)
-	gl.GenBuffers(1, &vbo)
-	// 首先绑定顶点数组对象，然后绑定并设置顶点缓冲区，最后配置顶点属性
-	gl.BindVertexArray(vao)
-
-	// 绑定顶点缓冲类型
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	// 把之前定义的顶点数据复制到缓冲的内存中
-	gl.BufferData(gl.ARRAY_BUFFER,
-		// float32 每个元素占用 4 字节
-		len(vertices)*4,
-		gl.Ptr(vertices),
-		// GL_STATIC_DRAW ：数据不会或几乎不会改变
-		// GL_DYNAMIC_DRAW：数据会被改变很多
-		// GL_STREAM_DRAW ：数据每次绘制时都会改变
-		gl.STATIC_DRAW,
+	// 创建 VAO 和 VBO, 每个顶点 6 个 float, 步长和偏移由顶点布局计算
+	triangle, err := common.NewMesh(
+		common.NewVertexLayout(
+			common.Float("aPos", 3),   // 位置属性 layout (location = 0), 偏移 0
+			common.Float("aColor", 3), // 颜色属性 layout (location = 1), 偏移 3*4
+		),
+		vertices,
+		nil,
 	)
-
-	// 位置属性
-	gl.VertexAttribPointerWithOffset(
-		// layout (location = 0)
-		0,
-		// 顶点属性的大小, vec3
-		3,
-		// 数据的类型
-		gl.FLOAT,
-		// 是否希望数据被标准化,
-		// 如果设置为 true，所有数据都会被映射到 0（或 -1 用于符号数据）到 1 之间
-		false,
-		// 每次步长6
-		6*4,
-		// 0偏移的3个元素
-		0,
-	)
-	gl.EnableVertexAttribArray(0)
-	// 颜色属性
-	gl.VertexAttribPointerWithOffset(
-		// layout (location = 1)
-		1,
-		// 顶点属性的大小, vec3
-		3,
-		// 数据的类型
-		gl.FLOAT,
-		// 是否希望数据被标准化,
-		// 如果设置为 true，所有数据都会被映射到 0（或 -1 用于符号数据）到 1 之间
-		false,
-		// 每次步长6
-		6*4,
-		// 3偏移的3个元素
-		3*4,
-	)
-	gl.EnableVertexAttribArray(1)
-
-	// 之后您可以取消绑定 VAO，这样其他 VAO 调用就不会意外修改此 VAO，但这种情况很少发生。修改其他
-	// 无论如何，VAO 都需要调用 glBindVertexArray，因此当不是直接需要时，我们通常不会取消绑定 VAO（或 VBO）
-	// gl.BindVertexArray(0);
+	if err != nil {
+		return err
+	}
 
 	for !window.ShouldClose() {
 		// 处理所有输入：查询GLFW是否按下-释放此帧相关按键并做出相应反应
@@ -166,8 +120,7 @@ void main()
 		sd.SetFloat("colorSet", 0.5*sf, 0.5*cf, 0.5*sf*cf)
 
 		// 渲染三角形
-		gl.BindVertexArray(vao)
-		gl.DrawArrays(gl.TRIANGLES, 0, 3)
+		triangle.Draw()
 
 		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
 		window.SwapBuffers()
@@ -176,8 +129,7 @@ void main()
 
 	// 可选：一旦超出其用途，就取消分配所有资源
 	// ------------------------------------------------------------------------
-	gl.DeleteVertexArrays(1, &vao)
-	gl.DeleteBuffers(1, &vbo)
+	triangle.Delete()
 	sd.Del()
 
 	return nil
@@ -100,81 +100,19 @@ void main()
 		1, 2, 3, // second triangle
 	}
 
-	// 创建顶点数组对象(VAO)
-	var vbo, vao, ebo uint32
-	gl.GenVertexArrays(1, &vao)
-	gl.GenBuffers(1, &vbo)
-	gl.GenBuffers(1, &ebo)
-	// 首先绑定顶点数组对象，然后绑定并设置顶点缓冲区，最后配置顶点属性
-	gl.BindVertexArray(vao)
-
-	// 绑定顶点缓冲类型
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	// 把之前定义的顶点数据复制到缓冲的内存中
-	gl.BufferData(gl.ARRAY_BUFFER,
-		// float32 每个元素占用 4 字节
-		len(vertices)*4,
-		gl.Ptr(vertices),
-		// GL_STATIC_DRAW ：数据不会或几乎不会改变
-		// GL_DYNAMIC_DRAW：数据会被改变很多
-		// GL_STREAM_DRAW ：数据每次绘制时都会改变
-		gl.STATIC_DRAW,
+	// 创建 VAO、VBO 和 EBO, 每个顶点 8 个 float, 步长和偏移由顶点布局计算
+	quad, err := common.NewMesh(
+		common.NewVertexLayout(
+			common.Float("aPos", 3),      // layout (location = 0), 偏移 0
+			common.Float("aColor", 3),    // layout (location = 1), 偏移 3*4
+			common.Float("aTexCoord", 2), // layout (location = 2), 偏移 6*4
+		),
+		vertices,
+		indices,
 	)
-
-	// 绑定元素缓冲类型
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ebo)
-	// 把之前定义的索引数据复制到缓冲的内存中
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER,
-		// uint32 每个元素占用 4 字节
-		len(indices)*4,
-		gl.Ptr(indices),
-		// GL_STATIC_DRAW ：数据不会或几乎不会改变
-		// GL_DYNAMIC_DRAW：数据会被改变很多
-		// GL_STREAM_DRAW ：数据每次绘制时都会改变
-		gl.STATIC_DRAW,
-	)
-
-	// 位置属性
-	gl.VertexAttribPointerWithOffset(
-		// layout (location = 0)
-		0,
-		// 顶点属性的大小, vec3
-		3,
-		// 数据的类型
-		gl.FLOAT,
-		false,
-		8*4,
-		0,
-	)
-	gl.EnableVertexAttribArray(0)
-	// 颜色属性
-	gl.VertexAttribPointerWithOffset(
-		// layout (location = 1)
-		1,
-		// 顶点属性的大小, vec3
-		3,
-		// 数据的类型
-		gl.FLOAT,
-		false,
-		// 每次步长6
-		8*4,
-		// 3偏移的3个元素
-		3*4,
-	)
-	gl.EnableVertexAttribArray(1)
-	// 纹理坐标属性
-	gl.VertexAttribPointerWithOffset(
-		// layout (location = 2)
-		2,
-		// 顶点属性的大小, vec2
-		2,
-		// 数据的类型
-		gl.FLOAT,
-		false,
-		8*4,
-		6*4,
-	)
-	gl.EnableVertexAttribArray(2)
+	if err != nil {
+		return err
+	}
 
 	// 加载并创建纹理
 	var texture1, texture2 uint32
@@ -275,8 +213,7 @@ void main()
 
 		// 渲染容器
 		sd.Use()
-		gl.BindVertexArray(vao)
-		gl.DrawElementsWithOffset(gl.TRIANGLES, int32(len(indices)), gl.UNSIGNED_INT, 0)
+		quad.Draw()
 
 		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
 		window.SwapBuffers()
@@ -285,9 +222,7 @@ void main()
 
 	// 可选：一旦超出其用途，就取消分配所有资源
 	// ------------------------------------------------------------------------
-	gl.DeleteVertexArrays(1, &vao)
-	gl.DeleteBuffers(1, &vbo)
-	gl.DeleteBuffers(1, &ebo)
+	quad.Delete()
 	sd.Del()
 
 	return nil
@@ -156,56 +156,19 @@ void main()
 		{-1.3, 1.0, -1.5},
 	}
 
-	// 创建顶点数组对象(VAO)
-	var vbo, vao uint32
-	gl.GenVertexArrays(1, &vao)
-	gl.GenBuffers(1, &vbo)
-	// 首先绑定顶点数组对象，然后绑定并设置顶点缓冲区，最后配置顶点属性
-	gl.BindVertexArray(vao)
-
-	// 绑定顶点缓冲类型
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	// 把之前定义的顶点数据复制到缓冲的内存中
-	gl.BufferData(gl.ARRAY_BUFFER,
-		// float32 每个元素占用 4 字节
-		len(vertices)*4,
-		gl.Ptr(vertices),
-		// GL_STATIC_DRAW ：数据不会或几乎不会改变
-		// GL_DYNAMIC_DRAW：数据会被改变很多
-		// GL_STREAM_DRAW ：数据每次绘制时都会改变
-		gl.STATIC_DRAW,
-	)
-
-	// 位置属性
-	gl.VertexAttribPointerWithOffset(
-		0,
-		3,
-		gl.FLOAT,
-		false,
-		6*4,
-		0,
-	)
-	gl.EnableVertexAttribArray(0)
-	// 纹理坐标属性
-	gl.VertexAttribPointerWithOffset(
-		1,
-		2,
-		gl.FLOAT,
-		false,
-		6*4,
-		3*4,
-	)
-	gl.EnableVertexAttribArray(1)
-	// 每个面的索引
-	gl.VertexAttribPointerWithOffset(
-		2,
-		1,
-		gl.FLOAT,
-		false,
-		6*4,
-		5*4,
+	// 创建 VAO 和 VBO, 每个顶点 6 个 float, 步长和偏移由顶点布局计算
+	cube, err := common.NewMesh(
+		common.NewVertexLayout(
+			common.Float("aPos", 3),      // layout (location = 0), 偏移 0
+			common.Float("aTexCoord", 2), // layout (location = 1), 偏移 3*4
+			common.Float("aFace", 1),     // layout (location = 2), 偏移 5*4
+		),
+		vertices,
+		nil,
 	)
-	gl.EnableVertexAttribArray(2)
+	if err != nil {
+		return err
+	}
 
 	// 创建一个纹理数组
 	var texArray uint32
@@ -281,7 +244,6 @@ void main()
 		sd.SetMat("projection", 4, &projection[0])
 
 		// 渲染容器
-		gl.BindVertexArray(vao)
 		for i, pos := range cubePositions {
 			model := mgl32.Ident4().
 				Mul4(
@@ -300,7 +262,7 @@ void main()
 			sd.SetMat("model", 4, &model[0])
 
 			// 一次性绘制6个面的三角形
-			gl.DrawArrays(gl.TRIANGLES, 0, 36)
+			cube.Draw()
 		}
 
 		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
@@ -310,8 +272,7 @@ void main()
 
 	// 可选：一旦超出其用途，就取消分配所有资源
 	// ------------------------------------------------------------------------
-	gl.DeleteVertexArrays(1, &vao)
-	gl.DeleteBuffers(1, &vbo)
+	cube.Delete()
 	sd.Del()
 
 	return nil
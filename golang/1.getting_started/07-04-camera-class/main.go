@@ -190,20 +190,17 @@ void main()
 		{-1.3, 1.0, -1.5},
 	}
 
-	var vbo, vao uint32
-	gl.GenVertexArrays(1, &vao)
-	gl.GenBuffers(1, &vbo)
-	gl.BindVertexArray(vao)
-
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
-
-	// 位置属性
-	gl.VertexAttribPointerWithOffset(0, 3, gl.FLOAT, false, 5*4, 0)
-	gl.EnableVertexAttribArray(0)
-	// 纹理坐标属性
-	gl.VertexAttribPointerWithOffset(1, 2, gl.FLOAT, false, 5*4, 3*4)
-	gl.EnableVertexAttribArray(1)
+	cube, err := common.NewMesh(
+		common.NewVertexLayout(
+			common.Float("aPos", 3),      // 位置属性
+			common.Float("aTexCoord", 2), // 纹理坐标属性
+		),
+		vertices,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
 
 	var texture1, texture2 uint32
 	gl.GenTextures(1, &texture1)
@@ -306,7 +303,6 @@ void main()
 		view := camera.GetViewMatrix()
 		sd.SetMat("view", 4, &view[0])
 
-		gl.BindVertexArray(vao)
 		for i, v := range cubePositions {
 			angle := float32(i * 20)
 			model := mgl32.Ident4().
@@ -320,7 +316,7 @@ void main()
 					),
 				)
 			sd.SetMat("model", 4, &model[0])
-			gl.DrawArrays(gl.TRIANGLES, 0, 36)
+			cube.Draw()
 		}
 
 		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
@@ -330,8 +326,7 @@ void main()
 
 	// 可选：一旦超出其用途，就取消分配所有资源
 	// ------------------------------------------------------------------------
-	gl.DeleteVertexArrays(1, &vao)
-	gl.DeleteBuffers(1, &vbo)
+	cube.Delete()
 	sd.Del()
 
 	return nil
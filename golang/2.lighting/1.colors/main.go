@@ -149,74 +149,12 @@ void main()
 		return err
 	}
 
-	vertices := []float32{
-		-0.5, -0.5, -0.5,
-		0.5, -0.5, -0.5,
-		0.5, 0.5, -0.5,
-		0.5, 0.5, -0.5,
-		-0.5, 0.5, -0.5,
-		-0.5, -0.5, -0.5,
-
-		-0.5, -0.5, 0.5,
-		0.5, -0.5, 0.5,
-		0.5, 0.5, 0.5,
-		0.5, 0.5, 0.5,
-		-0.5, 0.5, 0.5,
-		-0.5, -0.5, 0.5,
-
-		-0.5, 0.5, 0.5,
-		-0.5, 0.5, -0.5,
-		-0.5, -0.5, -0.5,
-		-0.5, -0.5, -0.5,
-		-0.5, -0.5, 0.5,
-		-0.5, 0.5, 0.5,
-
-		0.5, 0.5, 0.5,
-		0.5, 0.5, -0.5,
-		0.5, -0.5, -0.5,
-		0.5, -0.5, -0.5,
-		0.5, -0.5, 0.5,
-		0.5, 0.5, 0.5,
-
-		-0.5, -0.5, -0.5,
-		0.5, -0.5, -0.5,
-		0.5, -0.5, 0.5,
-		0.5, -0.5, 0.5,
-		-0.5, -0.5, 0.5,
-		-0.5, -0.5, -0.5,
-
-		-0.5, 0.5, -0.5,
-		0.5, 0.5, -0.5,
-		0.5, 0.5, 0.5,
-		0.5, 0.5, 0.5,
-		-0.5, 0.5, 0.5,
-		-0.5, 0.5, -0.5,
+	// 物体和光源立方体使用同一个网格,着色器只用到了 location 0 的位置属性
+	cube, err := common.GenCube(1, 1).Upload()
+	if err != nil {
+		return err
 	}
 
-	var vbo, cubeVao uint32
-	gl.GenVertexArrays(1, &cubeVao)
-	gl.GenBuffers(1, &vbo)
-
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
-
-	gl.BindVertexArray(cubeVao)
-
-	// 位置属性
-	gl.VertexAttribPointerWithOffset(0, 3, gl.FLOAT, false, 3*4, 0)
-	gl.EnableVertexAttribArray(0)
-
-	var lightCubeVao uint32
-	gl.GenVertexArrays(1, &lightCubeVao)
-	gl.BindVertexArray(lightCubeVao)
-
-	// 我们只需要绑定到VBO（将其与glVertexAttribPointer链接），不需要填充它； VBO 的数据已经包含我们需要的所有内容（它已经绑定，但我们出于教育目的再次绑定）
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-
-	// 位置属性
-	gl.VertexAttribPointerWithOffset(0, 3, gl.FLOAT, false, 3*4, 0)
-	gl.EnableVertexAttribArray(0)
-
 	lightPos := mgl32.Vec3{1.2, 1.0, 2.0}
 
 	for !window.ShouldClose() {
@@ -266,8 +204,7 @@ void main()
 		model := mgl32.Ident4()
 		lightingShader.SetMat("model", 4, &model[0])
 
-		gl.BindVertexArray(cubeVao)
-		gl.DrawArrays(gl.TRIANGLES, 0, 36)
+		cube.Draw()
 
 		lightCubeShader.Use()
 		lightCubeShader.SetMat("projection", 4, &projection[0])
@@ -282,8 +219,7 @@ void main()
 			)
 		lightCubeShader.SetMat("model", 4, &model[0])
 
-		gl.BindVertexArray(lightCubeVao)
-		gl.DrawArrays(gl.TRIANGLES, 0, 36)
+		cube.Draw()
 
 		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
 		window.SwapBuffers()
@@ -292,9 +228,9 @@ void main()
 
 	// 可选：一旦超出其用途，就取消分配所有资源
 	// ------------------------------------------------------------------------
-	gl.DeleteVertexArrays(1, &cubeVao)
-	gl.DeleteVertexArrays(1, &lightCubeVao)
-	gl.DeleteBuffers(1, &vbo)
+	cube.Delete()
+	lightingShader.Del()
+	lightCubeShader.Del()
 
 	return nil
 }
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+
+	"opengl/common"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// https://learnopengl-cn.github.io/02%20Lighting/06%20Multiple%20lights/
+// https://learnopengl-cn.github.io/05%20Advanced%20Lighting/01%20Advanced%20Lighting/
+
+func main() {
+	runtime.LockOSThread()
+
+	err := HelloTriangle()
+	if err != nil {
+		panic(err)
+	}
+}
+
+const (
+	ScreenWidth  = 800
+	ScreenHeight = 600
+)
+
+var cubePositions = []mgl32.Vec3{
+	{0.0, 0.0, 0.0},
+	{2.0, 5.0, -15.0},
+	{-1.5, -2.2, -2.5},
+	{-3.8, -2.0, -12.3},
+	{2.4, -0.4, -3.5},
+	{-1.7, 3.0, -7.5},
+	{1.3, -2.0, -2.5},
+	{1.5, 2.0, -2.5},
+	{1.5, 0.2, -1.5},
+	{-1.3, 1.0, -1.5},
+}
+
+var pointLightColors = []mgl32.Vec3{
+	{1.0, 0.6, 0.2},
+	{0.2, 0.6, 1.0},
+	{0.3, 1.0, 0.3},
+	{1.0, 0.2, 0.6},
+}
+
+func HelloTriangle() error {
+	err := glfw.Init()
+	if err != nil {
+		return err
+	}
+
+	defer glfw.Terminate() // glfw: 终止，清除所有先前分配的 GLFW 资源
+
+	// glfw: 初始化配置,设置gl版本
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 4)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	// Mac OS X 需要如下配置
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+
+	window, err := glfw.CreateWindow(ScreenWidth, ScreenHeight, "LearnOpenGL", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	window.MakeContextCurrent()
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		// 确保视口与新窗口尺寸匹配；请注意宽度和
+		// 高度将明显大于视网膜显示器上指定的高度
+		gl.Viewport(0, 0, int32(width), int32(height))
+	})
+
+	var (
+		camera = common.NewCamera(
+			common.WithPosition(mgl32.Vec3{0, 0, 3}),
+		)
+
+		firstMouse         = true
+		lastX      float32 = ScreenWidth / 2.0
+		lastY      float32 = ScreenHeight / 2.0
+
+		deltaTime float32 = 0
+		lastFrame float32 = 0
+	)
+	// glfw：每当鼠标移动时，都会调用此回调
+	window.SetCursorPosCallback(func(w *glfw.Window, x float64, y float64) {
+		xPos := float32(x)
+		yPos := float32(y)
+
+		if firstMouse {
+			lastX = xPos
+			lastY = yPos
+			firstMouse = false
+		}
+
+		// 计算当前光标位置与上次位置的偏移量
+		xOffset := xPos - lastX
+		yOffset := lastY - yPos // 注意：y 轴是从下到上的
+		lastX = xPos
+		lastY = yPos
+
+		camera.ProcessMouseMovement(xOffset, yOffset)
+	})
+	// glfw：每当鼠标滚轮滚动时，都会调用此回调
+	window.SetScrollCallback(func(w *glfw.Window, x float64, y float64) {
+		camera.ProcessMouseScroll(float32(y))
+	})
+	// 告诉 GLFW 捕获我们的鼠标
+	window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+
+	// 加载所有 OpenGL 方法
+	err = gl.Init()
+	if err != nil {
+		return err
+	}
+
+	// 配置全局 opengl 状态
+	gl.Enable(gl.DEPTH_TEST) // 启用深度测试
+
+	lightingShader, err := common.NewPhongShader()
+	if err != nil {
+		return err
+	}
+
+	lightCubeShader, err := common.NewShader(`
+#version 440 core
+layout (location = 0) in vec3 aPos;
+
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+
+void main()
+{
+	gl_Position = projection * view * model * vec4(aPos, 1.0);
+}`, `
+#version 440 core
+out vec4 FragColor;
+
+uniform vec3 lightColor;
+
+void main()
+{
+	FragColor = vec4(lightColor, 1.0);
+}`)
+	if err != nil {
+		return err
+	}
+
+	cube, err := common.GenCube(1, 1).Upload()
+	if err != nil {
+		return err
+	}
+	// 地面的纹理重复 10 次
+	plane := common.GenPlane(30, 30, 1, 1)
+	for i, uv := range plane.UVs {
+		plane.UVs[i] = uv.Mul(10)
+	}
+	floor, err := plane.Upload()
+	if err != nil {
+		return err
+	}
+
+	loadTexture := func(name string) (*common.Texture, error) {
+		img, err := common.LoadImgRGB(name)
+		if err != nil {
+			return nil, err
+		}
+		return common.NewTexture(img, common.DefaultSampler()), nil
+	}
+	containerTex, err := loadTexture("resource/container.jpg")
+	if err != nil {
+		return err
+	}
+	wallTex, err := loadTexture("resource/wall.jpg")
+	if err != nil {
+		return err
+	}
+
+	container := common.NewPhongMaterial(
+		common.WithPhongMaps(containerTex, nil, nil),
+		common.WithSpecularColor(mgl32.Vec3{0.5, 0.5, 0.5}, 32),
+	)
+	ground := common.NewPhongMaterial(
+		common.WithPhongMaps(wallTex, nil, nil),
+		common.WithSpecularColor(mgl32.Vec3{0.3, 0.3, 0.3}, 8),
+	)
+
+	// B 切换 Phong 和 Blinn-Phong, F 开关手电筒
+	var (
+		phong      = false
+		flashlight = true
+	)
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action != glfw.Press {
+			return
+		}
+		switch key {
+		case glfw.KeyB:
+			phong = !phong
+		case glfw.KeyF:
+			flashlight = !flashlight
+		}
+		model := "Blinn-Phong"
+		if phong {
+			model = "Phong"
+		}
+		window.SetTitle(fmt.Sprintf("LearnOpenGL - %s, flashlight %v", model, flashlight))
+	})
+
+	for !window.ShouldClose() {
+		// 每帧时间逻辑
+		currentFrame := float32(glfw.GetTime())
+		deltaTime = currentFrame - lastFrame
+		lastFrame = currentFrame
+
+		if window.GetKey(glfw.KeyEscape) == glfw.Press {
+			window.SetShouldClose(true)
+		}
+		if window.GetKey(glfw.KeyW) == glfw.Press {
+			camera.ProcessKeyboard(common.ForWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyS) == glfw.Press {
+			camera.ProcessKeyboard(common.BackWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyA) == glfw.Press {
+			camera.ProcessKeyboard(common.Left, deltaTime)
+		}
+		if window.GetKey(glfw.KeyD) == glfw.Press {
+			camera.ProcessKeyboard(common.Right, deltaTime)
+		}
+
+		// 一个定向光, 四个绕圈移动的彩色点光源, 以及跟随相机的手电筒
+		lights := []common.Light{
+			common.NewDirectionalLight(mgl32.Vec3{-0.2, -1.0, -0.3},
+				common.WithLightComponents(mgl32.Vec3{0.05, 0.05, 0.05}, mgl32.Vec3{0.3, 0.3, 0.3}, mgl32.Vec3{0.4, 0.4, 0.4}),
+			),
+		}
+		for i, c := range pointLightColors {
+			a := float64(currentFrame)*0.5 + float64(i)*math.Pi/2
+			pos := mgl32.Vec3{float32(4 * math.Cos(a)), float32(1 + math.Sin(a*2)), float32(4*math.Sin(a)) - 4}
+			lights = append(lights, common.NewPointLight(pos, common.WithLightColor(c), common.WithLightRange(20)))
+		}
+		if flashlight {
+			lights = append(lights, common.NewSpotLight(camera.Position, camera.Front, common.WithSpotCone(12.5, 15)))
+		}
+
+		// 渲染: 清空屏幕为背景颜色
+		gl.ClearColor(0.05, 0.05, 0.05, 1.0)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		projection := camera.GetProjectionMatrix(float32(ScreenWidth)/float32(ScreenHeight), 0.1, 100.0)
+		view := camera.GetViewMatrix()
+
+		lightingShader.Use()
+		lightingShader.SetMat("projection", 4, &projection[0])
+		lightingShader.SetMat("view", 4, &view[0])
+		lightingShader.SetFloat("viewPos", camera.Position[0], camera.Position[1], camera.Position[2])
+		phongFlag := int32(0)
+		if phong {
+			phongFlag = 1
+		}
+		lightingShader.SetInt("phong", phongFlag)
+		err = common.BindLights(lightingShader, lights)
+		if err != nil {
+			return err
+		}
+
+		container.Bind(lightingShader, 0)
+		for i, p := range cubePositions {
+			model := mgl32.Translate3D(p[0], p[1], p[2]).
+				Mul4(mgl32.HomogRotate3D(mgl32.DegToRad(20*float32(i)), mgl32.Vec3{1.0, 0.3, 0.5}.Normalize()))
+			lightingShader.SetMat("model", 4, &model[0])
+			cube.Draw()
+		}
+
+		ground.Bind(lightingShader, 0)
+		model := mgl32.Translate3D(0, -3.5, -5)
+		lightingShader.SetMat("model", 4, &model[0])
+		floor.Draw()
+
+		// 点光源用同色的小立方体表示
+		lightCubeShader.Use()
+		lightCubeShader.SetMat("projection", 4, &projection[0])
+		lightCubeShader.SetMat("view", 4, &view[0])
+		for _, l := range lights {
+			if l.Type != common.PointLight {
+				continue
+			}
+			model := mgl32.Translate3D(l.Position[0], l.Position[1], l.Position[2]).Mul4(mgl32.Scale3D(0.2, 0.2, 0.2))
+			lightCubeShader.SetMat("model", 4, &model[0])
+			lightCubeShader.SetFloat("lightColor", l.Specular[0], l.Specular[1], l.Specular[2])
+			cube.Draw()
+		}
+
+		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+
+	// 可选：一旦超出其用途，就取消分配所有资源
+	// ------------------------------------------------------------------------
+	cube.Delete()
+	floor.Delete()
+	container.Delete()
+	ground.Delete()
+	lightingShader.Del()
+	lightCubeShader.Del()
+
+	return nil
+}
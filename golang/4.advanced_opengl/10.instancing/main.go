@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+
+	"opengl/common"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// https://learnopengl-cn.github.io/04%20Advanced%20OpenGL/10%20Instancing/
+
+func main() {
+	runtime.LockOSThread()
+
+	err := HelloTriangle()
+	if err != nil {
+		panic(err)
+	}
+}
+
+const (
+	ScreenWidth  = 800
+	ScreenHeight = 600
+
+	CubeAmount = 100000
+)
+
+func HelloTriangle() error {
+	err := glfw.Init()
+	if err != nil {
+		return err
+	}
+
+	defer glfw.Terminate() // glfw: 终止，清除所有先前分配的 GLFW 资源
+
+	// glfw: 初始化配置,设置gl版本
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 4)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	// Mac OS X 需要如下配置
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+
+	window, err := glfw.CreateWindow(ScreenWidth, ScreenHeight, "LearnOpenGL", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	window.MakeContextCurrent()
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		// 确保视口与新窗口尺寸匹配；请注意宽度和
+		// 高度将明显大于视网膜显示器上指定的高度
+		gl.Viewport(0, 0, int32(width), int32(height))
+	})
+
+	var (
+		camera = common.NewCamera(
+			common.WithPosition(mgl32.Vec3{0, 20, 160}),
+		)
+
+		firstMouse         = true
+		lastX      float32 = ScreenWidth / 2.0
+		lastY      float32 = ScreenHeight / 2.0
+
+		deltaTime float32 = 0
+		lastFrame float32 = 0
+	)
+	// glfw：每当鼠标移动时，都会调用此回调
+	window.SetCursorPosCallback(func(w *glfw.Window, x float64, y float64) {
+		xPos := float32(x)
+		yPos := float32(y)
+
+		if firstMouse {
+			lastX = xPos
+			lastY = yPos
+			firstMouse = false
+		}
+
+		// 计算当前光标位置与上次位置的偏移量
+		xOffset := xPos - lastX
+		yOffset := lastY - yPos // 注意：y 轴是从下到上的
+		lastX = xPos
+		lastY = yPos
+
+		camera.ProcessMouseMovement(xOffset, yOffset)
+	})
+	// glfw：每当鼠标滚轮滚动时，都会调用此回调
+	window.SetScrollCallback(func(w *glfw.Window, x float64, y float64) {
+		camera.ProcessMouseScroll(float32(y))
+	})
+	// 告诉 GLFW 捕获我们的鼠标
+	window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+
+	// 加载所有 OpenGL 方法
+	err = gl.Init()
+	if err != nil {
+		return err
+	}
+
+	camera.MovementSpeed = 20 // 场景比较大,移动快一些
+
+	// 配置全局 opengl 状态
+	gl.Enable(gl.DEPTH_TEST) // 启用深度测试
+
+	sd, err := common.NewShader(`
+#version 440 core
+layout (location = 0) in vec3 aPos;
+layout (location = 1) in vec3 aNormal;
+layout (location = 5) in mat4 aModel;
+layout (location = 9) in vec4 aColor;
+
+out vec3 Normal;
+out vec4 Color;
+
+uniform mat4 view;
+uniform mat4 projection;
+
+void main()
+{
+	gl_Position = projection * view * aModel * vec4(aPos, 1.0);
+	Normal = mat3(aModel) * aNormal; // 只有旋转和等比缩放,不需要法线矩阵
+	Color = aColor;
+}`, `
+#version 440 core
+out vec4 FragColor;
+
+in vec3 Normal;
+in vec4 Color;
+
+uniform vec3 lightDir;
+
+void main()
+{
+	float diff = max(dot(normalize(Normal), -lightDir), 0.0);
+	FragColor = vec4(Color.rgb * (0.3 + 0.7 * diff), Color.a);
+}`)
+	if err != nil {
+		return err
+	}
+
+	cubeData := common.GenCube(1, 1)
+	cube, err := cubeData.Upload()
+	if err != nil {
+		return err
+	}
+
+	// 在圆环上随机摆放立方体,每个立方体的变换和颜色作为实例属性
+	rng := rand.New(rand.NewSource(1))
+	models := make([]common.Instance, CubeAmount)
+	for i := range models {
+		angle := float64(i) / CubeAmount * 2 * math.Pi
+		radius := 100 + rng.Float64()*50 - 25
+		x := float32(math.Sin(angle) * radius)
+		y := float32(rng.Float64()*10 - 5)
+		z := float32(math.Cos(angle) * radius)
+		scale := float32(rng.Float64()*0.4 + 0.1)
+
+		model := mgl32.Translate3D(x, y, z).
+			Mul4(mgl32.HomogRotate3D(float32(rng.Float64()*2*math.Pi), mgl32.Vec3{0.4, 0.6, 0.8}.Normalize())).
+			Mul4(mgl32.Scale3D(scale, scale, scale))
+
+		models[i] = common.NewInstance(model)
+		models[i].Color = mgl32.Vec4{rng.Float32(), rng.Float32(), rng.Float32(), 1}
+	}
+
+	instances, err := common.NewInstanceBuffer(cube, models)
+	if err != nil {
+		return err
+	}
+	err = instances.Validate(sd)
+	if err != nil {
+		return err
+	}
+
+	// 用每个立方体的世界包围盒构建 BVH, 点击鼠标左键拾取屏幕中心的立方体
+	cubeBox := cubeData.Bounds()
+	boxes := make([]common.AABB, len(models))
+	for i, m := range models {
+		boxes[i] = cubeBox.Transform(m.Model)
+	}
+	bvh := common.NewBVH(boxes)
+
+	hitCube := func(i int, r common.Ray) (common.RayHit, bool) {
+		hit, ok := r.IntersectModel(models[i].Model, func(local common.Ray) (common.RayHit, bool) {
+			return local.IntersectAABB(cubeBox)
+		})
+		hit.Index = i
+		return hit, ok
+	}
+	picked, changed := -1, false
+	window.SetMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		if button != glfw.MouseButtonLeft || action != glfw.Press {
+			return
+		}
+
+		ray := common.Ray{Origin: camera.Position, Direction: camera.Front}
+
+		hit, ok := bvh.Raycast(ray, hitCube)
+		if picked >= 0 {
+			models[picked].Color = mgl32.Vec4{rng.Float32(), rng.Float32(), rng.Float32(), 1}
+			picked = -1
+		}
+		if ok {
+			picked = hit.Index
+			models[picked].Color = mgl32.Vec4{1, 1, 1, 1}
+		}
+		changed = true
+	})
+
+	lightDir := mgl32.Vec3{-0.3, -1, -0.5}.Normalize()
+
+	var (
+		frames    int
+		lastPrint = glfw.GetTime()
+	)
+	for !window.ShouldClose() {
+		// 每帧时间逻辑
+		currentFrame := float32(glfw.GetTime())
+		deltaTime = currentFrame - lastFrame
+		lastFrame = currentFrame
+
+		frames++
+		if now := glfw.GetTime(); now-lastPrint >= 1 {
+			window.SetTitle(fmt.Sprintf("LearnOpenGL - %d cubes, %d fps", CubeAmount, frames))
+			frames, lastPrint = 0, now
+		}
+
+		if window.GetKey(glfw.KeyEscape) == glfw.Press {
+			window.SetShouldClose(true)
+		}
+		if window.GetKey(glfw.KeyW) == glfw.Press {
+			camera.ProcessKeyboard(common.ForWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyS) == glfw.Press {
+			camera.ProcessKeyboard(common.BackWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyA) == glfw.Press {
+			camera.ProcessKeyboard(common.Left, deltaTime)
+		}
+		if window.GetKey(glfw.KeyD) == glfw.Press {
+			camera.ProcessKeyboard(common.Right, deltaTime)
+		}
+
+		// 拾取改变了颜色时重新上传实例
+		if changed {
+			err = common.UpdateInstances(instances, models)
+			if err != nil {
+				return err
+			}
+			changed = false
+		}
+
+		// 渲染: 清空屏幕为背景颜色
+		gl.ClearColor(0.1, 0.1, 0.1, 1.0)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		sd.Use()
+		sd.SetFloat("lightDir", lightDir[0], lightDir[1], lightDir[2])
+
+		projection := camera.GetProjectionMatrix(float32(ScreenWidth)/float32(ScreenHeight), 0.1, 1000.0)
+		sd.SetMat("projection", 4, &projection[0])
+
+		view := camera.GetViewMatrix()
+		sd.SetMat("view", 4, &view[0])
+
+		// 所有立方体只需要一次绘制调用
+		instances.Draw()
+
+		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+
+	// 可选：一旦超出其用途，就取消分配所有资源
+	// ------------------------------------------------------------------------
+	instances.Delete()
+	cube.Delete()
+	sd.Del()
+
+	return nil
+}
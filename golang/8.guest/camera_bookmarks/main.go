@@ -0,0 +1,280 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"strconv"
+
+	"opengl/common"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// https://learnopengl-cn.github.io/01%20Getting%20started/09%20Camera/
+
+var cameraFlag = flag.String("camera", "", "initial camera state: x,y,z,yaw,pitch[,zoom,speed,sensitivity[,acceleration,damping,smoothing]] or json")
+
+func main() {
+	flag.Parse()
+	runtime.LockOSThread()
+
+	err := HelloTriangle()
+	if err != nil {
+		panic(err)
+	}
+}
+
+const (
+	ScreenWidth  = 800
+	ScreenHeight = 600
+
+	// 在 golang 目录下运行时保存在 golang/camera_bookmarks.json
+	BookmarkFile = "camera_bookmarks.json"
+)
+
+func HelloTriangle() error {
+	err := glfw.Init()
+	if err != nil {
+		return err
+	}
+
+	defer glfw.Terminate() // glfw: 终止，清除所有先前分配的 GLFW 资源
+
+	// glfw: 初始化配置,设置gl版本
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 4)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	// Mac OS X 需要如下配置
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+
+	window, err := glfw.CreateWindow(ScreenWidth, ScreenHeight, "LearnOpenGL", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	window.MakeContextCurrent()
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		// 确保视口与新窗口尺寸匹配；请注意宽度和
+		// 高度将明显大于视网膜显示器上指定的高度
+		gl.Viewport(0, 0, int32(width), int32(height))
+	})
+
+	var (
+		camera = common.NewCamera(
+			common.WithPosition(mgl32.Vec3{0, 1.5, 6}),
+			common.WithSmoothMovement(10, 6),
+		)
+
+		firstMouse         = true
+		lastX      float32 = ScreenWidth / 2.0
+		lastY      float32 = ScreenHeight / 2.0
+
+		deltaTime float32 = 0
+		lastFrame float32 = 0
+	)
+	// glfw：每当鼠标移动时，都会调用此回调
+	window.SetCursorPosCallback(func(w *glfw.Window, x float64, y float64) {
+		xPos := float32(x)
+		yPos := float32(y)
+
+		if firstMouse {
+			lastX = xPos
+			lastY = yPos
+			firstMouse = false
+		}
+
+		// 计算当前光标位置与上次位置的偏移量
+		xOffset := xPos - lastX
+		yOffset := lastY - yPos // 注意：y 轴是从下到上的
+		lastX = xPos
+		lastY = yPos
+
+		camera.ProcessMouseMovement(xOffset, yOffset)
+	})
+	// glfw：每当鼠标滚轮滚动时，都会调用此回调
+	window.SetScrollCallback(func(w *glfw.Window, x float64, y float64) {
+		camera.ProcessMouseScroll(float32(y))
+	})
+	if *cameraFlag != "" {
+		state, err := common.ParseCameraState(*cameraFlag)
+		if err != nil {
+			return err
+		}
+		camera.SetState(state)
+	}
+
+	bookmarks, err := common.LoadBookmarks(BookmarkFile)
+	if err != nil {
+		return err
+	}
+	// glfw：F1~F4 恢复书签, Ctrl+F1~F4 保存书签, P 在标题栏显示可以传给 -camera 的当前状态
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action != glfw.Press {
+			return
+		}
+
+		switch key {
+		case glfw.KeyF1, glfw.KeyF2, glfw.KeyF3, glfw.KeyF4:
+			name := strconv.Itoa(int(key-glfw.KeyF1) + 1)
+			if mods&glfw.ModControl != 0 {
+				bookmarks[name] = camera.State()
+				if err := bookmarks.Save(BookmarkFile); err != nil {
+					w.SetTitle(fmt.Sprintf("LearnOpenGL - save bookmark %s: %v", name, err))
+				} else {
+					w.SetTitle(fmt.Sprintf("LearnOpenGL - bookmark %s saved", name))
+				}
+			} else if state, ok := bookmarks[name]; ok {
+				camera.SetState(state)
+				w.SetTitle(fmt.Sprintf("LearnOpenGL - bookmark %s", name))
+			} else {
+				w.SetTitle(fmt.Sprintf("LearnOpenGL - bookmark %s is empty", name))
+			}
+		case glfw.KeyP:
+			w.SetTitle(fmt.Sprintf("LearnOpenGL - -camera %s", camera.State()))
+		}
+	})
+	// 告诉 GLFW 捕获我们的鼠标
+	window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+
+	// 加载所有 OpenGL 方法
+	err = gl.Init()
+	if err != nil {
+		return err
+	}
+
+	// 配置全局 opengl 状态
+	gl.Enable(gl.DEPTH_TEST) // 启用深度测试
+
+	sd, err := common.NewShader(`
+#version 440 core
+layout (location = 0) in vec3 aPos;
+layout (location = 1) in vec3 aNormal;
+layout (location = 2) in vec2 aTexCoord;
+
+out vec3 Normal;
+out vec2 TexCoord;
+
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+
+void main()
+{
+	gl_Position = projection * view * model * vec4(aPos, 1.0);
+	Normal = mat3(transpose(inverse(model))) * aNormal;
+	TexCoord = aTexCoord;
+}`, `
+#version 440 core
+out vec4 FragColor;
+
+in vec3 Normal;
+in vec2 TexCoord;
+
+uniform sampler2D texture1;
+
+void main()
+{
+	float diff = max(dot(normalize(Normal), normalize(vec3(0.4, 1.0, 0.6))), 0.0);
+	FragColor = vec4(texture(texture1, TexCoord).rgb * (0.3 + 0.7 * diff), 1.0);
+}`)
+	if err != nil {
+		return err
+	}
+
+	cube, err := common.GenCube(1, 1).Upload()
+	if err != nil {
+		return err
+	}
+	plane := common.GenPlane(40, 40, 1, 1)
+	for i, uv := range plane.UVs {
+		plane.UVs[i] = uv.Mul(20)
+	}
+	floor, err := plane.Upload()
+	if err != nil {
+		return err
+	}
+
+	img, err := common.LoadImgRGB("resource/container.jpg")
+	if err != nil {
+		return err
+	}
+	container := common.NewTexture(img, common.DefaultSampler())
+	img, err = common.LoadImgRGB("resource/wall.jpg")
+	if err != nil {
+		return err
+	}
+	wall := common.NewTexture(img, common.DefaultSampler())
+
+	sd.Use()
+	sd.SetInt("texture1", 0)
+
+	// 一条两侧摆满箱子的走廊
+	var boxes []mgl32.Mat4
+	for z := -18; z <= 18; z += 3 {
+		for _, x := range []float32{-3, 3} {
+			boxes = append(boxes, mgl32.Translate3D(x, 0.5, float32(z)))
+		}
+	}
+
+	for !window.ShouldClose() {
+		// 每帧时间逻辑
+		currentFrame := float32(glfw.GetTime())
+		deltaTime = currentFrame - lastFrame
+		lastFrame = currentFrame
+
+		if window.GetKey(glfw.KeyEscape) == glfw.Press {
+			window.SetShouldClose(true)
+		}
+		if window.GetKey(glfw.KeyW) == glfw.Press {
+			camera.ProcessKeyboard(common.ForWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyS) == glfw.Press {
+			camera.ProcessKeyboard(common.BackWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyA) == glfw.Press {
+			camera.ProcessKeyboard(common.Left, deltaTime)
+		}
+		if window.GetKey(glfw.KeyD) == glfw.Press {
+			camera.ProcessKeyboard(common.Right, deltaTime)
+		}
+		camera.Update(deltaTime)
+
+		// 渲染: 清空屏幕为背景颜色
+		gl.ClearColor(0.2, 0.3, 0.3, 1.0)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		sd.Use()
+		projection := camera.GetProjectionMatrix(float32(ScreenWidth)/float32(ScreenHeight), 0.1, 100.0)
+		sd.SetMat("projection", 4, &projection[0])
+		view := camera.GetViewMatrix()
+		sd.SetMat("view", 4, &view[0])
+
+		wall.Bind(0)
+		model := mgl32.Ident4()
+		sd.SetMat("model", 4, &model[0])
+		floor.Draw()
+
+		container.Bind(0)
+		for _, model := range boxes {
+			sd.SetMat("model", 4, &model[0])
+			cube.Draw()
+		}
+
+		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+
+	// 可选：一旦超出其用途，就取消分配所有资源
+	// ------------------------------------------------------------------------
+	cube.Delete()
+	floor.Delete()
+	container.Delete()
+	wall.Delete()
+	sd.Del()
+
+	return nil
+}
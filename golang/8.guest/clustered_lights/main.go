@@ -0,0 +1,365 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"time"
+
+	"opengl/common"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// http://www.aortiz.me/2018/12/21/CG.html
+
+var lightsFlag = flag.Int("lights", 256, "number of moving point lights")
+
+func main() {
+	runtime.LockOSThread()
+	flag.Parse()
+
+	err := HelloTriangle()
+	if err != nil {
+		panic(err)
+	}
+}
+
+const (
+	ScreenWidth  = 800
+	ScreenHeight = 600
+
+	FieldSize = 40 // 场景在 x/z 方向的边长
+)
+
+// movingLight 在地面上方绕各自的圆心转圈
+type movingLight struct {
+	center mgl32.Vec3
+	radius float32
+	speed  float32
+	phase  float32
+	color  mgl32.Vec3
+}
+
+func (m *movingLight) position(t float32) mgl32.Vec3 {
+	a := float64(m.phase + m.speed*t)
+	return m.center.Add(mgl32.Vec3{m.radius * float32(math.Cos(a)), 0, m.radius * float32(math.Sin(a))})
+}
+
+func HelloTriangle() error {
+	err := glfw.Init()
+	if err != nil {
+		return err
+	}
+
+	defer glfw.Terminate() // glfw: 终止，清除所有先前分配的 GLFW 资源
+
+	// glfw: 初始化配置,设置gl版本
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 4)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	// Mac OS X 需要如下配置
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+
+	window, err := glfw.CreateWindow(ScreenWidth, ScreenHeight, "LearnOpenGL", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	window.MakeContextCurrent()
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		// 确保视口与新窗口尺寸匹配；请注意宽度和
+		// 高度将明显大于视网膜显示器上指定的高度
+		gl.Viewport(0, 0, int32(width), int32(height))
+	})
+
+	var (
+		camera = common.NewCamera(
+			common.WithPosition(mgl32.Vec3{0, 6, FieldSize / 2}),
+		)
+
+		firstMouse         = true
+		lastX      float32 = ScreenWidth / 2.0
+		lastY      float32 = ScreenHeight / 2.0
+
+		deltaTime float32 = 0
+		lastFrame float32 = 0
+	)
+	camera.MovementSpeed *= 3
+	// glfw：每当鼠标移动时，都会调用此回调
+	window.SetCursorPosCallback(func(w *glfw.Window, x float64, y float64) {
+		xPos := float32(x)
+		yPos := float32(y)
+
+		if firstMouse {
+			lastX = xPos
+			lastY = yPos
+			firstMouse = false
+		}
+
+		// 计算当前光标位置与上次位置的偏移量
+		xOffset := xPos - lastX
+		yOffset := lastY - yPos // 注意：y 轴是从下到上的
+		lastX = xPos
+		lastY = yPos
+
+		camera.ProcessMouseMovement(xOffset, yOffset)
+	})
+	// glfw：每当鼠标滚轮滚动时，都会调用此回调
+	window.SetScrollCallback(func(w *glfw.Window, x float64, y float64) {
+		camera.ProcessMouseScroll(float32(y))
+	})
+	// 告诉 GLFW 捕获我们的鼠标
+	window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+
+	// 加载所有 OpenGL 方法
+	err = gl.Init()
+	if err != nil {
+		return err
+	}
+
+	// 配置全局 opengl 状态
+	gl.Enable(gl.DEPTH_TEST) // 启用深度测试
+	gl.Enable(gl.CULL_FACE)
+
+	sd, err := common.NewShader(`
+#version 440 core
+layout (location = 0) in vec3 aPos;
+layout (location = 1) in vec3 aNormal;
+layout (location = 2) in vec2 aTexCoords;
+
+out vec3 FragPos;
+out vec3 Normal;
+out vec2 TexCoords;
+out float ViewDepth;
+
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+
+void main()
+{
+	FragPos = vec3(model * vec4(aPos, 1.0));
+	Normal = mat3(transpose(inverse(model))) * aNormal;
+	TexCoords = aTexCoords;
+
+	vec4 viewPos = view * vec4(FragPos, 1.0);
+	ViewDepth = -viewPos.z;
+	gl_Position = projection * viewPos;
+}`, `
+#version 440 core
+out vec4 FragColor;
+
+in vec3 FragPos;
+in vec3 Normal;
+in vec2 TexCoords;
+in float ViewDepth;
+
+uniform vec3 viewPos;
+uniform bool heatmap;
+`+common.ClusterGLSL+`
+void main()
+{
+	// 热力图: 每个簇的光源数, 0 为蓝色, 32 个及以上为红色
+	if (heatmap) {
+		float t = clamp(float(clusterLightCount(ViewDepth)) / 32.0, 0.0, 1.0);
+		FragColor = vec4(mix(vec3(0.0, 0.0, 1.0), vec3(1.0, 0.0, 0.0), t), 1.0);
+		return;
+	}
+
+	vec3 color = clusteredLighting(FragPos, normalize(Normal), normalize(viewPos - FragPos), TexCoords, ViewDepth);
+	FragColor = vec4(color, 1.0);
+}`)
+	if err != nil {
+		return err
+	}
+
+	lightCubeShader, err := common.NewShader(`
+#version 440 core
+layout (location = 0) in vec3 aPos;
+layout (location = 5) in mat4 aModel;
+layout (location = 9) in vec4 aColor;
+
+out vec3 Color;
+
+uniform mat4 view;
+uniform mat4 projection;
+
+void main()
+{
+	gl_Position = projection * view * aModel * vec4(aPos, 1.0);
+	Color = aColor.rgb;
+}`, `
+#version 440 core
+out vec4 FragColor;
+
+in vec3 Color;
+
+void main()
+{
+	FragColor = vec4(Color, 1.0);
+}`)
+	if err != nil {
+		return err
+	}
+
+	// 地面和一排排柱子
+	plane := common.GenPlane(FieldSize, FieldSize, 1, 1)
+	for i, uv := range plane.UVs {
+		plane.UVs[i] = uv.Mul(FieldSize / 4)
+	}
+	floor, err := plane.Upload()
+	if err != nil {
+		return err
+	}
+	cube, err := common.GenCube(1, 1).Upload()
+	if err != nil {
+		return err
+	}
+	var pillars []mgl32.Mat4
+	for x := -FieldSize/2 + 4; x < FieldSize/2; x += 6 {
+		for z := -FieldSize/2 + 4; z < FieldSize/2; z += 6 {
+			pillars = append(pillars, mgl32.Translate3D(float32(x), 1.5, float32(z)).Mul4(mgl32.Scale3D(1, 3, 1)))
+		}
+	}
+
+	img, err := common.LoadImgRGB("resource/wall.jpg")
+	if err != nil {
+		return err
+	}
+	material := common.NewPhongMaterial(
+		common.WithPhongMaps(common.NewTexture(img, common.DefaultSampler()), nil, nil),
+		common.WithSpecularColor(mgl32.Vec3{0.4, 0.4, 0.4}, 32),
+	)
+
+	// 随机颜色的点光源, 外加一个很暗的定向光
+	rng := rand.New(rand.NewSource(1))
+	moving := make([]movingLight, max(*lightsFlag, 0))
+	for i := range moving {
+		moving[i] = movingLight{
+			center: mgl32.Vec3{(rng.Float32() - 0.5) * FieldSize, 0.3 + rng.Float32()*2, (rng.Float32() - 0.5) * FieldSize},
+			radius: 1 + rng.Float32()*3,
+			speed:  (rng.Float32() - 0.5) * 2,
+			phase:  rng.Float32() * 2 * math.Pi,
+			color:  mgl32.Vec3{rng.Float32(), rng.Float32(), rng.Float32()}.Normalize(),
+		}
+	}
+	lights := make([]common.Light, 0, len(moving)+1)
+	instances := make([]common.Instance, len(moving))
+
+	lightCubes, err := common.NewInstanceBuffer(cube, instances)
+	if err != nil {
+		return err
+	}
+
+	clusters := common.NewClusteredLights()
+
+	// H 切换每个簇光源数的热力图
+	heatmap := false
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action == glfw.Press && key == glfw.KeyH {
+			heatmap = !heatmap
+		}
+	})
+
+	lastTitle := time.Time{}
+	for !window.ShouldClose() {
+		// 每帧时间逻辑
+		currentFrame := float32(glfw.GetTime())
+		deltaTime = currentFrame - lastFrame
+		lastFrame = currentFrame
+
+		if window.GetKey(glfw.KeyEscape) == glfw.Press {
+			window.SetShouldClose(true)
+		}
+		if window.GetKey(glfw.KeyW) == glfw.Press {
+			camera.ProcessKeyboard(common.ForWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyS) == glfw.Press {
+			camera.ProcessKeyboard(common.BackWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyA) == glfw.Press {
+			camera.ProcessKeyboard(common.Left, deltaTime)
+		}
+		if window.GetKey(glfw.KeyD) == glfw.Press {
+			camera.ProcessKeyboard(common.Right, deltaTime)
+		}
+
+		lights = append(lights[:0], common.NewDirectionalLight(mgl32.Vec3{-0.2, -1.0, -0.3},
+			common.WithLightComponents(mgl32.Vec3{0.02, 0.02, 0.02}, mgl32.Vec3{0.05, 0.05, 0.05}, mgl32.Vec3{}),
+		))
+		for i := range moving {
+			m := &moving[i]
+			p := m.position(currentFrame)
+			lights = append(lights, common.NewPointLight(p, common.WithLightColor(m.color), common.WithLightRange(7)))
+
+			instances[i] = common.NewInstance(mgl32.Translate3D(p[0], p[1], p[2]).Mul4(mgl32.Scale3D(0.1, 0.1, 0.1)))
+			instances[i].Color = m.color.Vec4(1)
+		}
+		err = common.UpdateInstances(lightCubes, instances)
+		if err != nil {
+			return err
+		}
+
+		projection := camera.GetProjectionMatrix(float32(ScreenWidth)/float32(ScreenHeight), 0.1, 100.0)
+		view := camera.GetViewMatrix()
+
+		width, height := window.GetFramebufferSize()
+		start := time.Now()
+		clusters.Update(lights, view, projection, width, height)
+		if elapsed := time.Since(start); time.Since(lastTitle) > time.Second/2 {
+			lastTitle = time.Now()
+			window.SetTitle(fmt.Sprintf("LearnOpenGL - %d lights, assigned in %v, at most %d per cluster",
+				len(lights), elapsed.Round(time.Microsecond), clusters.MaxCount))
+		}
+
+		// 渲染: 清空屏幕为背景颜色
+		gl.ClearColor(0.0, 0.0, 0.0, 1.0)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		sd.Use()
+		sd.SetMat("projection", 4, &projection[0])
+		sd.SetMat("view", 4, &view[0])
+		sd.SetFloat("viewPos", camera.Position[0], camera.Position[1], camera.Position[2])
+		heatmapFlag := int32(0)
+		if heatmap {
+			heatmapFlag = 1
+		}
+		sd.SetInt("heatmap", heatmapFlag)
+		clusters.Bind(sd)
+		material.Bind(sd, 0)
+
+		model := mgl32.Ident4()
+		sd.SetMat("model", 4, &model[0])
+		floor.Draw()
+		for _, model := range pillars {
+			sd.SetMat("model", 4, &model[0])
+			cube.Draw()
+		}
+
+		lightCubeShader.Use()
+		lightCubeShader.SetMat("projection", 4, &projection[0])
+		lightCubeShader.SetMat("view", 4, &view[0])
+		lightCubes.Draw()
+
+		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+
+	// 可选：一旦超出其用途，就取消分配所有资源
+	// ------------------------------------------------------------------------
+	clusters.Delete()
+	lightCubes.Delete()
+	material.Delete()
+	floor.Delete()
+	cube.Delete()
+	sd.Del()
+	lightCubeShader.Del()
+
+	return nil
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"opengl/common"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// 网格简化生成的 LOD 链, 一排相同的模型按到相机的距离各自选择一级
+// WASD 移动, 滚轮缩放, 上下方向键调整允许的屏幕误差
+// O/P 把最近一个模型当前使用的一级导出为 lod<N>.obj / lod<N>.ply, 结果显示在标题栏
+
+var modelFlag = flag.String("model", "", "model to simplify (.obj, .ply or .stl), a torus is used when empty")
+
+func main() {
+	runtime.LockOSThread()
+	flag.Parse()
+
+	err := HelloTriangle()
+	if err != nil {
+		panic(err)
+	}
+}
+
+const (
+	ScreenWidth  = 800
+	ScreenHeight = 600
+
+	LODLevels = 6   // 最多生成几级 LOD
+	LODRatio  = 0.5 // 每级保留的三角形比例
+	Copies    = 8   // 沿 -z 方向摆放的模型个数
+)
+
+// loadModel 读取模型并缩放到原点处半径为 1 的包围球内
+func loadModel(name string) (*common.MeshData, error) {
+	if name == "" {
+		return common.GenTorus(0.7, 0.3, 128, 64), nil
+	}
+
+	var d *common.MeshData
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".obj":
+		m, err := common.LoadOBJ(name)
+		if err != nil {
+			return nil, err
+		}
+		d = &common.MeshData{}
+		for _, g := range m.Groups {
+			d.Append(g.Mesh)
+		}
+	case ".ply":
+		m, err := common.LoadPLY(name)
+		if err != nil {
+			return nil, err
+		}
+		d = m.Mesh
+	case ".stl":
+		m, err := common.LoadSTL(name)
+		if err != nil {
+			return nil, err
+		}
+		d = m
+	default:
+		return nil, fmt.Errorf("%s: unsupported model format", name)
+	}
+	if d.TriangleCount() == 0 {
+		return nil, fmt.Errorf("%s: no triangle", name)
+	}
+
+	// 着色器用屏幕空间导数计算面法线, 去掉法线后按位置焊接, stl 等按面片存储的模型才能简化
+	d.Normals, d.Tangents = nil, nil
+	d.Weld(1e-6)
+	s := d.BoundingSphere()
+	d.Transform(mgl32.Scale3D(1/s.Radius, 1/s.Radius, 1/s.Radius).
+		Mul4(mgl32.Translate3D(-s.Center[0], -s.Center[1], -s.Center[2])))
+	return d, nil
+}
+
+func HelloTriangle() error {
+	err := glfw.Init()
+	if err != nil {
+		return err
+	}
+
+	defer glfw.Terminate() // glfw: 终止，清除所有先前分配的 GLFW 资源
+
+	// glfw: 初始化配置,设置gl版本
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 4)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	// Mac OS X 需要如下配置
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+
+	window, err := glfw.CreateWindow(ScreenWidth, ScreenHeight, "LearnOpenGL", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	window.MakeContextCurrent()
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		// 确保视口与新窗口尺寸匹配；请注意宽度和
+		// 高度将明显大于视网膜显示器上指定的高度
+		gl.Viewport(0, 0, int32(width), int32(height))
+	})
+
+	var (
+		camera = common.NewCamera(common.WithPosition(mgl32.Vec3{2, 1, 3}))
+
+		firstMouse         = true
+		lastX      float32 = ScreenWidth / 2.0
+		lastY      float32 = ScreenHeight / 2.0
+
+		deltaTime float32 = 0
+		lastFrame float32 = 0
+
+		pixelError float32 = 1 // 允许的屏幕空间误差(像素)
+
+		nearest int    // 最近一个模型使用的一级
+		status  string // 最近一次导出的结果
+	)
+	// glfw：每当鼠标移动时，都会调用此回调
+	window.SetCursorPosCallback(func(w *glfw.Window, x float64, y float64) {
+		xPos := float32(x)
+		yPos := float32(y)
+
+		if firstMouse {
+			lastX = xPos
+			lastY = yPos
+			firstMouse = false
+		}
+
+		// 计算当前光标位置与上次位置的偏移量
+		xOffset := xPos - lastX
+		yOffset := lastY - yPos // 注意：y 轴是从下到上的
+		lastX = xPos
+		lastY = yPos
+
+		camera.ProcessMouseMovement(xOffset, yOffset)
+	})
+	// glfw：每当鼠标滚轮滚动时，都会调用此回调
+	window.SetScrollCallback(func(w *glfw.Window, x float64, y float64) {
+		camera.ProcessMouseScroll(float32(y))
+	})
+	// 告诉 GLFW 捕获我们的鼠标
+	window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+
+	// 加载所有 OpenGL 方法
+	err = gl.Init()
+	if err != nil {
+		return err
+	}
+
+	// 配置全局 opengl 状态
+	gl.Enable(gl.DEPTH_TEST) // 启用深度测试
+
+	sd, err := common.NewShader(`
+#version 440 core
+layout (location = 0) in vec3 aPos;
+
+out vec3 FragPos;
+
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+
+void main()
+{
+	FragPos = vec3(model * vec4(aPos, 1.0));
+	gl_Position = projection * view * vec4(FragPos, 1.0);
+}`, `
+#version 440 core
+out vec4 FragColor;
+
+in vec3 FragPos;
+
+uniform vec3 color;
+
+void main()
+{
+	// 面法线, 简化后的三角形清晰可见
+	vec3 n = normalize(cross(dFdx(FragPos), dFdy(FragPos)));
+	float diff = max(dot(n, normalize(vec3(0.4, 1.0, 0.6))), 0.0);
+	FragColor = vec4(color * (0.2 + 0.8 * diff), 1.0);
+}`)
+	if err != nil {
+		return err
+	}
+
+	data, err := loadModel(*modelFlag)
+	if err != nil {
+		return err
+	}
+	levels := common.GenerateLODs(data, LODLevels, LODRatio, 0)
+	lods, err := common.NewLODChain(levels)
+	if err != nil {
+		return err
+	}
+
+	// 导出用于检查简化结果, 失败时只显示错误, 不退出
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action != glfw.Press {
+			return
+		}
+
+		d := levels[nearest].Data
+		var name string
+		var err error
+		switch key {
+		case glfw.KeyO:
+			name = fmt.Sprintf("lod%d.obj", nearest)
+			err = common.SaveOBJ(name, &common.OBJModel{Groups: []*common.OBJGroup{{Object: name, Mesh: d}}})
+		case glfw.KeyP:
+			name = fmt.Sprintf("lod%d.ply", nearest)
+			err = common.SavePLY(name, d)
+		default:
+			return
+		}
+		if err != nil {
+			status = err.Error()
+		} else {
+			status = fmt.Sprintf("saved %s (%d triangles)", name, d.TriangleCount())
+		}
+	})
+
+	// 每一级使用不同的颜色
+	colors := []mgl32.Vec3{
+		{0.9, 0.9, 0.9}, {0.4, 0.8, 0.4}, {0.4, 0.6, 1.0},
+		{1.0, 0.8, 0.3}, {1.0, 0.5, 0.3}, {0.9, 0.3, 0.6},
+	}
+
+	for !window.ShouldClose() {
+		// 每帧时间逻辑
+		currentFrame := float32(glfw.GetTime())
+		deltaTime = currentFrame - lastFrame
+		lastFrame = currentFrame
+
+		if window.GetKey(glfw.KeyEscape) == glfw.Press {
+			window.SetShouldClose(true)
+		}
+		if window.GetKey(glfw.KeyW) == glfw.Press {
+			camera.ProcessKeyboard(common.ForWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyS) == glfw.Press {
+			camera.ProcessKeyboard(common.BackWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyA) == glfw.Press {
+			camera.ProcessKeyboard(common.Left, deltaTime)
+		}
+		if window.GetKey(glfw.KeyD) == glfw.Press {
+			camera.ProcessKeyboard(common.Right, deltaTime)
+		}
+		if window.GetKey(glfw.KeyUp) == glfw.Press {
+			pixelError = min(pixelError*(1+deltaTime), 64)
+		}
+		if window.GetKey(glfw.KeyDown) == glfw.Press {
+			pixelError = max(pixelError/(1+deltaTime), 0.25)
+		}
+
+		// 渲染: 清空屏幕为背景颜色
+		gl.ClearColor(0.1, 0.1, 0.1, 1.0)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		sd.Use()
+		projection := camera.GetProjectionMatrix(float32(ScreenWidth)/float32(ScreenHeight), 0.1, 200.0)
+		sd.SetMat("projection", 4, &projection[0])
+		view := camera.GetViewMatrix()
+		sd.SetMat("view", 4, &view[0])
+
+		triangles := 0
+		used := make([]int, 0, Copies)
+		nearestDist := float32(math.Inf(1))
+		for i := 0; i < Copies; i++ {
+			// 间距越来越大, 远处的模型使用更粗糙的一级
+			z := -float32(i*i) * 2
+			model := mgl32.Translate3D(0, 0, z)
+			level := lods.Select(model, view, projection, ScreenHeight, pixelError)
+			used = append(used, level)
+			if dist := camera.Position.Sub(mgl32.Vec3{0, 0, z}).Len(); dist < nearestDist {
+				nearest, nearestDist = level, dist
+			}
+			triangles += int(lods.Meshes[level].Count / 3)
+
+			c := colors[level%len(colors)]
+			sd.SetFloat("color", c[0], c[1], c[2])
+			sd.SetMat("model", 4, &model[0])
+			lods.Draw(level)
+		}
+
+		window.SetTitle(fmt.Sprintf("LearnOpenGL - %d levels, error %.2fpx, levels %v, %d triangles %s",
+			len(levels), pixelError, used, triangles, status))
+
+		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+
+	// 可选：一旦超出其用途，就取消分配所有资源
+	// ------------------------------------------------------------------------
+	lods.Delete()
+	sd.Del()
+
+	return nil
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"runtime"
+	"time"
+
+	"opengl/common"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// https://learnopengl-cn.github.io/02%20Lighting/02%20Basic%20Lighting/
+// https://paulbourke.net/geometry/polygonise/
+
+var (
+	rawFlag     = flag.String("raw", "", "raw volume file, animated metaballs when empty")
+	sizeFlag    = flag.String("size", "256x256x256", "raw volume dimensions")
+	formatFlag  = flag.String("format", "u8", "raw voxel format: u8, u16le, u16be, i16le, i16be")
+	spacingFlag = flag.String("spacing", "1,1,1", "raw voxel spacing")
+	isoFlag     = flag.Float64("iso", 0, "iso value, default half of the value range for raw volumes")
+)
+
+var formats = map[string]common.VolumeFormat{
+	"u8":    common.VolumeUint8,
+	"u16le": common.VolumeUint16LE,
+	"u16be": common.VolumeUint16BE,
+	"i16le": common.VolumeInt16LE,
+	"i16be": common.VolumeInt16BE,
+}
+
+func main() {
+	runtime.LockOSThread()
+	flag.Parse()
+
+	err := HelloTriangle()
+	if err != nil {
+		panic(err)
+	}
+}
+
+const (
+	ScreenWidth  = 800
+	ScreenHeight = 600
+
+	BallResolution = 64 // 元球场每个方向的采样数
+)
+
+// loadRaw 读取 raw 体数据并缩放到边长为 2 的立方体内
+func loadRaw() (*common.Volume, float32, error) {
+	var (
+		w, h, d    int
+		sx, sy, sz float32
+	)
+	if _, err := fmt.Sscanf(*sizeFlag, "%dx%dx%d", &w, &h, &d); err != nil {
+		return nil, 0, fmt.Errorf("bad -size %q: %w", *sizeFlag, err)
+	}
+	if _, err := fmt.Sscanf(*spacingFlag, "%g,%g,%g", &sx, &sy, &sz); err != nil {
+		return nil, 0, fmt.Errorf("bad -spacing %q: %w", *spacingFlag, err)
+	}
+	format, ok := formats[*formatFlag]
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown -format %q", *formatFlag)
+	}
+
+	v, err := common.LoadRawVolume(*rawFlag, w, h, d, format)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	size := mgl32.Vec3{float32(w-1) * sx, float32(h-1) * sy, float32(d-1) * sz}
+	scale := 2 / max(size[0], size[1], size[2])
+	v.Spacing = mgl32.Vec3{sx, sy, sz}.Mul(scale)
+	v.Origin = size.Mul(-scale / 2)
+
+	iso := float32(*isoFlag)
+	if iso == 0 {
+		lo, hi := float32(math.Inf(1)), float32(math.Inf(-1))
+		for _, x := range v.Data {
+			lo, hi = min(lo, x), max(hi, x)
+		}
+		iso = (lo + hi) / 2
+	}
+	return v, iso, nil
+}
+
+// metaballs 几个沿李萨如曲线运动的元球, 场值大于 1 的部分为内部
+func metaballs(t float32) *common.Volume {
+	var balls [5]mgl32.Vec3
+	for i := range balls {
+		f := float64(t) + float64(i)*1.3
+		balls[i] = mgl32.Vec3{
+			float32(0.6 * math.Sin(f*0.9)),
+			float32(0.6 * math.Sin(f*1.3+1)),
+			float32(0.6 * math.Cos(f*0.7)),
+		}
+	}
+
+	return common.SampleVolume(BallResolution, BallResolution, BallResolution,
+		mgl32.Vec3{-1, -1, -1}, mgl32.Vec3{1, 1, 1},
+		func(p mgl32.Vec3) float32 {
+			var sum float32
+			for _, b := range balls {
+				sum += 0.08 / (p.Sub(b).LenSqr() + 1e-6)
+			}
+			return sum
+		})
+}
+
+func HelloTriangle() error {
+	var (
+		volume *common.Volume
+		iso    float32 = 1
+	)
+	if *rawFlag != "" {
+		var err error
+		volume, iso, err = loadRaw()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := glfw.Init()
+	if err != nil {
+		return err
+	}
+
+	defer glfw.Terminate() // glfw: 终止，清除所有先前分配的 GLFW 资源
+
+	// glfw: 初始化配置,设置gl版本
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 4)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	// Mac OS X 需要如下配置
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+
+	window, err := glfw.CreateWindow(ScreenWidth, ScreenHeight, "LearnOpenGL", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	window.MakeContextCurrent()
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		// 确保视口与新窗口尺寸匹配；请注意宽度和
+		// 高度将明显大于视网膜显示器上指定的高度
+		gl.Viewport(0, 0, int32(width), int32(height))
+	})
+
+	var (
+		camera = common.NewCamera(
+			common.WithPosition(mgl32.Vec3{0, 0, 3}),
+		)
+
+		firstMouse         = true
+		lastX      float32 = ScreenWidth / 2.0
+		lastY      float32 = ScreenHeight / 2.0
+
+		deltaTime float32 = 0
+		lastFrame float32 = 0
+	)
+	// glfw：每当鼠标移动时，都会调用此回调
+	window.SetCursorPosCallback(func(w *glfw.Window, x float64, y float64) {
+		xPos := float32(x)
+		yPos := float32(y)
+
+		if firstMouse {
+			lastX = xPos
+			lastY = yPos
+			firstMouse = false
+		}
+
+		// 计算当前光标位置与上次位置的偏移量
+		xOffset := xPos - lastX
+		yOffset := lastY - yPos // 注意：y 轴是从下到上的
+		lastX = xPos
+		lastY = yPos
+
+		camera.ProcessMouseMovement(xOffset, yOffset)
+	})
+	// glfw：每当鼠标滚轮滚动时，都会调用此回调
+	window.SetScrollCallback(func(w *glfw.Window, x float64, y float64) {
+		camera.ProcessMouseScroll(float32(y))
+	})
+	// 告诉 GLFW 捕获我们的鼠标
+	window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+
+	// 加载所有 OpenGL 方法
+	err = gl.Init()
+	if err != nil {
+		return err
+	}
+
+	// 配置全局 opengl 状态
+	gl.Enable(gl.DEPTH_TEST) // 启用深度测试
+
+	lightingShader, err := common.NewShader(`
+#version 440 core
+layout (location = 0) in vec3 aPos;
+layout (location = 1) in vec3 aNormal;
+
+out vec3 FragPos;
+out vec3 Normal;
+
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+
+void main()
+{
+	FragPos = vec3(model * vec4(aPos, 1.0));
+	Normal = mat3(transpose(inverse(model))) * aNormal;
+
+	gl_Position = projection * view * vec4(FragPos, 1.0);
+}`, `
+#version 440 core
+out vec4 FragColor;
+
+in vec3 Normal;
+in vec3 FragPos;
+
+uniform vec3 lightPos;
+uniform vec3 viewPos;
+uniform vec3 lightColor;
+uniform vec3 objectColor;
+
+void main()
+{
+	// ambient
+	float ambientStrength = 0.1;
+	vec3 ambient = ambientStrength * lightColor;
+
+	// diffuse, 体数据可能从内部观察, 背面翻转法线
+	vec3 norm = normalize(gl_FrontFacing ? Normal : -Normal);
+	vec3 lightDir = normalize(lightPos - FragPos);
+	float diff = max(dot(norm, lightDir), 0.0);
+	vec3 diffuse = diff * lightColor;
+
+	// specular
+	float specularStrength = 0.5;
+	vec3 viewDir = normalize(viewPos - FragPos);
+	vec3 reflectDir = reflect(-lightDir, norm);
+	float spec = pow(max(dot(viewDir, reflectDir), 0.0), 32);
+	vec3 specular = specularStrength * spec * lightColor;
+
+	vec3 result = (ambient + diffuse + specular) * objectColor;
+	FragColor = vec4(result, 1.0);
+}`)
+	if err != nil {
+		return err
+	}
+
+	// 体数据只需要提取一次, 元球每帧重新采样和提取
+	var mesh *common.Mesh
+	extract := func(v *common.Volume) (time.Duration, int, error) {
+		start := time.Now()
+		data := v.MarchingCubes(iso, common.WithMarchingWorkers(0))
+		elapsed := time.Since(start)
+
+		if mesh != nil {
+			mesh.Delete()
+		}
+		mesh, err = data.Upload()
+		return elapsed, data.TriangleCount(), err
+	}
+	if volume != nil {
+		elapsed, count, err := extract(volume)
+		if err != nil {
+			return err
+		}
+		window.SetTitle(fmt.Sprintf("LearnOpenGL - %d triangles in %v", count, elapsed))
+	}
+
+	lightPos := mgl32.Vec3{1.2, 1.0, 2.0}
+
+	for !window.ShouldClose() {
+		// 每帧时间逻辑
+		currentFrame := float32(glfw.GetTime())
+		deltaTime = currentFrame - lastFrame
+		lastFrame = currentFrame
+
+		if window.GetKey(glfw.KeyEscape) == glfw.Press {
+			window.SetShouldClose(true)
+		}
+		if window.GetKey(glfw.KeyW) == glfw.Press {
+			camera.ProcessKeyboard(common.ForWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyS) == glfw.Press {
+			camera.ProcessKeyboard(common.BackWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyA) == glfw.Press {
+			camera.ProcessKeyboard(common.Left, deltaTime)
+		}
+		if window.GetKey(glfw.KeyD) == glfw.Press {
+			camera.ProcessKeyboard(common.Right, deltaTime)
+		}
+
+		if volume == nil {
+			elapsed, count, err := extract(metaballs(currentFrame))
+			if err != nil {
+				return err
+			}
+			window.SetTitle(fmt.Sprintf("LearnOpenGL - %d triangles in %v", count, elapsed.Round(time.Microsecond)))
+		}
+
+		// 渲染: 清空屏幕为背景颜色
+		gl.ClearColor(0.1, 0.1, 0.1, 1.0)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		lightingShader.Use()
+		lightingShader.SetFloat("objectColor", 1.0, 0.5, 0.31)
+		lightingShader.SetFloat("lightColor", 1.0, 1.0, 1.0)
+		lightingShader.SetFloat("lightPos", lightPos[0], lightPos[1], lightPos[2])
+		lightingShader.SetFloat("viewPos", camera.Position[0], camera.Position[1], camera.Position[2])
+
+		projection := camera.GetProjectionMatrix(float32(ScreenWidth)/float32(ScreenHeight), 0.1, 100.0)
+		lightingShader.SetMat("projection", 4, &projection[0])
+		view := camera.GetViewMatrix()
+		lightingShader.SetMat("view", 4, &view[0])
+		model := mgl32.Ident4()
+		lightingShader.SetMat("model", 4, &model[0])
+
+		mesh.Draw()
+
+		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+
+	// 可选：一旦超出其用途，就取消分配所有资源
+	// ------------------------------------------------------------------------
+	if mesh != nil {
+		mesh.Delete()
+	}
+	lightingShader.Del()
+
+	return nil
+}
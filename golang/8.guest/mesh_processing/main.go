@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+
+	"opengl/common"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// CPU 端的网格处理: 焊接、平滑/平面法线、切线生成和顶点缓存优化
+// Tab 切换模型, N 切换平面/平滑法线, 上下方向键调整硬边角度, T 显示切线, O 开关顶点缓存优化, F 线框
+
+func main() {
+	runtime.LockOSThread()
+
+	err := HelloTriangle()
+	if err != nil {
+		panic(err)
+	}
+}
+
+const (
+	ScreenWidth  = 800
+	ScreenHeight = 600
+)
+
+// tutorialCube 入门章节中没有索引的 36 个顶点, 每个顶点为位置和纹理坐标
+var tutorialCube = []float32{
+	-0.5, -0.5, -0.5, 0.0, 0.0, 0.5, -0.5, -0.5, 1.0, 0.0, 0.5, 0.5, -0.5, 1.0, 1.0,
+	0.5, 0.5, -0.5, 1.0, 1.0, -0.5, 0.5, -0.5, 0.0, 1.0, -0.5, -0.5, -0.5, 0.0, 0.0,
+
+	-0.5, -0.5, 0.5, 0.0, 0.0, 0.5, -0.5, 0.5, 1.0, 0.0, 0.5, 0.5, 0.5, 1.0, 1.0,
+	0.5, 0.5, 0.5, 1.0, 1.0, -0.5, 0.5, 0.5, 0.0, 1.0, -0.5, -0.5, 0.5, 0.0, 0.0,
+
+	-0.5, 0.5, 0.5, 1.0, 0.0, -0.5, 0.5, -0.5, 1.0, 1.0, -0.5, -0.5, -0.5, 0.0, 1.0,
+	-0.5, -0.5, -0.5, 0.0, 1.0, -0.5, -0.5, 0.5, 0.0, 0.0, -0.5, 0.5, 0.5, 1.0, 0.0,
+
+	0.5, 0.5, 0.5, 1.0, 0.0, 0.5, 0.5, -0.5, 1.0, 1.0, 0.5, -0.5, -0.5, 0.0, 1.0,
+	0.5, -0.5, -0.5, 0.0, 1.0, 0.5, -0.5, 0.5, 0.0, 0.0, 0.5, 0.5, 0.5, 1.0, 0.0,
+
+	-0.5, -0.5, -0.5, 0.0, 1.0, 0.5, -0.5, -0.5, 1.0, 1.0, 0.5, -0.5, 0.5, 1.0, 0.0,
+	0.5, -0.5, 0.5, 1.0, 0.0, -0.5, -0.5, 0.5, 0.0, 0.0, -0.5, -0.5, -0.5, 0.0, 1.0,
+
+	-0.5, 0.5, -0.5, 0.0, 1.0, 0.5, 0.5, -0.5, 1.0, 1.0, 0.5, 0.5, 0.5, 1.0, 0.0,
+	0.5, 0.5, 0.5, 1.0, 0.0, -0.5, 0.5, 0.5, 0.0, 0.0, -0.5, 0.5, -0.5, 0.0, 1.0,
+}
+
+// weldTutorialCube 把没有索引的顶点数组焊接为带索引的网格
+func weldTutorialCube() *common.MeshData {
+	vertices, indices := common.WeldArray(tutorialCube, 5)
+	d := &common.MeshData{Indices: indices}
+	for i := 0; i+5 <= len(vertices); i += 5 {
+		d.Positions = append(d.Positions, mgl32.Vec3{vertices[i], vertices[i+1], vertices[i+2]})
+		d.UVs = append(d.UVs, mgl32.Vec2{vertices[i+3], vertices[i+4]})
+	}
+	return d
+}
+
+// shuffleTriangles 打乱三角形顺序, 模拟没有优化过的模型
+func shuffleTriangles(d *common.MeshData) *common.MeshData {
+	rng := rand.New(rand.NewSource(1))
+	tris := d.TriangleCount()
+	indices := make([]uint32, 0, len(d.Indices))
+	for _, i := range rng.Perm(tris) {
+		indices = append(indices, d.Indices[3*i:3*i+3]...)
+	}
+	d.Indices = indices
+	return d
+}
+
+var models = []struct {
+	name string
+	gen  func() *common.MeshData
+}{
+	{"tutorial cube", weldTutorialCube},
+	{"cylinder", func() *common.MeshData { return shuffleTriangles(common.GenCylinder(0.8, 1.6, 24, 4)) }},
+	{"icosphere", func() *common.MeshData { return shuffleTriangles(common.GenIcosphere(1, 3)) }},
+	{"torus", func() *common.MeshData { return shuffleTriangles(common.GenTorus(0.8, 0.35, 48, 24)) }},
+}
+
+func HelloTriangle() error {
+	err := glfw.Init()
+	if err != nil {
+		return err
+	}
+
+	defer glfw.Terminate() // glfw: 终止，清除所有先前分配的 GLFW 资源
+
+	// glfw: 初始化配置,设置gl版本
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 4)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	// Mac OS X 需要如下配置
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+
+	window, err := glfw.CreateWindow(ScreenWidth, ScreenHeight, "LearnOpenGL", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	window.MakeContextCurrent()
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		// 确保视口与新窗口尺寸匹配；请注意宽度和
+		// 高度将明显大于视网膜显示器上指定的高度
+		gl.Viewport(0, 0, int32(width), int32(height))
+	})
+
+	// 加载所有 OpenGL 方法
+	err = gl.Init()
+	if err != nil {
+		return err
+	}
+
+	// 配置全局 opengl 状态
+	gl.Enable(gl.DEPTH_TEST) // 启用深度测试
+
+	sd, err := common.NewShader(`
+#version 440 core
+layout (location = 0) in vec3 aPos;
+layout (location = 1) in vec3 aNormal;
+layout (location = 3) in vec4 aTangent;
+
+out vec3 Normal;
+out vec4 Tangent;
+
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+
+void main()
+{
+	gl_Position = projection * view * model * vec4(aPos, 1.0);
+	Normal = mat3(model) * aNormal;
+	Tangent = vec4(mat3(model) * aTangent.xyz, aTangent.w);
+}`, `
+#version 440 core
+out vec4 FragColor;
+
+in vec3 Normal;
+in vec4 Tangent;
+
+uniform bool showTangents;
+
+void main()
+{
+	vec3 n = normalize(Normal);
+	if (showTangents) {
+		// 副切线方向为负(镜像 UV)时颜色变暗
+		FragColor = vec4((normalize(Tangent.xyz) * 0.5 + 0.5) * (Tangent.w < 0.0 ? 0.5 : 1.0), 1.0);
+		return;
+	}
+	float diff = max(dot(n, normalize(vec3(0.4, 1.0, 0.6))), 0.0);
+	FragColor = vec4(vec3(0.8, 0.6, 0.4) * (0.2 + 0.8 * diff), 1.0);
+}`)
+	if err != nil {
+		return err
+	}
+
+	var (
+		current  = 0
+		smooth   = true
+		crease   = float32(60)
+		tangents = false
+		optimize = true
+
+		mesh  *common.Mesh
+		stats string
+	)
+	// rebuild 从原始数据开始按当前设置重新处理网格
+	rebuild := func() error {
+		d := models[current].gen()
+		if smooth {
+			d.SmoothNormals(crease)
+		} else {
+			d.FlatNormals()
+		}
+		d.GenerateTangents()
+
+		before := d.ACMR(common.VertexCacheSize)
+		if optimize {
+			d.OptimizeVertexCache()
+			d.OptimizeVertexFetch()
+		}
+		after := d.ACMR(common.VertexCacheSize)
+
+		m, err := d.Upload()
+		if err != nil {
+			return fmt.Errorf("%s: %w", models[current].name, err)
+		}
+		if mesh != nil {
+			mesh.Delete()
+		}
+		mesh = m
+
+		normals := "flat"
+		if smooth {
+			normals = fmt.Sprintf("smooth %.0f°", crease)
+		}
+		stats = fmt.Sprintf("%s, %s normals, %d vertices, %d triangles, ACMR %.2f -> %.2f, radius %.2f",
+			models[current].name, normals, d.VertexCount(), d.TriangleCount(), before, after, d.BoundingSphere().Radius)
+		return nil
+	}
+	err = rebuild()
+	if err != nil {
+		return err
+	}
+
+	var (
+		wireframe  bool
+		rebuildErr error
+	)
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action != glfw.Press && action != glfw.Repeat {
+			return
+		}
+
+		switch key {
+		case glfw.KeyEscape:
+			w.SetShouldClose(true)
+			return
+		case glfw.KeyTab:
+			current = (current + 1) % len(models)
+		case glfw.KeyN:
+			smooth = !smooth
+		case glfw.KeyUp:
+			crease = min(crease+10, 180)
+		case glfw.KeyDown:
+			crease = max(crease-10, 0)
+		case glfw.KeyO:
+			optimize = !optimize
+		case glfw.KeyT:
+			tangents = !tangents
+			return
+		case glfw.KeyF:
+			wireframe = !wireframe
+			return
+		default:
+			return
+		}
+		rebuildErr = rebuild()
+	})
+
+	for !window.ShouldClose() {
+		if rebuildErr != nil {
+			return rebuildErr
+		}
+		window.SetTitle("LearnOpenGL - " + stats)
+
+		// 渲染: 清空屏幕为背景颜色
+		gl.ClearColor(0.1, 0.1, 0.1, 1.0)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		if wireframe {
+			gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+		} else {
+			gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
+		}
+
+		sd.Use()
+		if tangents {
+			sd.SetInt("showTangents", 1)
+		} else {
+			sd.SetInt("showTangents", 0)
+		}
+		projection := mgl32.Perspective(mgl32.DegToRad(45), float32(ScreenWidth)/float32(ScreenHeight), 0.1, 100.0)
+		sd.SetMat("projection", 4, &projection[0])
+		view := mgl32.LookAtV(mgl32.Vec3{0, 1.5, 4}, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
+		sd.SetMat("view", 4, &view[0])
+		model := mgl32.HomogRotate3D(float32(glfw.GetTime())*0.5, mgl32.Vec3{0.3, 1, 0.2}.Normalize())
+		sd.SetMat("model", 4, &model[0])
+		mesh.Draw()
+
+		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+
+	// 可选：一旦超出其用途，就取消分配所有资源
+	// ------------------------------------------------------------------------
+	mesh.Delete()
+	sd.Del()
+
+	return nil
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+
+	"opengl/common"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// https://antongerdelan.net/opengl/raycasting.html
+
+func main() {
+	runtime.LockOSThread()
+
+	err := HelloTriangle()
+	if err != nil {
+		panic(err)
+	}
+}
+
+const (
+	ScreenWidth  = 800
+	ScreenHeight = 600
+)
+
+// object 可拾取的物体, intersect 在模型空间中求交
+type object struct {
+	name      string
+	mesh      *common.Mesh
+	model     mgl32.Mat4
+	color     mgl32.Vec3
+	intersect func(local common.Ray) (common.RayHit, bool)
+}
+
+func abs(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func HelloTriangle() error {
+	err := glfw.Init()
+	if err != nil {
+		return err
+	}
+
+	defer glfw.Terminate() // glfw: 终止，清除所有先前分配的 GLFW 资源
+
+	// glfw: 初始化配置,设置gl版本
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 4)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	// Mac OS X 需要如下配置
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+
+	window, err := glfw.CreateWindow(ScreenWidth, ScreenHeight, "LearnOpenGL", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	window.MakeContextCurrent()
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		// 确保视口与新窗口尺寸匹配；请注意宽度和
+		// 高度将明显大于视网膜显示器上指定的高度
+		gl.Viewport(0, 0, int32(width), int32(height))
+	})
+
+	var (
+		camera = common.NewCamera(
+			common.WithPosition(mgl32.Vec3{0, 2, 8}),
+		)
+
+		firstMouse         = true
+		lastX      float32 = ScreenWidth / 2.0
+		lastY      float32 = ScreenHeight / 2.0
+
+		// 光标被捕获时转动视角, 射线从屏幕中心发出; 释放后射线从光标位置发出
+		captured = true
+
+		deltaTime float32 = 0
+		lastFrame float32 = 0
+	)
+	// glfw：每当鼠标移动时，都会调用此回调
+	window.SetCursorPosCallback(func(w *glfw.Window, x float64, y float64) {
+		xPos := float32(x)
+		yPos := float32(y)
+
+		if firstMouse {
+			lastX = xPos
+			lastY = yPos
+			firstMouse = false
+		}
+
+		// 计算当前光标位置与上次位置的偏移量
+		xOffset := xPos - lastX
+		yOffset := lastY - yPos // 注意：y 轴是从下到上的
+		lastX = xPos
+		lastY = yPos
+
+		if captured {
+			camera.ProcessMouseMovement(xOffset, yOffset)
+		}
+	})
+	// glfw：每当鼠标滚轮滚动时，都会调用此回调
+	window.SetScrollCallback(func(w *glfw.Window, x float64, y float64) {
+		camera.ProcessMouseScroll(float32(y))
+	})
+	// 告诉 GLFW 捕获我们的鼠标
+	window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+
+	// 加载所有 OpenGL 方法
+	err = gl.Init()
+	if err != nil {
+		return err
+	}
+
+	// 配置全局 opengl 状态
+	gl.Enable(gl.DEPTH_TEST) // 启用深度测试
+
+	sd, err := common.NewShader(`
+#version 440 core
+layout (location = 0) in vec3 aPos;
+layout (location = 1) in vec3 aNormal;
+
+out vec3 Normal;
+
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+
+void main()
+{
+	gl_Position = projection * view * model * vec4(aPos, 1.0);
+	Normal = mat3(transpose(inverse(model))) * aNormal;
+}`, `
+#version 440 core
+out vec4 FragColor;
+
+in vec3 Normal;
+
+uniform vec3 color;
+uniform bool selected;
+
+void main()
+{
+	float diff = max(dot(normalize(Normal), normalize(vec3(0.4, 1.0, 0.6))), 0.0);
+	vec3 result = color * (0.3 + 0.7 * diff);
+	// 选中的物体叠加一层高亮
+	if (selected) {
+		result = mix(result, vec3(1.0, 0.9, 0.2), 0.5);
+	}
+	FragColor = vec4(result, 1.0);
+}`)
+	if err != nil {
+		return err
+	}
+
+	// 立方体用包围盒求交, 球体用球求交, 圆环用三角形网格求交, 地面用平面求交
+	cube, err := common.GenCube(1, 1).Upload()
+	if err != nil {
+		return err
+	}
+	sphere, err := common.GenUVSphere(0.5, 32, 16).Upload()
+	if err != nil {
+		return err
+	}
+	torusData := common.GenTorus(0.6, 0.2, 32, 16)
+	torus, err := torusData.Upload()
+	if err != nil {
+		return err
+	}
+	ground, err := common.GenPlane(20, 20, 1, 1).Upload()
+	if err != nil {
+		return err
+	}
+
+	cubeBox := common.AABB{Min: mgl32.Vec3{-0.5, -0.5, -0.5}, Max: mgl32.Vec3{0.5, 0.5, 0.5}}
+	objects := []object{
+		{name: "ground", mesh: ground, model: mgl32.Ident4(), color: mgl32.Vec3{0.4, 0.4, 0.4},
+			intersect: func(local common.Ray) (common.RayHit, bool) {
+				// 平面是无限大的, 只保留落在 20x20 地面内的命中
+				hit, ok := local.IntersectPlane(common.NewPlane(mgl32.Vec3{0, 1, 0}, mgl32.Vec3{}))
+				return hit, ok && max(abs(hit.Point[0]), abs(hit.Point[2])) <= 10
+			}},
+	}
+	for i := 0; i < 3; i++ {
+		x := float32(i-1) * 2.5
+		objects = append(objects,
+			object{name: fmt.Sprintf("cube %d", i), mesh: cube,
+				model: mgl32.Translate3D(x, 0.5, -2).Mul4(mgl32.HomogRotate3DY(mgl32.DegToRad(float32(i * 30)))),
+				color: mgl32.Vec3{0.8, 0.4, 0.2},
+				intersect: func(local common.Ray) (common.RayHit, bool) {
+					return local.IntersectAABB(cubeBox)
+				}},
+			object{name: fmt.Sprintf("sphere %d", i), mesh: sphere,
+				model: mgl32.Translate3D(x, 0.5, 0),
+				color: mgl32.Vec3{0.2, 0.5, 0.8},
+				intersect: func(local common.Ray) (common.RayHit, bool) {
+					return local.IntersectSphere(common.Sphere{Radius: 0.5})
+				}},
+			object{name: fmt.Sprintf("torus %d", i), mesh: torus,
+				model: mgl32.Translate3D(x, 0.8, 2).Mul4(mgl32.HomogRotate3DX(mgl32.DegToRad(60))),
+				color: mgl32.Vec3{0.3, 0.7, 0.3},
+				intersect: func(local common.Ray) (common.RayHit, bool) {
+					return local.IntersectMesh(torusData.Positions, torusData.Indices)
+				}},
+		)
+	}
+
+	// 命中点用一个小立方体标出
+	var (
+		selected = -1
+		marker   mgl32.Vec3
+	)
+	// glfw：鼠标左键拾取最近的物体
+	window.SetMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		if button != glfw.MouseButtonLeft || action != glfw.Press {
+			return
+		}
+
+		// 光标坐标以窗口坐标为单位, 与帧缓冲大小可能不同
+		width, height := w.GetSize()
+		x, y := float64(width)/2, float64(height)/2
+		if !captured {
+			x, y = w.GetCursorPos()
+		}
+		projection := camera.GetProjectionMatrix(float32(width)/float32(height), 0.1, 100.0)
+		ray := camera.CursorRay(x, y, width, height, projection)
+
+		selected = -1
+		var nearest common.RayHit
+		for i, o := range objects {
+			hit, ok := ray.IntersectModel(o.model, o.intersect)
+			if ok && (selected < 0 || hit.Distance < nearest.Distance) {
+				selected, nearest = i, hit
+			}
+		}
+
+		title := "LearnOpenGL - nothing selected"
+		if selected >= 0 {
+			marker = nearest.Point
+			title = fmt.Sprintf("LearnOpenGL - %s at (%.2f, %.2f, %.2f), distance %.2f",
+				objects[selected].name, marker[0], marker[1], marker[2], nearest.Distance)
+		}
+		w.SetTitle(title)
+	})
+	// glfw：Tab 切换光标捕获
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if key != glfw.KeyTab || action != glfw.Press {
+			return
+		}
+
+		captured = !captured
+		if captured {
+			w.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+		} else {
+			w.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
+		}
+		firstMouse = true
+	})
+
+	for !window.ShouldClose() {
+		// 每帧时间逻辑
+		currentFrame := float32(glfw.GetTime())
+		deltaTime = currentFrame - lastFrame
+		lastFrame = currentFrame
+
+		if window.GetKey(glfw.KeyEscape) == glfw.Press {
+			window.SetShouldClose(true)
+		}
+		if window.GetKey(glfw.KeyW) == glfw.Press {
+			camera.ProcessKeyboard(common.ForWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyS) == glfw.Press {
+			camera.ProcessKeyboard(common.BackWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyA) == glfw.Press {
+			camera.ProcessKeyboard(common.Left, deltaTime)
+		}
+		if window.GetKey(glfw.KeyD) == glfw.Press {
+			camera.ProcessKeyboard(common.Right, deltaTime)
+		}
+
+		// 渲染: 清空屏幕为背景颜色
+		gl.ClearColor(0.2, 0.3, 0.3, 1.0)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		sd.Use()
+		width, height := window.GetSize()
+		projection := camera.GetProjectionMatrix(float32(width)/float32(max(height, 1)), 0.1, 100.0)
+		sd.SetMat("projection", 4, &projection[0])
+		view := camera.GetViewMatrix()
+		sd.SetMat("view", 4, &view[0])
+
+		for i, o := range objects {
+			sd.SetMat("model", 4, &o.model[0])
+			sd.SetFloat("color", o.color[0], o.color[1], o.color[2])
+			if i == selected {
+				sd.SetInt("selected", 1)
+			} else {
+				sd.SetInt("selected", 0)
+			}
+			o.mesh.Draw()
+		}
+
+		if selected >= 0 {
+			model := mgl32.Translate3D(marker[0], marker[1], marker[2]).Mul4(mgl32.Scale3D(0.08, 0.08, 0.08))
+			sd.SetMat("model", 4, &model[0])
+			sd.SetFloat("color", 1, 0, 0)
+			sd.SetInt("selected", 0)
+			cube.Draw()
+		}
+
+		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+
+	// 可选：一旦超出其用途，就取消分配所有资源
+	// ------------------------------------------------------------------------
+	cube.Delete()
+	sphere.Delete()
+	torus.Delete()
+	ground.Delete()
+	sd.Del()
+
+	return nil
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"math"
+	"runtime"
+
+	"opengl/common"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// https://en.wikipedia.org/wiki/Centripetal_Catmull%E2%80%93Rom_spline
+// https://en.wikipedia.org/wiki/B%C3%A9zier_surface
+
+func main() {
+	runtime.LockOSThread()
+
+	err := HelloTriangle()
+	if err != nil {
+		panic(err)
+	}
+}
+
+const (
+	ScreenWidth  = 800
+	ScreenHeight = 600
+)
+
+// plot 一个图元及其摆放位置
+type plot struct {
+	data  *common.MeshData
+	mesh  *common.Mesh
+	model mgl32.Mat4
+}
+
+// colorByAxis 按某个坐标轴的值着色
+func colorByAxis(d *common.MeshData, c common.Colormap, axis int) error {
+	values := make([]float32, len(d.Positions))
+	for i, p := range d.Positions {
+		values[i] = p[axis]
+	}
+	return d.ApplyColormap(c, values, 0, 0)
+}
+
+// colorByUV 按 UV 的一个分量着色, 管道的 v 为沿曲线的弧长, 曲线的 u 为参数
+func colorByUV(d *common.MeshData, c common.Colormap, component int) error {
+	values := make([]float32, len(d.Positions))
+	for i, uv := range d.UVs {
+		values[i] = uv[component]
+	}
+	return d.ApplyColormap(c, values, 0, 1)
+}
+
+func plots() ([]*plot, error) {
+	// sinc 函数曲面, 圆外的部分返回 NaN 留空
+	sinc := common.GenFunctionSurface(func(x, y float32) float32 {
+		r := math.Hypot(float64(x), float64(y))
+		if r > 10 {
+			return float32(math.NaN())
+		}
+		if r < 1e-6 {
+			return 1
+		}
+		return float32(math.Sin(r) / r)
+	}, mgl32.Vec2{-10, -10}, mgl32.Vec2{10, 10}, 120, 120)
+	sinc.Transform(mgl32.Scale3D(0.2, 2, 0.2))
+	err := colorByAxis(sinc, common.ColormapViridis, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	// 双三次 Bezier 曲面片
+	bezier, err := common.GenBezierSurface([][]mgl32.Vec3{
+		{{-1.5, 0, 1.5}, {-0.5, 0.5, 1.5}, {0.5, -0.5, 1.5}, {1.5, 0, 1.5}},
+		{{-1.5, 0.5, 0.5}, {-0.5, 2, 0.5}, {0.5, 0, 0.5}, {1.5, -0.5, 0.5}},
+		{{-1.5, -0.5, -0.5}, {-0.5, 0, -0.5}, {0.5, 2, -0.5}, {1.5, 0.5, -0.5}},
+		{{-1.5, 0, -1.5}, {-0.5, -0.5, -1.5}, {0.5, 0.5, -1.5}, {1.5, 0, -1.5}},
+	}, 32, 32)
+	if err != nil {
+		return nil, err
+	}
+	err = colorByAxis(bezier, common.ColormapCoolWarm, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	// 三叶结: 稀疏的控制点用 Catmull-Rom 样条插值后生成管道
+	var knot []mgl32.Vec3
+	for i := 0; i < 12; i++ {
+		a := float64(i) / 12 * 2 * math.Pi
+		knot = append(knot, mgl32.Vec3{
+			float32(math.Sin(a)+2*math.Sin(2*a)) * 0.5,
+			float32(math.Cos(a)-2*math.Cos(2*a)) * 0.5,
+			float32(-math.Sin(3*a)) * 0.5,
+		})
+	}
+	tube := common.GenTube(common.CatmullRom(knot, 12, true), 0.15, 16, true)
+	err = colorByUV(tube, common.ColormapInferno, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	// 莫比乌斯带, 单侧曲面, 着色器中背面翻转法线
+	mobius := common.GenParametricSurface(func(u, v float32) mgl32.Vec3 {
+		theta := float64(u) * 2 * math.Pi
+		s := float64(v)*2 - 1
+		r := 1 + s/2*math.Cos(theta/2)
+		return mgl32.Vec3{
+			float32(r * math.Cos(theta)),
+			float32(s / 2 * math.Sin(theta/2)),
+			float32(r * math.Sin(theta)),
+		}
+	}, 96, 8)
+	err = colorByUV(mobius, common.ColormapJet, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// 李萨如曲线
+	curve := common.GenParametricCurve(func(t float32) mgl32.Vec3 {
+		a := float64(t) * 2 * math.Pi
+		return mgl32.Vec3{float32(math.Sin(3 * a)), float32(math.Sin(4 * a)), float32(math.Sin(5 * a))}
+	}, 512)
+	err = colorByUV(curve, common.ColormapJet, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	grid := common.GenGrid(12, 12)
+
+	return []*plot{
+		{data: sinc, model: mgl32.Translate3D(-2.5, 0, -2.5)},
+		{data: bezier, model: mgl32.Translate3D(2.5, 0, -2.5)},
+		{data: tube, model: mgl32.Translate3D(-2.5, 1, 2.5)},
+		{data: mobius, model: mgl32.Translate3D(2.5, 0.5, 2.5)},
+		{data: curve, model: mgl32.Translate3D(0, 1.5, 0).Mul4(mgl32.Scale3D(0.8, 0.8, 0.8))},
+		{data: grid, model: mgl32.Translate3D(0, -0.5, 0)},
+	}, nil
+}
+
+func HelloTriangle() error {
+	err := glfw.Init()
+	if err != nil {
+		return err
+	}
+
+	defer glfw.Terminate() // glfw: 终止，清除所有先前分配的 GLFW 资源
+
+	// glfw: 初始化配置,设置gl版本
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 4)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	// Mac OS X 需要如下配置
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+
+	window, err := glfw.CreateWindow(ScreenWidth, ScreenHeight, "LearnOpenGL", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	window.MakeContextCurrent()
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		// 确保视口与新窗口尺寸匹配；请注意宽度和
+		// 高度将明显大于视网膜显示器上指定的高度
+		gl.Viewport(0, 0, int32(width), int32(height))
+	})
+
+	var (
+		camera = common.NewCamera(
+			common.WithPosition(mgl32.Vec3{0, 4, 10}),
+		)
+
+		firstMouse         = true
+		lastX      float32 = ScreenWidth / 2.0
+		lastY      float32 = ScreenHeight / 2.0
+
+		deltaTime float32 = 0
+		lastFrame float32 = 0
+	)
+	// glfw：每当鼠标移动时，都会调用此回调
+	window.SetCursorPosCallback(func(w *glfw.Window, x float64, y float64) {
+		xPos := float32(x)
+		yPos := float32(y)
+
+		if firstMouse {
+			lastX = xPos
+			lastY = yPos
+			firstMouse = false
+		}
+
+		// 计算当前光标位置与上次位置的偏移量
+		xOffset := xPos - lastX
+		yOffset := lastY - yPos // 注意：y 轴是从下到上的
+		lastX = xPos
+		lastY = yPos
+
+		camera.ProcessMouseMovement(xOffset, yOffset)
+	})
+	// glfw：每当鼠标滚轮滚动时，都会调用此回调
+	window.SetScrollCallback(func(w *glfw.Window, x float64, y float64) {
+		camera.ProcessMouseScroll(float32(y))
+	})
+	// 告诉 GLFW 捕获我们的鼠标
+	window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+
+	// 加载所有 OpenGL 方法
+	err = gl.Init()
+	if err != nil {
+		return err
+	}
+
+	// 配置全局 opengl 状态
+	gl.Enable(gl.DEPTH_TEST) // 启用深度测试
+
+	sd, err := common.NewShader(`
+#version 440 core
+layout (location = 0) in vec3 aPos;
+layout (location = 1) in vec3 aNormal;
+layout (location = 4) in vec4 aColor;
+
+out vec3 Normal;
+out vec4 Color;
+
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+
+void main()
+{
+	gl_Position = projection * view * model * vec4(aPos, 1.0);
+	Normal = mat3(transpose(inverse(model))) * aNormal;
+	Color = aColor;
+}`, `
+#version 440 core
+out vec4 FragColor;
+
+in vec3 Normal;
+in vec4 Color;
+
+void main()
+{
+	// 线段没有法线, 直接输出颜色
+	if (length(Normal) < 0.5) {
+		FragColor = Color;
+		return;
+	}
+
+	// 曲面的两面都可见, 背面翻转法线
+	vec3 norm = normalize(gl_FrontFacing ? Normal : -Normal);
+	float diff = max(dot(norm, normalize(vec3(0.4, 1.0, 0.6))), 0.0);
+	FragColor = vec4(Color.rgb * (0.3 + 0.7 * diff), Color.a);
+}`)
+	if err != nil {
+		return err
+	}
+
+	scene, err := plots()
+	if err != nil {
+		return err
+	}
+	for _, p := range scene {
+		p.mesh, err = p.data.Upload()
+		if err != nil {
+			return err
+		}
+	}
+
+	for !window.ShouldClose() {
+		// 每帧时间逻辑
+		currentFrame := float32(glfw.GetTime())
+		deltaTime = currentFrame - lastFrame
+		lastFrame = currentFrame
+
+		if window.GetKey(glfw.KeyEscape) == glfw.Press {
+			window.SetShouldClose(true)
+		}
+		if window.GetKey(glfw.KeyW) == glfw.Press {
+			camera.ProcessKeyboard(common.ForWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyS) == glfw.Press {
+			camera.ProcessKeyboard(common.BackWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyA) == glfw.Press {
+			camera.ProcessKeyboard(common.Left, deltaTime)
+		}
+		if window.GetKey(glfw.KeyD) == glfw.Press {
+			camera.ProcessKeyboard(common.Right, deltaTime)
+		}
+
+		// 渲染: 清空屏幕为背景颜色
+		gl.ClearColor(0.1, 0.1, 0.1, 1.0)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		sd.Use()
+		projection := camera.GetProjectionMatrix(float32(ScreenWidth)/float32(ScreenHeight), 0.1, 100.0)
+		sd.SetMat("projection", 4, &projection[0])
+		view := camera.GetViewMatrix()
+		sd.SetMat("view", 4, &view[0])
+
+		// 曲面缓慢自转
+		spin := mgl32.HomogRotate3DY(currentFrame * 0.3)
+		for _, p := range scene {
+			model := p.model
+			if p.data.Topology == common.Triangles {
+				model = model.Mul4(spin)
+			}
+			sd.SetMat("model", 4, &model[0])
+			p.mesh.Draw()
+		}
+
+		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+
+	// 可选：一旦超出其用途，就取消分配所有资源
+	// ------------------------------------------------------------------------
+	for _, p := range scene {
+		p.mesh.Delete()
+	}
+	sd.Del()
+
+	return nil
+}
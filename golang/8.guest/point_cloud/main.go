@@ -0,0 +1,246 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"opengl/common"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// 点云渲染: 读取 ply/stl 文件的顶点, 或者在圆环面上随机采样
+// WASD 移动, 滚轮缩放, 上下方向键调整点的大小, P 切换像素/世界空间大小, Q 切换圆形/方形点
+
+var modelFlag = flag.String("model", "", "point cloud to show (.ply or .stl), random samples on a torus are used when empty")
+
+func main() {
+	runtime.LockOSThread()
+	flag.Parse()
+
+	err := HelloTriangle()
+	if err != nil {
+		panic(err)
+	}
+}
+
+const (
+	ScreenWidth  = 800
+	ScreenHeight = 600
+
+	SampleCount = 200000 // 没有指定文件时采样的点数
+)
+
+// samplePoints 在圆环面上均匀采样, 颜色按绕圆环的角度取色表
+func samplePoints(n int) *common.MeshData {
+	const radius, tube = 1, 0.35
+	rng := rand.New(rand.NewSource(1))
+
+	d := &common.MeshData{Topology: common.Points}
+	for len(d.Positions) < n {
+		u, v := rng.Float64()*2*math.Pi, rng.Float64()*2*math.Pi
+		// 按面积密度拒绝采样, 外圈比内圈面积大
+		if rng.Float64()*(radius+tube) > radius+tube*math.Cos(v) {
+			continue
+		}
+		r := radius + tube*math.Cos(v)
+		d.Positions = append(d.Positions, mgl32.Vec3{
+			float32(r * math.Cos(u)),
+			float32(tube * math.Sin(v)),
+			float32(r * math.Sin(u)),
+		})
+		d.Colors = append(d.Colors, common.ColormapViridis.At(float32(u/(2*math.Pi))))
+	}
+	return d
+}
+
+// loadPoints 读取文件中的顶点, 缩放到原点处半径为 1 的包围球内
+func loadPoints(name string) (*common.MeshData, error) {
+	if name == "" {
+		return samplePoints(SampleCount), nil
+	}
+
+	var d *common.MeshData
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ply":
+		m, err := common.LoadPLY(name)
+		if err != nil {
+			return nil, err
+		}
+		d = m.Mesh
+	case ".stl":
+		m, err := common.LoadSTL(name)
+		if err != nil {
+			return nil, err
+		}
+		d = m
+	default:
+		return nil, fmt.Errorf("%s: unsupported point cloud format", name)
+	}
+	if len(d.Positions) == 0 {
+		return nil, fmt.Errorf("%s: no vertex", name)
+	}
+
+	s := d.BoundingSphere()
+	d.Transform(mgl32.Scale3D(1/s.Radius, 1/s.Radius, 1/s.Radius).
+		Mul4(mgl32.Translate3D(-s.Center[0], -s.Center[1], -s.Center[2])))
+	return d, nil
+}
+
+func HelloTriangle() error {
+	err := glfw.Init()
+	if err != nil {
+		return err
+	}
+
+	defer glfw.Terminate() // glfw: 终止，清除所有先前分配的 GLFW 资源
+
+	// glfw: 初始化配置,设置gl版本
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 4)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	// Mac OS X 需要如下配置
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+
+	window, err := glfw.CreateWindow(ScreenWidth, ScreenHeight, "LearnOpenGL", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	window.MakeContextCurrent()
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		// 确保视口与新窗口尺寸匹配；请注意宽度和
+		// 高度将明显大于视网膜显示器上指定的高度
+		gl.Viewport(0, 0, int32(width), int32(height))
+	})
+
+	var (
+		camera = common.NewCamera(common.WithPosition(mgl32.Vec3{0, 0.5, 3}))
+
+		firstMouse         = true
+		lastX      float32 = ScreenWidth / 2.0
+		lastY      float32 = ScreenHeight / 2.0
+
+		deltaTime float32 = 0
+		lastFrame float32 = 0
+	)
+	// glfw：每当鼠标移动时，都会调用此回调
+	window.SetCursorPosCallback(func(w *glfw.Window, x float64, y float64) {
+		xPos := float32(x)
+		yPos := float32(y)
+
+		if firstMouse {
+			lastX = xPos
+			lastY = yPos
+			firstMouse = false
+		}
+
+		// 计算当前光标位置与上次位置的偏移量
+		xOffset := xPos - lastX
+		yOffset := lastY - yPos // 注意：y 轴是从下到上的
+		lastX = xPos
+		lastY = yPos
+
+		camera.ProcessMouseMovement(xOffset, yOffset)
+	})
+	// glfw：每当鼠标滚轮滚动时，都会调用此回调
+	window.SetScrollCallback(func(w *glfw.Window, x float64, y float64) {
+		camera.ProcessMouseScroll(float32(y))
+	})
+	// 告诉 GLFW 捕获我们的鼠标
+	window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+
+	// 加载所有 OpenGL 方法
+	err = gl.Init()
+	if err != nil {
+		return err
+	}
+
+	// 配置全局 opengl 状态
+	gl.Enable(gl.DEPTH_TEST) // 启用深度测试
+
+	data, err := loadPoints(*modelFlag)
+	if err != nil {
+		return err
+	}
+	cloud, err := common.NewPointCloud(data, common.WithPointSize(4, false))
+	if err != nil {
+		return err
+	}
+
+	// 像素和世界空间两种大小分别调整
+	sizes := map[bool]float32{false: 4, true: 0.01}
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action != glfw.Press {
+			return
+		}
+		switch key {
+		case glfw.KeyP:
+			sizes[cloud.WorldSize] = cloud.PointSize
+			cloud.WorldSize = !cloud.WorldSize
+			cloud.PointSize = sizes[cloud.WorldSize]
+		case glfw.KeyQ:
+			cloud.Round = !cloud.Round
+		}
+	})
+
+	for !window.ShouldClose() {
+		// 每帧时间逻辑
+		currentFrame := float32(glfw.GetTime())
+		deltaTime = currentFrame - lastFrame
+		lastFrame = currentFrame
+
+		if window.GetKey(glfw.KeyEscape) == glfw.Press {
+			window.SetShouldClose(true)
+		}
+		if window.GetKey(glfw.KeyW) == glfw.Press {
+			camera.ProcessKeyboard(common.ForWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyS) == glfw.Press {
+			camera.ProcessKeyboard(common.BackWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyA) == glfw.Press {
+			camera.ProcessKeyboard(common.Left, deltaTime)
+		}
+		if window.GetKey(glfw.KeyD) == glfw.Press {
+			camera.ProcessKeyboard(common.Right, deltaTime)
+		}
+		if window.GetKey(glfw.KeyUp) == glfw.Press {
+			cloud.PointSize *= 1 + deltaTime
+		}
+		if window.GetKey(glfw.KeyDown) == glfw.Press {
+			cloud.PointSize /= 1 + deltaTime
+		}
+
+		// 渲染: 清空屏幕为背景颜色
+		gl.ClearColor(0.1, 0.1, 0.1, 1.0)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		projection := camera.GetProjectionMatrix(float32(ScreenWidth)/float32(ScreenHeight), 0.1, 100.0)
+		view := camera.GetViewMatrix()
+		cloud.Draw(mgl32.Ident4(), view, projection, ScreenHeight)
+
+		unit := "px"
+		if cloud.WorldSize {
+			unit = " world units"
+		}
+		window.SetTitle(fmt.Sprintf("LearnOpenGL - %d points, size %.3g%s", len(data.Positions), cloud.PointSize, unit))
+
+		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+
+	// 可选：一旦超出其用途，就取消分配所有资源
+	// ------------------------------------------------------------------------
+	cloud.Delete()
+
+	return nil
+}
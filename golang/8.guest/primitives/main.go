@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+
+	"opengl/common"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// 程序化生成的基本几何体: 立方体、UV 球、正二十面体细分球、平面、圆柱、圆锥、圆环、胶囊和参考网格
+// M 切换显示模式(光照/法线/切线/UV), F 切换线框, +/- 调整细分程度
+
+func main() {
+	runtime.LockOSThread()
+
+	err := HelloTriangle()
+	if err != nil {
+		panic(err)
+	}
+}
+
+const (
+	ScreenWidth  = 800
+	ScreenHeight = 600
+)
+
+var modeNames = []string{"lit", "normal", "tangent", "uv"}
+
+// primitive 一个几何体及其在场景中的位置
+type primitive struct {
+	name string
+	gen  func(detail int) *common.MeshData
+	pos  mgl32.Vec3
+	mesh *common.Mesh
+}
+
+func HelloTriangle() error {
+	err := glfw.Init()
+	if err != nil {
+		return err
+	}
+
+	defer glfw.Terminate() // glfw: 终止，清除所有先前分配的 GLFW 资源
+
+	// glfw: 初始化配置,设置gl版本
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 4)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	// Mac OS X 需要如下配置
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+
+	window, err := glfw.CreateWindow(ScreenWidth, ScreenHeight, "LearnOpenGL", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	window.MakeContextCurrent()
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		// 确保视口与新窗口尺寸匹配；请注意宽度和
+		// 高度将明显大于视网膜显示器上指定的高度
+		gl.Viewport(0, 0, int32(width), int32(height))
+	})
+
+	// 加载所有 OpenGL 方法
+	err = gl.Init()
+	if err != nil {
+		return err
+	}
+
+	// 配置全局 opengl 状态
+	gl.Enable(gl.DEPTH_TEST) // 启用深度测试
+
+	sd, err := common.NewShader(`
+#version 440 core
+layout (location = 0) in vec3 aPos;
+layout (location = 1) in vec3 aNormal;
+layout (location = 2) in vec2 aTexCoord;
+layout (location = 3) in vec4 aTangent;
+
+out vec3 Normal;
+out vec3 Tangent;
+out vec2 TexCoord;
+
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+
+void main()
+{
+	gl_Position = projection * view * model * vec4(aPos, 1.0);
+	Normal = mat3(model) * aNormal;
+	Tangent = mat3(model) * aTangent.xyz;
+	TexCoord = aTexCoord;
+}`, `
+#version 440 core
+out vec4 FragColor;
+
+in vec3 Normal;
+in vec3 Tangent;
+in vec2 TexCoord;
+
+uniform int mode;
+
+void main()
+{
+	vec3 n = normalize(Normal);
+	if (mode == 1) {
+		FragColor = vec4(n * 0.5 + 0.5, 1.0);
+	} else if (mode == 2) {
+		FragColor = vec4(normalize(Tangent) * 0.5 + 0.5, 1.0);
+	} else if (mode == 3) {
+		FragColor = vec4(fract(TexCoord), 0.0, 1.0);
+	} else {
+		// 用 UV 生成棋盘格, 方便观察纹理坐标的拉伸
+		vec2 c = floor(TexCoord * 8.0);
+		float checker = mod(c.x + c.y, 2.0) * 0.3 + 0.6;
+		float diff = max(dot(n, normalize(vec3(0.4, 1.0, 0.6))), 0.0);
+		FragColor = vec4(vec3(0.9, 0.7, 0.4) * checker * (0.25 + 0.75 * diff), 1.0);
+	}
+}`)
+	if err != nil {
+		return err
+	}
+
+	primitives := []*primitive{
+		{name: "cube", pos: mgl32.Vec3{-3, 0, -3}, gen: func(d int) *common.MeshData { return common.GenCube(1.4, d) }},
+		{name: "uv sphere", pos: mgl32.Vec3{0, 0, -3}, gen: func(d int) *common.MeshData { return common.GenUVSphere(0.8, d*8, d*4) }},
+		{name: "icosphere", pos: mgl32.Vec3{3, 0, -3}, gen: func(d int) *common.MeshData { return common.GenIcosphere(0.8, min(d-1, 5)) }},
+		{name: "cylinder", pos: mgl32.Vec3{-3, 0, 0}, gen: func(d int) *common.MeshData { return common.GenCylinder(0.6, 1.4, d*8, d) }},
+		{name: "cone", pos: mgl32.Vec3{0, 0, 0}, gen: func(d int) *common.MeshData { return common.GenCone(0.7, 1.4, d*8, d) }},
+		{name: "torus", pos: mgl32.Vec3{3, 0, 0}, gen: func(d int) *common.MeshData { return common.GenTorus(0.6, 0.25, d*8, d*4) }},
+		{name: "capsule", pos: mgl32.Vec3{-3, 0, 3}, gen: func(d int) *common.MeshData { return common.GenCapsule(0.4, 0.8, d*8, d*2) }},
+		{name: "plane", pos: mgl32.Vec3{0, 0, 3}, gen: func(d int) *common.MeshData { return common.GenPlane(1.6, 1.6, d, d) }},
+	}
+
+	grid, err := common.GenGrid(12, 12).Upload()
+	if err != nil {
+		return err
+	}
+
+	var (
+		detail    = 2
+		mode      = 0
+		wireframe = false
+
+		vertices, triangles int
+	)
+	// rebuild 按当前细分程度重新生成所有几何体
+	rebuild := func() error {
+		vertices, triangles = 0, 0
+		for _, p := range primitives {
+			d := p.gen(detail)
+			vertices += d.VertexCount()
+			triangles += d.TriangleCount()
+
+			m, err := d.Upload()
+			if err != nil {
+				return fmt.Errorf("%s: %w", p.name, err)
+			}
+			if p.mesh != nil {
+				p.mesh.Delete()
+			}
+			p.mesh = m
+		}
+		return nil
+	}
+	err = rebuild()
+	if err != nil {
+		return err
+	}
+
+	var rebuildErr error
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action != glfw.Press && action != glfw.Repeat {
+			return
+		}
+
+		switch key {
+		case glfw.KeyEscape:
+			w.SetShouldClose(true)
+		case glfw.KeyM:
+			mode = (mode + 1) % len(modeNames)
+		case glfw.KeyF:
+			wireframe = !wireframe
+		case glfw.KeyEqual, glfw.KeyKPAdd:
+			if detail < 8 {
+				detail++
+				rebuildErr = rebuild()
+			}
+		case glfw.KeyMinus, glfw.KeyKPSubtract:
+			if detail > 1 {
+				detail--
+				rebuildErr = rebuild()
+			}
+		}
+	})
+
+	for !window.ShouldClose() {
+		if rebuildErr != nil {
+			return rebuildErr
+		}
+
+		window.SetTitle(fmt.Sprintf("LearnOpenGL - %s, detail %d, %d vertices, %d triangles",
+			modeNames[mode], detail, vertices, triangles))
+
+		// 渲染: 清空屏幕为背景颜色
+		gl.ClearColor(0.1, 0.1, 0.1, 1.0)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		if wireframe {
+			gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+		} else {
+			gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
+		}
+
+		sd.Use()
+		sd.SetInt("mode", int32(mode))
+		projection := mgl32.Perspective(mgl32.DegToRad(45), float32(ScreenWidth)/float32(ScreenHeight), 0.1, 100.0)
+		sd.SetMat("projection", 4, &projection[0])
+		view := mgl32.LookAtV(mgl32.Vec3{0, 6, 9}, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
+		sd.SetMat("view", 4, &view[0])
+
+		model := mgl32.Translate3D(0, -1, 0)
+		sd.SetMat("model", 4, &model[0])
+		grid.Draw()
+
+		angle := float32(glfw.GetTime()) * 0.5
+		for _, p := range primitives {
+			model := mgl32.Translate3D(p.pos[0], p.pos[1], p.pos[2]).
+				Mul4(mgl32.HomogRotate3D(angle, mgl32.Vec3{0.3, 1, 0.2}.Normalize()))
+			sd.SetMat("model", 4, &model[0])
+			p.mesh.Draw()
+		}
+
+		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+
+	// 可选：一旦超出其用途，就取消分配所有资源
+	// ------------------------------------------------------------------------
+	for _, p := range primitives {
+		p.mesh.Delete()
+	}
+	grid.Delete()
+	sd.Del()
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+
+	"opengl/common"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// 场景图: 太阳、地球和月球的层级变换, 子节点跟随父节点运动
+// R 把月球在地球轨道和根节点之间切换(保持世界变换不变), H 隐藏地球及其子树, 空格暂停
+
+func main() {
+	runtime.LockOSThread()
+
+	err := HelloTriangle()
+	if err != nil {
+		panic(err)
+	}
+}
+
+const (
+	ScreenWidth  = 800
+	ScreenHeight = 600
+)
+
+func HelloTriangle() error {
+	err := glfw.Init()
+	if err != nil {
+		return err
+	}
+
+	defer glfw.Terminate() // glfw: 终止，清除所有先前分配的 GLFW 资源
+
+	// glfw: 初始化配置,设置gl版本
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 4)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	// Mac OS X 需要如下配置
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+
+	window, err := glfw.CreateWindow(ScreenWidth, ScreenHeight, "LearnOpenGL", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	window.MakeContextCurrent()
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		// 确保视口与新窗口尺寸匹配；请注意宽度和
+		// 高度将明显大于视网膜显示器上指定的高度
+		gl.Viewport(0, 0, int32(width), int32(height))
+	})
+
+	// 加载所有 OpenGL 方法
+	err = gl.Init()
+	if err != nil {
+		return err
+	}
+
+	// 配置全局 opengl 状态
+	gl.Enable(gl.DEPTH_TEST) // 启用深度测试
+
+	sd, err := common.NewShader(`
+#version 440 core
+layout (location = 0) in vec3 aPos;
+layout (location = 1) in vec3 aNormal;
+
+out vec3 Normal;
+out vec3 FragPos;
+
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+
+void main()
+{
+	FragPos = vec3(model * vec4(aPos, 1.0));
+	Normal = mat3(transpose(inverse(model))) * aNormal;
+	gl_Position = projection * view * vec4(FragPos, 1.0);
+}`, `
+#version 440 core
+out vec4 FragColor;
+
+in vec3 Normal;
+in vec3 FragPos;
+
+uniform vec3 color;
+uniform bool emissive;
+
+void main()
+{
+	if (emissive) {
+		FragColor = vec4(color, 1.0);
+		return;
+	}
+	// 光源位于原点的太阳
+	float diff = max(dot(normalize(Normal), normalize(-FragPos)), 0.0);
+	FragColor = vec4(color * (0.1 + 0.9 * diff), 1.0);
+}`)
+	if err != nil {
+		return err
+	}
+
+	sphere, err := common.GenUVSphere(1, 32, 16).Upload()
+	if err != nil {
+		return err
+	}
+
+	// 轨道节点只有变换没有网格, 旋转它就能带动子节点公转
+	var (
+		root       = common.NewNode("root")
+		sun        = common.NewNode("sun", common.WithMesh(sphere), common.WithMaterial(mgl32.Vec3{1, 0.8, 0.3}))
+		earthOrbit = common.NewNode("earth orbit")
+		earth      = common.NewNode("earth", common.WithMesh(sphere), common.WithMaterial(mgl32.Vec3{0.2, 0.4, 1}))
+		moonOrbit  = common.NewNode("moon orbit")
+		moon       = common.NewNode("moon", common.WithMesh(sphere), common.WithMaterial(mgl32.Vec3{0.7, 0.7, 0.7}))
+	)
+	earth.SetScale(mgl32.Vec3{0.4, 0.4, 0.4})
+	// 地球位于月球轨道的中心
+	moonOrbit.SetTranslation(mgl32.Vec3{5, 0, 0})
+	moon.SetTranslation(mgl32.Vec3{1, 0, 0})
+	moon.SetScale(mgl32.Vec3{0.15, 0.15, 0.15})
+
+	for _, edge := range []struct{ parent, child *common.Node }{
+		{root, sun},
+		{root, earthOrbit},
+		{earthOrbit, moonOrbit},
+		{moonOrbit, earth},
+		{moonOrbit, moon},
+	} {
+		err = edge.parent.AddChild(edge.child)
+		if err != nil {
+			return err
+		}
+	}
+
+	var (
+		paused    bool
+		moonFree  bool
+		sceneErr  error
+		lastFrame = float32(glfw.GetTime())
+	)
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action != glfw.Press {
+			return
+		}
+
+		switch key {
+		case glfw.KeyEscape:
+			w.SetShouldClose(true)
+		case glfw.KeySpace:
+			paused = !paused
+		case glfw.KeyH:
+			earthOrbit.Visible = !earthOrbit.Visible
+		case glfw.KeyR:
+			// 重新挂接时保持世界变换, 月球停在原地或从当前位置继续绕地球转
+			if moonFree {
+				sceneErr = moon.Reparent(moonOrbit)
+			} else {
+				sceneErr = moon.Reparent(root)
+			}
+			moonFree = !moonFree
+		}
+	})
+
+	renderer := common.RenderFunc(func(node *common.Node, world mgl32.Mat4) {
+		color := node.Material.(mgl32.Vec3)
+		sd.SetFloat("color", color[:]...)
+		if node == sun {
+			sd.SetInt("emissive", 1)
+		} else {
+			sd.SetInt("emissive", 0)
+		}
+		sd.SetMat("model", 4, &world[0])
+		node.Mesh.Draw()
+	})
+
+	for !window.ShouldClose() {
+		if sceneErr != nil {
+			return sceneErr
+		}
+
+		// 每帧时间逻辑
+		currentFrame := float32(glfw.GetTime())
+		deltaTime := currentFrame - lastFrame
+		lastFrame = currentFrame
+
+		if !paused {
+			earthOrbit.Rotate(mgl32.QuatRotate(deltaTime*0.3, mgl32.Vec3{0, 1, 0}))
+			moonOrbit.Rotate(mgl32.QuatRotate(deltaTime*1.5, mgl32.Vec3{0, 1, 0}))
+			sun.Rotate(mgl32.QuatRotate(deltaTime*0.1, mgl32.Vec3{0, 1, 0}))
+		}
+
+		parent := moon.Parent().Name
+		p := moon.WorldPosition()
+		window.SetTitle(fmt.Sprintf("LearnOpenGL - moon parent %q, world position (%.2f, %.2f, %.2f)", parent, p[0], p[1], p[2]))
+
+		// 渲染: 清空屏幕为背景颜色
+		gl.ClearColor(0.02, 0.02, 0.05, 1.0)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		sd.Use()
+		projection := mgl32.Perspective(mgl32.DegToRad(45), float32(ScreenWidth)/float32(ScreenHeight), 0.1, 100.0)
+		sd.SetMat("projection", 4, &projection[0])
+		view := mgl32.LookAtV(mgl32.Vec3{0, 6, 10}, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
+		sd.SetMat("view", 4, &view[0])
+
+		root.Render(renderer)
+
+		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+
+	// 可选：一旦超出其用途，就取消分配所有资源
+	// ------------------------------------------------------------------------
+	sphere.Delete()
+	sd.Del()
+
+	return nil
+}
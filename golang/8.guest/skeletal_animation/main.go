@@ -0,0 +1,318 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+
+	"opengl/common"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// https://learnopengl-cn.github.io/08%20Guest%20Articles/2020/01%20Skeletal%20Animation/
+
+var modelFlag = flag.String("model", "", "skinned or morphed glTF model (.gltf or .glb)")
+
+func main() {
+	runtime.LockOSThread()
+	flag.Parse()
+
+	err := HelloTriangle()
+	if err != nil {
+		panic(err)
+	}
+}
+
+const (
+	ScreenWidth  = 800
+	ScreenHeight = 600
+
+	CrossFadeTime = 0.3 // 切换动画时的过渡时间(秒)
+)
+
+// animatedMesh 一个带蒙皮或变形目标的图元
+type animatedMesh struct {
+	node   int
+	mesh   *common.Mesh
+	skin   *common.Skin        // 为 nil 时使用节点的世界矩阵
+	joints *common.JointBuffer // 骨骼矩阵, 没有蒙皮时为 nil
+	morph  *common.MorphBuffer // 没有变形目标时为 nil
+	color  mgl32.Vec4
+}
+
+func HelloTriangle() error {
+	if *modelFlag == "" {
+		return fmt.Errorf("usage: -model path/to/model.gltf")
+	}
+
+	model, err := common.LoadGLTF(*modelFlag)
+	if err != nil {
+		return err
+	}
+	skeleton, err := model.Skeleton()
+	if err != nil {
+		return err
+	}
+
+	err = glfw.Init()
+	if err != nil {
+		return err
+	}
+
+	defer glfw.Terminate() // glfw: 终止，清除所有先前分配的 GLFW 资源
+
+	// glfw: 初始化配置,设置gl版本
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 4)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	// Mac OS X 需要如下配置
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+
+	window, err := glfw.CreateWindow(ScreenWidth, ScreenHeight, "LearnOpenGL", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	window.MakeContextCurrent()
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		// 确保视口与新窗口尺寸匹配；请注意宽度和
+		// 高度将明显大于视网膜显示器上指定的高度
+		gl.Viewport(0, 0, int32(width), int32(height))
+	})
+
+	var (
+		camera = common.NewCamera(
+			common.WithPosition(mgl32.Vec3{0, 1, 4}),
+		)
+
+		firstMouse         = true
+		lastX      float32 = ScreenWidth / 2.0
+		lastY      float32 = ScreenHeight / 2.0
+
+		deltaTime float32 = 0
+		lastFrame float32 = 0
+	)
+	// glfw：每当鼠标移动时，都会调用此回调
+	window.SetCursorPosCallback(func(w *glfw.Window, x float64, y float64) {
+		xPos := float32(x)
+		yPos := float32(y)
+
+		if firstMouse {
+			lastX = xPos
+			lastY = yPos
+			firstMouse = false
+		}
+
+		// 计算当前光标位置与上次位置的偏移量
+		xOffset := xPos - lastX
+		yOffset := lastY - yPos // 注意：y 轴是从下到上的
+		lastX = xPos
+		lastY = yPos
+
+		camera.ProcessMouseMovement(xOffset, yOffset)
+	})
+	// glfw：每当鼠标滚轮滚动时，都会调用此回调
+	window.SetScrollCallback(func(w *glfw.Window, x float64, y float64) {
+		camera.ProcessMouseScroll(float32(y))
+	})
+	// 告诉 GLFW 捕获我们的鼠标
+	window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+
+	// 加载所有 OpenGL 方法
+	err = gl.Init()
+	if err != nil {
+		return err
+	}
+
+	// 配置全局 opengl 状态
+	gl.Enable(gl.DEPTH_TEST) // 启用深度测试
+
+	sd, err := common.NewShader(`
+#version 440 core
+`+common.SkinningGLSL+common.MorphGLSL+`
+layout (location = 0) in vec3 aPos;
+layout (location = 1) in vec3 aNormal;
+
+out vec3 Normal;
+
+uniform bool skinned;
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+
+void main()
+{
+	// 先变形再蒙皮, 骨骼矩阵已经包含了模型到世界的变换
+	mat4 skin = skinned ? skinMatrix() : model;
+	gl_Position = projection * view * skin * vec4(morphPosition(aPos), 1.0);
+	Normal = mat3(skin) * morphNormal(aNormal);
+}`, `
+#version 440 core
+out vec4 FragColor;
+
+in vec3 Normal;
+
+uniform vec4 baseColor;
+
+void main()
+{
+	float diff = max(dot(normalize(Normal), normalize(vec3(0.5, 1.0, 0.8))), 0.0);
+	FragColor = vec4(baseColor.rgb * (0.3 + 0.7 * diff), baseColor.a);
+}`)
+	if err != nil {
+		return err
+	}
+
+	// 上传所有节点的图元, 变形目标放在纹理缓冲中
+	var meshes []animatedMesh
+	for i, node := range model.Nodes {
+		if node.Mesh < 0 {
+			continue
+		}
+
+		var skin *common.Skin
+		if node.Skin >= 0 {
+			skin = &model.Skins[node.Skin]
+		}
+
+		for _, p := range model.Meshes[node.Mesh].Primitives {
+			mesh, err := p.Mesh.Upload()
+			if err != nil {
+				return err
+			}
+
+			m := animatedMesh{node: i, mesh: mesh, skin: skin, color: mgl32.Vec4{0.8, 0.8, 0.8, 1}}
+			if p.Material >= 0 {
+				m.color = model.Materials[p.Material].BaseColorFactor
+			}
+			if skin != nil {
+				m.joints, err = common.NewJointBuffer(len(skin.Joints))
+				if err != nil {
+					return err
+				}
+			}
+			if len(p.Mesh.Targets) > 0 {
+				m.morph, err = common.NewMorphBuffer(p.Mesh)
+				if err != nil {
+					return err
+				}
+			}
+			meshes = append(meshes, m)
+		}
+	}
+	if len(meshes) == 0 {
+		return fmt.Errorf("%s: no mesh", *modelFlag)
+	}
+
+	player := common.NewAnimationPlayer(skeleton)
+	clip := 0
+	if len(model.Animations) > 0 {
+		player.Play(model.Animations[clip], true)
+		window.SetTitle("LearnOpenGL - " + model.Animations[clip].Name)
+	}
+
+	// N 切换到下一个动画, 空格暂停
+	paused := false
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action != glfw.Press {
+			return
+		}
+
+		switch key {
+		case glfw.KeyN:
+			if len(model.Animations) > 0 {
+				clip = (clip + 1) % len(model.Animations)
+				player.CrossFade(model.Animations[clip], true, CrossFadeTime)
+				w.SetTitle("LearnOpenGL - " + model.Animations[clip].Name)
+			}
+		case glfw.KeySpace:
+			paused = !paused
+		}
+	})
+
+	var (
+		world  []mgl32.Mat4
+		joints []mgl32.Mat4
+	)
+	for !window.ShouldClose() {
+		// 每帧时间逻辑
+		currentFrame := float32(glfw.GetTime())
+		deltaTime = currentFrame - lastFrame
+		lastFrame = currentFrame
+
+		if window.GetKey(glfw.KeyEscape) == glfw.Press {
+			window.SetShouldClose(true)
+		}
+		if window.GetKey(glfw.KeyW) == glfw.Press {
+			camera.ProcessKeyboard(common.ForWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyS) == glfw.Press {
+			camera.ProcessKeyboard(common.BackWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyA) == glfw.Press {
+			camera.ProcessKeyboard(common.Left, deltaTime)
+		}
+		if window.GetKey(glfw.KeyD) == glfw.Press {
+			camera.ProcessKeyboard(common.Right, deltaTime)
+		}
+
+		if !paused {
+			player.Update(deltaTime)
+		}
+		world = skeleton.WorldMatrices(player.Pose, world)
+
+		// 渲染: 清空屏幕为背景颜色
+		gl.ClearColor(0.2, 0.3, 0.3, 1.0)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		sd.Use()
+		projection := camera.GetProjectionMatrix(float32(ScreenWidth)/float32(ScreenHeight), 0.1, 100.0)
+		sd.SetMat("projection", 4, &projection[0])
+		view := camera.GetViewMatrix()
+		sd.SetMat("view", 4, &view[0])
+
+		for _, m := range meshes {
+			if m.skin != nil {
+				joints = m.skin.JointMatrices(world, joints)
+				m.joints.Set(joints)
+				m.joints.Bind(sd, 2) // 0 和 1 留给变形目标
+				sd.SetInt("skinned", 1)
+			} else {
+				sd.SetMat("model", 4, &world[m.node][0])
+				sd.SetInt("skinned", 0)
+			}
+
+			if m.morph != nil {
+				m.morph.SetWeights(player.Weights[m.node])
+				m.morph.Bind(sd, 0)
+			} else {
+				common.UnbindMorph(sd)
+			}
+
+			sd.SetFloat("baseColor", m.color[0], m.color[1], m.color[2], m.color[3])
+			m.mesh.Draw()
+		}
+
+		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+
+	// 可选：一旦超出其用途，就取消分配所有资源
+	// ------------------------------------------------------------------------
+	for _, m := range meshes {
+		m.mesh.Delete()
+		if m.joints != nil {
+			m.joints.Delete()
+		}
+		if m.morph != nil {
+			m.morph.Delete()
+		}
+	}
+	sd.Del()
+
+	return nil
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"opengl/common"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// https://learnopengl.com/Guest-Articles/2021/Tessellation/Height-map
+
+var (
+	heightmapFlag = flag.String("heightmap", "resource/wall.jpg", "grayscale heightmap")
+	splatFlag     = flag.String("splat", "", "splat map, r/g/b/a are the weights of each layer (default: derived from height)")
+	layersFlag    = flag.String("layers", "resource/container.jpg,resource/wall.jpg", "comma separated layer textures, at most 4")
+)
+
+func main() {
+	runtime.LockOSThread()
+	flag.Parse()
+
+	err := HelloTriangle()
+	if err != nil {
+		panic(err)
+	}
+}
+
+const (
+	ScreenWidth  = 800
+	ScreenHeight = 600
+)
+
+// heightSplat 没有 splat 贴图时按高度在前两层之间过渡
+func heightSplat(h *common.Heightmap) *common.ImageData {
+	img := &common.ImageData{Width: h.Width, Height: h.Depth, Pixels: make([]uint8, len(h.Heights)*4)}
+	for i, v := range h.Heights {
+		w := mgl32.Clamp((v-0.4)*4, 0, 1)
+		img.Pixels[i*4] = uint8((1 - w) * 255)
+		img.Pixels[i*4+1] = uint8(w * 255)
+	}
+	return img
+}
+
+func HelloTriangle() error {
+	heightmap, err := common.LoadHeightmap(*heightmapFlag)
+	if err != nil {
+		return err
+	}
+
+	var layers []*common.ImageData
+	for _, name := range strings.Split(*layersFlag, ",") {
+		img, err := common.LoadImgRGB(name)
+		if err != nil {
+			return err
+		}
+		layers = append(layers, img)
+	}
+
+	splat := heightSplat(heightmap)
+	if *splatFlag != "" {
+		splat, err = common.LoadImgRGB(*splatFlag, len(layers) > 3)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = glfw.Init()
+	if err != nil {
+		return err
+	}
+
+	defer glfw.Terminate() // glfw: 终止，清除所有先前分配的 GLFW 资源
+
+	// glfw: 初始化配置,设置gl版本
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 4)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	// Mac OS X 需要如下配置
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+
+	window, err := glfw.CreateWindow(ScreenWidth, ScreenHeight, "LearnOpenGL", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	window.MakeContextCurrent()
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		// 确保视口与新窗口尺寸匹配；请注意宽度和
+		// 高度将明显大于视网膜显示器上指定的高度
+		gl.Viewport(0, 0, int32(width), int32(height))
+	})
+
+	var (
+		camera = common.NewCamera(
+			common.WithPosition(mgl32.Vec3{0, 0, 0}),
+		)
+
+		firstMouse         = true
+		lastX      float32 = ScreenWidth / 2.0
+		lastY      float32 = ScreenHeight / 2.0
+
+		deltaTime float32 = 0
+		lastFrame float32 = 0
+	)
+	camera.MovementSpeed *= 4
+	// glfw：每当鼠标移动时，都会调用此回调
+	window.SetCursorPosCallback(func(w *glfw.Window, x float64, y float64) {
+		xPos := float32(x)
+		yPos := float32(y)
+
+		if firstMouse {
+			lastX = xPos
+			lastY = yPos
+			firstMouse = false
+		}
+
+		// 计算当前光标位置与上次位置的偏移量
+		xOffset := xPos - lastX
+		yOffset := lastY - yPos // 注意：y 轴是从下到上的
+		lastX = xPos
+		lastY = yPos
+
+		camera.ProcessMouseMovement(xOffset, yOffset)
+	})
+	// glfw：每当鼠标滚轮滚动时，都会调用此回调
+	window.SetScrollCallback(func(w *glfw.Window, x float64, y float64) {
+		camera.ProcessMouseScroll(float32(y))
+	})
+	// 告诉 GLFW 捕获我们的鼠标
+	window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+
+	// 加载所有 OpenGL 方法
+	err = gl.Init()
+	if err != nil {
+		return err
+	}
+
+	// 配置全局 opengl 状态
+	gl.Enable(gl.DEPTH_TEST) // 启用深度测试
+	gl.Enable(gl.CULL_FACE)
+
+	sd, err := common.NewShader(`
+#version 440 core
+layout (location = 0) in vec3 aPos;
+layout (location = 1) in vec3 aNormal;
+layout (location = 2) in vec2 aTexCoords;
+
+out vec3 Normal;
+out vec2 TexCoords;
+
+uniform mat4 view;
+uniform mat4 projection;
+
+void main()
+{
+	// 地形直接使用世界坐标
+	gl_Position = projection * view * vec4(aPos, 1.0);
+	Normal = aNormal;
+	TexCoords = aTexCoords;
+}`, `
+#version 440 core
+out vec4 FragColor;
+
+in vec3 Normal;
+in vec2 TexCoords;
+`+common.TerrainGLSL+`
+void main()
+{
+	float diff = max(dot(normalize(Normal), normalize(vec3(0.5, 1.0, 0.3))), 0.0);
+	FragColor = vec4(terrainColor(TexCoords) * (0.3 + 0.7 * diff), 1.0);
+}`)
+	if err != nil {
+		return err
+	}
+
+	terrain, err := common.NewTerrain(heightmap)
+	if err != nil {
+		return err
+	}
+	err = terrain.SetSplat(splat, layers...)
+	if err != nil {
+		return err
+	}
+
+	// 从地形中心上空开始, G 切换行走模式, 行走时受重力影响, 空格跳跃
+	camera.Position[1] = terrain.HeightAt(0, 0) + terrain.Size[1]/2
+	controller := common.NewCharacterController(camera, common.WithGround(terrain.HeightAt))
+	walking := false
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action == glfw.Press && key == glfw.KeyG {
+			walking = !walking
+			controller.Velocity = mgl32.Vec3{}
+		}
+	})
+
+	lastDrawn := -1
+	for !window.ShouldClose() {
+		// 每帧时间逻辑
+		currentFrame := float32(glfw.GetTime())
+		deltaTime = currentFrame - lastFrame
+		lastFrame = currentFrame
+
+		if window.GetKey(glfw.KeyEscape) == glfw.Press {
+			window.SetShouldClose(true)
+		}
+		moves := []struct {
+			key  glfw.Key
+			move common.CameraMove
+		}{
+			{glfw.KeyW, common.ForWard},
+			{glfw.KeyS, common.BackWard},
+			{glfw.KeyA, common.Left},
+			{glfw.KeyD, common.Right},
+		}
+		for _, m := range moves {
+			if window.GetKey(m.key) != glfw.Press {
+				continue
+			}
+			if walking {
+				controller.ProcessKeyboard(m.move)
+			} else {
+				camera.ProcessKeyboard(m.move, deltaTime)
+			}
+		}
+
+		if walking {
+			if window.GetKey(glfw.KeySpace) == glfw.Press {
+				controller.Jump()
+			}
+			controller.Update(deltaTime)
+		} else if ground := terrain.HeightAt(camera.Position[0], camera.Position[2]) + common.EyeHeight; camera.Position[1] < ground {
+			// 不允许钻到地面以下
+			camera.Position[1] = ground
+		}
+
+		// 渲染: 清空屏幕为背景颜色
+		gl.ClearColor(0.5, 0.7, 0.9, 1.0)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		sd.Use()
+		projection := camera.GetProjectionMatrix(float32(ScreenWidth)/float32(ScreenHeight), 0.1, 1000.0)
+		sd.SetMat("projection", 4, &projection[0])
+		view := camera.GetViewMatrix()
+		sd.SetMat("view", 4, &view[0])
+
+		terrain.Bind(sd, 0)
+		drawn := terrain.Draw(common.NewFrustum(projection.Mul4(view)))
+		if drawn != lastDrawn {
+			lastDrawn = drawn
+			window.SetTitle(fmt.Sprintf("LearnOpenGL - %d/%d chunks", drawn, len(terrain.Chunks)))
+		}
+
+		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+
+	// 可选：一旦超出其用途，就取消分配所有资源
+	// ------------------------------------------------------------------------
+	terrain.Delete()
+	sd.Del()
+
+	return nil
+}
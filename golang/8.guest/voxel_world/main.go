@@ -0,0 +1,341 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+
+	"opengl/common"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// https://0fps.net/2012/06/30/meshing-in-a-minecraft-game/
+// https://0fps.net/2013/07/03/ambient-occlusion-for-minecraft-like-worlds/
+
+func main() {
+	runtime.LockOSThread()
+
+	err := HelloTriangle()
+	if err != nil {
+		panic(err)
+	}
+}
+
+const (
+	ScreenWidth  = 800
+	ScreenHeight = 600
+
+	WorldSize    = 96 // 世界在 x/z 方向的方块数
+	ReachDist    = 8  // 能够挖掘和放置方块的距离
+	RemeshBudget = 4  // 每帧最多重建的块数
+)
+
+// 纹理数组的层: 0~5 为骰子的 6 个面, 6 为箱子, 7 为墙
+var textures = []string{
+	"resource/dice-1.png",
+	"resource/dice-2.png",
+	"resource/dice-3.png",
+	"resource/dice-4.png",
+	"resource/dice-5.png",
+	"resource/dice-6.png",
+	"resource/container.jpg",
+	"resource/wall.jpg",
+}
+
+const (
+	BlockWall common.Block = iota + 1
+	BlockContainer
+	BlockDice
+)
+
+var blockTypes = []common.BlockType{
+	{},
+	common.UniformBlock("wall", 7),
+	common.UniformBlock("container", 6),
+	{Name: "dice", Faces: [6]int32{0, 5, 1, 4, 2, 3}}, // 相对的两面点数之和为 7
+}
+
+// generate 用几个正弦波叠加出起伏的地面, 表层为箱子, 偶尔放一个骰子
+func generate(w *common.VoxelWorld) error {
+	for x := 0; x < WorldSize; x++ {
+		for z := 0; z < WorldSize; z++ {
+			fx, fz := float64(x), float64(z)
+			h := int(12 + 5*math.Sin(fx*0.08)*math.Cos(fz*0.06) + 3*math.Sin((fx+fz)*0.15))
+			for y := 0; y < h; y++ {
+				b := BlockWall
+				if y >= h-2 {
+					b = BlockContainer
+				}
+				err := w.Set(x, y, z, b)
+				if err != nil {
+					return err
+				}
+			}
+			if (x*7+z*13)%97 == 0 {
+				err := w.Set(x, h, z, BlockDice)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func HelloTriangle() error {
+	err := glfw.Init()
+	if err != nil {
+		return err
+	}
+
+	defer glfw.Terminate() // glfw: 终止，清除所有先前分配的 GLFW 资源
+
+	// glfw: 初始化配置,设置gl版本
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 4)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	// Mac OS X 需要如下配置
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+
+	window, err := glfw.CreateWindow(ScreenWidth, ScreenHeight, "LearnOpenGL", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	window.MakeContextCurrent()
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		// 确保视口与新窗口尺寸匹配；请注意宽度和
+		// 高度将明显大于视网膜显示器上指定的高度
+		gl.Viewport(0, 0, int32(width), int32(height))
+	})
+
+	var (
+		camera = common.NewCamera(
+			common.WithPosition(mgl32.Vec3{WorldSize / 2, 30, WorldSize / 2}),
+		)
+
+		firstMouse         = true
+		lastX      float32 = ScreenWidth / 2.0
+		lastY      float32 = ScreenHeight / 2.0
+
+		deltaTime float32 = 0
+		lastFrame float32 = 0
+	)
+	camera.MovementSpeed *= 3
+	// glfw：每当鼠标移动时，都会调用此回调
+	window.SetCursorPosCallback(func(w *glfw.Window, x float64, y float64) {
+		xPos := float32(x)
+		yPos := float32(y)
+
+		if firstMouse {
+			lastX = xPos
+			lastY = yPos
+			firstMouse = false
+		}
+
+		// 计算当前光标位置与上次位置的偏移量
+		xOffset := xPos - lastX
+		yOffset := lastY - yPos // 注意：y 轴是从下到上的
+		lastX = xPos
+		lastY = yPos
+
+		camera.ProcessMouseMovement(xOffset, yOffset)
+	})
+	// glfw：每当鼠标滚轮滚动时，都会调用此回调
+	window.SetScrollCallback(func(w *glfw.Window, x float64, y float64) {
+		camera.ProcessMouseScroll(float32(y))
+	})
+	// 告诉 GLFW 捕获我们的鼠标
+	window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+
+	// 加载所有 OpenGL 方法
+	err = gl.Init()
+	if err != nil {
+		return err
+	}
+
+	// 配置全局 opengl 状态
+	gl.Enable(gl.DEPTH_TEST) // 启用深度测试
+	gl.Enable(gl.CULL_FACE)
+
+	sd, err := common.NewShader(`
+#version 440 core
+layout (location = 0) in vec3 aPos;
+layout (location = 1) in vec3 aNormal;
+layout (location = 2) in vec2 aTexCoord;
+layout (location = 4) in float aAO;
+layout (location = 10) in float aLayer;
+
+out vec3 FragPos;
+out vec3 Normal;
+out vec2 TexCoord;
+out float AO;
+flat out int Layer;
+
+uniform mat4 view;
+uniform mat4 projection;
+
+void main()
+{
+	// 体素网格的顶点已经在世界空间
+	gl_Position = projection * view * vec4(aPos, 1.0);
+	FragPos = aPos;
+	Normal = aNormal;
+	TexCoord = aTexCoord;
+	AO = aAO;
+	Layer = int(aLayer);
+}`, `
+#version 440 core
+out vec4 FragColor;
+
+in vec3 FragPos;
+in vec3 Normal;
+in vec2 TexCoord;
+in float AO;
+flat in int Layer;
+
+uniform sampler2DArray blockTex;
+uniform ivec3 selected; // 准星指向的方块
+uniform bool hasSelected;
+
+void main()
+{
+	vec3 color = texture(blockTex, vec3(TexCoord, Layer)).rgb;
+	float diff = max(dot(Normal, normalize(vec3(0.4, 1.0, 0.3))), 0.0);
+	color *= (0.35 + 0.65 * diff) * mix(0.4, 1.0, AO);
+
+	// 沿法线往方块内部退半格, 判断片段是否属于选中的方块
+	ivec3 block = ivec3(floor(FragPos - Normal * 0.5));
+	if (hasSelected && block == selected) {
+		color = mix(color, vec3(1.0), 0.3);
+	}
+	FragColor = vec4(color, 1.0);
+}`)
+	if err != nil {
+		return err
+	}
+
+	var layers []*common.ImageData
+	for _, name := range textures {
+		img, err := common.LoadImgRGB(name)
+		if err != nil {
+			return err
+		}
+		layers = append(layers, img)
+	}
+	blockTex, err := common.NewTextureArray(layers, common.DefaultSampler())
+	if err != nil {
+		return err
+	}
+
+	world := common.NewVoxelWorld(blockTypes)
+	err = generate(world)
+	if err != nil {
+		return err
+	}
+	_, err = world.Remesh(0)
+	if err != nil {
+		return err
+	}
+
+	// 左键挖掉准星指向的方块, 右键贴着指向的面放置方块, 1~3 选择方块类型
+	current := BlockContainer
+	var editErr error // 回调里修改方块的错误, 在渲染循环中返回
+	window.SetMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		if action != glfw.Press {
+			return
+		}
+
+		hit, ok := world.Raycast(common.Ray{Origin: camera.Position, Direction: camera.Front}, ReachDist)
+		if !ok {
+			return
+		}
+		switch button {
+		case glfw.MouseButtonLeft:
+			editErr = world.Set(hit.Block[0], hit.Block[1], hit.Block[2], 0)
+		case glfw.MouseButtonRight:
+			editErr = world.Set(hit.Block[0]+hit.Face[0], hit.Block[1]+hit.Face[1], hit.Block[2]+hit.Face[2], current)
+		}
+	})
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action == glfw.Press && key >= glfw.Key1 && key <= glfw.Key3 {
+			current = common.Block(key-glfw.Key1) + BlockWall
+		}
+	})
+
+	lastDrawn, lastBlock := -1, common.Block(0)
+	for !window.ShouldClose() {
+		// 每帧时间逻辑
+		currentFrame := float32(glfw.GetTime())
+		deltaTime = currentFrame - lastFrame
+		lastFrame = currentFrame
+
+		if window.GetKey(glfw.KeyEscape) == glfw.Press {
+			window.SetShouldClose(true)
+		}
+		if window.GetKey(glfw.KeyW) == glfw.Press {
+			camera.ProcessKeyboard(common.ForWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyS) == glfw.Press {
+			camera.ProcessKeyboard(common.BackWard, deltaTime)
+		}
+		if window.GetKey(glfw.KeyA) == glfw.Press {
+			camera.ProcessKeyboard(common.Left, deltaTime)
+		}
+		if window.GetKey(glfw.KeyD) == glfw.Press {
+			camera.ProcessKeyboard(common.Right, deltaTime)
+		}
+
+		if editErr != nil {
+			return editErr
+		}
+
+		// 只重建修改过的块, 每帧有上限
+		_, err = world.Remesh(RemeshBudget)
+		if err != nil {
+			return err
+		}
+
+		// 渲染: 清空屏幕为背景颜色
+		gl.ClearColor(0.5, 0.7, 0.9, 1.0)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+		sd.Use()
+		projection := camera.GetProjectionMatrix(float32(ScreenWidth)/float32(ScreenHeight), 0.1, 500.0)
+		sd.SetMat("projection", 4, &projection[0])
+		view := camera.GetViewMatrix()
+		sd.SetMat("view", 4, &view[0])
+
+		blockTex.Bind(0)
+		sd.SetInt("blockTex", 0)
+
+		hit, ok := world.Raycast(common.Ray{Origin: camera.Position, Direction: camera.Front}, ReachDist)
+		if ok {
+			sd.SetInt("selected", int32(hit.Block[0]), int32(hit.Block[1]), int32(hit.Block[2]))
+			sd.SetInt("hasSelected", 1)
+		} else {
+			sd.SetInt("hasSelected", 0)
+		}
+
+		drawn := world.Draw(common.NewFrustum(projection.Mul4(view)))
+		if drawn != lastDrawn || current != lastBlock {
+			lastDrawn, lastBlock = drawn, current
+			window.SetTitle(fmt.Sprintf("LearnOpenGL - %d chunks drawn, block: %s", drawn, blockTypes[current].Name))
+		}
+
+		// glfw: 交换缓冲区和轮询 IO 事件（按键按下、释放、鼠标移动等）
+		window.SwapBuffers()
+		glfw.PollEvents()
+	}
+
+	// 可选：一旦超出其用途，就取消分配所有资源
+	// ------------------------------------------------------------------------
+	world.Delete()
+	blockTex.Delete()
+	sd.Del()
+
+	return nil
+}
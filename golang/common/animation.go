@@ -0,0 +1,658 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type Interpolation int
+
+const (
+	InterpLinear      Interpolation = 0
+	InterpStep        Interpolation = 1
+	InterpCubicSpline Interpolation = 2
+)
+
+// AnimationPath 通道作用的变换分量
+type AnimationPath int
+
+const (
+	PathTranslation AnimationPath = 0
+	PathRotation    AnimationPath = 1
+	PathScale       AnimationPath = 2
+	PathWeights     AnimationPath = 3 // 变形目标的权重
+)
+
+// Channel 一个节点的一个变换分量的关键帧
+// Values 每个关键帧 3 个(旋转为 4 个, x,y,z,w; 权重为 Width 个)分量, 三次样条时每帧依次为入切线、值、出切线
+type Channel struct {
+	Node          int
+	Path          AnimationPath
+	Interpolation Interpolation
+	Times         []float32
+	Values        []float32
+	Width         int // PathWeights 时每个关键帧的权重数, 即变形目标数
+}
+
+func (c *Channel) components() int {
+	switch c.Path {
+	case PathRotation:
+		return 4
+	case PathWeights:
+		return c.Width
+	default:
+		return 3
+	}
+}
+
+// key 第 i 个关键帧的值, 三次样条时 part 为 0 入切线, 1 值, 2 出切线
+func (c *Channel) key(i, part int) mgl32.Vec4 {
+	n := c.components()
+	if c.Interpolation == InterpCubicSpline {
+		i = 3*i + part
+	}
+
+	var v mgl32.Vec4
+	copy(v[:n], c.Values[i*n:])
+	return v
+}
+
+// segment 找到 t 所在的关键帧区间 [k, k+1], u 为区间内的比例, dt 为区间长度
+// t 超出范围时 ok 为 false, k 为首或尾关键帧
+func (c *Channel) segment(t float32) (k int, u, dt float32, ok bool) {
+	n := len(c.Times)
+	if t <= c.Times[0] {
+		return 0, 0, 0, false
+	}
+	if t >= c.Times[n-1] {
+		return n - 1, 0, 0, false
+	}
+
+	k = sort.Search(n, func(i int) bool { return c.Times[i] > t }) - 1
+	dt = c.Times[k+1] - c.Times[k]
+	return k, (t - c.Times[k]) / dt, dt, true
+}
+
+// Sample 采样 t 时刻的值, t 超出范围时取首尾关键帧, 权重通道使用 SampleWeights
+func (c *Channel) Sample(t float32) mgl32.Vec4 {
+	if len(c.Times) == 0 {
+		return mgl32.Vec4{}
+	}
+	k, u, dt, ok := c.segment(t)
+	if !ok {
+		return c.key(k, 1)
+	}
+
+	switch c.Interpolation {
+	case InterpStep:
+		return c.key(k, 1)
+	case InterpCubicSpline:
+		// Hermite 样条, 切线需要乘以关键帧间隔
+		h00, h10, h01, h11 := hermite(u)
+		p0, m0 := c.key(k, 1), c.key(k, 2).Mul(dt)
+		p1, m1 := c.key(k+1, 1), c.key(k+1, 0).Mul(dt)
+		v := p0.Mul(h00).
+			Add(m0.Mul(h10)).
+			Add(p1.Mul(h01)).
+			Add(m1.Mul(h11))
+		if c.Path == PathRotation {
+			v = v.Normalize()
+		}
+		return v
+	default:
+		a, b := c.key(k, 1), c.key(k+1, 1)
+		if c.Path == PathRotation {
+			q := slerp(vec4Quat(a), vec4Quat(b), u)
+			return mgl32.Vec4{q.X(), q.Y(), q.Z(), q.W}
+		}
+		return a.Add(b.Sub(a).Mul(u))
+	}
+}
+
+func vec4Quat(v mgl32.Vec4) mgl32.Quat {
+	return mgl32.Quat{W: v[3], V: v.Vec3()}
+}
+
+// slerp 沿最短路径的球面插值
+func slerp(a, b mgl32.Quat, t float32) mgl32.Quat {
+	if a.Dot(b) < 0 {
+		b = b.Scale(-1)
+	}
+	return mgl32.QuatSlerp(a, b, t)
+}
+
+// hermite 三次 Hermite 样条的四个基函数
+func hermite(u float32) (h00, h10, h01, h11 float32) {
+	u2, u3 := u*u, u*u*u
+	return 2*u3 - 3*u2 + 1, u3 - 2*u2 + u, -2*u3 + 3*u2, u3 - u2
+}
+
+// SampleWeights 采样权重通道 t 时刻的 Width 个权重, out 长度不够时重新分配
+func (c *Channel) SampleWeights(t float32, out []float32) []float32 {
+	n := c.Width
+	if len(out) < n {
+		out = make([]float32, n)
+	}
+	out = out[:n]
+	if len(c.Times) == 0 {
+		clear(out)
+		return out
+	}
+
+	// 三次样条时第 i 帧的第 part 部分
+	value := func(i, part int) []float32 {
+		if c.Interpolation == InterpCubicSpline {
+			i = 3*i + part
+		}
+		return c.Values[i*n : (i+1)*n]
+	}
+
+	k, u, dt, ok := c.segment(t)
+	switch {
+	case !ok || c.Interpolation == InterpStep:
+		copy(out, value(k, 1))
+	case c.Interpolation == InterpCubicSpline:
+		h00, h10, h01, h11 := hermite(u)
+		p0, m0 := value(k, 1), value(k, 2)
+		p1, m1 := value(k+1, 1), value(k+1, 0)
+		for j := range out {
+			out[j] = h00*p0[j] + h10*dt*m0[j] + h01*p1[j] + h11*dt*m1[j]
+		}
+	default:
+		a, b := value(k, 1), value(k+1, 1)
+		for j := range out {
+			out[j] = a[j] + (b[j]-a[j])*u
+		}
+	}
+	return out
+}
+
+// apply 将 t 时刻的值写入姿势
+func (c *Channel) apply(t float32, pose []Transform) {
+	if c.Node < 0 || c.Node >= len(pose) {
+		return
+	}
+
+	v := c.Sample(t)
+	switch c.Path {
+	case PathTranslation:
+		pose[c.Node].Translation = v.Vec3()
+	case PathRotation:
+		pose[c.Node].Rotation = vec4Quat(v)
+	case PathScale:
+		pose[c.Node].Scale = v.Vec3()
+	}
+}
+
+// applyWeights 将 t 时刻的权重写入 weights[c.Node], 超出节点目标数的部分被忽略
+func (c *Channel) applyWeights(t float32, weights [][]float32, scratch []float32) []float32 {
+	if c.Node < 0 || c.Node >= len(weights) {
+		return scratch
+	}
+
+	scratch = c.SampleWeights(t, scratch)
+	copy(weights[c.Node], scratch)
+	return scratch
+}
+
+// AnimationClip 一段动画, 通道中的 Node 为 Skeleton 的节点下标
+type AnimationClip struct {
+	Name     string
+	Duration float32
+	Channels []Channel
+}
+
+// NewAnimationClip 检查关键帧并以最后一个关键帧的时间作为时长
+func NewAnimationClip(name string, channels []Channel) (*AnimationClip, error) {
+	clip := &AnimationClip{Name: name, Channels: channels}
+	for i := range channels {
+		c := &channels[i]
+		if len(c.Times) == 0 {
+			return nil, fmt.Errorf("animation %q channel %d: no keyframes", name, i)
+		}
+		if c.Path == PathWeights && c.Width <= 0 {
+			return nil, fmt.Errorf("animation %q channel %d: weights channel without width", name, i)
+		}
+
+		want := len(c.Times) * c.components()
+		if c.Interpolation == InterpCubicSpline {
+			want *= 3
+		}
+		if len(c.Values) != want {
+			return nil, fmt.Errorf("animation %q channel %d: %d values, want %d", name, i, len(c.Values), want)
+		}
+
+		for k := 1; k < len(c.Times); k++ {
+			if c.Times[k] < c.Times[k-1] {
+				return nil, fmt.Errorf("animation %q channel %d: keyframe times not increasing", name, i)
+			}
+		}
+		clip.Duration = max(clip.Duration, c.Times[len(c.Times)-1])
+	}
+
+	return clip, nil
+}
+
+// Sample 将 t 时刻的所有变换通道写入 pose, 没有动画的分量保持原值
+func (a *AnimationClip) Sample(t float32, pose []Transform) {
+	for i := range a.Channels {
+		if a.Channels[i].Path != PathWeights {
+			a.Channels[i].apply(t, pose)
+		}
+	}
+}
+
+// SampleWeights 将 t 时刻的所有权重通道写入 weights, weights 按节点下标索引
+func (a *AnimationClip) SampleWeights(t float32, weights [][]float32) {
+	var scratch []float32
+	for i := range a.Channels {
+		if a.Channels[i].Path == PathWeights {
+			scratch = a.Channels[i].applyWeights(t, weights, scratch)
+		}
+	}
+}
+
+// Skeleton 动画作用的节点层级, 通常就是 glTF 的全部节点
+type Skeleton struct {
+	Names   []string
+	Parents []int // -1 表示根节点
+	Rest    []Transform
+	Weights [][]float32 // 每个节点初始的变形权重, 没有变形目标的节点为 nil
+
+	order []int // 父节点排在子节点前面
+}
+
+func NewSkeleton(names []string, parents []int, rest []Transform) (*Skeleton, error) {
+	n := len(parents)
+	if len(names) != n || len(rest) != n {
+		return nil, errors.New("NewSkeleton: names, parents and rest length mismatch")
+	}
+
+	s := &Skeleton{Names: names, Parents: parents, Rest: rest, order: make([]int, 0, n)}
+	state := make([]int8, n) // 0 未访问, 1 访问中, 2 完成
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case 1:
+			return fmt.Errorf("NewSkeleton: cycle at node %d", i)
+		case 2:
+			return nil
+		}
+
+		state[i] = 1
+		if p := parents[i]; p >= 0 {
+			if p >= n {
+				return fmt.Errorf("NewSkeleton: parent %d out of range", p)
+			}
+			if err := visit(p); err != nil {
+				return err
+			}
+		}
+		state[i] = 2
+		s.order = append(s.order, i)
+		return nil
+	}
+
+	for i := range parents {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// RestPose 复制一份初始姿势
+func (s *Skeleton) RestPose() []Transform {
+	return append([]Transform(nil), s.Rest...)
+}
+
+// RestWeights 复制一份初始的变形权重
+func (s *Skeleton) RestWeights() [][]float32 {
+	out := make([][]float32, len(s.Parents))
+	copyWeights(out, s.Weights)
+	return out
+}
+
+// copyWeights 将 src 复制到 dst, dst 中长度不同的节点重新分配
+func copyWeights(dst, src [][]float32) {
+	for i := range dst {
+		var w []float32
+		if i < len(src) {
+			w = src[i]
+		}
+		if len(dst[i]) != len(w) {
+			dst[i] = make([]float32, len(w))
+		}
+		copy(dst[i], w)
+	}
+}
+
+// Find 按名字查找节点, 找不到返回 -1
+func (s *Skeleton) Find(name string) int {
+	for i, n := range s.Names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// WorldMatrices 根据姿势计算每个节点的世界矩阵, out 长度不够时重新分配
+func (s *Skeleton) WorldMatrices(pose []Transform, out []mgl32.Mat4) []mgl32.Mat4 {
+	if len(out) < len(pose) {
+		out = make([]mgl32.Mat4, len(pose))
+	}
+
+	for _, i := range s.order {
+		m := pose[i].Matrix()
+		if p := s.Parents[i]; p >= 0 {
+			m = out[p].Mul4(m)
+		}
+		out[i] = m
+	}
+	return out
+}
+
+// Skin 蒙皮, Joints 为骨骼对应的 Skeleton 节点下标
+type Skin struct {
+	Name        string
+	Joints      []int
+	InverseBind []mgl32.Mat4
+}
+
+// JointMatrices 计算每个骨骼的蒙皮矩阵, 结果将模型空间的顶点变换到世界空间,
+// 因此绘制蒙皮网格时 model 矩阵应为单位矩阵
+func (k *Skin) JointMatrices(world []mgl32.Mat4, out []mgl32.Mat4) []mgl32.Mat4 {
+	if len(out) < len(k.Joints) {
+		out = make([]mgl32.Mat4, len(k.Joints))
+	}
+
+	for i, j := range k.Joints {
+		m := world[j]
+		if i < len(k.InverseBind) {
+			m = m.Mul4(k.InverseBind[i])
+		}
+		out[i] = m
+	}
+	return out[:len(k.Joints)]
+}
+
+// BlendTransform 在两个变换之间插值, w 为 0 时为 a, 为 1 时为 b
+func BlendTransform(a, b Transform, w float32) Transform {
+	return Transform{
+		Translation: a.Translation.Add(b.Translation.Sub(a.Translation).Mul(w)),
+		Rotation:    slerp(a.Rotation, b.Rotation, w),
+		Scale:       a.Scale.Add(b.Scale.Sub(a.Scale).Mul(w)),
+	}
+}
+
+type clipState struct {
+	clip *AnimationClip
+	time float32
+	loop bool
+}
+
+func (c *clipState) advance(dt float32) {
+	if c.clip == nil {
+		return
+	}
+
+	c.time += dt
+	d := c.clip.Duration
+	switch {
+	case d <= 0:
+		c.time = 0
+	case c.loop:
+		for c.time >= d {
+			c.time -= d
+		}
+		for c.time < 0 {
+			c.time += d
+		}
+	default:
+		c.time = mgl32.Clamp(c.time, 0, d)
+	}
+}
+
+// AnimationPlayer 播放动画片段, 切换时可以在两个片段之间淡入淡出
+type AnimationPlayer struct {
+	Skeleton *Skeleton
+	Speed    float32
+	Pose     []Transform // 每次 Update 后的姿势
+	Weights  [][]float32 // 每次 Update 后每个节点的变形权重
+
+	current, previous clipState
+	fade, fadeTime    float32
+	scratch           []Transform
+	scratchWeights    [][]float32
+}
+
+func NewAnimationPlayer(s *Skeleton) *AnimationPlayer {
+	return &AnimationPlayer{
+		Skeleton: s,
+		Speed:    1,
+		Pose:     s.RestPose(),
+		Weights:  s.RestWeights(),
+	}
+}
+
+// Play 立即切换到 clip 并从头播放
+func (p *AnimationPlayer) Play(clip *AnimationClip, loop bool) {
+	p.current = clipState{clip: clip, loop: loop}
+	p.previous = clipState{}
+	p.fadeTime = 0
+}
+
+// CrossFade 在 duration 秒内从当前片段过渡到 clip, 两个片段在过渡期间都继续播放
+func (p *AnimationPlayer) CrossFade(clip *AnimationClip, loop bool, duration float32) {
+	if p.current.clip == nil || duration <= 0 {
+		p.Play(clip, loop)
+		return
+	}
+
+	p.previous = p.current
+	p.current = clipState{clip: clip, loop: loop}
+	p.fade, p.fadeTime = 0, duration
+}
+
+// Current 当前播放的片段和时间
+func (p *AnimationPlayer) Current() (*AnimationClip, float32) {
+	return p.current.clip, p.current.time
+}
+
+// Finished 不循环的片段是否已经播放完
+func (p *AnimationPlayer) Finished() bool {
+	c := p.current
+	return c.clip == nil || !c.loop && c.time >= c.clip.Duration
+}
+
+// Update 推进时间并计算新的姿势
+func (p *AnimationPlayer) Update(dt float32) {
+	dt *= p.Speed
+	p.current.advance(dt)
+
+	copy(p.Pose, p.Skeleton.Rest)
+	copyWeights(p.Weights, p.Skeleton.Weights)
+	if p.current.clip != nil {
+		p.current.clip.Sample(p.current.time, p.Pose)
+		p.current.clip.SampleWeights(p.current.time, p.Weights)
+	}
+
+	if p.previous.clip == nil {
+		return
+	}
+
+	p.previous.advance(dt)
+	p.fade += abs(dt)
+	w := p.fade / p.fadeTime
+	if w >= 1 {
+		p.previous = clipState{}
+		return
+	}
+
+	if len(p.scratch) != len(p.Pose) {
+		p.scratch = make([]Transform, len(p.Pose))
+	}
+	copy(p.scratch, p.Skeleton.Rest)
+	p.previous.clip.Sample(p.previous.time, p.scratch)
+	for i := range p.Pose {
+		p.Pose[i] = BlendTransform(p.scratch[i], p.Pose[i], w)
+	}
+
+	if len(p.scratchWeights) != len(p.Weights) {
+		p.scratchWeights = make([][]float32, len(p.Weights))
+	}
+	copyWeights(p.scratchWeights, p.Skeleton.Weights)
+	p.previous.clip.SampleWeights(p.previous.time, p.scratchWeights)
+	for i, ws := range p.Weights {
+		for j := range ws {
+			a := p.scratchWeights[i][j]
+			ws[j] = a + (ws[j]-a)*w
+		}
+	}
+}
+
+// SkinMesh CPU 蒙皮, 返回变换后的副本, 用于拾取、包围盒计算或不支持 GPU 蒙皮的情况
+func SkinMesh(d *MeshData, joints []mgl32.Mat4) *MeshData {
+	out := *d
+	out.Positions = make([]mgl32.Vec3, len(d.Positions))
+	out.Normals = make([]mgl32.Vec3, len(d.Normals))
+	out.Tangents = make([]mgl32.Vec4, len(d.Tangents))
+
+	for i, p := range d.Positions {
+		m := skinMatrix(d, i, joints)
+		out.Positions[i] = mgl32.TransformCoordinate(p, m)
+
+		if i < len(d.Normals) || i < len(d.Tangents) {
+			// 蒙皮矩阵通常不含非等比缩放, 直接使用左上 3x3
+			m3 := m.Mat3()
+			if i < len(d.Normals) {
+				out.Normals[i] = normalizeSafe(m3.Mul3x1(d.Normals[i]))
+			}
+			if i < len(d.Tangents) {
+				t := d.Tangents[i]
+				out.Tangents[i] = normalizeSafe(m3.Mul3x1(t.Vec3())).Vec4(t[3])
+			}
+		}
+	}
+	return &out
+}
+
+// skinMatrix 顶点 i 的加权蒙皮矩阵, 权重之和为 0 或骨骼下标越界时视为单位矩阵
+func skinMatrix(d *MeshData, i int, joints []mgl32.Mat4) mgl32.Mat4 {
+	if i >= len(d.Joints) || i >= len(d.Weights) {
+		return mgl32.Ident4()
+	}
+
+	var (
+		m   mgl32.Mat4
+		sum float32
+	)
+	for k, j := range d.Joints[i] {
+		w := d.Weights[i][k]
+		if w == 0 || int(j) >= len(joints) {
+			continue
+		}
+		m = m.Add(joints[j].Mul(w))
+		sum += w
+	}
+
+	if sum <= 0 {
+		return mgl32.Ident4()
+	}
+	return m.Mul(1 / sum)
+}
+
+func normalizeSafe(v mgl32.Vec3) mgl32.Vec3 {
+	if l := v.Len(); l > 0 {
+		return v.Mul(1 / l)
+	}
+	return v
+}
+
+// JointBuffer 骨骼矩阵保存在纹理缓冲中, 每个矩阵占 4 个 RGBA32F 纹素(按列),
+// 骨骼数只受 GL_MAX_TEXTURE_BUFFER_SIZE 限制, 不占用顶点着色器的 uniform 分量
+type JointBuffer struct {
+	Joints int
+
+	vbo, tex uint32
+}
+
+// NewJointBuffer 创建可以容纳 joints 个骨骼矩阵的纹理缓冲, 初始为单位矩阵
+func NewJointBuffer(joints int) (*JointBuffer, error) {
+	if joints <= 0 {
+		return nil, fmt.Errorf("NewJointBuffer: bad joint count %d", joints)
+	}
+
+	var maxTexels int32
+	gl.GetIntegerv(gl.MAX_TEXTURE_BUFFER_SIZE, &maxTexels)
+	if joints*4 > int(maxTexels) {
+		return nil, fmt.Errorf("NewJointBuffer: %d joints exceed GL_MAX_TEXTURE_BUFFER_SIZE %d", joints, maxTexels)
+	}
+
+	matrices := make([]mgl32.Mat4, joints)
+	for i := range matrices {
+		matrices[i] = mgl32.Ident4()
+	}
+
+	b := &JointBuffer{Joints: joints}
+	b.vbo, b.tex = newTextureBuffer(gl.RGBA32F, matrices, gl.DYNAMIC_DRAW)
+	return b, nil
+}
+
+// Set 更新骨骼矩阵, 多出的矩阵被忽略
+func (b *JointBuffer) Set(matrices []mgl32.Mat4) {
+	n := min(len(matrices), b.Joints)
+	if n == 0 {
+		return
+	}
+
+	gl.BindBuffer(gl.TEXTURE_BUFFER, b.vbo)
+	gl.BufferSubData(gl.TEXTURE_BUFFER, 0, n*16*4, gl.Ptr(matrices[:n]))
+	gl.BindBuffer(gl.TEXTURE_BUFFER, 0)
+}
+
+// Bind 将骨骼矩阵绑定到纹理单元 unit 并设置 SkinningGLSL 中的 uniform, 调用前需要先 Use 着色器
+func (b *JointBuffer) Bind(s *Shader, unit uint32) {
+	gl.ActiveTexture(gl.TEXTURE0 + unit)
+	gl.BindTexture(gl.TEXTURE_BUFFER, b.tex)
+	gl.Uniform1i(gl.GetUniformLocation(s.ID, gl.Str("jointMatrices"+CNull)), int32(unit))
+}
+
+func (b *JointBuffer) Delete() {
+	gl.DeleteTextures(1, &b.tex)
+	gl.DeleteBuffers(1, &b.vbo)
+}
+
+// SkinningGLSL 顶点着色器中的 GPU 蒙皮代码, 放在 #version 之后使用, 骨骼矩阵由 JointBuffer 提供:
+//
+//	mat4 skin = skinMatrix();
+//	gl_Position = projection * view * skin * vec4(aPos, 1.0);
+const SkinningGLSL = `
+layout (location = 11) in uvec4 aJoints;
+layout (location = 12) in vec4 aWeights;
+
+uniform samplerBuffer jointMatrices;
+
+mat4 jointMatrix(uint i)
+{
+	int base = int(i) * 4;
+	return mat4(
+		texelFetch(jointMatrices, base),
+		texelFetch(jointMatrices, base + 1),
+		texelFetch(jointMatrices, base + 2),
+		texelFetch(jointMatrices, base + 3));
+}
+
+mat4 skinMatrix()
+{
+	return aWeights.x * jointMatrix(aJoints.x) +
+		aWeights.y * jointMatrix(aJoints.y) +
+		aWeights.z * jointMatrix(aJoints.z) +
+		aWeights.w * jointMatrix(aJoints.w);
+}
+`
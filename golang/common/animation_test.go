@@ -0,0 +1,106 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// newTestArm 两节骨骼的手臂: 根骨骼在原点, 子骨骼在 y=1 处
+func newTestArm(t *testing.T) (*Skeleton, *Skin) {
+	t.Helper()
+
+	rest := []Transform{NewTransform(), NewTransform()}
+	rest[1].Translation = mgl32.Vec3{0, 1, 0}
+	skeleton, err := NewSkeleton([]string{"upper", "lower"}, []int{-1, 0}, rest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 逆绑定矩阵把模型空间变换到骨骼空间
+	world := skeleton.WorldMatrices(skeleton.RestPose(), nil)
+	skin := &Skin{Joints: []int{0, 1}}
+	for _, m := range world {
+		skin.InverseBind = append(skin.InverseBind, m.Inv())
+	}
+	return skeleton, skin
+}
+
+func TestSkinMesh(t *testing.T) {
+	skeleton, skin := newTestArm(t)
+
+	d := &MeshData{
+		Positions: []mgl32.Vec3{{0, 0.5, 0}, {0, 1.5, 0}, {0, 1, 0}, {1, 1.5, 0}},
+		Normals:   []mgl32.Vec3{{1, 0, 0}, {1, 0, 0}, {1, 0, 0}, {1, 0, 0}},
+		Joints:    [][4]uint16{{0}, {1}, {0, 1}, {1}},
+		Weights:   []mgl32.Vec4{{1}, {1}, {0.5, 0.5}, {0}}, // 最后一个顶点权重为 0, 不受骨骼影响
+	}
+
+	// 静止姿势下蒙皮不改变顶点
+	world := skeleton.WorldMatrices(skeleton.RestPose(), nil)
+	rest := SkinMesh(d, skin.JointMatrices(world, nil))
+	for i, p := range rest.Positions {
+		if p.Sub(d.Positions[i]).Len() > 1e-5 {
+			t.Errorf("rest pose: position %d = %v, want %v", i, p, d.Positions[i])
+		}
+	}
+
+	// 子骨骼绕 z 轴旋转 90 度
+	pose := skeleton.RestPose()
+	pose[1].Rotation = mgl32.QuatRotate(mgl32.DegToRad(90), mgl32.Vec3{0, 0, 1})
+	world = skeleton.WorldMatrices(pose, world)
+	skinned := SkinMesh(d, skin.JointMatrices(world, nil))
+
+	want := []struct{ pos, normal mgl32.Vec3 }{
+		{mgl32.Vec3{0, 0.5, 0}, mgl32.Vec3{1, 0, 0}},  // 只受根骨骼影响
+		{mgl32.Vec3{-0.5, 1, 0}, mgl32.Vec3{0, 1, 0}}, // 跟随子骨骼旋转
+		{mgl32.Vec3{0, 1, 0}, mgl32.Vec3{0.7071068, 0.7071068, 0}},
+		{mgl32.Vec3{1, 1.5, 0}, mgl32.Vec3{1, 0, 0}},
+	}
+	for i, w := range want {
+		if p := skinned.Positions[i]; p.Sub(w.pos).Len() > 1e-5 {
+			t.Errorf("position %d = %v, want %v", i, p, w.pos)
+		}
+		if n := skinned.Normals[i]; n.Sub(w.normal).Len() > 1e-5 {
+			t.Errorf("normal %d = %v, want %v", i, n, w.normal)
+		}
+	}
+
+	// 原始数据不被修改
+	if d.Positions[1] != (mgl32.Vec3{0, 1.5, 0}) {
+		t.Errorf("SkinMesh modified its input: %v", d.Positions[1])
+	}
+}
+
+func TestSkinMeshAnimated(t *testing.T) {
+	skeleton, skin := newTestArm(t)
+	clip, err := NewAnimationClip("bend", []Channel{{
+		Node:          1,
+		Path:          PathRotation,
+		Interpolation: InterpLinear,
+		Times:         []float32{0, 1},
+		Values: []float32{
+			0, 0, 0, 1,
+			0, 0, 0.7071068, 0.7071068,
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &MeshData{
+		Positions: []mgl32.Vec3{{0, 2, 0}},
+		Joints:    [][4]uint16{{1}},
+		Weights:   []mgl32.Vec4{{1}},
+	}
+
+	player := NewAnimationPlayer(skeleton)
+	player.Play(clip, false)
+	player.Update(1)
+	world := skeleton.WorldMatrices(player.Pose, nil)
+	got := SkinMesh(d, skin.JointMatrices(world, nil)).Positions[0]
+
+	if want := (mgl32.Vec3{-1, 1, 0}); got.Sub(want).Len() > 1e-4 {
+		t.Errorf("position %v, want %v", got, want)
+	}
+}
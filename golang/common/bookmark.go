@@ -0,0 +1,173 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// CameraState 相机的完整状态,可以保存为 json 或者通过命令行传入
+type CameraState struct {
+	Position         mgl32.Vec3 `json:"position"`
+	Yaw              float32    `json:"yaw"`
+	Pitch            float32    `json:"pitch"`
+	Zoom             float32    `json:"zoom"`
+	MovementSpeed    float32    `json:"movementSpeed"`
+	MouseSensitivity float32    `json:"mouseSensitivity"`
+	Acceleration     float32    `json:"acceleration,omitempty"`
+	Damping          float32    `json:"damping,omitempty"`
+	MouseSmoothing   float32    `json:"mouseSmoothing,omitempty"`
+}
+
+func WithState(s CameraState) CameraOption {
+	return func(c *Camera) {
+		c.SetState(s)
+	}
+}
+
+func (c *Camera) State() CameraState {
+	return CameraState{
+		Position:         c.Position,
+		Yaw:              c.Yaw,
+		Pitch:            c.Pitch,
+		Zoom:             c.Zoom,
+		MovementSpeed:    c.MovementSpeed,
+		MouseSensitivity: c.MouseSensitivity,
+		Acceleration:     c.Acceleration,
+		Damping:          c.Damping,
+		MouseSmoothing:   c.MouseSmoothing,
+	}
+}
+
+// SetState 恢复相机状态,同时清除残留的速度和未应用的鼠标偏移量
+func (c *Camera) SetState(s CameraState) {
+	c.Position = s.Position
+	c.Yaw = s.Yaw
+	c.Pitch = s.Pitch
+	c.Zoom = s.Zoom
+	c.MovementSpeed = s.MovementSpeed
+	c.MouseSensitivity = s.MouseSensitivity
+	c.Acceleration = s.Acceleration
+	c.Damping = s.Damping
+	c.MouseSmoothing = s.MouseSmoothing
+
+	c.Velocity = mgl32.Vec3{}
+	c.moveInput = mgl32.Vec3{}
+	c.mouseX, c.mouseY = 0, 0
+	c.updateCameraVectors()
+}
+
+// defaultCameraState 与 NewCamera 的默认值一致
+func defaultCameraState() CameraState {
+	return CameraState{
+		Yaw:              Yaw,
+		Pitch:            Pitch,
+		Zoom:             Zoom,
+		MovementSpeed:    Speed,
+		MouseSensitivity: Sensitivity,
+	}
+}
+
+// fields 紧凑格式中字段的顺序
+func (s *CameraState) fields() []*float32 {
+	return []*float32{
+		&s.Position[0], &s.Position[1], &s.Position[2],
+		&s.Yaw, &s.Pitch, &s.Zoom, &s.MovementSpeed, &s.MouseSensitivity,
+		&s.Acceleration, &s.Damping, &s.MouseSmoothing,
+	}
+}
+
+// String 输出紧凑格式: x,y,z,yaw,pitch,zoom,speed,sensitivity[,acceleration,damping,mouseSmoothing]
+// 没有开启平滑时省略最后三项, 可以直接粘贴到命令行参数中
+func (s CameraState) String() string {
+	var v []float32
+	for _, f := range s.fields() {
+		v = append(v, *f)
+	}
+	if s.Acceleration == 0 && s.Damping == 0 && s.MouseSmoothing == 0 {
+		v = v[:8]
+	}
+
+	str := make([]string, len(v))
+	for i, f := range v {
+		str[i] = strconv.FormatFloat(float64(f), 'g', -1, 32)
+	}
+	return strings.Join(str, ",")
+}
+
+// ParseCameraState 解析 json 或者 String 输出的紧凑格式,
+// 紧凑格式至少需要 x,y,z,yaw,pitch,省略的字段使用默认值
+func ParseCameraState(str string) (CameraState, error) {
+	s := defaultCameraState()
+
+	str = strings.TrimSpace(str)
+	if strings.HasPrefix(str, "{") {
+		err := json.Unmarshal([]byte(str), &s)
+		return s, err
+	}
+
+	dst := s.fields()
+	fields := strings.Split(str, ",")
+	if len(fields) < 5 || len(fields) > len(dst) {
+		return s, fmt.Errorf("camera state: want 5 to %d values, got %d", len(dst), len(fields))
+	}
+
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(f), 32)
+		if err != nil {
+			return s, fmt.Errorf("camera state: %w", err)
+		}
+		*dst[i] = float32(v)
+	}
+
+	return s, nil
+}
+
+// CameraBookmarks 命名的相机状态
+type CameraBookmarks map[string]CameraState
+
+// LoadBookmarks 从 json 文件读取书签,文件不存在时返回空书签,
+// 书签中省略的字段与 ParseCameraState 一样使用默认值
+func LoadBookmarks(path string) (CameraBookmarks, error) {
+	b := make(CameraBookmarks)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return b, nil
+		}
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	err = json.Unmarshal(data, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, msg := range raw {
+		s := defaultCameraState()
+		err = json.Unmarshal(msg, &s)
+		if err != nil {
+			return nil, fmt.Errorf("bookmark %q: %w", name, err)
+		}
+		b[name] = s
+	}
+
+	return b, nil
+}
+
+func (b CameraBookmarks) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
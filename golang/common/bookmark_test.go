@@ -0,0 +1,105 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestCameraStateRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		state CameraState
+	}{
+		{"default", NewCamera().State()},
+		{"moved", CameraState{
+			Position: mgl32.Vec3{1.5, -2, 30}, Yaw: 12.25, Pitch: -30,
+			Zoom: 20, MovementSpeed: 7, MouseSensitivity: 0.2,
+		}},
+		{"smoothed", NewCamera(WithPosition(mgl32.Vec3{0, 1, 2}), WithSmoothMovement(10, 6), WithMouseSmoothing(30)).State()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCameraState(tt.state.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.state {
+				t.Errorf("ParseCameraState(%q) = %+v, want %+v", tt.state.String(), got, tt.state)
+			}
+		})
+	}
+}
+
+func TestParseCameraState(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    CameraState
+		wantErr bool
+	}{
+		{in: "1,2,3,-45,10", want: CameraState{
+			Position: mgl32.Vec3{1, 2, 3}, Yaw: -45, Pitch: 10,
+			Zoom: Zoom, MovementSpeed: Speed, MouseSensitivity: Sensitivity,
+		}},
+		{in: `{"position":[1,2,3],"yaw":-45}`, want: CameraState{
+			Position: mgl32.Vec3{1, 2, 3}, Yaw: -45, Pitch: Pitch,
+			Zoom: Zoom, MovementSpeed: Speed, MouseSensitivity: Sensitivity,
+		}},
+		{in: "1,2,3,4", wantErr: true},
+		{in: "1,2,3,4,5,6,7,8,9,10,11,12", wantErr: true},
+		{in: "1,2,x,4,5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseCameraState(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCameraState(%q) error %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseCameraState(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoadBookmarks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.json")
+
+	b, err := LoadBookmarks(path)
+	if err != nil || len(b) != 0 {
+		t.Fatalf("missing file: %v, %v", b, err)
+	}
+
+	b["1"] = NewCamera(WithPosition(mgl32.Vec3{1, 2, 3}), WithSmoothMovement(5, 2)).State()
+	err = b.Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadBookmarks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded["1"] != b["1"] {
+		t.Errorf("loaded %+v, want %+v", loaded["1"], b["1"])
+	}
+
+	// 手写的书签省略了部分字段, 使用默认值而不是 0
+	err = os.WriteFile(path, []byte(`{"door": {"position": [4, 5, 6], "pitch": -10}}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = LoadBookmarks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CameraState{
+		Position: mgl32.Vec3{4, 5, 6}, Yaw: Yaw, Pitch: -10,
+		Zoom: Zoom, MovementSpeed: Speed, MouseSensitivity: Sensitivity,
+	}
+	if loaded["door"] != want {
+		t.Errorf("loaded %+v, want %+v", loaded["door"], want)
+	}
+}
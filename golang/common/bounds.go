@@ -0,0 +1,169 @@
+package common
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Plane 平面方程: Normal·p + D = 0
+type Plane struct {
+	Normal mgl32.Vec3
+	D      float32
+}
+
+func NewPlane(normal, point mgl32.Vec3) Plane {
+	n := normal.Normalize()
+	return Plane{Normal: n, D: -n.Dot(point)}
+}
+
+// Distance 点到平面的有符号距离,在法线一侧为正
+func (p Plane) Distance(point mgl32.Vec3) float32 {
+	return p.Normal.Dot(point) + p.D
+}
+
+type Sphere struct {
+	Center mgl32.Vec3
+	Radius float32
+}
+
+func (s Sphere) Contains(p mgl32.Vec3) bool {
+	return p.Sub(s.Center).Len() <= s.Radius
+}
+
+// AABB 轴对齐包围盒
+type AABB struct {
+	Min mgl32.Vec3
+	Max mgl32.Vec3
+}
+
+// EmptyAABB 返回一个空包围盒,用 Extend 扩展后才有意义
+func EmptyAABB() AABB {
+	inf := float32(math.Inf(1))
+	return AABB{
+		Min: mgl32.Vec3{inf, inf, inf},
+		Max: mgl32.Vec3{-inf, -inf, -inf},
+	}
+}
+
+func NewAABB(points ...mgl32.Vec3) AABB {
+	b := EmptyAABB()
+	for _, p := range points {
+		b = b.Extend(p)
+	}
+	return b
+}
+
+func (b AABB) IsEmpty() bool {
+	return b.Min[0] > b.Max[0] || b.Min[1] > b.Max[1] || b.Min[2] > b.Max[2]
+}
+
+func (b AABB) Center() mgl32.Vec3 {
+	return b.Min.Add(b.Max).Mul(0.5)
+}
+
+func (b AABB) Size() mgl32.Vec3 {
+	return b.Max.Sub(b.Min)
+}
+
+func (b AABB) Extend(p mgl32.Vec3) AABB {
+	for i := 0; i < 3; i++ {
+		b.Min[i] = min(b.Min[i], p[i])
+		b.Max[i] = max(b.Max[i], p[i])
+	}
+	return b
+}
+
+func (b AABB) Union(o AABB) AABB {
+	for i := 0; i < 3; i++ {
+		b.Min[i] = min(b.Min[i], o.Min[i])
+		b.Max[i] = max(b.Max[i], o.Max[i])
+	}
+	return b
+}
+
+func (b AABB) Contains(p mgl32.Vec3) bool {
+	return p[0] >= b.Min[0] && p[0] <= b.Max[0] &&
+		p[1] >= b.Min[1] && p[1] <= b.Max[1] &&
+		p[2] >= b.Min[2] && p[2] <= b.Max[2]
+}
+
+func (b AABB) Intersects(o AABB) bool {
+	return b.Min[0] <= o.Max[0] && b.Max[0] >= o.Min[0] &&
+		b.Min[1] <= o.Max[1] && b.Max[1] >= o.Min[1] &&
+		b.Min[2] <= o.Max[2] && b.Max[2] >= o.Min[2]
+}
+
+// ClosestPoint 包围盒上(或内部)距离 p 最近的点
+func (b AABB) ClosestPoint(p mgl32.Vec3) mgl32.Vec3 {
+	for i := 0; i < 3; i++ {
+		p[i] = mgl32.Clamp(p[i], b.Min[i], b.Max[i])
+	}
+	return p
+}
+
+// Transform 变换后的 8 个角点重新求包围盒
+func (b AABB) Transform(m mgl32.Mat4) AABB {
+	r := EmptyAABB()
+	for i := 0; i < 8; i++ {
+		p := b.Min
+		if i&1 != 0 {
+			p[0] = b.Max[0]
+		}
+		if i&2 != 0 {
+			p[1] = b.Max[1]
+		}
+		if i&4 != 0 {
+			p[2] = b.Max[2]
+		}
+		r = r.Extend(mgl32.TransformCoordinate(p, m))
+	}
+	return r
+}
+
+// Frustum 视锥体的 6 个平面(左右下上近远), 法线指向内侧
+type Frustum [6]Plane
+
+// NewFrustum 从 projection * view 矩阵提取视锥体平面, 传入 projection * view * model 时得到模型空间的视锥体
+func NewFrustum(viewProjection mgl32.Mat4) Frustum {
+	var f Frustum
+	r3 := viewProjection.Row(3)
+	for i := 0; i < 3; i++ {
+		r := viewProjection.Row(i)
+		f[i*2] = frustumPlane(r3.Add(r))
+		f[i*2+1] = frustumPlane(r3.Sub(r))
+	}
+	return f
+}
+
+func frustumPlane(v mgl32.Vec4) Plane {
+	n := v.Vec3()
+	l := n.Len()
+	return Plane{Normal: n.Mul(1 / l), D: v[3] / l}
+}
+
+// IntersectsAABB 包围盒与视锥体相交或在其内部时返回 true, 偶尔会把视锥体外角落附近的包围盒判为相交
+func (f Frustum) IntersectsAABB(b AABB) bool {
+	for _, p := range f {
+		// 只需检查沿法线方向最远的角点
+		v := b.Min
+		for i := 0; i < 3; i++ {
+			if p.Normal[i] >= 0 {
+				v[i] = b.Max[i]
+			}
+		}
+		if p.Distance(v) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (f Frustum) IntersectsSphere(s Sphere) bool {
+	for _, p := range f {
+		if p.Distance(s.Center) < -s.Radius {
+			return false
+		}
+	}
+	return true
+}
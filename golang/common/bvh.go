@@ -0,0 +1,627 @@
+package common
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	bvhBins    = 16 // SAH 划分时每个轴的桶数
+	bvhMaxLeaf = 8  // 叶子最多的图元数, 不超过时由 SAH 决定是否继续划分
+)
+
+// bvhNode 内部节点的左子节点紧跟在后面, right 为右子节点下标; 叶子的图元为 prims[start:start+count]
+type bvhNode struct {
+	bounds AABB
+	start  int32 // 叶子: 图元起点; 内部节点: 右子节点下标
+	count  int32 // 0 表示内部节点
+}
+
+// BVH 包围盒层次结构, 只保存图元的下标, 求交等操作通过回调交给调用者
+// 子节点总是排在父节点之后, 因此逆序遍历即可自底向上更新
+type BVH struct {
+	nodes []bvhNode
+	prims []int32
+}
+
+// NewBVH 使用分桶的 SAH 构建, 空包围盒的图元不会被加入
+func NewBVH(bounds []AABB) *BVH {
+	b := &BVH{}
+	b.Build(bounds)
+	return b
+}
+
+// Build 重新构建, 图元移动太多导致 Refit 后查询变慢时使用
+func (b *BVH) Build(bounds []AABB) {
+	b.nodes = b.nodes[:0]
+	b.prims = b.prims[:0]
+
+	centers := make([]mgl32.Vec3, len(bounds))
+	for i, box := range bounds {
+		if box.IsEmpty() {
+			continue
+		}
+		centers[i] = box.Center()
+		b.prims = append(b.prims, int32(i))
+	}
+	if len(b.prims) == 0 {
+		return
+	}
+
+	b.nodes = make([]bvhNode, 0, 2*len(b.prims)/bvhMaxLeaf+1)
+	b.build(bounds, centers, 0, len(b.prims))
+}
+
+func (b *BVH) build(bounds []AABB, centers []mgl32.Vec3, lo, hi int) int32 {
+	idx := int32(len(b.nodes))
+	b.nodes = append(b.nodes, bvhNode{})
+
+	var (
+		box     = EmptyAABB()
+		centBox = EmptyAABB()
+	)
+	for _, p := range b.prims[lo:hi] {
+		box = box.Union(bounds[p])
+		centBox = centBox.Extend(centers[p])
+	}
+	b.nodes[idx].bounds = box
+
+	n := hi - lo
+	mid, ok := b.split(bounds, centers, lo, hi, box, centBox)
+	if !ok {
+		if n <= bvhMaxLeaf {
+			b.nodes[idx].start, b.nodes[idx].count = int32(lo), int32(n)
+			return idx
+		}
+		// SAH 认为不值得划分但图元太多, 按最长轴上的中心点排序后对半分;
+		// 中心点全部重合时无法区分, 任意对半分
+		axis, extent := 0, centBox.Size()
+		for a, e := range extent {
+			if e > extent[axis] {
+				axis = a
+			}
+		}
+		slices.SortFunc(b.prims[lo:hi], func(p, q int32) int {
+			return cmp.Compare(centers[p][axis], centers[q][axis])
+		})
+		mid = lo + n/2
+	}
+
+	b.build(bounds, centers, lo, mid)
+	right := b.build(bounds, centers, mid, hi)
+	b.nodes[idx].start = right
+	return idx
+}
+
+// split 在三个轴上分桶计算 SAH 代价, 找到比叶子更便宜的划分时重新排列 prims[lo:hi] 并返回划分点
+func (b *BVH) split(bounds []AABB, centers []mgl32.Vec3, lo, hi int, box, centBox AABB) (int, bool) {
+	type bin struct {
+		box   AABB
+		count int
+	}
+
+	var (
+		n         = hi - lo
+		bestCost  = float32(n) // 叶子的代价: 与每个图元求交
+		bestAxis  = -1
+		bestSplit int
+		extent    = centBox.Size()
+		area      = surfaceArea(box)
+	)
+	if n <= 1 || area <= 0 {
+		return 0, false
+	}
+
+	// 图元少时桶也少, 节点数量多的底层可以省掉大部分开销
+	nb := min(n, bvhBins)
+	var scale mgl32.Vec3
+	for axis := range scale {
+		if extent[axis] > 0 {
+			scale[axis] = float32(nb) / extent[axis]
+		}
+	}
+	binOf := func(axis int, c mgl32.Vec3) int {
+		k := int((c[axis] - centBox.Min[axis]) * scale[axis])
+		return min(k, nb-1)
+	}
+
+	// 三个轴同时分桶, 只需要遍历一次图元
+	var bins [3][bvhBins]bin
+	for axis := range bins {
+		for i := range nb {
+			bins[axis][i].box = EmptyAABB()
+		}
+	}
+	for _, p := range b.prims[lo:hi] {
+		for axis := range bins {
+			k := binOf(axis, centers[p])
+			bins[axis][k].box = bins[axis][k].box.Union(bounds[p])
+			bins[axis][k].count++
+		}
+	}
+
+	for axis := 0; axis < 3; axis++ {
+		if extent[axis] <= 0 {
+			continue
+		}
+
+		// 从右往左累加, rightCost[i] 为桶 i 到末尾的面积乘以图元数
+		var rightCost [bvhBins]float32
+		acc, count := EmptyAABB(), 0
+		for i := nb - 1; i > 0; i-- {
+			acc = acc.Union(bins[axis][i].box)
+			count += bins[axis][i].count
+			rightCost[i] = surfaceArea(acc) * float32(count)
+		}
+
+		acc, count = EmptyAABB(), 0
+		for i := 0; i < nb-1; i++ {
+			acc = acc.Union(bins[axis][i].box)
+			count += bins[axis][i].count
+			if count == 0 || count == n {
+				continue
+			}
+
+			// 遍历代价取 1, 与图元求交的代价也取 1
+			cost := 1 + (surfaceArea(acc)*float32(count)+rightCost[i+1])/area
+			if cost < bestCost {
+				bestCost, bestAxis, bestSplit = cost, axis, i
+			}
+		}
+	}
+
+	if bestAxis < 0 {
+		return 0, false
+	}
+
+	// 原地划分
+	i, j := lo, hi-1
+	for i <= j {
+		if binOf(bestAxis, centers[b.prims[i]]) <= bestSplit {
+			i++
+		} else {
+			b.prims[i], b.prims[j] = b.prims[j], b.prims[i]
+			j--
+		}
+	}
+	return i, true
+}
+
+func surfaceArea(b AABB) float32 {
+	if b.IsEmpty() {
+		return 0
+	}
+	s := b.Size()
+	return 2 * (s[0]*s[1] + s[1]*s[2] + s[2]*s[0])
+}
+
+// Bounds 所有图元的包围盒
+func (b *BVH) Bounds() AABB {
+	if len(b.nodes) == 0 {
+		return EmptyAABB()
+	}
+	return b.nodes[0].bounds
+}
+
+// Refit 图元移动后自底向上更新包围盒, 树的结构不变
+// bounds 必须与构建时下标一致, 移动幅度较大时查询会变慢, 此时应重新 Build
+func (b *BVH) Refit(bounds []AABB) {
+	for i := len(b.nodes) - 1; i >= 0; i-- {
+		n := &b.nodes[i]
+		if n.count > 0 {
+			n.bounds = EmptyAABB()
+			for _, p := range b.prims[n.start : n.start+n.count] {
+				n.bounds = n.bounds.Union(bounds[p])
+			}
+		} else {
+			n.bounds = b.nodes[i+1].bounds.Union(b.nodes[n.start].bounds)
+		}
+	}
+}
+
+// Raycast 返回最近的命中, hit 对单个图元求交
+// 已经找到的命中会剪掉更远的节点, 因此 hit 返回的 Distance 必须是射线参数 t
+func (b *BVH) Raycast(r Ray, hit func(prim int, r Ray) (RayHit, bool)) (RayHit, bool) {
+	var (
+		best  RayHit
+		found bool
+		tMax  = float32(math.Inf(1))
+		inv   = mgl32.Vec3{1 / r.Direction[0], 1 / r.Direction[1], 1 / r.Direction[2]}
+		stack = make([]int32, 0, 64)
+	)
+	if len(b.nodes) == 0 {
+		return best, false
+	}
+	if _, ok := rayBox(r.Origin, inv, b.nodes[0].bounds, tMax); !ok {
+		return best, false
+	}
+
+	stack = append(stack, 0)
+	for len(stack) > 0 {
+		idx := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		n := &b.nodes[idx]
+		if n.count > 0 {
+			for _, p := range b.prims[n.start : n.start+n.count] {
+				h, ok := hit(int(p), r)
+				if ok && h.Distance < tMax {
+					best, found, tMax = h, true, h.Distance
+				}
+			}
+			continue
+		}
+
+		// 先访问近的子节点, 远的子节点压栈在下面
+		left, right := idx+1, n.start
+		tl, okl := rayBox(r.Origin, inv, b.nodes[left].bounds, tMax)
+		tr, okr := rayBox(r.Origin, inv, b.nodes[right].bounds, tMax)
+		switch {
+		case okl && okr:
+			if tl > tr {
+				left, right = right, left
+			}
+			stack = append(stack, right, left)
+		case okl:
+			stack = append(stack, left)
+		case okr:
+			stack = append(stack, right)
+		}
+	}
+
+	return best, found
+}
+
+// Overlap 对所在叶子与 box 相交的每个图元调用 fn, 图元本身是否相交由 fn 判断, fn 返回 false 时停止
+func (b *BVH) Overlap(box AABB, fn func(prim int) bool) {
+	if len(b.nodes) == 0 {
+		return
+	}
+
+	stack := make([]int32, 0, 64)
+	stack = append(stack, 0)
+	for len(stack) > 0 {
+		idx := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		n := &b.nodes[idx]
+		if !n.bounds.Intersects(box) {
+			continue
+		}
+		if n.count == 0 {
+			stack = append(stack, n.start, idx+1)
+			continue
+		}
+		for _, p := range b.prims[n.start : n.start+n.count] {
+			if !fn(int(p)) {
+				return
+			}
+		}
+	}
+}
+
+// Nearest 查找距离 p 最近的图元, closest 返回图元上距离 p 最近的点
+// maxDist 限制搜索范围, 为 0 时不限制
+func (b *BVH) Nearest(p mgl32.Vec3, maxDist float32, closest func(prim int, p mgl32.Vec3) mgl32.Vec3) (int, mgl32.Vec3, bool) {
+	var (
+		bestPrim = -1
+		bestPt   mgl32.Vec3
+		bestD2   = float32(math.Inf(1))
+	)
+	if maxDist > 0 {
+		bestD2 = maxDist * maxDist
+	}
+	if len(b.nodes) == 0 {
+		return -1, bestPt, false
+	}
+
+	boxDist2 := func(box AABB) float32 {
+		return box.ClosestPoint(p).Sub(p).LenSqr()
+	}
+
+	stack := make([]int32, 0, 64)
+	stack = append(stack, 0)
+	for len(stack) > 0 {
+		idx := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		n := &b.nodes[idx]
+		if boxDist2(n.bounds) > bestD2 {
+			continue
+		}
+		if n.count > 0 {
+			for _, prim := range b.prims[n.start : n.start+n.count] {
+				q := closest(int(prim), p)
+				if d2 := q.Sub(p).LenSqr(); d2 <= bestD2 {
+					bestPrim, bestPt, bestD2 = int(prim), q, d2
+				}
+			}
+			continue
+		}
+
+		// 先访问近的子节点
+		left, right := idx+1, n.start
+		if boxDist2(b.nodes[left].bounds) > boxDist2(b.nodes[right].bounds) {
+			left, right = right, left
+		}
+		stack = append(stack, right, left)
+	}
+
+	return bestPrim, bestPt, bestPrim >= 0
+}
+
+// rayBox slab 测试, 返回进入包围盒的 t, 射线起点在盒内时为 0
+func rayBox(origin, inv mgl32.Vec3, b AABB, tMax float32) (float32, bool) {
+	tMin := float32(0)
+	for i := 0; i < 3; i++ {
+		t1 := (b.Min[i] - origin[i]) * inv[i]
+		t2 := (b.Max[i] - origin[i]) * inv[i]
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		// 方向分量为 0 且起点在 slab 上时会出现 NaN, 按相交处理
+		if t1 > tMin {
+			tMin = t1
+		}
+		if t2 < tMax {
+			tMax = t2
+		}
+		if tMin > tMax {
+			return 0, false
+		}
+	}
+	return tMin, true
+}
+
+// MeshBVH 三角形网格的 BVH, 用于拾取和碰撞, 坐标都在网格的局部空间
+type MeshBVH struct {
+	Positions []mgl32.Vec3
+	Indices   []uint32 // 为空时每 3 个顶点组成一个三角形
+
+	tree  BVH
+	boxes []AABB
+}
+
+func NewMeshBVH(positions []mgl32.Vec3, indices []uint32) *MeshBVH {
+	m := &MeshBVH{Positions: positions, Indices: indices}
+	m.updateBoxes()
+	m.tree.Build(m.boxes)
+	return m
+}
+
+func (m *MeshBVH) TriangleCount() int {
+	if len(m.Indices) == 0 {
+		return len(m.Positions) / 3
+	}
+	return len(m.Indices) / 3
+}
+
+// Triangle 第 i 个三角形的三个顶点
+func (m *MeshBVH) Triangle(i int) (mgl32.Vec3, mgl32.Vec3, mgl32.Vec3) {
+	if len(m.Indices) == 0 {
+		return m.Positions[3*i], m.Positions[3*i+1], m.Positions[3*i+2]
+	}
+	return m.Positions[m.Indices[3*i]], m.Positions[m.Indices[3*i+1]], m.Positions[m.Indices[3*i+2]]
+}
+
+func (m *MeshBVH) updateBoxes() {
+	n := m.TriangleCount()
+	if len(m.boxes) != n {
+		m.boxes = make([]AABB, n)
+	}
+	for i := range m.boxes {
+		a, b, c := m.Triangle(i)
+		m.boxes[i] = NewAABB(a, b, c)
+	}
+}
+
+// Refit 修改 Positions(例如 CPU 蒙皮)后更新包围盒, 拓扑不能改变
+func (m *MeshBVH) Refit() {
+	m.updateBoxes()
+	m.tree.Refit(m.boxes)
+}
+
+// Rebuild 顶点移动较大或拓扑改变后重新构建
+func (m *MeshBVH) Rebuild() {
+	m.updateBoxes()
+	m.tree.Build(m.boxes)
+}
+
+func (m *MeshBVH) Bounds() AABB {
+	return m.tree.Bounds()
+}
+
+// IntersectRay 与 Ray.IntersectMesh 结果相同, RayHit.Index 为三角形序号
+// 可以直接传给 Ray.IntersectModel: r.IntersectModel(model, bvh.IntersectRay)
+func (m *MeshBVH) IntersectRay(r Ray) (RayHit, bool) {
+	return m.tree.Raycast(r, func(i int, r Ray) (RayHit, bool) {
+		a, b, c := m.Triangle(i)
+		hit, ok := r.IntersectTriangle(a, b, c)
+		hit.Index = i
+		return hit, ok
+	})
+}
+
+// ClosestPoint 网格表面上距离 p 最近的点及所在的三角形, maxDist 为 0 时不限制距离
+func (m *MeshBVH) ClosestPoint(p mgl32.Vec3, maxDist float32) (mgl32.Vec3, int, bool) {
+	i, q, ok := m.tree.Nearest(p, maxDist, func(i int, p mgl32.Vec3) mgl32.Vec3 {
+		a, b, c := m.Triangle(i)
+		return closestPointTriangle(p, a, b, c)
+	})
+	return q, i, ok
+}
+
+// Overlap 对与 box 相交的每个三角形调用 fn, fn 返回 false 时停止
+func (m *MeshBVH) Overlap(box AABB, fn func(tri int) bool) {
+	m.tree.Overlap(box, func(i int) bool {
+		a, b, c := m.Triangle(i)
+		if !triangleAABBOverlap(a, b, c, box) {
+			return true
+		}
+		return fn(i)
+	})
+}
+
+// closestPointTriangle 三角形上距离 p 最近的点, 按 p 所在的 Voronoi 区域分别处理
+func closestPointTriangle(p, a, b, c mgl32.Vec3) mgl32.Vec3 {
+	ab, ac, ap := b.Sub(a), c.Sub(a), p.Sub(a)
+	d1, d2 := ab.Dot(ap), ac.Dot(ap)
+	if d1 <= 0 && d2 <= 0 {
+		return a
+	}
+
+	bp := p.Sub(b)
+	d3, d4 := ab.Dot(bp), ac.Dot(bp)
+	if d3 >= 0 && d4 <= d3 {
+		return b
+	}
+
+	vc := d1*d4 - d3*d2
+	if vc <= 0 && d1 >= 0 && d3 <= 0 {
+		return a.Add(ab.Mul(d1 / (d1 - d3)))
+	}
+
+	cp := p.Sub(c)
+	d5, d6 := ab.Dot(cp), ac.Dot(cp)
+	if d6 >= 0 && d5 <= d6 {
+		return c
+	}
+
+	vb := d5*d2 - d1*d6
+	if vb <= 0 && d2 >= 0 && d6 <= 0 {
+		return a.Add(ac.Mul(d2 / (d2 - d6)))
+	}
+
+	va := d3*d6 - d5*d4
+	if va <= 0 && d4-d3 >= 0 && d5-d6 >= 0 {
+		return b.Add(c.Sub(b).Mul((d4 - d3) / ((d4 - d3) + (d5 - d6))))
+	}
+
+	// 在三角形内部
+	denom := 1 / (va + vb + vc)
+	return a.Add(ab.Mul(vb * denom)).Add(ac.Mul(vc * denom))
+}
+
+// triangleAABBOverlap 分离轴测试: 包围盒的 3 个轴、三角形法线、以及 9 个边与轴的叉积
+func triangleAABBOverlap(a, b, c mgl32.Vec3, box AABB) bool {
+	center := box.Center()
+	half := box.Size().Mul(0.5)
+	v := [3]mgl32.Vec3{a.Sub(center), b.Sub(center), c.Sub(center)}
+	e := [3]mgl32.Vec3{v[1].Sub(v[0]), v[2].Sub(v[1]), v[0].Sub(v[2])}
+
+	separated := func(axis mgl32.Vec3) bool {
+		p0, p1, p2 := v[0].Dot(axis), v[1].Dot(axis), v[2].Dot(axis)
+		r := half[0]*abs(axis[0]) + half[1]*abs(axis[1]) + half[2]*abs(axis[2])
+		return min(p0, p1, p2) > r || max(p0, p1, p2) < -r
+	}
+
+	for i := 0; i < 3; i++ {
+		var axis mgl32.Vec3
+		axis[i] = 1
+		if separated(axis) {
+			return false
+		}
+		for _, edge := range e {
+			if separated(axis.Cross(edge)) {
+				return false
+			}
+		}
+	}
+	return !separated(e[0].Cross(e[1]))
+}
+
+// SceneBVH 场景节点的 BVH, 使用节点的局部包围盒 Node.Bounds 和世界矩阵
+// 节点移动后调用 Refit, 增删节点后重新 NewSceneBVH
+type SceneBVH struct {
+	Nodes []*Node
+
+	tree  BVH
+	boxes []AABB
+}
+
+// NewSceneBVH 收集 root 子树中可见且包围盒不为空的节点
+func NewSceneBVH(root *Node) *SceneBVH {
+	s := &SceneBVH{}
+	root.Walk(func(n *Node) bool {
+		if !n.Visible {
+			return false
+		}
+		if !n.Bounds.IsEmpty() {
+			s.Nodes = append(s.Nodes, n)
+		}
+		return true
+	})
+
+	s.updateBoxes()
+	s.tree.Build(s.boxes)
+	return s
+}
+
+func (s *SceneBVH) updateBoxes() {
+	if len(s.boxes) != len(s.Nodes) {
+		s.boxes = make([]AABB, len(s.Nodes))
+	}
+	for i, n := range s.Nodes {
+		s.boxes[i] = n.Bounds.Transform(n.World())
+	}
+}
+
+// Refit 节点移动(例如动画)后更新世界包围盒
+func (s *SceneBVH) Refit() {
+	s.updateBoxes()
+	s.tree.Refit(s.boxes)
+}
+
+func (s *SceneBVH) Bounds() AABB {
+	return s.tree.Bounds()
+}
+
+// Pick 返回射线最先命中的节点, exact 为 nil 时只与世界包围盒求交,
+// 否则对包围盒命中的节点调用 exact 精确求交, r 为世界空间的射线, 例如:
+//
+//	s.Pick(r, func(n *Node, r Ray) (RayHit, bool) {
+//		return r.IntersectModel(n.World(), meshBVHs[n].IntersectRay)
+//	})
+func (s *SceneBVH) Pick(r Ray, exact func(n *Node, r Ray) (RayHit, bool)) (*Node, RayHit, bool) {
+	hit, ok := s.tree.Raycast(r, func(i int, r Ray) (RayHit, bool) {
+		var (
+			h  RayHit
+			ok bool
+		)
+		if exact != nil {
+			h, ok = exact(s.Nodes[i], r)
+		} else {
+			h, ok = r.IntersectAABB(s.boxes[i])
+		}
+		h.Index = i
+		return h, ok
+	})
+	if !ok {
+		return nil, hit, false
+	}
+	return s.Nodes[hit.Index], hit, true
+}
+
+// Overlap 对世界包围盒与 box 相交的每个节点调用 fn, fn 返回 false 时停止
+func (s *SceneBVH) Overlap(box AABB, fn func(n *Node) bool) {
+	s.tree.Overlap(box, func(i int) bool {
+		if !s.boxes[i].Intersects(box) {
+			return true
+		}
+		return fn(s.Nodes[i])
+	})
+}
+
+// Nearest 世界包围盒距离 p 最近的节点, p 在包围盒内时距离为 0
+func (s *SceneBVH) Nearest(p mgl32.Vec3, maxDist float32) (*Node, mgl32.Vec3, bool) {
+	i, q, ok := s.tree.Nearest(p, maxDist, func(i int, p mgl32.Vec3) mgl32.Vec3 {
+		return s.boxes[i].ClosestPoint(p)
+	})
+	if !ok {
+		return nil, q, false
+	}
+	return s.Nodes[i], q, true
+}
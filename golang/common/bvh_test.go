@@ -0,0 +1,172 @@
+package common
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// randomRays 从半径为 r 的球面上射向原点附近的射线
+func randomRays(n int, r float32) []Ray {
+	rng := rand.New(rand.NewSource(1))
+	rays := make([]Ray, n)
+	for i := range rays {
+		origin := mgl32.Vec3{rng.Float32()*2 - 1, rng.Float32()*2 - 1, rng.Float32()*2 - 1}.Normalize().Mul(r)
+		target := mgl32.Vec3{rng.Float32() - 0.5, rng.Float32() - 0.5, rng.Float32() - 0.5}
+		rays[i] = Ray{Origin: origin, Direction: target.Sub(origin).Normalize()}
+	}
+	return rays
+}
+
+func TestMeshBVHRaycast(t *testing.T) {
+	torus := GenTorus(1, 0.3, 64, 32)
+	bvh := NewMeshBVH(torus.Positions, torus.Indices)
+
+	hits := 0
+	for i, r := range randomRays(500, 3) {
+		want, wantOK := r.IntersectMesh(torus.Positions, torus.Indices)
+		got, ok := bvh.IntersectRay(r)
+		if ok != wantOK {
+			t.Fatalf("ray %d: hit %v, brute force %v", i, ok, wantOK)
+		}
+		if !ok {
+			continue
+		}
+		hits++
+		if !mgl32.FloatEqualThreshold(got.Distance, want.Distance, 1e-4) {
+			t.Errorf("ray %d: distance %v, brute force %v", i, got.Distance, want.Distance)
+		}
+	}
+	if hits == 0 {
+		t.Fatal("no ray hit the torus")
+	}
+}
+
+// TestBVHFallbackSplit 大量互相重叠的图元 SAH 不会划分, 强制划分时应按空间位置而不是下标分开
+func TestBVHFallbackSplit(t *testing.T) {
+	const n = 64
+	rng := rand.New(rand.NewSource(1))
+	boxes := make([]AABB, n)
+	for i, k := range rng.Perm(n) {
+		c := mgl32.Vec3{float32(k) * 0.01, 0, 0}
+		boxes[i] = NewAABB(c.Sub(mgl32.Vec3{50, 50, 50}), c.Add(mgl32.Vec3{50, 50, 50}))
+	}
+	b := NewBVH(boxes)
+	if len(b.nodes) == 1 {
+		t.Fatal("no split")
+	}
+
+	// 返回子树中心点 x 的范围, 内部节点的左右子树不能交错
+	var walk func(idx int32) (float32, float32)
+	walk = func(idx int32) (float32, float32) {
+		node := b.nodes[idx]
+		if node.count > 0 {
+			if node.count > bvhMaxLeaf {
+				t.Errorf("leaf with %d primitives", node.count)
+			}
+			lo, hi := float32(math.Inf(1)), float32(math.Inf(-1))
+			for _, p := range b.prims[node.start : node.start+node.count] {
+				x := boxes[p].Center().X()
+				lo, hi = min(lo, x), max(hi, x)
+			}
+			return lo, hi
+		}
+		llo, lhi := walk(idx + 1)
+		rlo, rhi := walk(node.start)
+		if lhi > rlo && rhi > llo {
+			t.Errorf("node %d: children overlap along x, [%v, %v] and [%v, %v]", idx, llo, lhi, rlo, rhi)
+		}
+		return min(llo, rlo), max(lhi, rhi)
+	}
+	walk(0)
+
+	// 查询结果与逐个检查一致, 并且能剪掉大部分图元
+	query := NewAABB(mgl32.Vec3{-49.8, 0, 0}, mgl32.Vec3{-49.7, 1, 1})
+	var candidates, got int
+	b.Overlap(query, func(prim int) bool {
+		candidates++
+		if boxes[prim].Intersects(query) {
+			got++
+		}
+		return true
+	})
+	want := 0
+	for _, box := range boxes {
+		if box.Intersects(query) {
+			want++
+		}
+	}
+	if got != want {
+		t.Errorf("overlap found %d boxes, want %d", got, want)
+	}
+	if candidates > want+bvhMaxLeaf {
+		t.Errorf("overlap visited %d of %d boxes for %d hits", candidates, n, want)
+	}
+}
+
+func BenchmarkMeshBVHRaycast(b *testing.B) {
+	torus := GenTorus(1, 0.3, 256, 128)
+	rays := randomRays(1024, 3)
+
+	b.Run("bvh", func(b *testing.B) {
+		bvh := NewMeshBVH(torus.Positions, torus.Indices)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			bvh.IntersectRay(rays[i%len(rays)])
+		}
+	})
+	b.Run("brute force", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			rays[i%len(rays)].IntersectMesh(torus.Positions, torus.Indices)
+		}
+	})
+}
+
+// BenchmarkBVHRaycast 与 10.instancing 相同的场景: 拾取大量随机摆放的立方体
+func BenchmarkBVHRaycast(b *testing.B) {
+	const cubes = 100000
+
+	rng := rand.New(rand.NewSource(1))
+	cube := NewAABB(mgl32.Vec3{-0.5, -0.5, -0.5}, mgl32.Vec3{0.5, 0.5, 0.5})
+	boxes := make([]AABB, cubes)
+	for i := range boxes {
+		p := mgl32.Vec3{rng.Float32()*200 - 100, rng.Float32()*200 - 100, rng.Float32()*200 - 100}
+		boxes[i] = cube.Transform(mgl32.Translate3D(p[0], p[1], p[2]))
+	}
+	hitBox := func(i int, r Ray) (RayHit, bool) {
+		hit, ok := r.IntersectAABB(boxes[i])
+		hit.Index = i
+		return hit, ok
+	}
+	rays := randomRays(1024, 150)
+
+	b.Run("build", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			NewBVH(boxes)
+		}
+	})
+	b.Run("bvh", func(b *testing.B) {
+		bvh := NewBVH(boxes)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			bvh.Raycast(rays[i%len(rays)], hitBox)
+		}
+	})
+	b.Run("brute force", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			r := rays[i%len(rays)]
+			var (
+				nearest RayHit
+				found   bool
+			)
+			for j := range boxes {
+				h, ok := hitBox(j, r)
+				if ok && (!found || h.Distance < nearest.Distance) {
+					nearest, found = h, true
+				}
+			}
+		}
+	})
+}
@@ -13,12 +13,25 @@ const (
 	BackWard CameraMove = 2
 	Left     CameraMove = 3
 	Right    CameraMove = 4
+	Up       CameraMove = 5
+	Down     CameraMove = 6
 
 	Yaw         float32 = -90.0
 	Pitch       float32 = 0.0
 	Speed       float32 = 2.5
 	Sensitivity float32 = 0.1
 	Zoom        float32 = 45.0
+
+	SprintMultiplier float32 = 3.0
+	SlowMultiplier   float32 = 0.25
+)
+
+type SpeedModifier int
+
+const (
+	SpeedNormal SpeedModifier = 0
+	SpeedSprint SpeedModifier = 1
+	SpeedSlow   SpeedModifier = 2
 )
 
 type Camera struct {
@@ -35,6 +48,19 @@ type Camera struct {
 	MovementSpeed    float32
 	MouseSensitivity float32
 	Zoom             float32
+	// 平滑移动选项,Acceleration 为 0 时保持原来的瞬时移动
+	Acceleration     float32 // 速度趋近目标速度的速率(1/s)
+	Damping          float32 // 无输入时速度衰减的速率(1/s)
+	MouseSmoothing   float32 // 鼠标偏移量释放的速率(1/s),为 0 时不平滑
+	SprintMultiplier float32
+	SlowMultiplier   float32
+	Velocity         mgl32.Vec3
+
+	modifier  SpeedModifier
+	moveInput mgl32.Vec3 // 本帧累计的移动方向
+	mouseX    float32    // 尚未应用的鼠标偏移量
+	mouseY    float32
+	constrain bool // 释放累计偏移量时是否限制 pitch
 }
 
 type CameraOption func(*Camera)
@@ -45,6 +71,27 @@ func WithPosition(p mgl32.Vec3) CameraOption {
 	}
 }
 
+// WithSmoothMovement 开启基于速度的移动,acceleration 和 damping 的单位都是 1/s
+func WithSmoothMovement(acceleration, damping float32) CameraOption {
+	return func(c *Camera) {
+		c.Acceleration = acceleration
+		c.Damping = damping
+	}
+}
+
+func WithMouseSmoothing(smoothing float32) CameraOption {
+	return func(c *Camera) {
+		c.MouseSmoothing = smoothing
+	}
+}
+
+func WithSpeedMultiplier(sprint, slow float32) CameraOption {
+	return func(c *Camera) {
+		c.SprintMultiplier = sprint
+		c.SlowMultiplier = slow
+	}
+}
+
 func NewCamera(opts ...CameraOption) *Camera {
 	camera := &Camera{
 		Position:         mgl32.Vec3{0, 0, 0},
@@ -55,6 +102,8 @@ func NewCamera(opts ...CameraOption) *Camera {
 		MovementSpeed:    Speed,
 		MouseSensitivity: Sensitivity,
 		Zoom:             Zoom,
+		SprintMultiplier: SprintMultiplier,
+		SlowMultiplier:   SlowMultiplier,
 	}
 
 	for _, opt := range opts {
@@ -69,29 +118,77 @@ func (c *Camera) GetViewMatrix() mgl32.Mat4 {
 	return mgl32.LookAtV(c.Position, c.Position.Add(c.Front), c.Up)
 }
 
-func (c *Camera) ProcessKeyboard(direction CameraMove, deltaTime float32) {
-	// 处理从任何类似键盘的输入系统接收的输入。接受相机定义的 ENUM 形式的输入参数（将其从窗口系统中抽象出来）
-	velocity := c.MovementSpeed * deltaTime
+// GetProjectionMatrix 透视投影矩阵,视野由 Zoom 决定
+func (c *Camera) GetProjectionMatrix(aspect, near, far float32) mgl32.Mat4 {
+	return mgl32.Perspective(mgl32.DegToRad(c.Zoom), aspect, near, far)
+}
+
+// SetSpeedModifier 设置加速/减速状态,对之后的移动生效
+func (c *Camera) SetSpeedModifier(m SpeedModifier) {
+	c.modifier = m
+}
+
+func (c *Camera) speed() float32 {
+	switch c.modifier {
+	case SpeedSprint:
+		return c.MovementSpeed * c.SprintMultiplier
+	case SpeedSlow:
+		return c.MovementSpeed * c.SlowMultiplier
+	default:
+		return c.MovementSpeed
+	}
+}
+
+func (c *Camera) moveDirection(direction CameraMove) mgl32.Vec3 {
 	switch direction {
 	case ForWard:
-		c.Position = c.Position.Add(c.Front.Mul(velocity))
+		return c.Front
 	case BackWard:
-		c.Position = c.Position.Sub(c.Front.Mul(velocity))
+		return c.Front.Mul(-1)
 	case Left:
-		c.Position = c.Position.Sub(c.Right.Mul(velocity))
+		return c.Right.Mul(-1)
 	case Right:
-		c.Position = c.Position.Add(c.Right.Mul(velocity))
+		return c.Right
+	case Up:
+		return c.WorldUp
+	case Down:
+		return c.WorldUp.Mul(-1)
 	default:
 		panic("unexpected camera move")
 	}
 }
 
+func (c *Camera) ProcessKeyboard(direction CameraMove, deltaTime float32) {
+	// 处理从任何类似键盘的输入系统接收的输入。接受相机定义的 ENUM 形式的输入参数（将其从窗口系统中抽象出来）
+	dir := c.moveDirection(direction)
+	if c.Acceleration > 0 {
+		// 平滑移动时只记录输入方向,由 Update 统一积分
+		c.moveInput = c.moveInput.Add(dir)
+		return
+	}
+
+	velocity := c.speed() * deltaTime
+	c.Position = c.Position.Add(dir.Mul(velocity))
+}
+
 func (c *Camera) ProcessMouseMovement(xOffset, yOffset float32, constrainPitch ...bool) {
 	// 处理从鼠标移动事件接收到的输入。只需要水平和垂直方向上的输入
+	if c.MouseSmoothing > 0 {
+		// 平滑时先累计偏移量,由 Update 逐帧释放
+		c.mouseX += xOffset
+		c.mouseY += yOffset
+		c.constrain = len(constrainPitch) == 0 || constrainPitch[0]
+		return
+	}
+
+	c.rotate(xOffset, yOffset, len(constrainPitch) == 0 || constrainPitch[0])
+}
+
+func (c *Camera) rotate(xOffset, yOffset float32, constrainPitch bool) {
 	c.Yaw += xOffset * c.MouseSensitivity
 	c.Pitch += yOffset * c.MouseSensitivity
 
-	if len(constrainPitch) == 0 || constrainPitch[0] {
+	if constrainPitch {
 		// 确保当 pitch 超过 89.0 或 -89.0 度时，不会出现 flipped 相机
 		if c.Pitch > 89.0 {
 			c.Pitch = 89.0
@@ -105,6 +202,46 @@ func (c *Camera) ProcessMouseMovement(xOffset, yOffset float32, constrainPitch .
 	c.updateCameraVectors()
 }
 
+// Update 每帧调用一次,积分平滑移动和平滑鼠标
+// 使用指数衰减的解析解,结果与帧率无关: 一帧 dt 与两帧 dt/2 得到相同的位置
+func (c *Camera) Update(deltaTime float32) {
+	if c.MouseSmoothing > 0 {
+		k := 1 - expf(-c.MouseSmoothing*deltaTime)
+		x, y := c.mouseX*k, c.mouseY*k
+		c.mouseX -= x
+		c.mouseY -= y
+		c.rotate(x, y, c.constrain)
+	}
+
+	if c.Acceleration <= 0 {
+		c.moveInput = mgl32.Vec3{}
+		return
+	}
+
+	var (
+		target mgl32.Vec3
+		rate   = c.Damping
+	)
+	if c.moveInput.Len() > 0 {
+		target = c.moveInput.Normalize().Mul(c.speed())
+		rate = c.Acceleration
+	}
+	c.moveInput = mgl32.Vec3{}
+
+	// v(t) = target + (v0-target)*e^(-k*t)
+	// x(t) = x0 + target*t + (v0-target)*(1-e^(-k*t))/k
+	diff := c.Velocity.Sub(target)
+	offset := target.Mul(deltaTime)
+	if rate > 0 {
+		e := expf(-rate * deltaTime)
+		offset = offset.Add(diff.Mul((1 - e) / rate))
+		c.Velocity = target.Add(diff.Mul(e))
+	} else {
+		offset = offset.Add(diff.Mul(deltaTime))
+	}
+	c.Position = c.Position.Add(offset)
+}
+
 func (c *Camera) ProcessMouseScroll(yOffset float32) {
 	// 处理从鼠标滚轮事件接收到的输入。只需要垂直轮轴上的输入
 	c.Zoom -= yOffset
@@ -116,6 +253,10 @@ func (c *Camera) ProcessMouseScroll(yOffset float32) {
 	}
 }
 
+func expf(x float32) float32 {
+	return float32(math.Exp(float64(x)))
+}
+
 func (c *Camera) updateCameraVectors() {
 	// 计算新的 Front 向量
 	front := mgl32.Vec3{
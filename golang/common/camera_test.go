@@ -0,0 +1,182 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// simulateCamera 以固定的 dt 模拟 duration 秒, 前 hold 秒按住前进键
+func simulateCamera(c *Camera, dt, hold, duration float32) {
+	steps := int(duration/dt + 0.5)
+	for i := 0; i < steps; i++ {
+		if float32(i)*dt < hold-dt/2 {
+			c.ProcessKeyboard(ForWard, dt)
+		}
+		c.Update(dt)
+	}
+}
+
+func TestCameraUpdateFrameRateIndependent(t *testing.T) {
+	tests := []struct {
+		name           string
+		hold, duration float32
+	}{
+		{"accelerate", 1, 1},
+		{"accelerate and coast", 0.5, 2},
+		{"short tap", 0.1, 1.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var positions []mgl32.Vec3
+			// 帧率分别为 10、30、60、120 和 240, 都能整除按键和模拟的时长
+			for _, dt := range []float32{1.0 / 10, 1.0 / 30, 1.0 / 60, 1.0 / 120, 1.0 / 240} {
+				c := NewCamera(WithSmoothMovement(8, 4))
+				simulateCamera(c, dt, tt.hold, tt.duration)
+				positions = append(positions, c.Position)
+			}
+
+			for i, p := range positions[1:] {
+				if !p.ApproxEqualThreshold(positions[0], 1e-3) {
+					t.Errorf("frame rate %d: position %v, want %v", i+1, p, positions[0])
+				}
+			}
+			if positions[0][2] >= 0 {
+				t.Errorf("camera did not move forward: %v", positions[0])
+			}
+		})
+	}
+}
+
+func TestCameraUpdateSplitFrame(t *testing.T) {
+	// 一帧 dt 与两帧 dt/2 得到相同的位置和速度
+	a := NewCamera(WithSmoothMovement(10, 6))
+	a.Velocity = mgl32.Vec3{1, 0, -2}
+	a.ProcessKeyboard(Right, 0.1)
+	a.Update(0.1)
+
+	b := NewCamera(WithSmoothMovement(10, 6))
+	b.Velocity = mgl32.Vec3{1, 0, -2}
+	for i := 0; i < 2; i++ {
+		b.ProcessKeyboard(Right, 0.05)
+		b.Update(0.05)
+	}
+
+	if !a.Position.ApproxEqualThreshold(b.Position, 1e-5) {
+		t.Errorf("position %v != %v", a.Position, b.Position)
+	}
+	if !a.Velocity.ApproxEqualThreshold(b.Velocity, 1e-5) {
+		t.Errorf("velocity %v != %v", a.Velocity, b.Velocity)
+	}
+}
+
+func TestCameraTerminalVelocity(t *testing.T) {
+	tests := []struct {
+		name     string
+		modifier SpeedModifier
+		want     float32
+	}{
+		{"normal", SpeedNormal, Speed},
+		{"sprint", SpeedSprint, Speed * SprintMultiplier},
+		{"slow", SpeedSlow, Speed * SlowMultiplier},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCamera(WithSmoothMovement(20, 5))
+			c.SetSpeedModifier(tt.modifier)
+			simulateCamera(c, 1.0/60, 3, 3)
+
+			if got := c.Velocity.Len(); !mgl32.FloatEqualThreshold(got, tt.want, 1e-3) {
+				t.Errorf("velocity %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCameraDamping(t *testing.T) {
+	c := NewCamera(WithSmoothMovement(10, 3))
+	c.Velocity = mgl32.Vec3{0, 0, -5}
+	// 没有输入时速度按 e^(-damping*t) 衰减, 位移收敛到 v0/damping
+	simulateCamera(c, 1.0/60, 0, 10)
+
+	if c.Velocity.Len() > 1e-4 {
+		t.Errorf("velocity %v did not decay", c.Velocity)
+	}
+	if want := float32(-5.0 / 3); !mgl32.FloatEqualThreshold(c.Position[2], want, 1e-3) {
+		t.Errorf("position %v, want z=%v", c.Position, want)
+	}
+}
+
+func TestCameraVerticalMovement(t *testing.T) {
+	c := NewCamera(WithPosition(mgl32.Vec3{0, 1, 0}))
+	c.Pitch = 45
+	c.updateCameraVectors()
+
+	// 上下移动沿世界坐标的 y 轴, 与相机朝向无关
+	c.ProcessKeyboard(Up, 1)
+	if want := (mgl32.Vec3{0, 1 + Speed, 0}); !c.Position.ApproxEqual(want) {
+		t.Errorf("up: position %v, want %v", c.Position, want)
+	}
+	c.ProcessKeyboard(Down, 2)
+	if want := (mgl32.Vec3{0, 1 - Speed, 0}); !c.Position.ApproxEqual(want) {
+		t.Errorf("down: position %v, want %v", c.Position, want)
+	}
+}
+
+func TestCameraMouseSmoothing(t *testing.T) {
+	raw := NewCamera()
+	raw.ProcessMouseMovement(200, 50)
+
+	var yaws []float32
+	for _, dt := range []float32{1.0 / 30, 1.0 / 60, 1.0 / 120} {
+		c := NewCamera(WithMouseSmoothing(20))
+		c.ProcessMouseMovement(200, 50)
+
+		// 0.1 秒时只释放了部分偏移量, 且与帧率无关
+		simulateCamera(c, dt, 0, 0.1)
+		yaws = append(yaws, c.Yaw)
+		if c.Yaw <= Yaw || c.Yaw >= raw.Yaw {
+			t.Errorf("dt %v: yaw %v should be between %v and %v", dt, c.Yaw, Yaw, raw.Yaw)
+		}
+
+		// 最终与不平滑时一致
+		simulateCamera(c, dt, 0, 2)
+		if !mgl32.FloatEqualThreshold(c.Yaw, raw.Yaw, 1e-3) || !mgl32.FloatEqualThreshold(c.Pitch, raw.Pitch, 1e-3) {
+			t.Errorf("dt %v: yaw/pitch %v/%v, want %v/%v", dt, c.Yaw, c.Pitch, raw.Yaw, raw.Pitch)
+		}
+	}
+
+	for _, y := range yaws[1:] {
+		if !mgl32.FloatEqualThreshold(y, yaws[0], 1e-3) {
+			t.Errorf("yaw after 0.1s depends on frame rate: %v", yaws)
+		}
+	}
+}
+
+func TestCameraConstrainPitch(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []CameraOption
+		constrain bool
+		want      float32
+	}{
+		{"raw constrained", nil, true, 89},
+		{"raw free", nil, false, 100},
+		{"smoothed constrained", []CameraOption{WithMouseSmoothing(50)}, true, 89},
+		{"smoothed free", []CameraOption{WithMouseSmoothing(50)}, false, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCamera(tt.opts...)
+			c.ProcessMouseMovement(0, 1000, tt.constrain)
+			simulateCamera(c, 1.0/60, 0, 2)
+
+			if !mgl32.FloatEqualThreshold(c.Pitch, tt.want, 1e-2) {
+				t.Errorf("pitch %v, want %v", c.Pitch, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// http://www.aortiz.me/2018/12/21/CG.html
+// https://www.humus.name/Articles/PracticalClusteredShading.pdf
+
+// ClusterGLSL 中三个 SSBO 的绑定点
+const (
+	ClusterLightBinding = 0 // 所有光源
+	ClusterIndexBinding = 1 // 每个簇的光源下标依次排列
+	ClusterGridBinding  = 2 // 每个簇在下标数组中的 (起点, 个数)
+)
+
+// GPULight 光源在 SSBO 中的 std430 布局, 与 GLSL 的 Light 结构体一致
+type GPULight struct {
+	Position    mgl32.Vec3
+	Radius      float32
+	Direction   mgl32.Vec3
+	Type        LightType
+	Ambient     mgl32.Vec3
+	Constant    float32
+	Diffuse     mgl32.Vec3
+	Linear      float32
+	Specular    mgl32.Vec3
+	Quadratic   float32
+	CutOff      float32
+	OuterCutOff float32
+	_           [2]float32 // 结构体按 vec3 对齐到 16 字节
+}
+
+func (l *Light) GPU() GPULight {
+	return GPULight{
+		Position:    l.Position,
+		Radius:      l.Radius(),
+		Direction:   l.Direction,
+		Type:        l.Type,
+		Ambient:     l.Ambient,
+		Constant:    l.Constant,
+		Diffuse:     l.Diffuse,
+		Linear:      l.Linear,
+		Specular:    l.Specular,
+		Quadratic:   l.Quadratic,
+		CutOff:      l.CutOff,
+		OuterCutOff: l.OuterCutOff,
+	}
+}
+
+// ClusteredLights 分簇前向渲染: 视锥体在屏幕上分成 X*Y 个格子, 深度按指数分成 Z 层,
+// 每帧在 CPU 上把光源分配到与其影响范围相交的簇, 片段着色器只遍历所在簇的光源
+// 只支持透视投影, near 和 far 从投影矩阵中得到
+type ClusteredLights struct {
+	Dims      [3]int
+	Near, Far float32
+
+	Lights  []GPULight
+	Grid    [][2]uint32 // 每个簇在 Indices 中的 (起点, 个数)
+	Indices []uint32
+
+	Viewport [2]float32 // 帧缓冲的大小(像素)
+	MaxCount int        // 单个簇中最多的光源数
+
+	bounds     []AABB // 每个簇在观察空间中的包围盒
+	rows       []AABB // 每层每一行簇合起来的包围盒, 用于快速排除
+	projection mgl32.Mat4
+	lists      [][]uint32
+
+	buffers [3]uint32
+	sizes   [3]int
+}
+
+type ClusterOption func(*ClusteredLights)
+
+// WithClusterGrid 屏幕上 x*y 个格子, 深度方向 z 层, 默认 16*9*24
+func WithClusterGrid(x, y, z int) ClusterOption {
+	return func(c *ClusteredLights) {
+		c.Dims = [3]int{max(x, 1), max(y, 1), max(z, 1)}
+	}
+}
+
+func NewClusteredLights(opts ...ClusterOption) *ClusteredLights {
+	c := &ClusteredLights{Dims: [3]int{16, 9, 24}}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ClusterCount 簇的总数
+func (c *ClusteredLights) ClusterCount() int {
+	return c.Dims[0] * c.Dims[1] * c.Dims[2]
+}
+
+// Slice 观察空间深度 depth (正数) 所在的层, 与 ClusterGLSL 中的计算一致
+func (c *ClusteredLights) Slice(depth float32) int {
+	z := math.Log(float64(max(depth, c.Near)/c.Near)) / math.Log(float64(c.Far/c.Near))
+	return min(max(int(z*float64(c.Dims[2])), 0), c.Dims[2]-1)
+}
+
+// Cluster 格子 (x, y) 和层 z 对应的簇下标, y 从屏幕底部开始
+func (c *ClusteredLights) Cluster(x, y, z int) int {
+	return x + c.Dims[0]*(y+c.Dims[1]*z)
+}
+
+// Assign 在 CPU 上把光源分配到簇, 结果保存在 Lights、Grid 和 Indices 中
+// 定向光分配到所有簇, 半径为 0 的光源(见 Light.Radius)照不到任何地方, 不分配到任何簇
+func (c *ClusteredLights) Assign(lights []Light, view, projection mgl32.Mat4) {
+	if projection != c.projection || len(c.bounds) != c.ClusterCount() {
+		c.computeBounds(projection)
+	}
+
+	c.Lights = c.Lights[:0]
+	if len(c.lists) != len(c.bounds) {
+		c.lists = make([][]uint32, len(c.bounds))
+	}
+	for i := range c.lists {
+		c.lists[i] = c.lists[i][:0]
+	}
+
+	for i := range lights {
+		l := &lights[i]
+		g := l.GPU()
+		c.Lights = append(c.Lights, g)
+		index := uint32(i)
+
+		if l.Type == DirectionalLight {
+			for k := range c.lists {
+				c.lists[k] = append(c.lists[k], index)
+			}
+			continue
+		}
+
+		// 先按深度范围确定层, 再逐个检查层内的簇
+		s := Sphere{Center: mgl32.TransformCoordinate(l.Position, view), Radius: g.Radius}
+		depth := -s.Center[2]
+		if s.Radius <= 0 || depth+s.Radius < c.Near || depth-s.Radius > c.Far {
+			continue
+		}
+		z0, z1 := c.Slice(depth-s.Radius), c.Slice(depth+s.Radius)
+		for z := z0; z <= z1; z++ {
+			for y := 0; y < c.Dims[1]; y++ {
+				if !s.Contains(c.rows[z*c.Dims[1]+y].ClosestPoint(s.Center)) {
+					continue
+				}
+				for k := c.Cluster(0, y, z); k < c.Cluster(0, y+1, z); k++ {
+					if s.Contains(c.bounds[k].ClosestPoint(s.Center)) {
+						c.lists[k] = append(c.lists[k], index)
+					}
+				}
+			}
+		}
+	}
+
+	c.Grid = c.Grid[:0]
+	c.Indices = c.Indices[:0]
+	c.MaxCount = 0
+	for _, list := range c.lists {
+		c.Grid = append(c.Grid, [2]uint32{uint32(len(c.Indices)), uint32(len(list))})
+		c.Indices = append(c.Indices, list...)
+		c.MaxCount = max(c.MaxCount, len(list))
+	}
+}
+
+// computeBounds 格子四角在近平面上的点沿视线缩放到每层的前后深度, 取包围盒
+func (c *ClusteredLights) computeBounds(projection mgl32.Mat4) {
+	c.projection = projection
+	// 透视矩阵 m[10] = (f+n)/(n-f), m[14] = 2fn/(n-f)
+	c.Near = projection[14] / (projection[10] - 1)
+	c.Far = projection[14] / (projection[10] + 1)
+
+	inv := projection.Inv()
+	nx, ny, nz := c.Dims[0], c.Dims[1], c.Dims[2]
+	corners := make([]mgl32.Vec3, (nx+1)*(ny+1))
+	for y := 0; y <= ny; y++ {
+		for x := 0; x <= nx; x++ {
+			ndc := mgl32.Vec3{-1 + 2*float32(x)/float32(nx), -1 + 2*float32(y)/float32(ny), -1}
+			p := mgl32.TransformCoordinate(ndc, inv)
+			corners[y*(nx+1)+x] = p.Mul(1 / -p[2]) // 深度为 1 处的点
+		}
+	}
+
+	c.bounds = make([]AABB, c.ClusterCount())
+	c.rows = make([]AABB, nz*ny)
+	for i := range c.rows {
+		c.rows[i] = EmptyAABB()
+	}
+	for z := 0; z < nz; z++ {
+		d0 := c.Near * float32(math.Pow(float64(c.Far/c.Near), float64(z)/float64(nz)))
+		d1 := c.Near * float32(math.Pow(float64(c.Far/c.Near), float64(z+1)/float64(nz)))
+		for y := 0; y < ny; y++ {
+			for x := 0; x < nx; x++ {
+				b := EmptyAABB()
+				for _, i := range [4]int{y*(nx+1) + x, y*(nx+1) + x + 1, (y+1)*(nx+1) + x, (y+1)*(nx+1) + x + 1} {
+					b = b.Extend(corners[i].Mul(d0)).Extend(corners[i].Mul(d1))
+				}
+				c.bounds[c.Cluster(x, y, z)] = b
+				c.rows[z*ny+y] = c.rows[z*ny+y].Union(b)
+			}
+		}
+	}
+}
+
+// Update 分配光源并上传到 SSBO, width 和 height 为帧缓冲的大小
+func (c *ClusteredLights) Update(lights []Light, view, projection mgl32.Mat4, width, height int) {
+	c.Assign(lights, view, projection)
+	c.Viewport = [2]float32{float32(width), float32(height)}
+
+	uploadStorage(c, ClusterLightBinding, c.Lights)
+	uploadStorage(c, ClusterIndexBinding, c.Indices)
+	uploadStorage(c, ClusterGridBinding, c.Grid)
+}
+
+// uploadStorage 容量不足时重新分配, 否则原地更新, 空数组也保留一点空间以便绑定
+func uploadStorage[T any](c *ClusteredLights, binding int, data []T) {
+	if c.buffers[binding] == 0 {
+		gl.GenBuffers(1, &c.buffers[binding])
+	}
+	size := len(data) * int(unsafe.Sizeof(*new(T)))
+
+	gl.BindBuffer(gl.SHADER_STORAGE_BUFFER, c.buffers[binding])
+	if size > c.sizes[binding] || c.sizes[binding] == 0 {
+		alloc := max(size, 16)
+		gl.BufferData(gl.SHADER_STORAGE_BUFFER, alloc, nil, gl.DYNAMIC_DRAW)
+		c.sizes[binding] = alloc
+	}
+	if size > 0 {
+		gl.BufferSubData(gl.SHADER_STORAGE_BUFFER, 0, size, unsafe.Pointer(unsafe.SliceData(data)))
+	}
+	gl.BindBuffer(gl.SHADER_STORAGE_BUFFER, 0)
+}
+
+// Bind 绑定 SSBO 并设置 ClusterGLSL 中的 uniform, 调用前需要先 Use 着色器
+func (c *ClusteredLights) Bind(s *Shader) {
+	for i, b := range c.buffers {
+		gl.BindBufferBase(gl.SHADER_STORAGE_BUFFER, uint32(i), b)
+	}
+
+	loc := func(name string) int32 {
+		return gl.GetUniformLocation(s.ID, gl.Str(name+CNull))
+	}
+	gl.Uniform3ui(loc("clusterDims"), uint32(c.Dims[0]), uint32(c.Dims[1]), uint32(c.Dims[2]))
+	gl.Uniform2f(loc("clusterViewport"), c.Viewport[0], c.Viewport[1])
+	gl.Uniform2f(loc("clusterDepth"), c.Near, c.Far)
+}
+
+func (c *ClusteredLights) Delete() {
+	for i := range c.buffers {
+		if c.buffers[i] != 0 {
+			gl.DeleteBuffers(1, &c.buffers[i])
+		}
+	}
+	c.buffers, c.sizes = [3]uint32{}, [3]int{}
+}
+
+// ClusterGLSL 分簇的 Blinn-Phong 光照, 与 PhongGLSL 共用材质和光源结构体(二者只能用其一), 放在 #version 之后使用:
+//
+//	float viewDepth = -(view * vec4(FragPos, 1.0)).z;
+//	vec3 color = clusteredLighting(FragPos, normalize(Normal), normalize(viewPos - FragPos), TexCoords, viewDepth);
+//
+// clusterLightCount(viewDepth) 返回片段所在簇的光源数, 可用于调试显示
+var ClusterGLSL = phongGLSL + fmt.Sprintf(`
+layout (std430, binding = %d) readonly buffer ClusterLightBuffer {
+	Light clusterLights[];
+};
+layout (std430, binding = %d) readonly buffer ClusterIndexBuffer {
+	uint clusterIndices[];
+};
+layout (std430, binding = %d) readonly buffer ClusterGridBuffer {
+	uvec2 clusterGrid[];
+};
+
+uniform uvec3 clusterDims;
+uniform vec2 clusterViewport;
+uniform vec2 clusterDepth; // near, far
+
+uvec2 clusterCell(float viewDepth)
+{
+	uvec2 tile = uvec2(clamp(gl_FragCoord.xy / clusterViewport * vec2(clusterDims.xy), vec2(0.0), vec2(clusterDims.xy - 1u)));
+	float z = log(max(viewDepth, clusterDepth.x) / clusterDepth.x) / log(clusterDepth.y / clusterDepth.x);
+	uint slice = uint(clamp(z * float(clusterDims.z), 0.0, float(clusterDims.z - 1u)));
+	return clusterGrid[tile.x + clusterDims.x * (tile.y + clusterDims.y * slice)];
+}
+
+uint clusterLightCount(float viewDepth)
+{
+	return clusterCell(viewDepth).y;
+}
+
+// clusterWindow 在光源半径处把衰减平滑地降到 0, 簇外的光源被剔除时不会出现明显的边界
+// 半径不大于 0 的光源照不到任何地方(Assign 也不会分配它们)
+float clusterWindow(Light light, vec3 fragPos)
+{
+	if (light.type == DIRECTIONAL_LIGHT) {
+		return 1.0;
+	}
+	if (light.radius <= 0.0) {
+		return 0.0;
+	}
+	float r = length(light.position - fragPos) / light.radius;
+	return pow(clamp(1.0 - r * r * r * r, 0.0, 1.0), 2.0);
+}
+
+vec3 clusteredLighting(vec3 fragPos, vec3 normal, vec3 viewDir, vec2 uv, float viewDepth)
+{
+	Surface s = phongSurface(uv);
+	vec3 result = s.emissive;
+
+	uvec2 cell = clusterCell(viewDepth);
+	for (uint i = 0u; i < cell.y; i++) {
+		Light light = clusterLights[clusterIndices[cell.x + i]];
+		result += clusterWindow(light, fragPos) * phongLight(light, s, fragPos, normal, viewDir);
+	}
+	return result;
+}
+`, ClusterLightBinding, ClusterIndexBinding, ClusterGridBinding)
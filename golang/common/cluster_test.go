@@ -0,0 +1,80 @@
+package common
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// clusterOf 观察空间中的点所在的簇, 与 ClusterGLSL 的计算一致
+func clusterOf(c *ClusteredLights, p mgl32.Vec3, projection mgl32.Mat4) int {
+	ndc := mgl32.TransformCoordinate(p, projection)
+	x := min(max(int((ndc[0]*0.5+0.5)*float32(c.Dims[0])), 0), c.Dims[0]-1)
+	y := min(max(int((ndc[1]*0.5+0.5)*float32(c.Dims[1])), 0), c.Dims[1]-1)
+	return c.Cluster(x, y, c.Slice(-p[2]))
+}
+
+func TestClusteredLightsAssign(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	view := mgl32.LookAtV(mgl32.Vec3{0, 2, 8}, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0})
+	projection := mgl32.Perspective(mgl32.DegToRad(60), 16.0/9, 0.1, 100)
+
+	var lights []Light
+	for i := 0; i < 64; i++ {
+		pos := mgl32.Vec3{rng.Float32()*40 - 20, rng.Float32()*10 - 5, rng.Float32()*40 - 30}
+		lights = append(lights, NewPointLight(pos, WithLightRange(1+rng.Float32()*8)))
+	}
+	lights = append(lights,
+		NewDirectionalLight(mgl32.Vec3{0, -1, 0}),
+		NewPointLight(mgl32.Vec3{0, 0, 0}, WithLightColor(mgl32.Vec3{0.001, 0.001, 0.001})), // 太暗, 半径为 0
+	)
+	dark := uint32(len(lights) - 1)
+	if r := lights[dark].Radius(); r != 0 {
+		t.Fatalf("dark light radius %v, want 0", r)
+	}
+
+	c := NewClusteredLights()
+	c.Assign(lights, view, projection)
+	if len(c.Grid) != c.ClusterCount() || len(c.Lights) != len(lights) {
+		t.Fatalf("%d cells %d lights, want %d and %d", len(c.Grid), len(c.Lights), c.ClusterCount(), len(lights))
+	}
+	if !mgl32.FloatEqualThreshold(c.Near, 0.1, 1e-4) || !mgl32.FloatEqualThreshold(c.Far, 100, 1e-1) {
+		t.Errorf("near %v far %v, want 0.1 and 100", c.Near, c.Far)
+	}
+
+	inv := projection.Mul4(view).Inv()
+	checked := 0
+	for i := 0; i < 2000; i++ {
+		// 视锥体内随机的点
+		ndc := mgl32.Vec3{rng.Float32()*2 - 1, rng.Float32()*2 - 1, rng.Float32()*2 - 1}
+		world := mgl32.TransformCoordinate(ndc, inv)
+		p := mgl32.TransformCoordinate(world, view)
+
+		cell := c.Grid[clusterOf(c, p, projection)]
+		list := c.Indices[cell[0] : cell[0]+cell[1]]
+		for k, l := range lights {
+			in := slices.Contains(list, uint32(k))
+			switch {
+			case l.Type == DirectionalLight:
+				if !in {
+					t.Fatalf("point %v: directional light missing", world)
+				}
+			case uint32(k) == dark:
+				if in {
+					t.Fatalf("point %v: light with radius 0 assigned", world)
+				}
+			case world.Sub(l.Position).Len() < l.Radius()*0.999:
+				checked++
+				if !in {
+					t.Fatalf("point %v: light %d at distance %v (radius %v) missing",
+						world, k, world.Sub(l.Position).Len(), l.Radius())
+				}
+			}
+		}
+	}
+	if checked == 0 {
+		t.Fatal("no sampled point inside any light")
+	}
+}
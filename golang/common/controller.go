@@ -0,0 +1,325 @@
+package common
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// HeightFunc 返回 (x,z) 处地面的高度
+type HeightFunc func(x, z float32) float32
+
+func FlatGround(height float32) HeightFunc {
+	return func(x, z float32) float32 { return height }
+}
+
+// Capsule 胶囊体: 线段 A-B 外扩 Radius
+type Capsule struct {
+	A      mgl32.Vec3
+	B      mgl32.Vec3
+	Radius float32
+}
+
+const (
+	EyeHeight   float32 = 1.7
+	BodyRadius  float32 = 0.3
+	BodyHeight  float32 = 1.8
+	Gravity     float32 = 9.8
+	JumpSpeed   float32 = 4.5
+	GroundSnap  float32 = 0.2 // 下坡时贴地的最大距离
+	MaxSubSteps         = 16
+)
+
+// CharacterController 第一人称角色控制器,控制 Camera 的位置
+// 角色身体为竖直的胶囊体,脚底位于眼睛下方 EyeHeight 处
+type CharacterController struct {
+	Camera *Camera
+
+	EyeHeight float32
+	Radius    float32
+	Height    float32
+	Gravity   float32
+	JumpSpeed float32
+
+	Ground   HeightFunc // 为 nil 时没有地面
+	Boxes    []AABB
+	Capsules []Capsule
+
+	Velocity mgl32.Vec3 // 只有竖直方向会保留到下一帧
+	OnGround bool
+
+	moveInput mgl32.Vec3
+	jump      bool
+}
+
+type ControllerOption func(*CharacterController)
+
+func WithGround(ground HeightFunc) ControllerOption {
+	return func(c *CharacterController) {
+		c.Ground = ground
+	}
+}
+
+func WithBoxColliders(boxes ...AABB) ControllerOption {
+	return func(c *CharacterController) {
+		c.Boxes = append(c.Boxes, boxes...)
+	}
+}
+
+func WithCapsuleColliders(capsules ...Capsule) ControllerOption {
+	return func(c *CharacterController) {
+		c.Capsules = append(c.Capsules, capsules...)
+	}
+}
+
+func WithBody(eyeHeight, radius, height float32) ControllerOption {
+	return func(c *CharacterController) {
+		c.EyeHeight = eyeHeight
+		c.Radius = radius
+		c.Height = height
+	}
+}
+
+func NewCharacterController(camera *Camera, opts ...ControllerOption) *CharacterController {
+	c := &CharacterController{
+		Camera:    camera,
+		EyeHeight: EyeHeight,
+		Radius:    BodyRadius,
+		Height:    BodyHeight,
+		Gravity:   Gravity,
+		JumpSpeed: JumpSpeed,
+		Ground:    FlatGround(0),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Feet 脚底位置
+func (c *CharacterController) Feet() mgl32.Vec3 {
+	return c.Camera.Position.Sub(mgl32.Vec3{0, c.EyeHeight, 0})
+}
+
+// ProcessKeyboard 记录本帧的移动方向,前后移动只在水平面内进行
+func (c *CharacterController) ProcessKeyboard(direction CameraMove) {
+	front := mgl32.Vec3{c.Camera.Front[0], 0, c.Camera.Front[2]}
+	if front.Len() > 0 {
+		front = front.Normalize()
+	}
+	right := mgl32.Vec3{c.Camera.Right[0], 0, c.Camera.Right[2]}
+	if right.Len() > 0 {
+		right = right.Normalize()
+	}
+
+	switch direction {
+	case ForWard:
+		c.moveInput = c.moveInput.Add(front)
+	case BackWard:
+		c.moveInput = c.moveInput.Sub(front)
+	case Left:
+		c.moveInput = c.moveInput.Sub(right)
+	case Right:
+		c.moveInput = c.moveInput.Add(right)
+	case Up:
+		c.jump = true
+	case Down:
+	default:
+		panic("unexpected camera move")
+	}
+}
+
+func (c *CharacterController) Jump() {
+	c.jump = true
+}
+
+// Update 每帧调用一次,应用重力和跳跃,并与地面和碰撞体求解
+func (c *CharacterController) Update(deltaTime float32) {
+	var horizontal mgl32.Vec3
+	if c.moveInput.Len() > 0 {
+		horizontal = c.moveInput.Normalize().Mul(c.Camera.speed())
+	}
+	c.moveInput = mgl32.Vec3{}
+
+	if c.jump && c.OnGround {
+		c.Velocity[1] = c.JumpSpeed
+	}
+	c.jump = false
+
+	wasOnGround := c.OnGround
+	c.OnGround = false
+	c.Velocity[1] -= c.Gravity * deltaTime
+	c.Velocity[0], c.Velocity[2] = horizontal[0], horizontal[2]
+
+	// 单步移动距离不超过半径的一半,避免穿过较薄的碰撞体
+	move := c.Velocity.Mul(deltaTime)
+	steps := int(math.Ceil(float64(move.Len() / (c.Radius * 0.5))))
+	steps = max(1, min(steps, MaxSubSteps))
+	step := move.Mul(1 / float32(steps))
+
+	feet := c.Feet()
+	for i := 0; i < steps; i++ {
+		feet = c.resolve(feet.Add(step))
+	}
+
+	// 走下坡时贴住地面,而不是每帧都进入下落状态
+	if c.Ground != nil && wasOnGround && !c.OnGround && c.Velocity[1] <= 0 {
+		h := c.Ground(feet[0], feet[2])
+		if feet[1]-h <= GroundSnap {
+			feet[1] = h
+			c.Velocity[1] = 0
+			c.OnGround = true
+		}
+	}
+
+	c.Camera.Position = feet.Add(mgl32.Vec3{0, c.EyeHeight, 0})
+}
+
+// body 身体胶囊体的中轴线段
+func (c *CharacterController) body(feet mgl32.Vec3) (mgl32.Vec3, mgl32.Vec3) {
+	a := feet.Add(mgl32.Vec3{0, c.Radius, 0})
+	b := feet.Add(mgl32.Vec3{0, max(c.Height-c.Radius, c.Radius), 0})
+	return a, b
+}
+
+func (c *CharacterController) resolve(feet mgl32.Vec3) mgl32.Vec3 {
+	for _, box := range c.Boxes {
+		a, b := c.body(feet)
+		p, q := closestSegmentAABB(a, b, box)
+		if n, depth, ok := separate(p, q, c.Radius, box); ok {
+			feet = c.slide(feet, n, depth)
+		}
+	}
+
+	for _, capsule := range c.Capsules {
+		a, b := c.body(feet)
+		p, q := closestSegmentSegment(a, b, capsule.A, capsule.B)
+		d := p.Sub(q)
+		dist := d.Len()
+		r := c.Radius + capsule.Radius
+		if dist >= r {
+			continue
+		}
+
+		n := mgl32.Vec3{0, 1, 0}
+		if dist > rayEpsilon {
+			n = d.Mul(1 / dist)
+		}
+		feet = c.slide(feet, n, r-dist)
+	}
+
+	if c.Ground != nil {
+		if h := c.Ground(feet[0], feet[2]); feet[1] <= h {
+			feet[1] = h
+			c.Velocity[1] = max(c.Velocity[1], 0)
+			c.OnGround = true
+		}
+	}
+
+	return feet
+}
+
+// slide 沿法线推出碰撞体,并去掉速度中朝向碰撞体的分量,使角色沿表面滑动
+func (c *CharacterController) slide(feet, n mgl32.Vec3, depth float32) mgl32.Vec3 {
+	feet = feet.Add(n.Mul(depth))
+
+	if vn := c.Velocity.Dot(n); vn < 0 {
+		c.Velocity = c.Velocity.Sub(n.Mul(vn))
+	}
+	if n[1] > 0.7 { // 站在足够平的表面上
+		c.Velocity[1] = max(c.Velocity[1], 0)
+		c.OnGround = true
+	}
+
+	return feet
+}
+
+// separate 根据线段最近点 p 和包围盒最近点 q 求推出方向和深度
+func separate(p, q mgl32.Vec3, radius float32, box AABB) (mgl32.Vec3, float32, bool) {
+	d := p.Sub(q)
+	dist := d.Len()
+	if dist >= radius {
+		return mgl32.Vec3{}, 0, false
+	}
+	if dist > rayEpsilon {
+		return d.Mul(1 / dist), radius - dist, true
+	}
+
+	// 线段已经进入包围盒内部,沿穿透最浅的轴推出
+	var (
+		n     mgl32.Vec3
+		depth = float32(math.Inf(1))
+	)
+	for i := 0; i < 3; i++ {
+		if v := p[i] - box.Min[i]; v < depth {
+			depth, n = v, mgl32.Vec3{}
+			n[i] = -1
+		}
+		if v := box.Max[i] - p[i]; v < depth {
+			depth, n = v, mgl32.Vec3{}
+			n[i] = 1
+		}
+	}
+	return n, depth + radius, true
+}
+
+func closestPointSegment(p, a, b mgl32.Vec3) mgl32.Vec3 {
+	ab := b.Sub(a)
+	l := ab.Dot(ab)
+	if l < rayEpsilon {
+		return a
+	}
+	t := mgl32.Clamp(p.Sub(a).Dot(ab)/l, 0, 1)
+	return a.Add(ab.Mul(t))
+}
+
+// closestSegmentAABB 交替投影求线段与包围盒之间的最近点对,两者都是凸集所以会收敛
+func closestSegmentAABB(a, b mgl32.Vec3, box AABB) (mgl32.Vec3, mgl32.Vec3) {
+	p := closestPointSegment(box.Center(), a, b)
+	q := box.ClosestPoint(p)
+	for i := 0; i < 4; i++ {
+		p = closestPointSegment(q, a, b)
+		q = box.ClosestPoint(p)
+	}
+	return p, q
+}
+
+// closestSegmentSegment 两条线段之间的最近点对
+func closestSegmentSegment(p1, q1, p2, q2 mgl32.Vec3) (mgl32.Vec3, mgl32.Vec3) {
+	d1 := q1.Sub(p1)
+	d2 := q2.Sub(p2)
+	r := p1.Sub(p2)
+	a := d1.Dot(d1)
+	e := d2.Dot(d2)
+	f := d2.Dot(r)
+
+	var s, t float32
+	switch {
+	case a <= rayEpsilon && e <= rayEpsilon:
+		return p1, p2
+	case a <= rayEpsilon:
+		t = mgl32.Clamp(f/e, 0, 1)
+	default:
+		c := d1.Dot(r)
+		if e <= rayEpsilon {
+			s = mgl32.Clamp(-c/a, 0, 1)
+		} else {
+			b := d1.Dot(d2)
+			if denom := a*e - b*b; denom != 0 {
+				s = mgl32.Clamp((b*f-c*e)/denom, 0, 1)
+			}
+			t = (b*s + f) / e
+			if t < 0 {
+				t = 0
+				s = mgl32.Clamp(-c/a, 0, 1)
+			} else if t > 1 {
+				t = 1
+				s = mgl32.Clamp((b-c)/a, 0, 1)
+			}
+		}
+	}
+
+	return p1.Add(d1.Mul(s)), p2.Add(d2.Mul(t))
+}
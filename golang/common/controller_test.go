@@ -0,0 +1,189 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const controllerDt float32 = 1.0 / 60
+
+// simulateController 模拟 duration 秒, 每帧都按住 moves 中的方向
+func simulateController(c *CharacterController, duration float32, moves ...CameraMove) {
+	for i := 0; i < int(duration/controllerDt+0.5); i++ {
+		for _, m := range moves {
+			c.ProcessKeyboard(m)
+		}
+		c.Update(controllerDt)
+	}
+}
+
+// newTestController 默认相机朝向 -z, 右方为 +x
+func newTestController(feet mgl32.Vec3, opts ...ControllerOption) *CharacterController {
+	camera := NewCamera(WithPosition(feet.Add(mgl32.Vec3{0, EyeHeight, 0})))
+	return NewCharacterController(camera, opts...)
+}
+
+func TestControllerGravity(t *testing.T) {
+	c := newTestController(mgl32.Vec3{0, 10, 0})
+
+	simulateController(c, 0.5)
+	if c.OnGround {
+		t.Fatal("on ground while falling")
+	}
+	// 自由落体 0.5 秒下落约 g*t²/2
+	if got, want := c.Feet()[1], 10-Gravity*0.25/2; !mgl32.FloatEqualThreshold(got, want, 0.1) {
+		t.Errorf("feet height %v, want about %v", got, want)
+	}
+
+	simulateController(c, 2)
+	if !c.OnGround || c.Feet()[1] != 0 || c.Velocity[1] != 0 {
+		t.Errorf("feet %v, velocity %v, on ground %v after landing", c.Feet(), c.Velocity, c.OnGround)
+	}
+	if got := c.Camera.Position[1]; got != EyeHeight {
+		t.Errorf("eye height %v, want %v", got, EyeHeight)
+	}
+}
+
+func TestControllerJump(t *testing.T) {
+	c := newTestController(mgl32.Vec3{})
+	simulateController(c, 0.1)
+
+	c.Jump()
+	var apex float32
+	for i := 0; i < 120; i++ {
+		c.Update(controllerDt)
+		apex = max(apex, c.Feet()[1])
+	}
+
+	if want := JumpSpeed * JumpSpeed / (2 * Gravity); !mgl32.FloatEqualThreshold(apex, want, 0.05) {
+		t.Errorf("apex %v, want about %v", apex, want)
+	}
+	if !c.OnGround || c.Feet()[1] != 0 {
+		t.Errorf("feet %v, on ground %v after jump", c.Feet(), c.OnGround)
+	}
+
+	// 空中不能再次起跳
+	c.Jump()
+	c.Update(controllerDt)
+	c.Jump()
+	c.Update(controllerDt)
+	if c.Velocity[1] >= JumpSpeed-Gravity*controllerDt {
+		t.Errorf("double jump: velocity %v", c.Velocity)
+	}
+}
+
+func TestControllerBoxCollision(t *testing.T) {
+	wall := AABB{Min: mgl32.Vec3{-50, 0, -3}, Max: mgl32.Vec3{50, 3, -2}}
+	tests := []struct {
+		name  string
+		moves []CameraMove
+		slide bool // 是否应当沿墙面横向滑动
+	}{
+		{"head on", []CameraMove{ForWard}, false},
+		{"diagonal", []CameraMove{ForWard, Right}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestController(mgl32.Vec3{}, WithBoxColliders(wall))
+			simulateController(c, 3, tt.moves...)
+
+			feet := c.Feet()
+			if limit := wall.Max[2] + BodyRadius; feet[2] < limit-1e-3 {
+				t.Errorf("feet %v penetrated the wall, z should be >= %v", feet, limit)
+			}
+			if !mgl32.FloatEqualThreshold(feet[2], wall.Max[2]+BodyRadius, 0.05) {
+				t.Errorf("feet %v did not reach the wall", feet)
+			}
+			if moved := feet[0] > 1; moved != tt.slide {
+				t.Errorf("feet %v: slide along wall = %v, want %v", feet, moved, tt.slide)
+			}
+			if !c.OnGround {
+				t.Error("left the ground while walking into a wall")
+			}
+		})
+	}
+}
+
+func TestControllerThinBox(t *testing.T) {
+	// 快速移动时分步求解, 不会穿过很薄的墙
+	wall := AABB{Min: mgl32.Vec3{-5, 0, -2.05}, Max: mgl32.Vec3{5, 3, -2}}
+	c := newTestController(mgl32.Vec3{}, WithBoxColliders(wall))
+	c.Camera.MovementSpeed = 100
+	simulateController(c, 1, ForWard)
+
+	if feet := c.Feet(); feet[2] < wall.Max[2] {
+		t.Errorf("feet %v tunnelled through the wall", feet)
+	}
+}
+
+func TestControllerStandOnBox(t *testing.T) {
+	box := AABB{Min: mgl32.Vec3{-1, 0, -1}, Max: mgl32.Vec3{1, 1, 1}}
+	c := newTestController(mgl32.Vec3{0, 3, 0}, WithBoxColliders(box))
+	simulateController(c, 2)
+
+	if feet := c.Feet(); !c.OnGround || !mgl32.FloatEqualThreshold(feet[1], box.Max[1], 1e-3) {
+		t.Errorf("feet %v, on ground %v, want standing on top of the box", feet, c.OnGround)
+	}
+
+	// 走出边缘后落回地面
+	simulateController(c, 2, Right)
+	if feet := c.Feet(); !c.OnGround || feet[1] != 0 || feet[0] < box.Max[0] {
+		t.Errorf("feet %v, on ground %v, want back on the ground", feet, c.OnGround)
+	}
+}
+
+func TestControllerCapsuleCollision(t *testing.T) {
+	pole := Capsule{A: mgl32.Vec3{0, 0, -2}, B: mgl32.Vec3{0, 3, -2}, Radius: 0.5}
+	c := newTestController(mgl32.Vec3{}, WithCapsuleColliders(pole))
+	simulateController(c, 3, ForWard)
+
+	feet := c.Feet()
+	horizontal := mgl32.Vec2{feet[0] - pole.A[0], feet[2] - pole.A[2]}.Len()
+	if limit := pole.Radius + BodyRadius; horizontal < limit-1e-3 {
+		t.Errorf("feet %v is %v from the pole axis, want >= %v", feet, horizontal, limit)
+	}
+}
+
+func TestControllerHeightfield(t *testing.T) {
+	tests := []struct {
+		name   string
+		ground HeightFunc
+		moves  []CameraMove
+	}{
+		// x > 1 处有一个 0.3 高的台阶, 直接走上去
+		{"step up", func(x, z float32) float32 {
+			if x > 1 {
+				return 0.3
+			}
+			return 0
+		}, []CameraMove{Right}},
+		// 沿 -z 方向下坡, 坡度小于 GroundSnap, 应当一直贴着地面
+		{"downhill", func(x, z float32) float32 { return z * 0.5 }, []CameraMove{ForWard}},
+		{"uphill", func(x, z float32) float32 { return -z * 0.5 }, []CameraMove{ForWard}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestController(mgl32.Vec3{0, tt.ground(0, 0), 0}, WithGround(tt.ground))
+			simulateController(c, 0.1)
+
+			for i := 0; i < 120; i++ {
+				for _, m := range tt.moves {
+					c.ProcessKeyboard(m)
+				}
+				c.Update(controllerDt)
+
+				feet := c.Feet()
+				if h := tt.ground(feet[0], feet[2]); !c.OnGround || !mgl32.FloatEqualThreshold(feet[1], h, 1e-4) {
+					t.Fatalf("frame %d: feet %v, ground %v, on ground %v", i, feet, h, c.OnGround)
+				}
+			}
+
+			if feet := c.Feet(); (mgl32.Vec2{feet[0], feet[2]}).Len() < 1.5 {
+				t.Errorf("feet %v did not move", feet)
+			}
+		})
+	}
+}
@@ -0,0 +1,418 @@
+package common
+
+import (
+	"slices"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type Topology int
+
+const (
+	Triangles Topology = 0
+	Lines     Topology = 1
+	Points    Topology = 2
+)
+
+func (t Topology) GLMode() uint32 {
+	switch t {
+	case Triangles:
+		return gl.TRIANGLES
+	case Lines:
+		return gl.LINES
+	case Points:
+		return gl.POINTS
+	default:
+		panic("unexpected topology")
+	}
+}
+
+// MeshData CPU 端的网格数据,除 Positions 外其余属性可以为空,非空时长度与 Positions 一致
+// Indices 为空时按顺序每 3 个(线段为 2 个)顶点组成一个图元
+type MeshData struct {
+	Topology  Topology
+	Positions []mgl32.Vec3
+	Normals   []mgl32.Vec3
+	UVs       []mgl32.Vec2
+	Tangents  []mgl32.Vec4 // w 为副切线的方向 ±1
+	Colors    []mgl32.Vec4
+	Joints    [][4]uint16 // 蒙皮的 4 个骨骼下标
+	Weights   []mgl32.Vec4
+	Targets   []MorphTarget // 变形目标
+	Indices   []uint32
+}
+
+// Vertex 通用的交错顶点格式,与 learnopengl 光照章节的 location 一致
+type Vertex struct {
+	Position mgl32.Vec3 `gl:"0"`
+	Normal   mgl32.Vec3 `gl:"1"`
+	UV       mgl32.Vec2 `gl:"2"`
+	Tangent  mgl32.Vec4 `gl:"3"`
+	Color    mgl32.Vec4 `gl:"4"`
+}
+
+// SkinnedVertex 带蒙皮的顶点, location 5~10 留给 Instance
+type SkinnedVertex struct {
+	Vertex
+	Joints  [4]uint16  `gl:"11,int"`
+	Weights mgl32.Vec4 `gl:"12"`
+}
+
+func (d *MeshData) VertexCount() int {
+	return len(d.Positions)
+}
+
+// TriangleCount 三角形个数,非三角形网格返回 0
+func (d *MeshData) TriangleCount() int {
+	if d.Topology != Triangles {
+		return 0
+	}
+	if len(d.Indices) > 0 {
+		return len(d.Indices) / 3
+	}
+	return len(d.Positions) / 3
+}
+
+// Triangle 返回第 i 个三角形的三个顶点索引
+func (d *MeshData) Triangle(i int) (uint32, uint32, uint32) {
+	if len(d.Indices) > 0 {
+		return d.Indices[3*i], d.Indices[3*i+1], d.Indices[3*i+2]
+	}
+	return uint32(3 * i), uint32(3*i + 1), uint32(3*i + 2)
+}
+
+// Clone 深拷贝, 修改副本不会影响原数据
+func (d *MeshData) Clone() *MeshData {
+	out := *d
+	out.Positions = slices.Clone(d.Positions)
+	out.Normals = slices.Clone(d.Normals)
+	out.UVs = slices.Clone(d.UVs)
+	out.Tangents = slices.Clone(d.Tangents)
+	out.Colors = slices.Clone(d.Colors)
+	out.Joints = slices.Clone(d.Joints)
+	out.Weights = slices.Clone(d.Weights)
+	out.Indices = slices.Clone(d.Indices)
+	out.Targets = slices.Clone(d.Targets)
+	for i, t := range out.Targets {
+		out.Targets[i].Positions = slices.Clone(t.Positions)
+		out.Targets[i].Normals = slices.Clone(t.Normals)
+		out.Targets[i].Tangents = slices.Clone(t.Tangents)
+	}
+	return &out
+}
+
+func (d *MeshData) Vertices() []Vertex {
+	vertices := make([]Vertex, len(d.Positions))
+	for i, p := range d.Positions {
+		vertices[i].Position = p
+		if i < len(d.Normals) {
+			vertices[i].Normal = d.Normals[i]
+		}
+		if i < len(d.UVs) {
+			vertices[i].UV = d.UVs[i]
+		}
+		if i < len(d.Tangents) {
+			vertices[i].Tangent = d.Tangents[i]
+		}
+		// 没有顶点颜色时默认为白色
+		vertices[i].Color = mgl32.Vec4{1, 1, 1, 1}
+		if i < len(d.Colors) {
+			vertices[i].Color = d.Colors[i]
+		}
+	}
+	return vertices
+}
+
+func (d *MeshData) SkinnedVertices() []SkinnedVertex {
+	vertices := make([]SkinnedVertex, len(d.Positions))
+	for i, v := range d.Vertices() {
+		vertices[i].Vertex = v
+		if i < len(d.Joints) {
+			vertices[i].Joints = d.Joints[i]
+		}
+		if i < len(d.Weights) {
+			vertices[i].Weights = d.Weights[i]
+		}
+	}
+	return vertices
+}
+
+// Upload 上传到 GPU, 有蒙皮数据时使用 SkinnedVertex 格式, 否则使用 Vertex
+func (d *MeshData) Upload() (*Mesh, error) {
+	var (
+		m   *Mesh
+		err error
+	)
+	if len(d.Joints) > 0 {
+		m, err = NewMeshOf(d.SkinnedVertices(), d.Indices)
+	} else {
+		m, err = NewMeshOf(d.Vertices(), d.Indices)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m.Mode = d.Topology.GLMode()
+	return m, nil
+}
+
+// Append 合并另一个网格,两者的拓扑需要一致
+func (d *MeshData) Append(o *MeshData) {
+	base := uint32(len(d.Positions))
+	if len(d.Indices) == 0 && len(o.Indices) > 0 {
+		d.Indices = sequence(base)
+	}
+
+	d.Normals = appendAttrib(d.Normals, len(d.Positions), o.Normals, len(o.Positions))
+	d.UVs = appendAttrib(d.UVs, len(d.Positions), o.UVs, len(o.Positions))
+	d.Tangents = appendAttrib(d.Tangents, len(d.Positions), o.Tangents, len(o.Positions))
+	d.Colors = appendAttrib(d.Colors, len(d.Positions), o.Colors, len(o.Positions))
+	d.Joints = appendAttrib(d.Joints, len(d.Positions), o.Joints, len(o.Positions))
+	d.Weights = appendAttrib(d.Weights, len(d.Positions), o.Weights, len(o.Positions))
+	d.Targets = appendTargets(d.Targets, len(d.Positions), o.Targets, len(o.Positions))
+	d.Positions = append(d.Positions, o.Positions...)
+
+	switch {
+	case len(o.Indices) > 0:
+		for _, i := range o.Indices {
+			d.Indices = append(d.Indices, base+i)
+		}
+	case len(d.Indices) > 0:
+		for i := range o.Positions {
+			d.Indices = append(d.Indices, base+uint32(i))
+		}
+	}
+}
+
+// appendAttrib 合并可选属性,一方缺失时用零值补齐
+func appendAttrib[T any](dst []T, dstLen int, src []T, srcLen int) []T {
+	if len(dst) == 0 && len(src) == 0 {
+		return dst
+	}
+	if len(dst) < dstLen {
+		dst = append(dst, make([]T, dstLen-len(dst))...)
+	}
+	if len(src) < srcLen {
+		return append(dst, make([]T, srcLen)...)
+	}
+	return append(dst, src...)
+}
+
+// appendTargets 按下标合并变形目标, 一方缺少的目标没有偏移
+func appendTargets(dst []MorphTarget, dstLen int, src []MorphTarget, srcLen int) []MorphTarget {
+	for len(dst) < len(src) {
+		dst = append(dst, MorphTarget{Name: src[len(dst)].Name})
+	}
+
+	for i := range dst {
+		var o MorphTarget
+		if i < len(src) {
+			o = src[i]
+		}
+		dst[i].Positions = appendAttrib(dst[i].Positions, dstLen, o.Positions, srcLen)
+		dst[i].Normals = appendAttrib(dst[i].Normals, dstLen, o.Normals, srcLen)
+		dst[i].Tangents = appendAttrib(dst[i].Tangents, dstLen, o.Tangents, srcLen)
+	}
+	return dst
+}
+
+func sequence(n uint32) []uint32 {
+	s := make([]uint32, n)
+	for i := range s {
+		s[i] = uint32(i)
+	}
+	return s
+}
+
+// Transform 对顶点做变换,法线和切线使用法线矩阵
+func (d *MeshData) Transform(m mgl32.Mat4) {
+	normal := m.Mat3().Inv().Transpose()
+	for i, p := range d.Positions {
+		d.Positions[i] = mgl32.TransformCoordinate(p, m)
+	}
+	for i, n := range d.Normals {
+		d.Normals[i] = normal.Mul3x1(n).Normalize()
+	}
+	for i, t := range d.Tangents {
+		v := m.Mat3().Mul3x1(t.Vec3()).Normalize()
+		d.Tangents[i] = v.Vec4(t[3])
+	}
+
+	// 变形目标的偏移是向量, 不受平移影响
+	linear := m.Mat3()
+	for _, t := range d.Targets {
+		for i, v := range t.Positions {
+			t.Positions[i] = linear.Mul3x1(v)
+		}
+		for i, v := range t.Normals {
+			t.Normals[i] = normal.Mul3x1(v)
+		}
+		for i, v := range t.Tangents {
+			t.Tangents[i] = linear.Mul3x1(v)
+		}
+	}
+}
+
+// computeTangents 按三角形累加切线和副切线,正交化后得到每个顶点的切线
+func (d *MeshData) computeTangents() {
+	if d.Topology != Triangles || len(d.Normals) != len(d.Positions) || len(d.UVs) != len(d.Positions) {
+		return
+	}
+
+	tan := make([]mgl32.Vec3, len(d.Positions))
+	bitan := make([]mgl32.Vec3, len(d.Positions))
+	for i := 0; i < d.TriangleCount(); i++ {
+		a, b, c := d.Triangle(i)
+		t, bt, ok := triangleTangent(
+			d.Positions[a], d.Positions[b], d.Positions[c],
+			d.UVs[a], d.UVs[b], d.UVs[c],
+		)
+		if !ok {
+			continue
+		}
+		for _, v := range [3]uint32{a, b, c} {
+			tan[v] = tan[v].Add(t)
+			bitan[v] = bitan[v].Add(bt)
+		}
+	}
+
+	d.Tangents = make([]mgl32.Vec4, len(d.Positions))
+	for i, n := range d.Normals {
+		d.Tangents[i] = orthogonalTangent(n, tan[i], bitan[i])
+	}
+}
+
+// triangleTangent 求解 dP = dU*T + dV*B
+func triangleTangent(p0, p1, p2 mgl32.Vec3, uv0, uv1, uv2 mgl32.Vec2) (mgl32.Vec3, mgl32.Vec3, bool) {
+	e1 := p1.Sub(p0)
+	e2 := p2.Sub(p0)
+	d1 := uv1.Sub(uv0)
+	d2 := uv2.Sub(uv0)
+
+	det := d1[0]*d2[1] - d2[0]*d1[1]
+	if det > -1e-12 && det < 1e-12 {
+		return mgl32.Vec3{}, mgl32.Vec3{}, false
+	}
+
+	r := 1 / det
+	t := e1.Mul(d2[1]).Sub(e2.Mul(d1[1])).Mul(r)
+	b := e2.Mul(d1[0]).Sub(e1.Mul(d2[0])).Mul(r)
+	return t, b, true
+}
+
+// orthogonalTangent Gram-Schmidt 正交化,w 记录副切线是否与 N×T 同向
+func orthogonalTangent(n, t, b mgl32.Vec3) mgl32.Vec4 {
+	t = t.Sub(n.Mul(n.Dot(t)))
+	if t.Len() < 1e-6 {
+		// UV 退化时任意取一个与法线垂直的方向
+		t = mgl32.Vec3{1, 0, 0}
+		if abs(n[0]) > 0.9 {
+			t = mgl32.Vec3{0, 1, 0}
+		}
+		t = t.Sub(n.Mul(n.Dot(t)))
+	}
+	t = t.Normalize()
+
+	w := float32(1)
+	if n.Cross(t).Dot(b) < 0 {
+		w = -1
+	}
+	return t.Vec4(w)
+}
+
+func abs(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// Triangulate 耳切法三角化一个平面多边形(可以是凹多边形),返回多边形顶点的下标,保持原来的环绕方向
+// 多边形退化或自相交时退化为扇形三角化
+func Triangulate(polygon []mgl32.Vec3) []int {
+	n := len(polygon)
+	if n < 3 {
+		return nil
+	}
+	if n == 3 {
+		return []int{0, 1, 2}
+	}
+
+	// Newell 法求多边形法线,投影到法线分量最大的轴所垂直的平面
+	var normal mgl32.Vec3
+	for i, p := range polygon {
+		q := polygon[(i+1)%n]
+		normal[0] += (p[1] - q[1]) * (p[2] + q[2])
+		normal[1] += (p[2] - q[2]) * (p[0] + q[0])
+		normal[2] += (p[0] - q[0]) * (p[1] + q[1])
+	}
+	ax, ay, axis := 0, 1, 2
+	if abs(normal[0]) > abs(normal[axis]) {
+		axis = 0
+	}
+	if abs(normal[1]) > abs(normal[axis]) {
+		axis = 1
+	}
+	switch axis {
+	case 0:
+		ax, ay = 1, 2
+	case 1:
+		ax, ay = 2, 0
+	}
+	sign := float32(1)
+	if normal[axis] < 0 {
+		sign = -1
+	}
+
+	pt := func(i int) mgl32.Vec2 { return mgl32.Vec2{polygon[i][ax], polygon[i][ay]} }
+	cross := func(a, b, c mgl32.Vec2) float32 {
+		return ((b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])) * sign
+	}
+
+	remain := make([]int, n)
+	for i := range remain {
+		remain[i] = i
+	}
+
+	out := make([]int, 0, 3*(n-2))
+	for len(remain) > 3 {
+		found := false
+		for i := range remain {
+			m := len(remain)
+			i0, i1, i2 := remain[(i+m-1)%m], remain[i], remain[(i+1)%m]
+			a, b, c := pt(i0), pt(i1), pt(i2)
+			if cross(a, b, c) <= 0 {
+				continue // 凹顶点
+			}
+
+			ear := true
+			for _, j := range remain {
+				if j == i0 || j == i1 || j == i2 {
+					continue
+				}
+				p := pt(j)
+				if cross(a, b, p) >= 0 && cross(b, c, p) >= 0 && cross(c, a, p) >= 0 {
+					ear = false
+					break
+				}
+			}
+			if ear {
+				out = append(out, i0, i1, i2)
+				remain = append(remain[:i], remain[i+1:]...)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			// 找不到耳朵,剩余部分按扇形处理
+			for i := 1; i+1 < len(remain); i++ {
+				out = append(out, remain[0], remain[i], remain[i+1])
+			}
+			return out
+		}
+	}
+
+	return append(out, remain[0], remain[1], remain[2])
+}
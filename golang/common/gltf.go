@@ -0,0 +1,1312 @@
+package common
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io/fs"
+	"math"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// TextureRef 材质对纹理的引用, Index 为 GLTFModel.Textures 的下标
+type TextureRef struct {
+	Index    int
+	TexCoord int
+	Scale    float32 // normalTexture 的 scale 或 occlusionTexture 的 strength
+}
+
+// PBRMaterial glTF 的金属度-粗糙度材质, 纹理引用为 nil 表示没有该纹理
+type PBRMaterial struct {
+	Name string
+
+	BaseColorFactor          mgl32.Vec4
+	BaseColorTexture         *TextureRef
+	MetallicFactor           float32
+	RoughnessFactor          float32
+	MetallicRoughnessTexture *TextureRef
+	NormalTexture            *TextureRef
+	OcclusionTexture         *TextureRef
+	EmissiveFactor           mgl32.Vec3
+	EmissiveTexture          *TextureRef
+
+	AlphaMode   string // OPAQUE, MASK, BLEND
+	AlphaCutoff float32
+	DoubleSided bool
+}
+
+// GLTFTexture 已解码的图片和采样参数, 可通过 NewTexture 上传
+type GLTFTexture struct {
+	Name    string
+	Image   *ImageData
+	Sampler Sampler
+}
+
+type GLTFPrimitive struct {
+	Mesh     *MeshData
+	Material int // -1 表示使用默认材质
+}
+
+type GLTFMesh struct {
+	Name       string
+	Primitives []GLTFPrimitive
+	Weights    []float32 // 变形目标的默认权重
+}
+
+type GLTFNode struct {
+	Name        string
+	Children    []int
+	Mesh        int       // -1 表示没有网格
+	Skin        int       // -1 表示没有蒙皮
+	Weights     []float32 // 覆盖网格的默认变形权重, 为空时使用网格的
+	Translation mgl32.Vec3
+	Rotation    mgl32.Quat
+	Scale       mgl32.Vec3
+}
+
+type GLTFScene struct {
+	Name  string
+	Nodes []int
+}
+
+type GLTFModel struct {
+	Meshes     []GLTFMesh
+	Materials  []PBRMaterial
+	Textures   []GLTFTexture
+	Nodes      []GLTFNode
+	Scenes     []GLTFScene
+	Scene      int // 默认场景
+	Skins      []Skin
+	Animations []*AnimationClip // 通道的 Node 为 Nodes 的下标, 与 Skeleton() 一致
+}
+
+// Skeleton 以全部节点生成动画使用的骨架, 节点的 TRS 作为初始姿势
+func (m *GLTFModel) Skeleton() (*Skeleton, error) {
+	var (
+		n       = len(m.Nodes)
+		names   = make([]string, n)
+		parents = make([]int, n)
+		rest    = make([]Transform, n)
+		weights = make([][]float32, n)
+	)
+	for i := range parents {
+		parents[i] = -1
+	}
+
+	for i, node := range m.Nodes {
+		names[i] = node.Name
+		rest[i] = Transform{Translation: node.Translation, Rotation: node.Rotation, Scale: node.Scale}
+		weights[i] = m.nodeWeights(i)
+		for _, c := range node.Children {
+			if parents[c] >= 0 {
+				return nil, fmt.Errorf("gltf: node %d has multiple parents", c)
+			}
+			parents[c] = i
+		}
+	}
+
+	s, err := NewSkeleton(names, parents, rest)
+	if err != nil {
+		return nil, err
+	}
+	s.Weights = weights
+	return s, nil
+}
+
+// nodeWeights 节点的初始变形权重, 长度为网格的变形目标数, 没有变形目标时为 nil
+func (m *GLTFModel) nodeWeights(i int) []float32 {
+	node := m.Nodes[i]
+	if node.Mesh < 0 {
+		return nil
+	}
+
+	mesh := m.Meshes[node.Mesh]
+	n := 0
+	for _, p := range mesh.Primitives {
+		n = max(n, len(p.Mesh.Targets))
+	}
+	if n == 0 {
+		return nil
+	}
+
+	w := make([]float32, n)
+	if len(node.Weights) > 0 {
+		copy(w, node.Weights)
+	} else {
+		copy(w, mesh.Weights)
+	}
+	return w
+}
+
+// LoadGLTF 读取 .gltf 或 .glb 文件, 外部资源相对文件所在目录解析
+func LoadGLTF(name string) (*GLTFModel, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	model, err := ParseGLTF(data, os.DirFS(filepath.Dir(name)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return model, nil
+}
+
+// ParseGLTF 解析 json 或 glb 格式, fsys 用于读取外部 buffer 和图片, 为 nil 时只支持内嵌数据
+func ParseGLTF(data []byte, fsys fs.FS) (*GLTFModel, error) {
+	var (
+		doc gltfDocument
+		bin []byte
+		err error
+	)
+
+	if bytes.HasPrefix(data, []byte("glTF")) {
+		data, bin, err = parseGLB(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = json.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(doc.Asset.Version, "2.") {
+		return nil, fmt.Errorf("gltf: unsupported version %q", doc.Asset.Version)
+	}
+
+	l := &gltfLoader{doc: &doc, bin: bin, fsys: fsys}
+	return l.load()
+}
+
+const (
+	glbMagic     = 0x46546C67 // "glTF"
+	glbChunkJSON = 0x4E4F534A // "JSON"
+	glbChunkBIN  = 0x004E4942 // "BIN\0"
+)
+
+// parseGLB 拆分二进制容器, 返回 json 块和可选的 BIN 块
+func parseGLB(data []byte) ([]byte, []byte, error) {
+	if len(data) < 12 {
+		return nil, nil, errors.New("glb: truncated header")
+	}
+
+	le := binary.LittleEndian
+	if le.Uint32(data) != glbMagic {
+		return nil, nil, errors.New("glb: bad magic")
+	}
+	if v := le.Uint32(data[4:]); v != 2 {
+		return nil, nil, fmt.Errorf("glb: unsupported version %d", v)
+	}
+	if n := le.Uint32(data[8:]); int(n) < len(data) {
+		data = data[:n]
+	}
+
+	var js, bin []byte
+	for off := 12; off+8 <= len(data); {
+		size := int(le.Uint32(data[off:]))
+		typ := le.Uint32(data[off+4:])
+		off += 8
+		if size < 0 || off+size > len(data) {
+			return nil, nil, errors.New("glb: truncated chunk")
+		}
+
+		chunk := data[off : off+size]
+		switch {
+		case typ == glbChunkJSON && js == nil:
+			js = chunk
+		case typ == glbChunkBIN && bin == nil:
+			bin = chunk
+		}
+		off += size
+	}
+
+	if js == nil {
+		return nil, nil, errors.New("glb: missing JSON chunk")
+	}
+	return js, bin, nil
+}
+
+type gltfDocument struct {
+	Asset struct {
+		Version string `json:"version"`
+	} `json:"asset"`
+	Scene       *int               `json:"scene"`
+	Scenes      []gltfSceneJSON    `json:"scenes"`
+	Nodes       []gltfNodeJSON     `json:"nodes"`
+	Meshes      []gltfMeshJSON     `json:"meshes"`
+	Accessors   []gltfAccessor     `json:"accessors"`
+	BufferViews []gltfBufferView   `json:"bufferViews"`
+	Buffers     []gltfBuffer       `json:"buffers"`
+	Materials   []gltfMaterialJSON `json:"materials"`
+	Textures    []gltfTextureJSON  `json:"textures"`
+	Images      []gltfImage        `json:"images"`
+	Samplers    []gltfSamplerJSON  `json:"samplers"`
+	Skins       []gltfSkinJSON     `json:"skins"`
+	Animations  []gltfAnimation    `json:"animations"`
+}
+
+type gltfSkinJSON struct {
+	Name                string `json:"name"`
+	InverseBindMatrices *int   `json:"inverseBindMatrices"`
+	Joints              []int  `json:"joints"`
+}
+
+type gltfAnimation struct {
+	Name     string `json:"name"`
+	Channels []struct {
+		Sampler int `json:"sampler"`
+		Target  struct {
+			Node *int   `json:"node"`
+			Path string `json:"path"`
+		} `json:"target"`
+	} `json:"channels"`
+	Samplers []struct {
+		Input         int    `json:"input"`
+		Output        int    `json:"output"`
+		Interpolation string `json:"interpolation"`
+	} `json:"samplers"`
+}
+
+type gltfSceneJSON struct {
+	Name  string `json:"name"`
+	Nodes []int  `json:"nodes"`
+}
+
+type gltfNodeJSON struct {
+	Name        string       `json:"name"`
+	Children    []int        `json:"children"`
+	Mesh        *int         `json:"mesh"`
+	Skin        *int         `json:"skin"`
+	Weights     []float32    `json:"weights"`
+	Matrix      *[16]float32 `json:"matrix"`
+	Translation *[3]float32  `json:"translation"`
+	Rotation    *[4]float32  `json:"rotation"` // x,y,z,w
+	Scale       *[3]float32  `json:"scale"`
+}
+
+type gltfMeshJSON struct {
+	Name       string              `json:"name"`
+	Primitives []gltfPrimitiveJSON `json:"primitives"`
+	Weights    []float32           `json:"weights"`
+	Extras     struct {
+		TargetNames []string `json:"targetNames"` // 常见的约定, 不是规范的一部分
+	} `json:"extras"`
+}
+
+type gltfPrimitiveJSON struct {
+	Attributes map[string]int   `json:"attributes"`
+	Indices    *int             `json:"indices"`
+	Material   *int             `json:"material"`
+	Mode       *int             `json:"mode"`
+	Targets    []map[string]int `json:"targets"`
+}
+
+type gltfAccessor struct {
+	BufferView    *int   `json:"bufferView"`
+	ByteOffset    int    `json:"byteOffset"`
+	ComponentType int    `json:"componentType"`
+	Normalized    bool   `json:"normalized"`
+	Count         int    `json:"count"`
+	Type          string `json:"type"`
+	Sparse        *struct {
+		Count   int `json:"count"`
+		Indices struct {
+			BufferView    int `json:"bufferView"`
+			ByteOffset    int `json:"byteOffset"`
+			ComponentType int `json:"componentType"`
+		} `json:"indices"`
+		Values struct {
+			BufferView int `json:"bufferView"`
+			ByteOffset int `json:"byteOffset"`
+		} `json:"values"`
+	} `json:"sparse"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+type gltfBuffer struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+type gltfTextureInfo struct {
+	Index    int      `json:"index"`
+	TexCoord int      `json:"texCoord"`
+	Scale    *float32 `json:"scale"`
+	Strength *float32 `json:"strength"`
+}
+
+type gltfMaterialJSON struct {
+	Name                 string `json:"name"`
+	PBRMetallicRoughness *struct {
+		BaseColorFactor          *[4]float32      `json:"baseColorFactor"`
+		BaseColorTexture         *gltfTextureInfo `json:"baseColorTexture"`
+		MetallicFactor           *float32         `json:"metallicFactor"`
+		RoughnessFactor          *float32         `json:"roughnessFactor"`
+		MetallicRoughnessTexture *gltfTextureInfo `json:"metallicRoughnessTexture"`
+	} `json:"pbrMetallicRoughness"`
+	NormalTexture    *gltfTextureInfo `json:"normalTexture"`
+	OcclusionTexture *gltfTextureInfo `json:"occlusionTexture"`
+	EmissiveTexture  *gltfTextureInfo `json:"emissiveTexture"`
+	EmissiveFactor   *[3]float32      `json:"emissiveFactor"`
+	AlphaMode        string           `json:"alphaMode"`
+	AlphaCutoff      *float32         `json:"alphaCutoff"`
+	DoubleSided      bool             `json:"doubleSided"`
+}
+
+type gltfTextureJSON struct {
+	Name    string `json:"name"`
+	Sampler *int   `json:"sampler"`
+	Source  *int   `json:"source"`
+}
+
+type gltfImage struct {
+	URI        string `json:"uri"`
+	MimeType   string `json:"mimeType"`
+	BufferView *int   `json:"bufferView"`
+}
+
+type gltfSamplerJSON struct {
+	MagFilter int32 `json:"magFilter"`
+	MinFilter int32 `json:"minFilter"`
+	WrapS     int32 `json:"wrapS"`
+	WrapT     int32 `json:"wrapT"`
+}
+
+type gltfLoader struct {
+	doc     *gltfDocument
+	bin     []byte
+	fsys    fs.FS
+	buffers [][]byte
+}
+
+func (l *gltfLoader) load() (*GLTFModel, error) {
+	doc := l.doc
+	m := &GLTFModel{}
+
+	l.buffers = make([][]byte, len(doc.Buffers))
+	for i := range doc.Buffers {
+		b, err := l.loadBuffer(i)
+		if err != nil {
+			return nil, fmt.Errorf("gltf buffer %d: %w", i, err)
+		}
+		l.buffers[i] = b
+	}
+
+	for i := range doc.Textures {
+		t, err := l.loadTexture(i)
+		if err != nil {
+			return nil, fmt.Errorf("gltf texture %d: %w", i, err)
+		}
+		m.Textures = append(m.Textures, t)
+	}
+
+	for _, mat := range doc.Materials {
+		m.Materials = append(m.Materials, l.loadMaterial(mat))
+	}
+
+	for i, mesh := range doc.Meshes {
+		gm := GLTFMesh{Name: mesh.Name, Weights: mesh.Weights}
+		for j, p := range mesh.Primitives {
+			data, err := l.loadPrimitive(p)
+			if err != nil {
+				return nil, fmt.Errorf("gltf mesh %d primitive %d: %w", i, j, err)
+			}
+			for k := range data.Targets {
+				if k < len(mesh.Extras.TargetNames) {
+					data.Targets[k].Name = mesh.Extras.TargetNames[k]
+				}
+			}
+
+			prim := GLTFPrimitive{Mesh: data, Material: -1}
+			if p.Material != nil {
+				prim.Material = *p.Material
+			}
+			gm.Primitives = append(gm.Primitives, prim)
+		}
+		m.Meshes = append(m.Meshes, gm)
+	}
+
+	for _, n := range doc.Nodes {
+		m.Nodes = append(m.Nodes, loadNode(n))
+	}
+
+	for i, skin := range doc.Skins {
+		k, err := l.loadSkin(skin)
+		if err != nil {
+			return nil, fmt.Errorf("gltf skin %d: %w", i, err)
+		}
+		m.Skins = append(m.Skins, k)
+	}
+
+	for i, a := range doc.Animations {
+		clip, err := l.loadAnimation(a)
+		if err != nil {
+			return nil, fmt.Errorf("gltf animation %d: %w", i, err)
+		}
+		if clip.Name == "" {
+			clip.Name = fmt.Sprintf("animation%d", i)
+		}
+		m.Animations = append(m.Animations, clip)
+	}
+
+	for _, s := range doc.Scenes {
+		m.Scenes = append(m.Scenes, GLTFScene{Name: s.Name, Nodes: s.Nodes})
+	}
+	if doc.Scene != nil {
+		m.Scene = *doc.Scene
+	}
+
+	return m, l.validate(m)
+}
+
+// validate 检查节点、网格、材质之间的引用是否越界
+func (l *gltfLoader) validate(m *GLTFModel) error {
+	check := func(what string, i, n int) error {
+		if i < -1 || i >= n {
+			return fmt.Errorf("gltf: %s index %d out of range", what, i)
+		}
+		return nil
+	}
+
+	var errs []error
+	for _, n := range m.Nodes {
+		errs = append(errs, check("mesh", n.Mesh, len(m.Meshes)))
+		errs = append(errs, check("skin", n.Skin, len(m.Skins)))
+		for _, c := range n.Children {
+			errs = append(errs, check("node", c, len(m.Nodes)))
+		}
+	}
+	for _, mesh := range m.Meshes {
+		for _, p := range mesh.Primitives {
+			errs = append(errs, check("material", p.Material, len(m.Materials)))
+		}
+	}
+	for _, mat := range m.Materials {
+		for _, t := range []*TextureRef{mat.BaseColorTexture, mat.MetallicRoughnessTexture,
+			mat.NormalTexture, mat.OcclusionTexture, mat.EmissiveTexture} {
+			if t != nil {
+				errs = append(errs, check("texture", t.Index, len(m.Textures)))
+			}
+		}
+	}
+	for _, k := range m.Skins {
+		for _, j := range k.Joints {
+			errs = append(errs, check("joint node", j, len(m.Nodes)))
+		}
+	}
+	for _, a := range m.Animations {
+		for _, c := range a.Channels {
+			errs = append(errs, check("animation node", c.Node, len(m.Nodes)))
+		}
+	}
+	for _, s := range m.Scenes {
+		for _, n := range s.Nodes {
+			errs = append(errs, check("node", n, len(m.Nodes)))
+		}
+	}
+	if len(m.Scenes) > 0 {
+		errs = append(errs, check("scene", m.Scene, len(m.Scenes)))
+	}
+
+	return errors.Join(errs...)
+}
+
+// readURI 读取 data URI 或相对路径的外部文件
+func (l *gltfLoader) readURI(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		i := strings.IndexByte(uri, ',')
+		if i < 0 || !strings.HasSuffix(uri[:i], ";base64") {
+			return nil, errors.New("unsupported data uri")
+		}
+		return base64.StdEncoding.DecodeString(uri[i+1:])
+	}
+
+	if l.fsys == nil {
+		return nil, fmt.Errorf("external uri %q without file system", uri)
+	}
+	name, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(l.fsys, path.Clean(name))
+}
+
+func (l *gltfLoader) loadBuffer(i int) ([]byte, error) {
+	b := l.doc.Buffers[i]
+
+	var (
+		data []byte
+		err  error
+	)
+	if b.URI == "" {
+		// glb 中第一个没有 uri 的 buffer 指向 BIN 块
+		if l.bin == nil {
+			return nil, errors.New("missing GLB BIN chunk")
+		}
+		data = l.bin
+	} else {
+		data, err = l.readURI(b.URI)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(data) < b.ByteLength {
+		return nil, fmt.Errorf("buffer has %d bytes, want %d", len(data), b.ByteLength)
+	}
+	return data[:b.ByteLength], nil
+}
+
+func (l *gltfLoader) bufferView(i int) ([]byte, int, error) {
+	if i < 0 || i >= len(l.doc.BufferViews) {
+		return nil, 0, fmt.Errorf("bufferView %d out of range", i)
+	}
+
+	v := l.doc.BufferViews[i]
+	if v.Buffer < 0 || v.Buffer >= len(l.buffers) {
+		return nil, 0, fmt.Errorf("buffer %d out of range", v.Buffer)
+	}
+	b := l.buffers[v.Buffer]
+	if v.ByteOffset < 0 || v.ByteLength < 0 || v.ByteOffset+v.ByteLength > len(b) {
+		return nil, 0, fmt.Errorf("bufferView %d out of bounds", i)
+	}
+
+	return b[v.ByteOffset : v.ByteOffset+v.ByteLength], v.ByteStride, nil
+}
+
+func componentSize(ctype int) int {
+	switch ctype {
+	case 5120, 5121: // BYTE, UNSIGNED_BYTE
+		return 1
+	case 5122, 5123: // SHORT, UNSIGNED_SHORT
+		return 2
+	case 5125, 5126: // UNSIGNED_INT, FLOAT
+		return 4
+	default:
+		return 0
+	}
+}
+
+// accessorShape 返回 (分量个数, 矩阵列数), 非矩阵类型列数为 1
+func accessorShape(typ string) (int, int) {
+	switch typ {
+	case "SCALAR":
+		return 1, 1
+	case "VEC2":
+		return 2, 1
+	case "VEC3":
+		return 3, 1
+	case "VEC4":
+		return 4, 1
+	case "MAT2":
+		return 4, 2
+	case "MAT3":
+		return 9, 3
+	case "MAT4":
+		return 16, 4
+	default:
+		return 0, 0
+	}
+}
+
+// readComponent 读取一个分量, normalized 时按规范转换到 [0,1] 或 [-1,1]
+func readComponent(b []byte, ctype int, normalized bool) float64 {
+	le := binary.LittleEndian
+	switch ctype {
+	case 5120:
+		v := float64(int8(b[0]))
+		if normalized {
+			return math.Max(v/127, -1)
+		}
+		return v
+	case 5121:
+		v := float64(b[0])
+		if normalized {
+			return v / 255
+		}
+		return v
+	case 5122:
+		v := float64(int16(le.Uint16(b)))
+		if normalized {
+			return math.Max(v/32767, -1)
+		}
+		return v
+	case 5123:
+		v := float64(le.Uint16(b))
+		if normalized {
+			return v / 65535
+		}
+		return v
+	case 5125:
+		return float64(le.Uint32(b))
+	default:
+		return float64(math.Float32frombits(le.Uint32(b)))
+	}
+}
+
+// elementLayout 返回元素的字节大小和矩阵每列的字节步长
+func elementLayout(ctype, comps, cols int) (int, int) {
+	size := componentSize(ctype)
+	rows := comps / cols
+	// 矩阵每列按 4 字节对齐
+	colStride := rows * size
+	if cols > 1 && colStride%4 != 0 {
+		colStride += 4 - colStride%4
+	}
+	if cols == 1 {
+		return comps * size, colStride
+	}
+	return colStride * cols, colStride
+}
+
+// checkElements 检查 count 个元素是否都在长度为 viewLen 的 bufferView 内, 在分配输出之前调用
+func checkElements(viewLen, offset, stride, count, ctype, comps, cols int) error {
+	elemSize, _ := elementLayout(ctype, comps, cols)
+	if stride == 0 {
+		stride = elemSize
+	}
+	if stride < 0 {
+		return fmt.Errorf("bad byteStride %d", stride)
+	}
+	if count > 0 && (offset < 0 || offset+(count-1)*stride+elemSize > viewLen) {
+		return errors.New("accessor out of bounds")
+	}
+	return nil
+}
+
+// readElements 从 bufferView 中按步长读取 count 个元素, 写入 out[k*comps:]
+func readElements(view []byte, offset, stride, count, ctype, comps, cols int, normalized bool, out []float64) error {
+	err := checkElements(len(view), offset, stride, count, ctype, comps, cols)
+	if err != nil {
+		return err
+	}
+
+	size := componentSize(ctype)
+	rows := comps / cols
+	elemSize, colStride := elementLayout(ctype, comps, cols)
+	if stride == 0 {
+		stride = elemSize
+	}
+
+	for k := 0; k < count; k++ {
+		base := offset + k*stride
+		for c := 0; c < cols; c++ {
+			for r := 0; r < rows; r++ {
+				out[k*comps+c*rows+r] = readComponent(view[base+c*colStride+r*size:], ctype, normalized)
+			}
+		}
+	}
+	return nil
+}
+
+// readAccessor 读取访问器, 返回按元素展开的分量和每个元素的分量个数
+func (l *gltfLoader) readAccessor(i int) ([]float64, int, error) {
+	if i < 0 || i >= len(l.doc.Accessors) {
+		return nil, 0, fmt.Errorf("accessor %d out of range", i)
+	}
+
+	a := l.doc.Accessors[i]
+	comps, cols := accessorShape(a.Type)
+	if comps == 0 || componentSize(a.ComponentType) == 0 || a.Count < 0 {
+		return nil, 0, fmt.Errorf("accessor %d: bad type %s/%d", i, a.Type, a.ComponentType)
+	}
+	if a.Count > 1<<28/comps {
+		return nil, 0, fmt.Errorf("accessor %d: count %d too large", i, a.Count)
+	}
+
+	// 先检查 count*stride 不超出 bufferView, 避免按伪造的 count 分配内存
+	var (
+		view   []byte
+		stride int
+	)
+	if a.BufferView != nil {
+		var err error
+		view, stride, err = l.bufferView(*a.BufferView)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: %w", i, err)
+		}
+		err = checkElements(len(view), a.ByteOffset, stride, a.Count, a.ComponentType, comps, cols)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: %w", i, err)
+		}
+	}
+
+	out := make([]float64, a.Count*comps)
+	if a.BufferView != nil {
+		err := readElements(view, a.ByteOffset, stride, a.Count, a.ComponentType, comps, cols, a.Normalized, out)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: %w", i, err)
+		}
+	}
+
+	// 稀疏访问器: 用 values 覆盖 indices 指定的元素
+	if s := a.Sparse; s != nil && s.Count > 0 {
+		if s.Count > a.Count {
+			return nil, 0, fmt.Errorf("accessor %d: sparse count %d > %d", i, s.Count, a.Count)
+		}
+
+		idxView, _, err := l.bufferView(s.Indices.BufferView)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d sparse: %w", i, err)
+		}
+		if componentSize(s.Indices.ComponentType) == 0 {
+			return nil, 0, fmt.Errorf("accessor %d sparse: bad index type %d", i, s.Indices.ComponentType)
+		}
+		err = checkElements(len(idxView), s.Indices.ByteOffset, 0, s.Count, s.Indices.ComponentType, 1, 1)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d sparse: %w", i, err)
+		}
+		indices := make([]float64, s.Count)
+		err = readElements(idxView, s.Indices.ByteOffset, 0, s.Count, s.Indices.ComponentType, 1, 1, false, indices)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d sparse: %w", i, err)
+		}
+
+		valView, _, err := l.bufferView(s.Values.BufferView)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d sparse: %w", i, err)
+		}
+		err = checkElements(len(valView), s.Values.ByteOffset, 0, s.Count, a.ComponentType, comps, cols)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d sparse: %w", i, err)
+		}
+		values := make([]float64, s.Count*comps)
+		err = readElements(valView, s.Values.ByteOffset, 0, s.Count, a.ComponentType, comps, cols, a.Normalized, values)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d sparse: %w", i, err)
+		}
+
+		for k, idx := range indices {
+			e := int(idx)
+			if e >= a.Count {
+				return nil, 0, fmt.Errorf("accessor %d sparse: index %d out of range", i, e)
+			}
+			copy(out[e*comps:(e+1)*comps], values[k*comps:(k+1)*comps])
+		}
+	}
+
+	return out, comps, nil
+}
+
+func (l *gltfLoader) readVec3(i int) ([]mgl32.Vec3, error) {
+	v, comps, err := l.readAccessor(i)
+	if err != nil {
+		return nil, err
+	}
+	if comps != 3 {
+		return nil, fmt.Errorf("accessor %d: want VEC3", i)
+	}
+
+	out := make([]mgl32.Vec3, len(v)/3)
+	for k := range out {
+		out[k] = mgl32.Vec3{float32(v[3*k]), float32(v[3*k+1]), float32(v[3*k+2])}
+	}
+	return out, nil
+}
+
+func (l *gltfLoader) readVec4(i int) ([]mgl32.Vec4, error) {
+	v, comps, err := l.readAccessor(i)
+	if err != nil {
+		return nil, err
+	}
+	if comps != 4 {
+		return nil, fmt.Errorf("accessor %d: want VEC4", i)
+	}
+
+	out := make([]mgl32.Vec4, len(v)/4)
+	for k := range out {
+		out[k] = mgl32.Vec4{float32(v[4*k]), float32(v[4*k+1]), float32(v[4*k+2]), float32(v[4*k+3])}
+	}
+	return out, nil
+}
+
+func (l *gltfLoader) readUints(i int) ([]uint32, error) {
+	v, comps, err := l.readAccessor(i)
+	if err != nil {
+		return nil, err
+	}
+	if comps != 1 {
+		return nil, fmt.Errorf("accessor %d: want SCALAR", i)
+	}
+
+	out := make([]uint32, len(v))
+	for k, f := range v {
+		out[k] = uint32(f)
+	}
+	return out, nil
+}
+
+func (l *gltfLoader) loadPrimitive(p gltfPrimitiveJSON) (*MeshData, error) {
+	pos, ok := p.Attributes["POSITION"]
+	if !ok {
+		return nil, errors.New("missing POSITION")
+	}
+
+	d := &MeshData{}
+	var err error
+	d.Positions, err = l.readVec3(pos)
+	if err != nil {
+		return nil, err
+	}
+
+	if i, ok := p.Attributes["NORMAL"]; ok {
+		if d.Normals, err = l.readVec3(i); err != nil {
+			return nil, err
+		}
+	}
+	if i, ok := p.Attributes["TANGENT"]; ok {
+		if d.Tangents, err = l.readVec4(i); err != nil {
+			return nil, err
+		}
+	}
+	if i, ok := p.Attributes["TEXCOORD_0"]; ok {
+		v, comps, err := l.readAccessor(i)
+		if err != nil {
+			return nil, err
+		}
+		if comps != 2 {
+			return nil, fmt.Errorf("accessor %d: want VEC2", i)
+		}
+		// glTF 的 UV 原点在图片左上角, 而 LoadImgRGB 系列的图片是从下往上存储的, 翻转 V
+		d.UVs = make([]mgl32.Vec2, len(v)/2)
+		for k := range d.UVs {
+			d.UVs[k] = mgl32.Vec2{float32(v[2*k]), 1 - float32(v[2*k+1])}
+		}
+	}
+
+	if i, ok := p.Attributes["COLOR_0"]; ok {
+		v, comps, err := l.readAccessor(i)
+		if err != nil {
+			return nil, err
+		}
+		if comps != 3 && comps != 4 {
+			return nil, fmt.Errorf("accessor %d: want VEC3 or VEC4", i)
+		}
+		d.Colors = make([]mgl32.Vec4, len(v)/comps)
+		for k := range d.Colors {
+			c := mgl32.Vec4{1, 1, 1, 1}
+			for j := 0; j < comps; j++ {
+				c[j] = float32(v[k*comps+j])
+			}
+			d.Colors[k] = c
+		}
+	}
+
+	if i, ok := p.Attributes["JOINTS_0"]; ok {
+		v, comps, err := l.readAccessor(i)
+		if err != nil {
+			return nil, err
+		}
+		if comps != 4 {
+			return nil, fmt.Errorf("accessor %d: want VEC4", i)
+		}
+		d.Joints = make([][4]uint16, len(v)/4)
+		for k := range d.Joints {
+			for j := 0; j < 4; j++ {
+				d.Joints[k][j] = uint16(v[4*k+j])
+			}
+		}
+	}
+	if i, ok := p.Attributes["WEIGHTS_0"]; ok {
+		if d.Weights, err = l.readVec4(i); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, n := range []int{len(d.Normals), len(d.Tangents), len(d.UVs), len(d.Colors), len(d.Joints), len(d.Weights)} {
+		if n != 0 && n != len(d.Positions) {
+			return nil, errors.New("attribute count does not match POSITION")
+		}
+	}
+
+	for k, t := range p.Targets {
+		target, err := l.loadTarget(t, len(d.Positions))
+		if err != nil {
+			return nil, fmt.Errorf("target %d: %w", k, err)
+		}
+		d.Targets = append(d.Targets, target)
+	}
+
+	if p.Indices != nil {
+		d.Indices, err = l.readUints(*p.Indices)
+		if err != nil {
+			return nil, err
+		}
+		for _, idx := range d.Indices {
+			if int(idx) >= len(d.Positions) {
+				return nil, fmt.Errorf("index %d out of range", idx)
+			}
+		}
+	}
+
+	mode := 4
+	if p.Mode != nil {
+		mode = *p.Mode
+	}
+	err = d.convertMode(mode)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(d.Tangents) == 0 {
+		d.computeTangents()
+	}
+	return d, nil
+}
+
+// loadTarget 读取变形目标的 POSITION/NORMAL/TANGENT 偏移, 其他属性被忽略
+func (l *gltfLoader) loadTarget(t map[string]int, count int) (MorphTarget, error) {
+	var (
+		target MorphTarget
+		err    error
+	)
+	for _, attr := range []struct {
+		name string
+		list *[]mgl32.Vec3
+	}{
+		{"POSITION", &target.Positions},
+		{"NORMAL", &target.Normals},
+		{"TANGENT", &target.Tangents},
+	} {
+		i, ok := t[attr.name]
+		if !ok {
+			continue
+		}
+		if *attr.list, err = l.readVec3(i); err != nil {
+			return target, err
+		}
+		if len(*attr.list) != count {
+			return target, fmt.Errorf("%s count does not match POSITION", attr.name)
+		}
+	}
+	return target, nil
+}
+
+// convertMode 将 glTF 图元类型转换为 Triangles/Lines/Points, 条带和扇形展开为列表
+func (d *MeshData) convertMode(mode int) error {
+	idx := d.Indices
+	if len(idx) == 0 {
+		idx = sequence(uint32(len(d.Positions)))
+	}
+
+	switch mode {
+	case 0: // POINTS
+		d.Topology = Points
+	case 1: // LINES
+		d.Topology = Lines
+	case 2, 3: // LINE_LOOP, LINE_STRIP
+		d.Topology = Lines
+		var out []uint32
+		for i := 0; i+1 < len(idx); i++ {
+			out = append(out, idx[i], idx[i+1])
+		}
+		if mode == 2 && len(idx) > 2 {
+			out = append(out, idx[len(idx)-1], idx[0])
+		}
+		d.Indices = out
+	case 4: // TRIANGLES
+		d.Topology = Triangles
+	case 5: // TRIANGLE_STRIP, 奇数三角形需要翻转保持环绕方向
+		d.Topology = Triangles
+		var out []uint32
+		for i := 0; i+2 < len(idx); i++ {
+			if i%2 == 0 {
+				out = append(out, idx[i], idx[i+1], idx[i+2])
+			} else {
+				out = append(out, idx[i+1], idx[i], idx[i+2])
+			}
+		}
+		d.Indices = out
+	case 6: // TRIANGLE_FAN
+		d.Topology = Triangles
+		var out []uint32
+		for i := 1; i+1 < len(idx); i++ {
+			out = append(out, idx[0], idx[i], idx[i+1])
+		}
+		d.Indices = out
+	default:
+		return fmt.Errorf("unsupported primitive mode %d", mode)
+	}
+
+	return nil
+}
+
+func textureRef(t *gltfTextureInfo) *TextureRef {
+	if t == nil {
+		return nil
+	}
+
+	r := &TextureRef{Index: t.Index, TexCoord: t.TexCoord, Scale: 1}
+	if t.Scale != nil {
+		r.Scale = *t.Scale
+	}
+	if t.Strength != nil {
+		r.Scale = *t.Strength
+	}
+	return r
+}
+
+func (l *gltfLoader) loadMaterial(m gltfMaterialJSON) PBRMaterial {
+	mat := PBRMaterial{
+		Name:             m.Name,
+		BaseColorFactor:  mgl32.Vec4{1, 1, 1, 1},
+		MetallicFactor:   1,
+		RoughnessFactor:  1,
+		NormalTexture:    textureRef(m.NormalTexture),
+		OcclusionTexture: textureRef(m.OcclusionTexture),
+		EmissiveTexture:  textureRef(m.EmissiveTexture),
+		AlphaMode:        "OPAQUE",
+		AlphaCutoff:      0.5,
+		DoubleSided:      m.DoubleSided,
+	}
+
+	if pbr := m.PBRMetallicRoughness; pbr != nil {
+		if pbr.BaseColorFactor != nil {
+			mat.BaseColorFactor = *pbr.BaseColorFactor
+		}
+		if pbr.MetallicFactor != nil {
+			mat.MetallicFactor = *pbr.MetallicFactor
+		}
+		if pbr.RoughnessFactor != nil {
+			mat.RoughnessFactor = *pbr.RoughnessFactor
+		}
+		mat.BaseColorTexture = textureRef(pbr.BaseColorTexture)
+		mat.MetallicRoughnessTexture = textureRef(pbr.MetallicRoughnessTexture)
+	}
+	if m.EmissiveFactor != nil {
+		mat.EmissiveFactor = *m.EmissiveFactor
+	}
+	if m.AlphaMode != "" {
+		mat.AlphaMode = m.AlphaMode
+	}
+	if m.AlphaCutoff != nil {
+		mat.AlphaCutoff = *m.AlphaCutoff
+	}
+
+	return mat
+}
+
+func (l *gltfLoader) loadTexture(i int) (GLTFTexture, error) {
+	t := l.doc.Textures[i]
+	tex := GLTFTexture{Name: t.Name, Sampler: DefaultSampler()}
+
+	if t.Sampler != nil {
+		if *t.Sampler < 0 || *t.Sampler >= len(l.doc.Samplers) {
+			return tex, fmt.Errorf("sampler %d out of range", *t.Sampler)
+		}
+		// 未指定的字段保留默认值
+		s := l.doc.Samplers[*t.Sampler]
+		for _, f := range [][2]*int32{
+			{&tex.Sampler.MagFilter, &s.MagFilter},
+			{&tex.Sampler.MinFilter, &s.MinFilter},
+			{&tex.Sampler.WrapS, &s.WrapS},
+			{&tex.Sampler.WrapT, &s.WrapT},
+		} {
+			if *f[1] != 0 {
+				*f[0] = *f[1]
+			}
+		}
+	}
+
+	if t.Source == nil {
+		return tex, nil
+	}
+	if *t.Source < 0 || *t.Source >= len(l.doc.Images) {
+		return tex, fmt.Errorf("image %d out of range", *t.Source)
+	}
+
+	img := l.doc.Images[*t.Source]
+	var (
+		data []byte
+		err  error
+	)
+	if img.BufferView != nil {
+		data, _, err = l.bufferView(*img.BufferView)
+	} else {
+		data, err = l.readURI(img.URI)
+	}
+	if err != nil {
+		return tex, err
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return tex, err
+	}
+	tex.Image = NewImageData(decoded, true)
+
+	return tex, nil
+}
+
+func (l *gltfLoader) loadSkin(s gltfSkinJSON) (Skin, error) {
+	k := Skin{Name: s.Name, Joints: s.Joints}
+	if s.InverseBindMatrices == nil {
+		return k, nil // 没有时为单位矩阵
+	}
+
+	v, comps, err := l.readAccessor(*s.InverseBindMatrices)
+	if err != nil {
+		return k, err
+	}
+	if comps != 16 || len(v)/16 < len(s.Joints) {
+		return k, errors.New("inverseBindMatrices must be MAT4 for every joint")
+	}
+
+	k.InverseBind = make([]mgl32.Mat4, len(s.Joints))
+	for i := range k.InverseBind {
+		for j := 0; j < 16; j++ {
+			k.InverseBind[i][j] = float32(v[16*i+j])
+		}
+	}
+	return k, nil
+}
+
+func (l *gltfLoader) loadAnimation(a gltfAnimation) (*AnimationClip, error) {
+	var channels []Channel
+	for i, c := range a.Channels {
+		if c.Target.Node == nil {
+			continue // 由扩展定义的目标
+		}
+		if c.Sampler < 0 || c.Sampler >= len(a.Samplers) {
+			return nil, fmt.Errorf("channel %d: sampler %d out of range", i, c.Sampler)
+		}
+
+		ch := Channel{Node: *c.Target.Node}
+		switch c.Target.Path {
+		case "translation":
+			ch.Path = PathTranslation
+		case "rotation":
+			ch.Path = PathRotation
+		case "scale":
+			ch.Path = PathScale
+		case "weights":
+			ch.Path = PathWeights
+		default:
+			continue // 扩展定义的目标
+		}
+
+		s := a.Samplers[c.Sampler]
+		switch s.Interpolation {
+		case "", "LINEAR":
+			ch.Interpolation = InterpLinear
+		case "STEP":
+			ch.Interpolation = InterpStep
+		case "CUBICSPLINE":
+			ch.Interpolation = InterpCubicSpline
+		default:
+			return nil, fmt.Errorf("channel %d: unknown interpolation %q", i, s.Interpolation)
+		}
+
+		times, comps, err := l.readAccessor(s.Input)
+		if err != nil {
+			return nil, err
+		}
+		if comps != 1 {
+			return nil, fmt.Errorf("channel %d: input must be SCALAR", i)
+		}
+		values, _, err := l.readAccessor(s.Output)
+		if err != nil {
+			return nil, err
+		}
+
+		ch.Times = make([]float32, len(times))
+		for k, t := range times {
+			ch.Times[k] = float32(t)
+		}
+		ch.Values = make([]float32, len(values))
+		for k, v := range values {
+			ch.Values[k] = float32(v)
+		}
+		if ch.Path == PathWeights && len(times) > 0 {
+			// 每个关键帧的权重数等于目标网格的变形目标数
+			keys := len(times)
+			if ch.Interpolation == InterpCubicSpline {
+				keys *= 3
+			}
+			ch.Width = len(values) / keys
+		}
+		channels = append(channels, ch)
+	}
+
+	return NewAnimationClip(a.Name, channels)
+}
+
+func loadNode(n gltfNodeJSON) GLTFNode {
+	node := GLTFNode{
+		Name:     n.Name,
+		Children: n.Children,
+		Mesh:     -1,
+		Skin:     -1,
+		Rotation: mgl32.QuatIdent(),
+		Scale:    mgl32.Vec3{1, 1, 1},
+	}
+
+	if n.Mesh != nil {
+		node.Mesh = *n.Mesh
+	}
+	if n.Skin != nil {
+		node.Skin = *n.Skin
+	}
+	node.Weights = n.Weights
+
+	if n.Matrix != nil {
+		node.Translation, node.Rotation, node.Scale = DecomposeMatrix(mgl32.Mat4(*n.Matrix))
+		return node
+	}
+	if n.Translation != nil {
+		node.Translation = *n.Translation
+	}
+	if n.Rotation != nil {
+		r := *n.Rotation
+		node.Rotation = mgl32.Quat{W: r[3], V: mgl32.Vec3{r[0], r[1], r[2]}}.Normalize()
+	}
+	if n.Scale != nil {
+		node.Scale = *n.Scale
+	}
+
+	return node
+}
+
+// DecomposeMatrix 将不含切变的仿射矩阵分解为平移、旋转和缩放
+func DecomposeMatrix(m mgl32.Mat4) (mgl32.Vec3, mgl32.Quat, mgl32.Vec3) {
+	t := m.Col(3).Vec3()
+	s := mgl32.Vec3{m.Col(0).Vec3().Len(), m.Col(1).Vec3().Len(), m.Col(2).Vec3().Len()}
+	// 行列式为负时有镜像, 将其放到 x 轴的缩放上
+	if m.Mat3().Det() < 0 {
+		s[0] = -s[0]
+	}
+
+	var r mgl32.Mat3
+	for c := 0; c < 3; c++ {
+		col := m.Col(c).Vec3()
+		if s[c] != 0 {
+			col = col.Mul(1 / s[c])
+		}
+		r.SetCol(c, col)
+	}
+
+	return t, mgl32.Mat4ToQuat(r.Mat4()).Normalize(), s
+}
+
+// Matrix 节点的局部变换矩阵 T*R*S
+func (n *GLTFNode) Matrix() mgl32.Mat4 {
+	return Transform{Translation: n.Translation, Rotation: n.Rotation, Scale: n.Scale}.Matrix()
+}
@@ -0,0 +1,33 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseGLTFAccessorBounds(t *testing.T) {
+	const doc = `{
+	"asset": {"version": "2.0"},
+	"buffers": [{"uri": "data:application/octet-stream;base64,AACAPwAAAEAAAEBA", "byteLength": 12}],
+	"bufferViews": [{"buffer": 0, "byteLength": 12}],
+	"accessors": [{"bufferView": 0, "componentType": 5126, "count": COUNT, "type": "VEC3"}],
+	"meshes": [{"primitives": [{"attributes": {"POSITION": 0}}]}]
+}`
+
+	tests := []struct {
+		count   string
+		wantErr bool
+	}{
+		{"1", false},
+		{"2", true},
+		// 伪造的 count 在分配内存之前就被拒绝
+		{"200000000", true},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseGLTF([]byte(strings.Replace(doc, "COUNT", tt.count, 1)), nil)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("count %s: error %v, want error %v", tt.count, err, tt.wantErr)
+		}
+	}
+}
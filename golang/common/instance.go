@@ -0,0 +1,119 @@
+package common
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Instance 通用的实例属性, location 接在 Vertex 之后:
+//
+//	layout (location = 5) in mat4 aModel;
+//	layout (location = 9) in vec4 aColor;
+//	layout (location = 10) in float aLayer;
+type Instance struct {
+	Model mgl32.Mat4 `gl:"5"`
+	Color mgl32.Vec4 `gl:"9"`
+	Layer float32    `gl:"10"` // 纹理数组的层, 着色器中用 int(aLayer) 取整
+}
+
+func NewInstance(model mgl32.Mat4) Instance {
+	return Instance{Model: model, Color: mgl32.Vec4{1, 1, 1, 1}}
+}
+
+// InstanceBuffer 绑定在某个 Mesh 的 VAO 上的实例缓冲, 每个实例前进一次
+type InstanceBuffer struct {
+	VBO    uint32
+	Count  int32 // 实例个数
+	Layout VertexLayout
+
+	mesh *Mesh
+	size int // 缓冲区当前分配的字节数
+}
+
+// NewInstanceBuffer 根据 T 的结构体标签生成实例属性并绑定到 mesh 上,
+// 属性的 location 不能与 mesh 的顶点属性重复
+func NewInstanceBuffer[T any](mesh *Mesh, instances []T) (*InstanceBuffer, error) {
+	layout, err := LayoutOf[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := instanceLocations(mesh.Layout, layout); err != nil {
+		return nil, err
+	}
+	for i := range layout.Attribs {
+		layout.Attribs[i].Divisor = 1
+	}
+
+	b := &InstanceBuffer{Layout: layout, mesh: mesh}
+	gl.GenBuffers(1, &b.VBO)
+
+	gl.BindVertexArray(mesh.VAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, b.VBO)
+	layout.bind()
+	gl.BindVertexArray(0)
+
+	if err := UpdateInstances(b, instances); err != nil {
+		b.Delete()
+		return nil, err
+	}
+	return b, nil
+}
+
+// instanceLocations 检查实例属性占用的 location 是否与顶点属性重叠, mat4 和 dvec3/dvec4 等占用多个 location
+func instanceLocations(vertex, instance VertexLayout) error {
+	used := make(map[uint32]string)
+	for _, a := range vertex.Attribs {
+		for k := range a.locations() {
+			used[a.Location+k] = a.Name
+		}
+	}
+	for _, a := range instance.Attribs {
+		for k := range a.locations() {
+			if name, ok := used[a.Location+k]; ok {
+				return fmt.Errorf("NewInstanceBuffer: location %d used by both vertex %s and instance %s", a.Location+k, name, a.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// UpdateInstances 重新上传实例数据, 容量不足时重新分配, 否则原地更新
+// T 的大小必须与创建时的步长一致, 否则返回错误, 缓冲区不会被修改
+func UpdateInstances[T any](b *InstanceBuffer, instances []T) error {
+	stride := int(unsafe.Sizeof(*new(T)))
+	if stride != int(b.Layout.Stride) {
+		return fmt.Errorf("UpdateInstances: %T is %d bytes, instance stride is %d", *new(T), stride, b.Layout.Stride)
+	}
+	size := len(instances) * stride
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, b.VBO)
+	if size > b.size {
+		gl.BufferData(gl.ARRAY_BUFFER, size, unsafe.Pointer(unsafe.SliceData(instances)), gl.DYNAMIC_DRAW)
+		b.size = size
+	} else if size > 0 {
+		gl.BufferSubData(gl.ARRAY_BUFFER, 0, size, unsafe.Pointer(unsafe.SliceData(instances)))
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	b.Count = int32(len(instances))
+	return nil
+}
+
+// Draw 一次绘制所有实例
+func (b *InstanceBuffer) Draw() {
+	b.mesh.DrawInstanced(b.Count)
+}
+
+func (b *InstanceBuffer) Delete() {
+	gl.DeleteBuffers(1, &b.VBO)
+}
+
+// Validate 检查顶点属性和实例属性合起来是否与着色器一致
+func (b *InstanceBuffer) Validate(s *Shader) error {
+	attribs := append(append([]VertexAttrib(nil), b.mesh.Layout.Attribs...), b.Layout.Attribs...)
+	return VertexLayout{Attribs: attribs}.Validate(s)
+}
@@ -0,0 +1,63 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestInstanceLocations(t *testing.T) {
+	vertex, err := LayoutOf[Vertex]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance, err := LayoutOf[Instance]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := instanceLocations(vertex, instance); err != nil {
+		t.Errorf("Vertex and Instance: %v", err)
+	}
+
+	// 顶点的 dvec4 占用 4 和 5, 与 aModel 的第一列重叠
+	dvec := VertexAttrib{Name: "Precise", Location: 4, Components: 4, Type: gl.DOUBLE}
+	if err := instanceLocations(VertexLayout{Attribs: []VertexAttrib{dvec}}, instance); err == nil {
+		t.Error("vertex dvec4 at 4 overlapping instance mat4 at 5 accepted")
+	}
+
+	// 实例的 dvec3 占用 11 和 12
+	type preciseInstance struct {
+		Offset [3]float64 `gl:"11"`
+	}
+	precise, err := LayoutOf[preciseInstance]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra := VertexAttrib{Name: "Extra", Location: 12, Components: 1, Type: gl.FLOAT}
+	if err := instanceLocations(VertexLayout{Attribs: []VertexAttrib{extra}}, precise); err == nil {
+		t.Error("instance dvec3 at 11 overlapping vertex attribute at 12 accepted")
+	}
+	if err := instanceLocations(vertex, precise); err != nil {
+		t.Errorf("Vertex and dvec3 instance: %v", err)
+	}
+}
+
+func TestUpdateInstancesStride(t *testing.T) {
+	layout, err := LayoutOf[Instance]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &InstanceBuffer{Layout: layout}
+
+	// 大小不同的类型在调用 GL 之前被拒绝
+	if err := UpdateInstances(b, []mgl32.Mat4{mgl32.Ident4()}); err == nil {
+		t.Error("mat4 instances accepted for Instance layout")
+	}
+	if err := UpdateInstances(b, []Vertex{{}}); err == nil {
+		t.Error("Vertex instances accepted for Instance layout")
+	}
+	if b.Count != 0 || b.size != 0 {
+		t.Errorf("buffer modified: count %d, size %d", b.Count, b.size)
+	}
+}
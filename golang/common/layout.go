@@ -0,0 +1,287 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+)
+
+// LayoutOf 通过结构体标签生成顶点布局,例如:
+//
+//	type Vertex struct {
+//		Position mgl32.Vec3 `gl:"0"`
+//		UV       mgl32.Vec2 `gl:"1"`
+//		Color    [4]uint8   `gl:"2,normalized"`
+//		Joints   [4]uint16  `gl:"3,int"` // 着色器中为 uvec4
+//	}
+//
+// 支持 float32、mgl32.VecN、[N]整数/浮点数组,mgl32.Mat3/Mat4 会占用连续的 3/4 个 location
+// 没有 gl 标签的字段会被跳过,但仍然计入步长; 嵌入的结构体会展开其中带标签的字段,嵌入的结构体指针会返回错误
+func LayoutOf[T any]() (VertexLayout, error) {
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct {
+		return VertexLayout{}, fmt.Errorf("LayoutOf: %s is not a struct", typ)
+	}
+
+	var (
+		layout = VertexLayout{Stride: int32(typ.Size())}
+		used   = make(map[uint32]string)
+	)
+
+	// 包括嵌入结构体中提升的字段
+	var err error
+	for _, field := range reflect.VisibleFields(typ) {
+		tag, ok := field.Tag.Lookup("gl")
+		if !ok {
+			continue
+		}
+		field.Offset, err = fieldOffset(typ, field.Index)
+		if err != nil {
+			return VertexLayout{}, fmt.Errorf("LayoutOf: field %s.%s: %w", typ, field.Name, err)
+		}
+		if !field.IsExported() {
+			return VertexLayout{}, fmt.Errorf("LayoutOf: field %s.%s is not exported", typ, field.Name)
+		}
+
+		opts := strings.Split(tag, ",")
+		loc, err := strconv.ParseUint(opts[0], 10, 32)
+		if err != nil {
+			return VertexLayout{}, fmt.Errorf("LayoutOf: field %s.%s: bad location %q", typ, field.Name, opts[0])
+		}
+
+		attribs, err := fieldAttribs(field)
+		if err != nil {
+			return VertexLayout{}, fmt.Errorf("LayoutOf: field %s.%s: %w", typ, field.Name, err)
+		}
+
+		for _, opt := range opts[1:] {
+			switch opt {
+			case "normalized", "int":
+			default:
+				return VertexLayout{}, fmt.Errorf("LayoutOf: field %s.%s: unknown option %q", typ, field.Name, opt)
+			}
+		}
+
+		for j, a := range attribs {
+			a.Location = uint32(loc) + uint32(j)
+			a.Normalized = slices.Contains(opts[1:], "normalized")
+			a.Integer = slices.Contains(opts[1:], "int")
+			if a.Integer && (a.Type == gl.FLOAT || a.Type == gl.DOUBLE) {
+				return VertexLayout{}, fmt.Errorf("LayoutOf: field %s.%s: int option on float type", typ, field.Name)
+			}
+			for k := range a.locations() {
+				if prev, ok := used[a.Location+k]; ok {
+					return VertexLayout{}, fmt.Errorf("LayoutOf: location %d used by both %s and %s", a.Location+k, prev, field.Name)
+				}
+				used[a.Location+k] = field.Name
+			}
+			layout.Attribs = append(layout.Attribs, a)
+		}
+	}
+
+	return layout, nil
+}
+
+// NewMeshOf 根据 T 的结构体标签生成布局并上传顶点,顶点数据不会被复制
+func NewMeshOf[T any](vertices []T, indices []uint32) (*Mesh, error) {
+	layout, err := LayoutOf[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMesh(layout, vertices, indices)
+}
+
+// fieldOffset 字段相对最外层结构体的偏移
+// 通过嵌入的结构体指针提升的字段不在顶点数据中,返回错误
+func fieldOffset(typ reflect.Type, index []int) (uintptr, error) {
+	var offset uintptr
+	for _, i := range index {
+		if typ.Kind() == reflect.Pointer {
+			return 0, fmt.Errorf("promoted through embedded pointer %s", typ)
+		}
+		f := typ.Field(i)
+		offset += f.Offset
+		typ = f.Type
+	}
+	return offset, nil
+}
+
+func fieldAttribs(field reflect.StructField) ([]VertexAttrib, error) {
+	var (
+		typ    = field.Type
+		offset = int(field.Offset)
+		count  = 1
+	)
+
+	if typ.Kind() == reflect.Array {
+		count = typ.Len()
+		typ = typ.Elem()
+	}
+
+	glType, err := glTypeOf(typ.Kind())
+	if err != nil {
+		return nil, err
+	}
+
+	a := VertexAttrib{Name: field.Name, Type: glType, Offset: offset}
+	switch {
+	case count <= 4:
+		a.Components = int32(count)
+		return []VertexAttrib{a}, nil
+	case glType == gl.FLOAT && (count == 9 || count == 16):
+		// 矩阵按列拆成多个属性
+		n := 3
+		if count == 16 {
+			n = 4
+		}
+
+		attribs := make([]VertexAttrib, n)
+		for i := range attribs {
+			attribs[i] = a
+			attribs[i].Components = int32(n)
+			attribs[i].Offset = offset + i*n*4
+		}
+		return attribs, nil
+	default:
+		return nil, fmt.Errorf("unsupported array length %d", count)
+	}
+}
+
+func glTypeOf(kind reflect.Kind) (uint32, error) {
+	switch kind {
+	case reflect.Float32:
+		return gl.FLOAT, nil
+	case reflect.Float64:
+		return gl.DOUBLE, nil
+	case reflect.Int8:
+		return gl.BYTE, nil
+	case reflect.Uint8:
+		return gl.UNSIGNED_BYTE, nil
+	case reflect.Int16:
+		return gl.SHORT, nil
+	case reflect.Uint16:
+		return gl.UNSIGNED_SHORT, nil
+	case reflect.Int32:
+		return gl.INT, nil
+	case reflect.Uint32:
+		return gl.UNSIGNED_INT, nil
+	default:
+		return 0, fmt.Errorf("unsupported type %s", kind)
+	}
+}
+
+// ShaderAttrib 着色器中激活的顶点属性
+type ShaderAttrib struct {
+	Name     string
+	Location int32
+	Type     uint32 // gl.FLOAT_VEC3 等
+	Size     int32  // 数组长度
+}
+
+func (s *Shader) Attributes() []ShaderAttrib {
+	var count, maxLen int32
+	gl.GetProgramiv(s.ID, gl.ACTIVE_ATTRIBUTES, &count)
+	gl.GetProgramiv(s.ID, gl.ACTIVE_ATTRIBUTE_MAX_LENGTH, &maxLen)
+
+	attribs := make([]ShaderAttrib, 0, count)
+	name := make([]byte, maxLen+1)
+	for i := int32(0); i < count; i++ {
+		var (
+			length, size int32
+			xtype        uint32
+		)
+		gl.GetActiveAttrib(s.ID, uint32(i), int32(len(name)), &length, &size, &xtype, unsafe.SliceData(name))
+
+		a := ShaderAttrib{Name: string(name[:length]), Type: xtype, Size: size}
+		a.Location = gl.GetAttribLocation(s.ID, gl.Str(a.Name+CNull))
+		attribs = append(attribs, a)
+	}
+
+	return attribs
+}
+
+// glslAttribShape 返回 GLSL 属性类型的 (每个 location 的分量数, 占用的 location 数)
+func glslAttribShape(t uint32) (int32, int32) {
+	switch t {
+	case gl.FLOAT, gl.INT, gl.UNSIGNED_INT, gl.DOUBLE:
+		return 1, 1
+	case gl.FLOAT_VEC2, gl.INT_VEC2, gl.UNSIGNED_INT_VEC2, gl.DOUBLE_VEC2:
+		return 2, 1
+	case gl.FLOAT_VEC3, gl.INT_VEC3, gl.UNSIGNED_INT_VEC3, gl.DOUBLE_VEC3:
+		return 3, 1
+	case gl.FLOAT_VEC4, gl.INT_VEC4, gl.UNSIGNED_INT_VEC4, gl.DOUBLE_VEC4:
+		return 4, 1
+	case gl.FLOAT_MAT3:
+		return 3, 3
+	case gl.FLOAT_MAT4:
+		return 4, 4
+	default:
+		return 0, 0
+	}
+}
+
+func glslAttribInteger(t uint32) bool {
+	switch t {
+	case gl.INT, gl.INT_VEC2, gl.INT_VEC3, gl.INT_VEC4,
+		gl.UNSIGNED_INT, gl.UNSIGNED_INT_VEC2, gl.UNSIGNED_INT_VEC3, gl.UNSIGNED_INT_VEC4:
+		return true
+	default:
+		return false
+	}
+}
+
+func glslAttribDouble(t uint32) bool {
+	switch t {
+	case gl.DOUBLE, gl.DOUBLE_VEC2, gl.DOUBLE_VEC3, gl.DOUBLE_VEC4:
+		return true
+	default:
+		return false
+	}
+}
+
+// Validate 检查着色器中每个激活的属性都能在布局中找到相同 location 和分量数的属性
+func (l VertexLayout) Validate(s *Shader) error {
+	return l.validate(s.Attributes())
+}
+
+func (l VertexLayout) validate(attribs []ShaderAttrib) error {
+	byLoc := make(map[uint32]VertexAttrib, len(l.Attribs))
+	for _, a := range l.Attribs {
+		byLoc[a.Location] = a
+	}
+
+	var errs []error
+	for _, sa := range attribs {
+		if sa.Location < 0 {
+			continue // gl_VertexID 等内置变量
+		}
+
+		components, locations := glslAttribShape(sa.Type)
+		for i := int32(0); i < locations*sa.Size; i++ {
+			loc := uint32(sa.Location + i)
+			a, ok := byLoc[loc]
+			switch {
+			case !ok:
+				errs = append(errs, fmt.Errorf("attribute %s: location %d missing in layout", sa.Name, loc))
+			case components != 0 && a.Components != components:
+				errs = append(errs, fmt.Errorf("attribute %s: location %d has %d components, layout field %s has %d",
+					sa.Name, loc, components, a.Name, a.Components))
+			case a.Integer != glslAttribInteger(sa.Type):
+				errs = append(errs, fmt.Errorf("attribute %s: location %d integer type mismatch with layout field %s",
+					sa.Name, loc, a.Name))
+			case (a.Type == gl.DOUBLE) != glslAttribDouble(sa.Type):
+				errs = append(errs, fmt.Errorf("attribute %s: location %d double type mismatch with layout field %s",
+					sa.Name, loc, a.Name))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,177 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type layoutBase struct {
+	Position mgl32.Vec3 `gl:"0"`
+}
+
+func TestLayoutOfEmbedded(t *testing.T) {
+	type vertex struct {
+		layoutBase
+		UV mgl32.Vec2 `gl:"1"`
+	}
+	layout, err := LayoutOf[vertex]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layout.Attribs) != 2 || layout.Attribs[1].Offset != 12 || layout.Stride != 20 {
+		t.Errorf("layout %+v", layout)
+	}
+
+	// 嵌入指针时字段不在顶点数据中
+	type pointerVertex struct {
+		*layoutBase
+		UV mgl32.Vec2 `gl:"1"`
+	}
+	if _, err := LayoutOf[pointerVertex](); err == nil {
+		t.Error("LayoutOf accepted a field promoted through an embedded pointer")
+	}
+}
+
+func TestLayoutOfOffsets(t *testing.T) {
+	type skinned struct {
+		Position mgl32.Vec3 `gl:"0"`
+		_        float32    // 没有标签的字段计入步长
+		Color    [4]uint8   `gl:"1,normalized"`
+		Joints   [4]uint16  `gl:"2,int"`
+		Basis    mgl32.Mat3 `gl:"3"`
+		Weight   float64    `gl:"6"`
+	}
+	layout, err := LayoutOf[skinned]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []VertexAttrib{
+		{Name: "Position", Location: 0, Components: 3, Type: gl.FLOAT, Offset: 0},
+		{Name: "Color", Location: 1, Components: 4, Type: gl.UNSIGNED_BYTE, Normalized: true, Offset: 16},
+		{Name: "Joints", Location: 2, Components: 4, Type: gl.UNSIGNED_SHORT, Integer: true, Offset: 20},
+		{Name: "Basis", Location: 3, Components: 3, Type: gl.FLOAT, Offset: 28},
+		{Name: "Basis", Location: 4, Components: 3, Type: gl.FLOAT, Offset: 40},
+		{Name: "Basis", Location: 5, Components: 3, Type: gl.FLOAT, Offset: 52},
+		{Name: "Weight", Location: 6, Components: 1, Type: gl.DOUBLE, Offset: 64},
+	}
+	if layout.Stride != 72 || len(layout.Attribs) != len(want) {
+		t.Fatalf("stride %d, %d attribs, want 72 and %d", layout.Stride, len(layout.Attribs), len(want))
+	}
+	for i, a := range layout.Attribs {
+		if a != want[i] {
+			t.Errorf("attrib %d = %+v, want %+v", i, a, want[i])
+		}
+	}
+
+	// Instance 的 mat4 按列占用 5~8
+	layout, err = LayoutOf[Instance]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layout.Stride != 84 || len(layout.Attribs) != 6 {
+		t.Fatalf("Instance: stride %d, %d attribs, want 84 and 6", layout.Stride, len(layout.Attribs))
+	}
+	for i, a := range layout.Attribs[:4] {
+		if a.Location != uint32(5+i) || a.Offset != 16*i || a.Components != 4 {
+			t.Errorf("Instance model column %d = %+v", i, a)
+		}
+	}
+	if a := layout.Attribs[5]; a.Location != 10 || a.Offset != 80 || a.Components != 1 {
+		t.Errorf("Instance layer = %+v", a)
+	}
+}
+
+func TestLayoutOfErrors(t *testing.T) {
+	type badLocation struct {
+		Position mgl32.Vec3 `gl:"x"`
+	}
+	type negativeLocation struct {
+		Position mgl32.Vec3 `gl:"-1"`
+	}
+	type duplicate struct {
+		Position mgl32.Vec3 `gl:"0"`
+		Normal   mgl32.Vec3 `gl:"0"`
+	}
+	type matrixOverlap struct {
+		Model mgl32.Mat4 `gl:"3"`
+		Color mgl32.Vec4 `gl:"6"`
+	}
+	type doubleOverlap struct {
+		Position [3]float64 `gl:"0"`
+		UV       mgl32.Vec2 `gl:"1"`
+	}
+	type unknownOption struct {
+		Color [4]uint8 `gl:"0,normalised"`
+	}
+	type intFloat struct {
+		Position mgl32.Vec3 `gl:"0,int"`
+	}
+	type unexported struct {
+		position mgl32.Vec3 `gl:"0"`
+	}
+	type unsupportedType struct {
+		Flag bool `gl:"0"`
+	}
+	type unsupportedLength struct {
+		Values [5]float32 `gl:"0"`
+	}
+
+	for name, layoutOf := range map[string]func() (VertexLayout, error){
+		"bad location":       LayoutOf[badLocation],
+		"negative location":  LayoutOf[negativeLocation],
+		"duplicate location": LayoutOf[duplicate],
+		"matrix overlap":     LayoutOf[matrixOverlap],
+		"double overlap":     LayoutOf[doubleOverlap],
+		"unknown option":     LayoutOf[unknownOption],
+		"int on float":       LayoutOf[intFloat],
+		"unexported":         LayoutOf[unexported],
+		"unsupported type":   LayoutOf[unsupportedType],
+		"unsupported length": LayoutOf[unsupportedLength],
+		"not a struct":       LayoutOf[mgl32.Vec3],
+	} {
+		if _, err := layoutOf(); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestLayoutValidate(t *testing.T) {
+	layout, err := LayoutOf[Vertex]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance, err := LayoutOf[Instance]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	combined := VertexLayout{Attribs: append(append([]VertexAttrib(nil), layout.Attribs...), instance.Attribs...)}
+
+	tests := []struct {
+		name    string
+		layout  VertexLayout
+		attribs []ShaderAttrib
+		ok      bool
+	}{
+		{"match", layout, []ShaderAttrib{
+			{Name: "aPos", Location: 0, Type: gl.FLOAT_VEC3, Size: 1},
+			{Name: "aUV", Location: 2, Type: gl.FLOAT_VEC2, Size: 1},
+			{Name: "gl_VertexID", Location: -1, Type: gl.INT, Size: 1},
+		}, true},
+		{"instance mat4", combined, []ShaderAttrib{
+			{Name: "aModel", Location: 5, Type: gl.FLOAT_MAT4, Size: 1},
+			{Name: "aLayer", Location: 10, Type: gl.FLOAT, Size: 1},
+		}, true},
+		{"missing", layout, []ShaderAttrib{{Name: "aModel", Location: 5, Type: gl.FLOAT_MAT4, Size: 1}}, false},
+		{"components", layout, []ShaderAttrib{{Name: "aPos", Location: 0, Type: gl.FLOAT_VEC4, Size: 1}}, false},
+		{"integer", layout, []ShaderAttrib{{Name: "aColor", Location: 4, Type: gl.INT_VEC4, Size: 1}}, false},
+		{"double", layout, []ShaderAttrib{{Name: "aPos", Location: 0, Type: gl.DOUBLE_VEC3, Size: 1}}, false},
+		{"array", layout, []ShaderAttrib{{Name: "aData", Location: 3, Type: gl.FLOAT_VEC4, Size: 3}}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.layout.validate(tt.attribs); (err == nil) != tt.ok {
+			t.Errorf("%s: error %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
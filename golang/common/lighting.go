@@ -0,0 +1,483 @@
+package common
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// https://learnopengl-cn.github.io/02%20Lighting/06%20Multiple%20lights/
+// https://learnopengl-cn.github.io/05%20Advanced%20Lighting/01%20Advanced%20Lighting/
+
+type LightType int32
+
+const (
+	DirectionalLight LightType = 0
+	PointLight       LightType = 1
+	SpotLight        LightType = 2
+)
+
+// MaxLights PhongGLSL 中光源数组的长度
+const MaxLights = 16
+
+// Light 定向光只用到 Direction, 点光源只用到 Position 和衰减, 聚光灯都用到
+type Light struct {
+	Type      LightType
+	Position  mgl32.Vec3
+	Direction mgl32.Vec3 // 光线照射的方向
+
+	Ambient  mgl32.Vec3
+	Diffuse  mgl32.Vec3
+	Specular mgl32.Vec3
+
+	// 衰减 1/(Constant + Linear*d + Quadratic*d²)
+	Constant  float32
+	Linear    float32
+	Quadratic float32
+
+	// 聚光灯内外圆锥角的余弦, 两者之间平滑过渡
+	CutOff      float32
+	OuterCutOff float32
+}
+
+type LightOption func(*Light)
+
+// WithLightColor 环境光和漫反射按 learnopengl 的习惯取颜色的 0.05 和 0.8 倍
+func WithLightColor(color mgl32.Vec3) LightOption {
+	return func(l *Light) {
+		l.Ambient = color.Mul(0.05)
+		l.Diffuse = color.Mul(0.8)
+		l.Specular = color
+	}
+}
+
+// WithLightComponents 分别指定环境光、漫反射和镜面光的颜色
+func WithLightComponents(ambient, diffuse, specular mgl32.Vec3) LightOption {
+	return func(l *Light) {
+		l.Ambient = ambient
+		l.Diffuse = diffuse
+		l.Specular = specular
+	}
+}
+
+func WithAttenuation(constant, linear, quadratic float32) LightOption {
+	return func(l *Light) {
+		l.Constant = constant
+		l.Linear = linear
+		l.Quadratic = quadratic
+	}
+}
+
+// WithLightRange 按 Ogre3D 的经验公式取覆盖约 distance 距离的衰减系数
+func WithLightRange(distance float32) LightOption {
+	return func(l *Light) {
+		distance = max(distance, 1e-3)
+		l.Constant = 1
+		l.Linear = 4.5 / distance
+		l.Quadratic = 75 / (distance * distance)
+	}
+}
+
+// WithSpotCone 聚光灯内外圆锥的半角, 单位为角度
+func WithSpotCone(inner, outer float32) LightOption {
+	return func(l *Light) {
+		l.CutOff = float32(math.Cos(float64(mgl32.DegToRad(inner))))
+		l.OuterCutOff = float32(math.Cos(float64(mgl32.DegToRad(max(outer, inner)))))
+	}
+}
+
+func newLight(t LightType, position, direction mgl32.Vec3, opts []LightOption) Light {
+	l := Light{Type: t, Position: position, Direction: normalizeSafe(direction)}
+	WithLightColor(mgl32.Vec3{1, 1, 1})(&l)
+	WithAttenuation(1, 0.09, 0.032)(&l)
+	WithSpotCone(12.5, 17.5)(&l)
+
+	for _, opt := range opts {
+		opt(&l)
+	}
+	return l
+}
+
+func NewDirectionalLight(direction mgl32.Vec3, opts ...LightOption) Light {
+	return newLight(DirectionalLight, mgl32.Vec3{}, direction, opts)
+}
+
+func NewPointLight(position mgl32.Vec3, opts ...LightOption) Light {
+	return newLight(PointLight, position, mgl32.Vec3{}, opts)
+}
+
+func NewSpotLight(position, direction mgl32.Vec3, opts ...LightOption) Light {
+	return newLight(SpotLight, position, direction, opts)
+}
+
+// Attenuation 距离 distance 处的衰减, 定向光恒为 1
+func (l *Light) Attenuation(distance float32) float32 {
+	if l.Type == DirectionalLight {
+		return 1
+	}
+	return 1 / (l.Constant + l.Linear*distance + l.Quadratic*distance*distance)
+}
+
+// LightThreshold 衰减后的亮度低于该值时视为照不到, 用于计算光源的影响范围
+const LightThreshold = 5.0 / 256
+
+// Radius 亮度衰减到 LightThreshold 的距离, 定向光和不衰减的光源返回 math.MaxFloat32,
+// 亮度始终低于 LightThreshold 的光源返回 0, 表示照不到任何地方
+// ClusteredLights 不会把半径为 0 的光源分配到簇, ClusterGLSL 在接近半径时把衰减平滑地降到 0
+func (l *Light) Radius() float32 {
+	if l.Type == DirectionalLight {
+		return math.MaxFloat32
+	}
+
+	brightness := max(l.Diffuse[0], l.Diffuse[1], l.Diffuse[2], l.Specular[0], l.Specular[1], l.Specular[2])
+	a := brightness / LightThreshold
+	switch {
+	case a <= l.Constant:
+		return 0
+	case l.Quadratic > 0:
+		q, b, c := float64(l.Quadratic), float64(l.Linear), float64(l.Constant-a)
+		return float32((-b + math.Sqrt(b*b-4*q*c)) / (2 * q))
+	case l.Linear > 0:
+		return (a - l.Constant) / l.Linear
+	default:
+		return math.MaxFloat32
+	}
+}
+
+// Bind 设置 GLSL 中的 Light 结构体, name 例如 "lights[0]", 着色器中没有用到的字段会被忽略
+func (l *Light) Bind(s *Shader, name string) {
+	loc := func(field string) int32 {
+		return gl.GetUniformLocation(s.ID, gl.Str(name+"."+field+CNull))
+	}
+	vec3 := func(field string, v mgl32.Vec3) {
+		gl.Uniform3f(loc(field), v[0], v[1], v[2])
+	}
+
+	gl.Uniform1i(loc("type"), int32(l.Type))
+	vec3("position", l.Position)
+	gl.Uniform1f(loc("radius"), l.Radius())
+	vec3("direction", l.Direction)
+	vec3("ambient", l.Ambient)
+	vec3("diffuse", l.Diffuse)
+	vec3("specular", l.Specular)
+	gl.Uniform1f(loc("constant"), l.Constant)
+	gl.Uniform1f(loc("linear"), l.Linear)
+	gl.Uniform1f(loc("quadratic"), l.Quadratic)
+	gl.Uniform1f(loc("cutOff"), l.CutOff)
+	gl.Uniform1f(loc("outerCutOff"), l.OuterCutOff)
+}
+
+// BindLights 设置 PhongGLSL 中的 lights 数组和 lightCount
+func BindLights(s *Shader, lights []Light) error {
+	if len(lights) > MaxLights {
+		return fmt.Errorf("too many lights: %d > %d", len(lights), MaxLights)
+	}
+
+	for i := range lights {
+		lights[i].Bind(s, fmt.Sprintf("lights[%d]", i))
+	}
+	gl.Uniform1i(gl.GetUniformLocation(s.ID, gl.Str("lightCount"+CNull)), int32(len(lights)))
+	return nil
+}
+
+// PhongMaterial GPU 端的 Phong 材质, 贴图为 nil 时只使用颜色
+// 有漫反射贴图时环境光和漫反射颜色都乘以贴图颜色, 镜面和自发光贴图同理
+type PhongMaterial struct {
+	Ambient   mgl32.Vec3
+	Diffuse   mgl32.Vec3
+	Specular  mgl32.Vec3
+	Emissive  mgl32.Vec3
+	Shininess float32
+
+	DiffuseMap  *Texture
+	SpecularMap *Texture
+	EmissiveMap *Texture
+}
+
+type PhongOption func(*PhongMaterial)
+
+// WithDiffuseColor 环境光颜色与漫反射相同
+func WithDiffuseColor(color mgl32.Vec3) PhongOption {
+	return func(m *PhongMaterial) {
+		m.Ambient = color
+		m.Diffuse = color
+	}
+}
+
+func WithSpecularColor(color mgl32.Vec3, shininess float32) PhongOption {
+	return func(m *PhongMaterial) {
+		m.Specular = color
+		m.Shininess = shininess
+	}
+}
+
+func WithEmissiveColor(color mgl32.Vec3) PhongOption {
+	return func(m *PhongMaterial) {
+		m.Emissive = color
+	}
+}
+
+// WithPhongMaps 设置漫反射、镜面和自发光贴图, 可以为 nil
+func WithPhongMaps(diffuse, specular, emissive *Texture) PhongOption {
+	return func(m *PhongMaterial) {
+		m.DiffuseMap = diffuse
+		m.SpecularMap = specular
+		m.EmissiveMap = emissive
+	}
+}
+
+func NewPhongMaterial(opts ...PhongOption) *PhongMaterial {
+	m := &PhongMaterial{
+		Ambient:   mgl32.Vec3{1, 1, 1},
+		Diffuse:   mgl32.Vec3{1, 1, 1},
+		Specular:  mgl32.Vec3{0.5, 0.5, 0.5},
+		Shininess: 32,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// LoadPhongMaterial 由 MTL 材质创建, 加载其中的漫反射、镜面和自发光贴图
+func LoadPhongMaterial(mtl *Material) (*PhongMaterial, error) {
+	m := &PhongMaterial{
+		Ambient:   mtl.Ambient,
+		Diffuse:   mtl.Diffuse,
+		Specular:  mtl.Specular,
+		Emissive:  mtl.Emissive,
+		Shininess: max(mtl.Shininess, 1),
+	}
+
+	maps := []struct {
+		path string
+		tex  **Texture
+	}{
+		{mtl.DiffuseMap, &m.DiffuseMap},
+		{mtl.SpecularMap, &m.SpecularMap},
+		{mtl.EmissiveMap, &m.EmissiveMap},
+	}
+	for _, t := range maps {
+		if t.path == "" {
+			continue
+		}
+		img, err := LoadImgRGB(t.path)
+		if err != nil {
+			m.Delete()
+			return nil, err
+		}
+		*t.tex = NewTexture(img, DefaultSampler())
+	}
+	return m, nil
+}
+
+// Bind 设置 PhongGLSL 中的 material, 贴图依次绑定到 unit、unit+1、unit+2
+func (m *PhongMaterial) Bind(s *Shader, unit uint32) {
+	loc := func(field string) int32 {
+		return gl.GetUniformLocation(s.ID, gl.Str("material."+field+CNull))
+	}
+	vec3 := func(field string, v mgl32.Vec3) {
+		gl.Uniform3f(loc(field), v[0], v[1], v[2])
+	}
+
+	vec3("ambient", m.Ambient)
+	vec3("diffuse", m.Diffuse)
+	vec3("specular", m.Specular)
+	vec3("emissive", m.Emissive)
+	gl.Uniform1f(loc("shininess"), m.Shininess)
+
+	maps := []struct {
+		sampler, has string
+		tex          *Texture
+	}{
+		{"diffuseMap", "hasDiffuseMap", m.DiffuseMap},
+		{"specularMap", "hasSpecularMap", m.SpecularMap},
+		{"emissiveMap", "hasEmissiveMap", m.EmissiveMap},
+	}
+	for i, t := range maps {
+		// 采样器即使没有贴图也指向各自的纹理单元
+		gl.Uniform1i(loc(t.sampler), int32(unit)+int32(i))
+		has := int32(0)
+		if t.tex != nil {
+			t.tex.Bind(unit + uint32(i))
+			has = 1
+		}
+		gl.Uniform1i(loc(t.has), has)
+	}
+}
+
+func (m *PhongMaterial) Delete() {
+	for _, t := range []*Texture{m.DiffuseMap, m.SpecularMap, m.EmissiveMap} {
+		if t != nil {
+			t.Delete()
+		}
+	}
+	m.DiffuseMap, m.SpecularMap, m.EmissiveMap = nil, nil, nil
+}
+
+// phongGLSL 材质和光源结构体, 以及单个光源的光照计算, PhongGLSL 和 ClusterGLSL 共用
+// Light 的字段顺序按 std430 排列, 与 GPULight 一致
+var phongGLSL = fmt.Sprintf(`
+#define DIRECTIONAL_LIGHT %d
+#define POINT_LIGHT %d
+#define SPOT_LIGHT %d
+
+struct Material {
+	vec3 ambient;
+	vec3 diffuse;
+	vec3 specular;
+	vec3 emissive;
+	float shininess;
+
+	sampler2D diffuseMap;
+	sampler2D specularMap;
+	sampler2D emissiveMap;
+	bool hasDiffuseMap;
+	bool hasSpecularMap;
+	bool hasEmissiveMap;
+};
+
+struct Light {
+	vec3 position;
+	float radius; // 影响范围, 见 Light.Radius, 只在 ClusterGLSL 中使用
+	vec3 direction;
+	int type;
+	vec3 ambient;
+	float constant;
+	vec3 diffuse;
+	float linear;
+	vec3 specular;
+	float quadratic;
+	float cutOff;
+	float outerCutOff;
+};
+
+uniform Material material;
+uniform bool phong;
+
+// Surface 采样贴图后的材质颜色
+struct Surface {
+	vec3 ambient;
+	vec3 diffuse;
+	vec3 specular;
+	vec3 emissive;
+};
+
+Surface phongSurface(vec2 uv)
+{
+	vec3 base = material.hasDiffuseMap ? texture(material.diffuseMap, uv).rgb : vec3(1.0);
+
+	Surface s;
+	s.ambient = material.ambient * base;
+	s.diffuse = material.diffuse * base;
+	s.specular = material.specular;
+	if (material.hasSpecularMap) {
+		s.specular *= texture(material.specularMap, uv).rgb;
+	}
+	s.emissive = material.emissive;
+	if (material.hasEmissiveMap) {
+		s.emissive *= texture(material.emissiveMap, uv).rgb;
+	}
+	return s;
+}
+
+vec3 phongLight(Light light, Surface s, vec3 fragPos, vec3 normal, vec3 viewDir)
+{
+	vec3 lightDir;
+	float attenuation = 1.0;
+	if (light.type == DIRECTIONAL_LIGHT) {
+		lightDir = normalize(-light.direction);
+	} else {
+		vec3 toLight = light.position - fragPos;
+		float distance = length(toLight);
+		lightDir = toLight / distance;
+		attenuation = 1.0 / (light.constant + light.linear * distance + light.quadratic * distance * distance);
+	}
+	if (light.type == SPOT_LIGHT) {
+		float theta = dot(lightDir, normalize(-light.direction));
+		float epsilon = light.cutOff - light.outerCutOff;
+		attenuation *= clamp((theta - light.outerCutOff) / max(epsilon, 1e-4), 0.0, 1.0);
+	}
+
+	float diff = max(dot(normal, lightDir), 0.0);
+	float spec = 0.0;
+	if (diff > 0.0) {
+		if (phong) {
+			spec = pow(max(dot(viewDir, reflect(-lightDir, normal)), 0.0), material.shininess);
+		} else {
+			spec = pow(max(dot(normal, normalize(lightDir + viewDir)), 0.0), material.shininess);
+		}
+	}
+
+	// 环境光也随距离衰减, 多个点光源时远处不会被照亮
+	return attenuation * (light.ambient * s.ambient +
+		light.diffuse * diff * s.diffuse +
+		light.specular * spec * s.specular);
+}
+`, DirectionalLight, PointLight, SpotLight)
+
+// PhongGLSL 片段着色器中的材质、光源和光照计算, 光源通过 uniform 数组传入, 放在 #version 之后使用:
+//
+//	vec3 color = phongLighting(FragPos, normalize(Normal), normalize(viewPos - FragPos), TexCoords);
+//
+// phong 为 true 时使用原始 Phong 的反射向量计算高光, 否则(默认)使用 Blinn-Phong 的半程向量
+var PhongGLSL = phongGLSL + fmt.Sprintf(`
+#define MAX_LIGHTS %d
+
+uniform Light lights[MAX_LIGHTS];
+uniform int lightCount;
+
+vec3 phongLighting(vec3 fragPos, vec3 normal, vec3 viewDir, vec2 uv)
+{
+	Surface s = phongSurface(uv);
+	vec3 result = s.emissive;
+	for (int i = 0; i < min(lightCount, MAX_LIGHTS); i++) {
+		result += phongLight(lights[i], s, fragPos, normal, viewDir);
+	}
+	return result;
+}
+`, MaxLights)
+
+// NewPhongShader 使用 PhongGLSL 的通用着色器, uniform 为 model、view、projection 和 viewPos
+func NewPhongShader() (*Shader, error) {
+	return NewShader(`
+#version 440 core
+layout (location = 0) in vec3 aPos;
+layout (location = 1) in vec3 aNormal;
+layout (location = 2) in vec2 aTexCoords;
+
+out vec3 FragPos;
+out vec3 Normal;
+out vec2 TexCoords;
+
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+
+void main()
+{
+	FragPos = vec3(model * vec4(aPos, 1.0));
+	Normal = mat3(transpose(inverse(model))) * aNormal;
+	TexCoords = aTexCoords;
+
+	gl_Position = projection * view * vec4(FragPos, 1.0);
+}`, `
+#version 440 core
+out vec4 FragColor;
+
+in vec3 FragPos;
+in vec3 Normal;
+in vec2 TexCoords;
+
+uniform vec3 viewPos;
+`+PhongGLSL+`
+void main()
+{
+	// 双面材质从背面看时翻转法线
+	vec3 normal = normalize(gl_FrontFacing ? Normal : -Normal);
+	FragColor = vec4(phongLighting(FragPos, normal, normalize(viewPos - FragPos), TexCoords), 1.0);
+}`)
+}
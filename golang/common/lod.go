@@ -0,0 +1,113 @@
+package common
+
+import (
+	"errors"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// LOD 一个细节层次, Error 为与原网格之间的近似距离(模型空间)
+type LOD struct {
+	Data  *MeshData
+	Error float32
+}
+
+// GenerateLODs 生成最多 levels 级 LOD, 第 0 级为原网格的副本,
+// 之后每级的三角形数为上一级的 ratio 倍(0~1), 误差超过 maxError(为 0 时不限制)或无法继续简化时提前结束
+func GenerateLODs(d *MeshData, levels int, ratio, maxError float32) []LOD {
+	lods := []LOD{{Data: d.Clone()}}
+	for len(lods) < levels {
+		prev := lods[len(lods)-1]
+		count := prev.Data.TriangleCount()
+		target := int(float32(count) * ratio)
+		if target < 1 {
+			break
+		}
+
+		// 每级都从上一级继续简化, 误差累加作为保守估计
+		next := prev.Data.Clone()
+		e := next.Simplify(target, maxError)
+		if next.TriangleCount() >= count {
+			break // 误差限制下已经简化不动了
+		}
+		lods = append(lods, LOD{Data: next, Error: prev.Error + e})
+	}
+	return lods
+}
+
+// LODChain 上传到 GPU 的 LOD, Meshes[0] 最精细
+type LODChain struct {
+	Meshes []*Mesh
+	Errors []float32
+	Sphere Sphere // 模型空间的包围球
+}
+
+func NewLODChain(lods []LOD) (*LODChain, error) {
+	if len(lods) == 0 {
+		return nil, errors.New("NewLODChain: no levels")
+	}
+
+	c := &LODChain{Sphere: lods[0].Data.BoundingSphere()}
+	for _, l := range lods {
+		m, err := l.Data.Upload()
+		if err != nil {
+			c.Delete()
+			return nil, err
+		}
+		c.Meshes = append(c.Meshes, m)
+		c.Errors = append(c.Errors, l.Error)
+	}
+	return c, nil
+}
+
+// ProjectedSize 模型空间中长度为 size、位于 center 的物体在屏幕上大约占多少像素
+// 只适用于透视投影, 物体在相机后面或包含相机时返回 +Inf
+func ProjectedSize(center mgl32.Vec3, size float32, model, view, projection mgl32.Mat4, viewportHeight int32) float32 {
+	mv := view.Mul4(model)
+	p := mgl32.TransformCoordinate(center, mv)
+
+	// 模型矩阵可能有缩放, 取最大的轴
+	scale := max(mv.Col(0).Vec3().Len(), mv.Col(1).Vec3().Len(), mv.Col(2).Vec3().Len())
+	dist := p.Len() - size*scale/2
+	if dist <= 0 {
+		return float32(math.Inf(1))
+	}
+
+	// projection[5] = 1/tan(fovy/2), 距离 dist 处屏幕高度对应 2*dist/projection[5]
+	return size * scale * projection[5] * float32(viewportHeight) / (2 * dist)
+}
+
+// Select 选择误差投影到屏幕后不超过 pixelError 个像素的最粗糙的一级
+func (c *LODChain) Select(model, view, projection mgl32.Mat4, viewportHeight int32, pixelError float32) int {
+	if c.Sphere.Radius <= 0 {
+		return len(c.Meshes) - 1
+	}
+
+	level := 0
+	for i, e := range c.Errors {
+		if e == 0 {
+			continue
+		}
+
+		// 用包围球离相机最近的点估计误差的投影
+		px := ProjectedSize(c.Sphere.Center, 2*c.Sphere.Radius, model, view, projection, viewportHeight) *
+			e / (2 * c.Sphere.Radius)
+		if px > pixelError {
+			break
+		}
+		level = i
+	}
+	return level
+}
+
+// Draw 绘制选择的一级
+func (c *LODChain) Draw(level int) {
+	c.Meshes[min(level, len(c.Meshes)-1)].Draw()
+}
+
+func (c *LODChain) Delete() {
+	for _, m := range c.Meshes {
+		m.Delete()
+	}
+}
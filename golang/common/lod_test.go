@@ -0,0 +1,93 @@
+package common
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestGenerateLODs(t *testing.T) {
+	src := GenTorus(1, 0.3, 48, 24)
+	lods := GenerateLODs(src, 6, 0.5, 0)
+	if len(lods) != 6 {
+		t.Fatalf("%d levels, want 6", len(lods))
+	}
+	if lods[0].Data.TriangleCount() != src.TriangleCount() || lods[0].Error != 0 {
+		t.Errorf("level 0: %d triangles error %v, want a copy", lods[0].Data.TriangleCount(), lods[0].Error)
+	}
+	for i := 1; i < len(lods); i++ {
+		prev, cur := lods[i-1], lods[i]
+		if n := cur.Data.TriangleCount(); n >= prev.Data.TriangleCount() || n > prev.Data.TriangleCount()/2 {
+			t.Errorf("level %d: %d triangles after %d", i, n, prev.Data.TriangleCount())
+		}
+		if cur.Error < prev.Error {
+			t.Errorf("level %d: error %v less than %v", i, cur.Error, prev.Error)
+		}
+	}
+
+	// 误差上限让链提前结束
+	limited := GenerateLODs(src, 20, 0.5, 0.01)
+	if len(limited) >= 20 {
+		t.Errorf("%d levels with an error limit", len(limited))
+	}
+	for i, l := range limited {
+		if l.Error > 0.01*float32(i) {
+			t.Errorf("level %d: error %v", i, l.Error)
+		}
+	}
+}
+
+func TestProjectedSize(t *testing.T) {
+	view := mgl32.LookAtV(mgl32.Vec3{0, 0, 10}, mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})
+	projection := mgl32.Perspective(mgl32.DegToRad(90), 1, 0.1, 100) // projection[5] = 1
+
+	// 直径 2 的物体, 最近点距离 9, 该处屏幕高度为 18
+	if px := ProjectedSize(mgl32.Vec3{}, 2, mgl32.Ident4(), view, projection, 600); abs(px-600*2/18.0) > 1e-3 {
+		t.Errorf("size %v px, want %v", px, 600*2/18.0)
+	}
+	// 缩放 2 倍的模型矩阵
+	if px := ProjectedSize(mgl32.Vec3{}, 1, mgl32.Scale3D(2, 2, 2), view, projection, 600); abs(px-600*2/18.0) > 1e-3 {
+		t.Errorf("scaled size %v px, want %v", px, 600*2/18.0)
+	}
+	// 包含相机
+	if px := ProjectedSize(mgl32.Vec3{0, 0, 10}, 2, mgl32.Ident4(), view, projection, 600); !math.IsInf(float64(px), 1) {
+		t.Errorf("size %v px around the camera, want +Inf", px)
+	}
+}
+
+func TestLODChainSelect(t *testing.T) {
+	chain := &LODChain{
+		Meshes: make([]*Mesh, 4),
+		Errors: []float32{0, 0.01, 0.05, 0.2},
+		Sphere: Sphere{Radius: 1},
+	}
+	projection := mgl32.Perspective(mgl32.DegToRad(90), 1, 0.1, 1000)
+
+	prev := 0
+	for _, dist := range []float32{2, 5, 10, 50, 100, 500} {
+		view := mgl32.Translate3D(0, 0, -dist)
+		level := chain.Select(mgl32.Ident4(), view, projection, 600, 1)
+		if level < prev {
+			t.Errorf("distance %v: level %d, closer objects used %d", dist, level, prev)
+		}
+		prev = level
+
+		// 选中一级的误差不超过 1 像素, 下一级超过
+		px := func(e float32) float32 {
+			return ProjectedSize(mgl32.Vec3{}, 2, mgl32.Ident4(), view, projection, 600) * e / 2
+		}
+		if px(chain.Errors[level]) > 1 {
+			t.Errorf("distance %v: level %d has %v px error", dist, level, px(chain.Errors[level]))
+		}
+		if level+1 < len(chain.Errors) && px(chain.Errors[level+1]) <= 1 {
+			t.Errorf("distance %v: level %d, but level %d is within 1 px", dist, level, level+1)
+		}
+	}
+	if prev != 3 {
+		t.Errorf("farthest level %d, want 3", prev)
+	}
+	if level := chain.Select(mgl32.Ident4(), mgl32.Ident4(), projection, 600, 1); level != 0 {
+		t.Errorf("camera inside the bounds: level %d, want 0", level)
+	}
+}
@@ -0,0 +1,468 @@
+package common
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Volume 规则网格上的标量场, 格点 (x, y, z) 位于 Origin + (x, y, z)*Spacing
+type Volume struct {
+	Width   int
+	Height  int
+	Depth   int
+	Data    []float32 // 下标为 (z*Height+y)*Width+x
+	Spacing mgl32.Vec3
+	Origin  mgl32.Vec3
+}
+
+func NewVolume(width, height, depth int) *Volume {
+	return &Volume{
+		Width:   width,
+		Height:  height,
+		Depth:   depth,
+		Data:    make([]float32, width*height*depth),
+		Spacing: mgl32.Vec3{1, 1, 1},
+	}
+}
+
+// VolumeFormat raw 体数据中每个体素的存储格式
+type VolumeFormat int
+
+const (
+	VolumeUint8 VolumeFormat = iota
+	VolumeUint16LE
+	VolumeUint16BE
+	VolumeInt16LE
+	VolumeInt16BE
+)
+
+// size 每个体素的字节数, 未知的格式返回 0
+func (f VolumeFormat) size() int {
+	switch f {
+	case VolumeUint8:
+		return 1
+	case VolumeUint16LE, VolumeUint16BE, VolumeInt16LE, VolumeInt16BE:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// LoadRawVolume 读取没有文件头的 raw 体数据, 按 x、y、z 的顺序存储
+func LoadRawVolume(name string, width, height, depth int, format VolumeFormat) (*Volume, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := ParseRawVolume(data, width, height, depth, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return v, nil
+}
+
+// ParseRawVolume 把 raw 数据转换为浮点数, 数值保持原样不做归一化
+func ParseRawVolume(data []byte, width, height, depth int, format VolumeFormat) (*Volume, error) {
+	if width < 2 || height < 2 || depth < 2 {
+		return nil, fmt.Errorf("ParseRawVolume: volume too small (%dx%dx%d)", width, height, depth)
+	}
+	n := width * height * depth
+	size := format.size()
+	if size == 0 {
+		return nil, fmt.Errorf("ParseRawVolume: unknown format %d", format)
+	}
+	if len(data) < n*size {
+		return nil, fmt.Errorf("ParseRawVolume: want %d bytes, got %d", n*size, len(data))
+	}
+
+	v := NewVolume(width, height, depth)
+	for i := range v.Data {
+		b := data[i*size:]
+		switch format {
+		case VolumeUint8:
+			v.Data[i] = float32(b[0])
+		case VolumeUint16LE:
+			v.Data[i] = float32(binary.LittleEndian.Uint16(b))
+		case VolumeUint16BE:
+			v.Data[i] = float32(binary.BigEndian.Uint16(b))
+		case VolumeInt16LE:
+			v.Data[i] = float32(int16(binary.LittleEndian.Uint16(b)))
+		case VolumeInt16BE:
+			v.Data[i] = float32(int16(binary.BigEndian.Uint16(b)))
+		}
+	}
+	return v, nil
+}
+
+// SampleVolume 在 [min, max] 范围内按 width*height*depth 个格点对函数采样, 各层并行计算
+func SampleVolume(width, height, depth int, min, max mgl32.Vec3, f func(p mgl32.Vec3) float32) *Volume {
+	v := NewVolume(width, height, depth)
+	v.Origin = min
+	size := max.Sub(min)
+	v.Spacing = mgl32.Vec3{size[0] / float32(width-1), size[1] / float32(height-1), size[2] / float32(depth-1)}
+
+	parallelSlabs(depth, 0, func(_, z0, z1 int) {
+		for z := z0; z < z1; z++ {
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					v.Data[(z*height+y)*width+x] = f(v.Point(x, y, z))
+				}
+			}
+		}
+	})
+	return v
+}
+
+// slabCount 实际使用的段数, workers 小于等于 0 时使用所有 CPU
+func slabCount(n, workers int) int {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return max(min(workers, n), 1)
+}
+
+// parallelSlabs 把 [0, n) 切成 slabCount 段并行执行, fn 的第一个参数为段号
+func parallelSlabs(n, workers int, fn func(i, z0, z1 int)) {
+	workers = slabCount(n, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		z0, z1 := n*i/workers, n*(i+1)/workers
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(i, z0, z1)
+		}()
+	}
+	wg.Wait()
+}
+
+// Point 格点的坐标
+func (v *Volume) Point(x, y, z int) mgl32.Vec3 {
+	return v.Origin.Add(mgl32.Vec3{float32(x) * v.Spacing[0], float32(y) * v.Spacing[1], float32(z) * v.Spacing[2]})
+}
+
+// At 格点上的值, 超出范围时取边界
+func (v *Volume) At(x, y, z int) float32 {
+	x = min(max(x, 0), v.Width-1)
+	y = min(max(y, 0), v.Height-1)
+	z = min(max(z, 0), v.Depth-1)
+	return v.Data[(z*v.Height+y)*v.Width+x]
+}
+
+// Gradient 用中心差分求格点处的梯度, 边界处使用单侧差分
+func (v *Volume) Gradient(x, y, z int) mgl32.Vec3 {
+	diff := func(a, b float32, i, lo, hi int, spacing float32) float32 {
+		n := min(i+1, hi) - max(i-1, lo)
+		if n == 0 {
+			return 0
+		}
+		return (b - a) / (float32(n) * spacing)
+	}
+	return mgl32.Vec3{
+		diff(v.At(x-1, y, z), v.At(x+1, y, z), x, 0, v.Width-1, v.Spacing[0]),
+		diff(v.At(x, y-1, z), v.At(x, y+1, z), y, 0, v.Height-1, v.Spacing[1]),
+		diff(v.At(x, y, z-1), v.At(x, y, z+1), z, 0, v.Depth-1, v.Spacing[2]),
+	}
+}
+
+type MarchingOption func(*marchingConfig)
+
+type marchingConfig struct {
+	workers int
+}
+
+// WithMarchingWorkers 按 z 方向切成 workers 段并行提取, 小于等于 0 时使用所有 CPU, 默认为 1
+func WithMarchingWorkers(workers int) MarchingOption {
+	return func(c *marchingConfig) {
+		c.workers = workers
+	}
+}
+
+// mcEdges 立方体的 12 条边, 角点 i 的坐标为 (i&1, i>>1&1, i>>2&1), 每条边从 corner 沿 axis 方向延伸
+var mcEdges = func() (edges [12]struct{ corner, axis int }) {
+	for axis := 0; axis < 3; axis++ {
+		k := 0
+		for c := 0; c < 8; c++ {
+			if c>>axis&1 == 0 {
+				edges[axis*4+k].corner, edges[axis*4+k].axis = c, axis
+				k++
+			}
+		}
+	}
+	return
+}()
+
+// mcTriangles 每种角点状态对应的三角形(边的下标), 在每个面上沿等值线把内部的角点分开,
+// 相邻立方体在公共面上的选择一致, 因此生成的网格是封闭的
+var mcTriangles = func() (table [256][]uint8) {
+	edgeOf := func(a, b int) int {
+		for i, e := range mcEdges {
+			if lo := min(a, b); e.corner == lo && lo|1<<e.axis == max(a, b) {
+				return i
+			}
+		}
+		panic("unexpected cube edge")
+	}
+
+	for mask := 1; mask < 255; mask++ {
+		inside := func(c int) bool { return mask>>c&1 != 0 }
+
+		// 每个面从外面看逆时针遍历角点, 由进入内部处连到离开内部处, 这样所有线段首尾相接成环
+		next := make(map[int]int)
+		for axis := 0; axis < 3; axis++ {
+			b, c := (axis+1)%3, (axis+2)%3
+			for side := 0; side < 2; side++ {
+				var quad [4]int
+				for k, uv := range [4][2]int{{0, 0}, {1, 0}, {1, 1}, {0, 1}} {
+					quad[k] = side<<axis | uv[0]<<b | uv[1]<<c
+				}
+				if side == 0 {
+					quad[1], quad[3] = quad[3], quad[1]
+				}
+
+				entry := -1
+				for k := 0; k < 4; k++ {
+					p, q := quad[k], quad[(k+1)%4]
+					switch {
+					case !inside(p) && inside(q):
+						entry = edgeOf(p, q)
+					case inside(p) && !inside(q) && entry >= 0:
+						next[entry] = edgeOf(p, q)
+						entry = -1
+					}
+				}
+				// 内部区间跨过了遍历的起点
+				if entry >= 0 {
+					for k := 0; k < 4; k++ {
+						p, q := quad[k], quad[(k+1)%4]
+						if inside(p) && !inside(q) {
+							next[entry] = edgeOf(p, q)
+							break
+						}
+					}
+				}
+			}
+		}
+
+		// 把环拆成扇形三角形
+		for len(next) > 0 {
+			start := -1
+			for e := range next {
+				if start < 0 || e < start {
+					start = e
+				}
+			}
+			loop := []int{start}
+			for e := next[start]; e != start; e = next[e] {
+				loop = append(loop, e)
+			}
+			for _, e := range loop {
+				delete(next, e)
+			}
+			tris, ok := triangulateLoop(loop, func(a, b int) bool { return !sameFace(a, b) })
+			if !ok {
+				tris, _ = triangulateLoop(loop, func(a, b int) bool { return true })
+			}
+			for _, e := range tris {
+				table[mask] = append(table[mask], uint8(e))
+			}
+		}
+	}
+	return
+}()
+
+// sameFace 两条边是否在立方体的同一个面上
+func sameFace(a, b int) bool {
+	ea, eb := mcEdges[a], mcEdges[b]
+	for axis := 0; axis < 3; axis++ {
+		if axis != ea.axis && axis != eb.axis && ea.corner>>axis&1 == eb.corner>>axis&1 {
+			return true
+		}
+	}
+	return false
+}
+
+// triangulateLoop 三角化等值线环, 对角线需要满足 valid; 对角线落在立方体的面上时,
+// 相邻立方体可能生成同一条边, 导致网格不是流形
+func triangulateLoop(loop []int, valid func(a, b int) bool) ([]int, bool) {
+	n := len(loop)
+	if n == 3 {
+		return loop, true
+	}
+
+	// 以 loop[0]、loop[k]、loop[n-1] 为一个三角形, 剩下两边递归
+	for k := 1; k < n-1; k++ {
+		if k > 1 && !valid(loop[0], loop[k]) || k < n-2 && !valid(loop[k], loop[n-1]) {
+			continue
+		}
+
+		tris := []int{loop[0], loop[k], loop[n-1]}
+		if k > 1 {
+			left, ok := triangulateLoop(loop[:k+1], valid)
+			if !ok {
+				continue
+			}
+			tris = append(tris, left...)
+		}
+		if k < n-2 {
+			right, ok := triangulateLoop(loop[k:], valid)
+			if !ok {
+				continue
+			}
+			tris = append(tris, right...)
+		}
+		return tris, true
+	}
+	return nil, false
+}
+
+// mcSlab 一段 z 范围内提取的结果, bottom/top 记录两端平面上 x/y 方向边的顶点, 用于合并时去重
+type mcSlab struct {
+	positions   []mgl32.Vec3
+	normals     []mgl32.Vec3
+	indices     []uint32
+	bottom, top []int32
+}
+
+// MarchingCubes 提取值等于 iso 的等值面, 值大于 iso 的一侧为内部, 法线由梯度插值得到并指向外部;
+// 内部值较小的场(例如有向距离场)可以先取反
+func (v *Volume) MarchingCubes(iso float32, opts ...MarchingOption) *MeshData {
+	cfg := marchingConfig{workers: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	cells := v.Depth - 1
+	slabs := make([]mcSlab, slabCount(cells, cfg.workers))
+	parallelSlabs(cells, cfg.workers, func(i, z0, z1 int) {
+		slabs[i] = v.marchSlab(iso, z0, z1)
+	})
+
+	// 合并各段, 相邻段公共平面上的顶点只保留一份
+	d := &MeshData{}
+	var prevTop []int32
+	for _, s := range slabs {
+		remap := make([]uint32, len(s.positions))
+		shared := make([]bool, len(s.positions))
+		if prevTop != nil {
+			for i, local := range s.bottom {
+				if local >= 0 && prevTop[i] >= 0 {
+					remap[local] = uint32(prevTop[i])
+					shared[local] = true
+				}
+			}
+		}
+		for i := range s.positions {
+			if !shared[i] {
+				remap[i] = uint32(len(d.Positions))
+				d.Positions = append(d.Positions, s.positions[i])
+				d.Normals = append(d.Normals, s.normals[i])
+			}
+		}
+		for _, i := range s.indices {
+			d.Indices = append(d.Indices, remap[i])
+		}
+
+		prevTop = make([]int32, len(s.top))
+		for i, local := range s.top {
+			prevTop[i] = -1
+			if local >= 0 {
+				prevTop[i] = int32(remap[local])
+			}
+		}
+	}
+	return d
+}
+
+func (v *Volume) marchSlab(iso float32, z0, z1 int) mcSlab {
+	w, h := v.Width, v.Height
+	plane := w * h * 3
+
+	// lower 为当前层底面上所有边和竖直边的顶点, upper 为顶面上 x/y 方向边的顶点
+	var s mcSlab
+	lower, upper := make([]int32, plane), make([]int32, plane)
+	for i := range lower {
+		lower[i], upper[i] = -1, -1
+	}
+
+	for z := z0; z < z1; z++ {
+		edge := func(x, y, dz, axis int) uint32 {
+			layer := lower
+			if dz > 0 {
+				layer = upper
+			}
+			i := (y*w+x)*3 + axis
+			if layer[i] >= 0 {
+				return uint32(layer[i])
+			}
+
+			// 在边的两个端点间线性插值位置和梯度
+			ex, ey, ez := x, y, z+dz
+			fx, fy, fz := ex, ey, ez
+			switch axis {
+			case 0:
+				fx++
+			case 1:
+				fy++
+			case 2:
+				fz++
+			}
+			a, b := v.At(ex, ey, ez), v.At(fx, fy, fz)
+			t := (iso - a) / (b - a)
+			p := v.Point(ex, ey, ez).Mul(1 - t).Add(v.Point(fx, fy, fz).Mul(t))
+			g := v.Gradient(ex, ey, ez).Mul(1 - t).Add(v.Gradient(fx, fy, fz).Mul(t))
+			n := g.Mul(-1)
+			if l := n.Len(); l > 0 {
+				n = n.Mul(1 / l)
+			}
+
+			layer[i] = int32(len(s.positions))
+			s.positions = append(s.positions, p)
+			s.normals = append(s.normals, n)
+			return uint32(layer[i])
+		}
+
+		for y := 0; y < h-1; y++ {
+			for x := 0; x < w-1; x++ {
+				mask := 0
+				for c := 0; c < 8; c++ {
+					if v.At(x+c&1, y+c>>1&1, z+c>>2&1) > iso {
+						mask |= 1 << c
+					}
+				}
+				for _, e := range mcTriangles[mask] {
+					ed := mcEdges[e]
+					c := ed.corner
+					s.indices = append(s.indices, edge(x+c&1, y+c>>1&1, c>>2&1, ed.axis))
+				}
+			}
+		}
+
+		if z == z0 {
+			s.bottom = xyEdges(lower)
+		}
+		// 顶面成为下一层的底面, 竖直边需要重新生成
+		lower, upper = upper, lower
+		for i := range upper {
+			upper[i] = -1
+		}
+	}
+	s.top = xyEdges(lower)
+	return s
+}
+
+// xyEdges 取出一个平面上 x/y 方向边的顶点, 竖直边不会被相邻的段共享
+func xyEdges(layer []int32) []int32 {
+	out := make([]int32, 0, len(layer)/3*2)
+	for i := 0; i < len(layer); i += 3 {
+		out = append(out, layer[i], layer[i+1])
+	}
+	return out
+}
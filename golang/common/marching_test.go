@@ -0,0 +1,108 @@
+package common
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestParseRawVolume(t *testing.T) {
+	want := []float32{0, 1, 2, 255, 256, 1000, 32767, 65535}
+	signed := []float32{0, 1, 2, 255, 256, 1000, 32767, -1}
+
+	tests := []struct {
+		format VolumeFormat
+		data   []byte
+		want   []float32
+	}{
+		{VolumeUint8, []byte{0, 1, 2, 255, 0, 232, 127, 255}, []float32{0, 1, 2, 255, 0, 232, 127, 255}},
+		{VolumeUint16LE, []byte{0, 0, 1, 0, 2, 0, 255, 0, 0, 1, 0xe8, 3, 0xff, 0x7f, 0xff, 0xff}, want},
+		{VolumeUint16BE, []byte{0, 0, 0, 1, 0, 2, 0, 255, 1, 0, 3, 0xe8, 0x7f, 0xff, 0xff, 0xff}, want},
+		{VolumeInt16LE, []byte{0, 0, 1, 0, 2, 0, 255, 0, 0, 1, 0xe8, 3, 0xff, 0x7f, 0xff, 0xff}, signed},
+		{VolumeInt16BE, []byte{0, 0, 0, 1, 0, 2, 0, 255, 1, 0, 3, 0xe8, 0x7f, 0xff, 0xff, 0xff}, signed},
+	}
+	for _, tt := range tests {
+		v, err := ParseRawVolume(tt.data, 2, 2, 2, tt.format)
+		if err != nil {
+			t.Fatalf("format %d: %v", tt.format, err)
+		}
+		if !slices.Equal(v.Data, tt.want) {
+			t.Errorf("format %d: %v, want %v", tt.format, v.Data, tt.want)
+		}
+		if v.At(1, 1, 1) != tt.want[7] || v.At(1, 0, 1) != tt.want[5] {
+			t.Errorf("format %d: At does not follow the x, y, z order", tt.format)
+		}
+	}
+
+	if _, err := ParseRawVolume(make([]byte, 8), 2, 2, 2, VolumeFormat(99)); err == nil {
+		t.Error("unknown format accepted")
+	}
+	if _, err := ParseRawVolume(make([]byte, 15), 2, 2, 2, VolumeUint16LE); err == nil {
+		t.Error("short data accepted")
+	}
+	if _, err := ParseRawVolume(make([]byte, 8), 1, 2, 4, VolumeUint8); err == nil {
+		t.Error("flat volume accepted")
+	}
+}
+
+// sphereVolume 半径为 r 的球, 内部为正值
+func sphereVolume(n int, r float32) *Volume {
+	return SampleVolume(n, n, n, mgl32.Vec3{-1, -1, -1}, mgl32.Vec3{1, 1, 1}, func(p mgl32.Vec3) float32 {
+		return r - p.Len()
+	})
+}
+
+func TestMarchingCubesSphere(t *testing.T) {
+	const r = 0.7
+	v := sphereVolume(24, r)
+	d := v.MarchingCubes(0)
+	if d.TriangleCount() == 0 {
+		t.Fatal("empty mesh")
+	}
+
+	// 封闭的流形: 每条边正好属于两个三角形, 且两个三角形方向相反
+	edges := make(map[[2]uint32]int)
+	for i := 0; i < d.TriangleCount(); i++ {
+		a, b, c := d.Triangle(i)
+		if a == b || b == c || c == a {
+			t.Fatalf("triangle %d is degenerate", i)
+		}
+		for _, e := range [3][2]uint32{{a, b}, {b, c}, {c, a}} {
+			edges[e]++
+		}
+	}
+	for e, n := range edges {
+		if n != 1 || edges[[2]uint32{e[1], e[0]}] != 1 {
+			t.Fatalf("edge %v used %d times, opposite %d times", e, n, edges[[2]uint32{e[1], e[0]}])
+		}
+	}
+
+	// 欧拉示性数为 2
+	if chi := len(d.Positions) - len(edges)/2 + d.TriangleCount(); chi != 2 {
+		t.Errorf("Euler characteristic %d, want 2", chi)
+	}
+
+	spacing := v.Spacing[0]
+	for i, p := range d.Positions {
+		if e := abs(p.Len() - r); e > spacing/4 {
+			t.Errorf("vertex %d: %v is %v from the sphere", i, p, e)
+		}
+		if n := d.Normals[i]; n.Dot(p.Normalize()) < 0.95 {
+			t.Errorf("vertex %d: normal %v does not point outward", i, n)
+		}
+	}
+}
+
+func TestMarchingCubesWorkers(t *testing.T) {
+	v := sphereVolume(33, 0.8)
+	want := v.MarchingCubes(0, WithMarchingWorkers(1))
+	for _, workers := range []int{2, 3, 7, 32, 0} {
+		got := v.MarchingCubes(0, WithMarchingWorkers(workers))
+		if !slices.Equal(got.Positions, want.Positions) || !slices.Equal(got.Normals, want.Normals) ||
+			!slices.Equal(got.Indices, want.Indices) {
+			t.Errorf("%d workers: %d vertices %d triangles, want %d and %d",
+				workers, len(got.Positions), got.TriangleCount(), len(want.Positions), want.TriangleCount())
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package common
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+)
+
+// VertexAttrib 顶点属性描述,对应一次 glVertexAttribPointer
+type VertexAttrib struct {
+	Name       string
+	Location   uint32
+	Components int32  // 分量个数 1~4
+	Type       uint32 // gl.FLOAT, gl.UNSIGNED_BYTE 等, gl.DOUBLE 使用 glVertexAttribLPointer, 着色器中为 double/dvec
+	Normalized bool
+	Integer    bool   // 使用 glVertexAttribIPointer, 着色器中为 ivec/uvec
+	Offset     int    // 相对顶点起始位置的字节偏移
+	Divisor    uint32 // 实例属性每隔多少个实例前进一次, 0 表示逐顶点
+}
+
+func Float(name string, components int32) VertexAttrib {
+	return VertexAttrib{Name: name, Components: components, Type: gl.FLOAT}
+}
+
+// VertexLayout 交错存储的顶点布局
+type VertexLayout struct {
+	Attribs []VertexAttrib
+	Stride  int32 // 一个顶点的字节数
+}
+
+// NewVertexLayout 按顺序排列属性,location 依次为 0,1,2...,自动计算偏移和步长
+func NewVertexLayout(attribs ...VertexAttrib) VertexLayout {
+	offset := 0
+	location := uint32(0)
+	for i := range attribs {
+		attribs[i].Location = location
+		attribs[i].Offset = offset
+		offset += int(attribs[i].Components) * glTypeSize(attribs[i].Type)
+		location += attribs[i].locations()
+	}
+
+	return VertexLayout{Attribs: attribs, Stride: int32(offset)}
+}
+
+// locations 属性占用的 location 数, dvec3 和 dvec4 占用两个
+func (a VertexAttrib) locations() uint32 {
+	if a.Type == gl.DOUBLE && a.Components > 2 {
+		return 2
+	}
+	return 1
+}
+
+func glTypeSize(t uint32) int {
+	switch t {
+	case gl.BYTE, gl.UNSIGNED_BYTE:
+		return 1
+	case gl.SHORT, gl.UNSIGNED_SHORT, gl.HALF_FLOAT:
+		return 2
+	case gl.INT, gl.UNSIGNED_INT, gl.FLOAT:
+		return 4
+	case gl.DOUBLE:
+		return 8
+	default:
+		panic("unexpected gl type")
+	}
+}
+
+// bind 在当前绑定的 VAO 和 ARRAY_BUFFER 上设置属性指针
+func (l VertexLayout) bind() {
+	for _, a := range l.Attribs {
+		switch {
+		case a.Integer:
+			gl.VertexAttribIPointerWithOffset(a.Location, a.Components, a.Type, l.Stride, uintptr(a.Offset))
+		case a.Type == gl.DOUBLE:
+			gl.VertexAttribLPointerWithOffset(a.Location, a.Components, a.Type, l.Stride, uintptr(a.Offset))
+		default:
+			gl.VertexAttribPointerWithOffset(a.Location, a.Components, a.Type, a.Normalized, l.Stride, uintptr(a.Offset))
+		}
+		gl.EnableVertexAttribArray(a.Location)
+		gl.VertexAttribDivisor(a.Location, a.Divisor)
+	}
+}
+
+// Mesh 封装 VAO/VBO/EBO
+type Mesh struct {
+	VAO uint32
+	VBO uint32
+	EBO uint32 // 为 0 时使用 glDrawArrays
+
+	Mode   uint32 // 图元类型,默认 gl.TRIANGLES
+	Count  int32  // 顶点数或索引数
+	Layout VertexLayout
+}
+
+// NewMesh 上传顶点和可选的索引,vertices 可以是 []float32 或任意与 layout 对应的结构体切片
+func NewMesh[T any](layout VertexLayout, vertices []T, indices []uint32) (*Mesh, error) {
+	size := len(vertices) * int(unsafe.Sizeof(*new(T)))
+	if layout.Stride <= 0 || size%int(layout.Stride) != 0 {
+		return nil, fmt.Errorf("NewMesh: %d bytes is not a multiple of stride %d", size, layout.Stride)
+	}
+
+	m := &Mesh{
+		Mode:   gl.TRIANGLES,
+		Count:  int32(size / int(layout.Stride)),
+		Layout: layout,
+	}
+
+	gl.GenVertexArrays(1, &m.VAO)
+	gl.BindVertexArray(m.VAO)
+
+	gl.GenBuffers(1, &m.VBO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.VBO)
+	gl.BufferData(gl.ARRAY_BUFFER, size, unsafe.Pointer(unsafe.SliceData(vertices)), gl.STATIC_DRAW)
+
+	if len(indices) > 0 {
+		// EBO 的绑定状态记录在 VAO 中
+		gl.GenBuffers(1, &m.EBO)
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.EBO)
+		gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(indices), gl.STATIC_DRAW)
+		m.Count = int32(len(indices))
+	}
+
+	layout.bind()
+
+	gl.BindVertexArray(0)
+	return m, nil
+}
+
+func (m *Mesh) Draw() {
+	gl.BindVertexArray(m.VAO)
+	if m.EBO != 0 {
+		gl.DrawElements(m.Mode, m.Count, gl.UNSIGNED_INT, gl.PtrOffset(0))
+	} else {
+		gl.DrawArrays(m.Mode, 0, m.Count)
+	}
+}
+
+func (m *Mesh) DrawInstanced(instances int32) {
+	gl.BindVertexArray(m.VAO)
+	if m.EBO != 0 {
+		gl.DrawElementsInstanced(m.Mode, m.Count, gl.UNSIGNED_INT, gl.PtrOffset(0), instances)
+	} else {
+		gl.DrawArraysInstanced(m.Mode, 0, m.Count, instances)
+	}
+}
+
+func (m *Mesh) Delete() {
+	gl.DeleteVertexArrays(1, &m.VAO)
+	gl.DeleteBuffers(1, &m.VBO)
+	if m.EBO != 0 {
+		gl.DeleteBuffers(1, &m.EBO)
+	}
+}
@@ -0,0 +1,655 @@
+package common
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// VertexCacheSize 顶点缓存优化假设的 post-transform 缓存大小
+const VertexCacheSize = 32
+
+// Bounds 顶点的轴对齐包围盒
+func (d *MeshData) Bounds() AABB {
+	return NewAABB(d.Positions...)
+}
+
+// BoundingSphere Ritter 算法求近似最小包围球
+func (d *MeshData) BoundingSphere() Sphere {
+	if len(d.Positions) == 0 {
+		return Sphere{}
+	}
+
+	farthest := func(from mgl32.Vec3) mgl32.Vec3 {
+		best, dist := from, float32(-1)
+		for _, p := range d.Positions {
+			if l := p.Sub(from).Len(); l > dist {
+				best, dist = p, l
+			}
+		}
+		return best
+	}
+
+	a := farthest(d.Positions[0])
+	b := farthest(a)
+	s := Sphere{Center: a.Add(b).Mul(0.5), Radius: b.Sub(a).Len() / 2}
+
+	for _, p := range d.Positions {
+		l := p.Sub(s.Center).Len()
+		if l > s.Radius {
+			// 扩大球体恰好包含该点
+			r := (s.Radius + l) / 2
+			s.Center = s.Center.Add(p.Sub(s.Center).Mul((r - s.Radius) / l))
+			s.Radius = r
+		}
+	}
+	return s
+}
+
+// Unweld 展开索引, 每个图元的顶点独立
+func (d *MeshData) Unweld() {
+	if len(d.Indices) == 0 {
+		return
+	}
+
+	d.gatherVertices(d.Indices)
+	d.Indices = nil
+}
+
+// gatherVertices 按 order 重新排列所有顶点属性
+func (d *MeshData) gatherVertices(order []uint32) {
+	d.Positions = gather(d.Positions, order)
+	d.Normals = gather(d.Normals, order)
+	d.UVs = gather(d.UVs, order)
+	d.Tangents = gather(d.Tangents, order)
+	d.Colors = gather(d.Colors, order)
+	d.Joints = gather(d.Joints, order)
+	d.Weights = gather(d.Weights, order)
+	for i := range d.Targets {
+		t := &d.Targets[i]
+		t.Positions = gather(t.Positions, order)
+		t.Normals = gather(t.Normals, order)
+		t.Tangents = gather(t.Tangents, order)
+	}
+}
+
+func gather[T any](src []T, indices []uint32) []T {
+	if len(src) == 0 {
+		return src
+	}
+
+	out := make([]T, len(indices))
+	for i, idx := range indices {
+		out[i] = src[idx]
+	}
+	return out
+}
+
+// Weld 合并所有属性都相同的顶点并生成索引, epsilon 为 0 时要求完全相等,
+// 否则属性按 epsilon 量化后比较
+func (d *MeshData) Weld(epsilon float32) {
+	type key struct {
+		attribs [27]int64
+		morph   string // 变形目标的偏移, 没有时为空
+	}
+
+	quantize := func(v float32) int64 {
+		if epsilon > 0 {
+			return int64(math.Round(float64(v / epsilon)))
+		}
+		if v == 0 {
+			return 0 // +0 和 -0 视为相同
+		}
+		return int64(math.Float32bits(v))
+	}
+
+	vertexKey := func(i int) key {
+		var k key
+		n := 0
+		put := func(v ...float32) {
+			for _, f := range v {
+				k.attribs[n] = quantize(f)
+				n++
+			}
+		}
+
+		put(d.Positions[i][:]...)
+		if len(d.Normals) > 0 {
+			put(d.Normals[i][:]...)
+		}
+		if len(d.UVs) > 0 {
+			put(d.UVs[i][:]...)
+		}
+		if len(d.Tangents) > 0 {
+			put(d.Tangents[i][:]...)
+		}
+		if len(d.Colors) > 0 {
+			put(d.Colors[i][:]...)
+		}
+		if len(d.Joints) > 0 {
+			for _, j := range d.Joints[i] {
+				k.attribs[n] = int64(j)
+				n++
+			}
+		}
+		if len(d.Weights) > 0 {
+			put(d.Weights[i][:]...)
+		}
+
+		// 偏移不同的顶点变形后会分开, 不能合并
+		if len(d.Targets) > 0 {
+			var morph []byte
+			for _, t := range d.Targets {
+				for _, list := range [][]mgl32.Vec3{t.Positions, t.Normals, t.Tangents} {
+					if len(list) == 0 {
+						continue
+					}
+					for _, f := range list[i] {
+						morph = binary.LittleEndian.AppendUint64(morph, uint64(quantize(f)))
+					}
+				}
+			}
+			k.morph = string(morph)
+		}
+		return k
+	}
+
+	var (
+		seen  = make(map[key]uint32, len(d.Positions))
+		remap = make([]uint32, len(d.Positions))
+		keep  []uint32
+	)
+	for i := range d.Positions {
+		k := vertexKey(i)
+		v, ok := seen[k]
+		if !ok {
+			v = uint32(len(keep))
+			seen[k] = v
+			keep = append(keep, uint32(i))
+		}
+		remap[i] = v
+	}
+
+	indices := d.Indices
+	if len(indices) == 0 {
+		indices = sequence(uint32(len(d.Positions)))
+	}
+	for i, idx := range indices {
+		indices[i] = remap[idx]
+	}
+
+	d.gatherVertices(keep)
+	d.Indices = indices
+}
+
+// WeldArray 合并交错的 float 顶点数组中完全相同的顶点, stride 为每个顶点的 float 个数
+func WeldArray(data []float32, stride int) ([]float32, []uint32) {
+	var (
+		seen     = make(map[string]uint32)
+		vertices = make([]float32, 0, len(data))
+		indices  = make([]uint32, 0, len(data)/stride)
+		buf      = make([]byte, 4*stride)
+	)
+
+	for i := 0; i+stride <= len(data); i += stride {
+		for j, f := range data[i : i+stride] {
+			if f == 0 {
+				f = 0 // -0 按 +0 处理
+			}
+			b := math.Float32bits(f)
+			buf[4*j], buf[4*j+1], buf[4*j+2], buf[4*j+3] = byte(b), byte(b>>8), byte(b>>16), byte(b>>24)
+		}
+
+		v, ok := seen[string(buf)]
+		if !ok {
+			v = uint32(len(vertices) / stride)
+			seen[string(buf)] = v
+			vertices = append(vertices, data[i:i+stride]...)
+		}
+		indices = append(indices, v)
+	}
+
+	return vertices, indices
+}
+
+// faceNormal 三角形的单位法线, 退化三角形返回零向量
+func faceNormal(a, b, c mgl32.Vec3) mgl32.Vec3 {
+	n := b.Sub(a).Cross(c.Sub(a))
+	if l := n.Len(); l > 1e-12 {
+		return n.Mul(1 / l)
+	}
+	return mgl32.Vec3{}
+}
+
+// cornerAngle 三角形在顶点 a 处的内角
+func cornerAngle(a, b, c mgl32.Vec3) float32 {
+	e1, e2 := b.Sub(a), c.Sub(a)
+	l := e1.Len() * e2.Len()
+	if l < 1e-12 {
+		return 0
+	}
+	return float32(math.Acos(float64(mgl32.Clamp(e1.Dot(e2)/l, -1, 1))))
+}
+
+// FlatNormals 每个三角形使用自己的面法线, 顶点会按面拆分后重新合并
+func (d *MeshData) FlatNormals() {
+	if d.Topology != Triangles {
+		return
+	}
+
+	d.Unweld()
+	d.Normals = make([]mgl32.Vec3, len(d.Positions))
+	for i := 0; i+2 < len(d.Positions); i += 3 {
+		n := faceNormal(d.Positions[i], d.Positions[i+1], d.Positions[i+2])
+		d.Normals[i], d.Normals[i+1], d.Normals[i+2] = n, n, n
+	}
+	d.Tangents = nil
+	d.Weld(0)
+}
+
+// SmoothNormals 按内角加权平均相邻面的法线, 面法线夹角超过 creaseAngle(角度) 的面不参与平均,
+// 从而保留硬边; creaseAngle >= 180 时完全平滑
+func (d *MeshData) SmoothNormals(creaseAngle float32) {
+	if d.Topology != Triangles {
+		return
+	}
+
+	d.Unweld()
+	var (
+		pos     = d.Positions
+		faces   = len(pos) / 3
+		normals = make([]mgl32.Vec3, faces)
+		weights = make([]float32, len(pos))
+		corners = make(map[mgl32.Vec3][]int) // 相同位置的所有角
+	)
+	for f := 0; f < faces; f++ {
+		a, b, c := pos[3*f], pos[3*f+1], pos[3*f+2]
+		normals[f] = faceNormal(a, b, c)
+		weights[3*f] = cornerAngle(a, b, c)
+		weights[3*f+1] = cornerAngle(b, c, a)
+		weights[3*f+2] = cornerAngle(c, a, b)
+		for k := 0; k < 3; k++ {
+			corners[pos[3*f+k]] = append(corners[pos[3*f+k]], 3*f+k)
+		}
+	}
+
+	limit := float32(math.Cos(float64(mgl32.DegToRad(creaseAngle))))
+	if creaseAngle >= 180 {
+		limit = -2
+	}
+
+	d.Normals = make([]mgl32.Vec3, len(pos))
+	for i := range pos {
+		fn := normals[i/3]
+		var sum mgl32.Vec3
+		for _, j := range corners[pos[i]] {
+			if on := normals[j/3]; fn.Dot(on) >= limit {
+				sum = sum.Add(on.Mul(weights[j]))
+			}
+		}
+		if sum.Len() > 1e-12 {
+			d.Normals[i] = sum.Normalize()
+		} else {
+			d.Normals[i] = fn
+		}
+	}
+
+	d.Tangents = nil
+	d.Weld(0)
+}
+
+// GenerateTangents 按 MikkTSpace 的思路生成切线: 每个角的切线投影到法线平面后按内角加权,
+// 只在位置、法线、UV 和副切线方向都相同的角之间平均, 镜像的 UV 接缝会拆分顶点
+func (d *MeshData) GenerateTangents() {
+	if d.Topology != Triangles || len(d.Normals) != len(d.Positions) || len(d.UVs) != len(d.Positions) {
+		return
+	}
+
+	d.Unweld()
+	type key struct {
+		p, n mgl32.Vec3
+		uv   mgl32.Vec2
+		sign float32
+	}
+
+	var (
+		pos      = d.Positions
+		tangents = make([]mgl32.Vec3, len(pos))
+		signs    = make([]float32, len(pos))
+		sums     = make(map[key]mgl32.Vec3)
+	)
+	for f := 0; f+2 < len(pos); f += 3 {
+		t, b, ok := triangleTangent(pos[f], pos[f+1], pos[f+2], d.UVs[f], d.UVs[f+1], d.UVs[f+2])
+		for k := 0; k < 3; k++ {
+			i := f + k
+			n := d.Normals[i]
+			signs[i] = 1
+			if !ok {
+				continue
+			}
+
+			tc := t.Sub(n.Mul(n.Dot(t)))
+			if tc.Len() < 1e-12 {
+				continue
+			}
+			if n.Cross(t).Dot(b) < 0 {
+				signs[i] = -1
+			}
+			w := cornerAngle(pos[i], pos[f+(k+1)%3], pos[f+(k+2)%3])
+			tangents[i] = tc.Normalize().Mul(w)
+		}
+	}
+
+	for i := range pos {
+		k := key{pos[i], d.Normals[i], d.UVs[i], signs[i]}
+		sums[k] = sums[k].Add(tangents[i])
+	}
+
+	d.Tangents = make([]mgl32.Vec4, len(pos))
+	for i := range pos {
+		n := d.Normals[i]
+		t := sums[key{pos[i], n, d.UVs[i], signs[i]}]
+		// 复用正交化和退化处理, 副切线方向由 sign 给出
+		d.Tangents[i] = orthogonalTangent(n, t, n.Cross(t).Mul(signs[i]))
+	}
+
+	d.Weld(0)
+}
+
+// ACMR 模拟 FIFO 顶点缓存, 返回平均每个三角形的缓存未命中次数, 越接近 0.5 越好
+func (d *MeshData) ACMR(cacheSize int) float32 {
+	tris := d.TriangleCount()
+	if tris == 0 {
+		return 0
+	}
+
+	var (
+		cache  = make([]uint32, 0, cacheSize)
+		misses = 0
+	)
+	for i := 0; i < tris; i++ {
+		a, b, c := d.Triangle(i)
+		for _, v := range [3]uint32{a, b, c} {
+			if !fifoContains(cache, v) {
+				misses++
+				if len(cache) == cacheSize {
+					cache = cache[1:]
+				}
+				cache = append(cache, v)
+			}
+		}
+	}
+	return float32(misses) / float32(tris)
+}
+
+func fifoContains(cache []uint32, v uint32) bool {
+	for _, c := range cache {
+		if c == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Forsyth 算法的评分参数
+const (
+	cacheDecayPower   = 1.5
+	lastTriScore      = 0.75
+	valenceBoostScale = 2.0
+	valenceBoostPower = 0.5
+)
+
+func forsythScore(cachePos, valence int) float32 {
+	if valence == 0 {
+		return -1 // 已经没有剩余三角形
+	}
+
+	var score float64
+	switch {
+	case cachePos < 0:
+	case cachePos < 3:
+		score = lastTriScore
+	default:
+		score = math.Pow(1-float64(cachePos-3)/float64(VertexCacheSize-3), cacheDecayPower)
+	}
+	return float32(score + valenceBoostScale*math.Pow(float64(valence), -valenceBoostPower))
+}
+
+// OptimizeVertexCache Forsyth 线性时间算法重排三角形, 提高 post-transform 缓存命中率
+func (d *MeshData) OptimizeVertexCache() {
+	tris := d.TriangleCount()
+	if tris == 0 {
+		return
+	}
+	if len(d.Indices) == 0 {
+		d.Indices = sequence(uint32(len(d.Positions)))
+	}
+
+	var (
+		nv        = len(d.Positions)
+		valence   = make([]int, nv)
+		adjStart  = make([]int, nv+1)
+		adj       = make([]int, 3*tris)
+		cachePos  = make([]int, nv)
+		vscore    = make([]float32, nv)
+		tscore    = make([]float32, tris)
+		added     = make([]bool, tris)
+		out       = make([]uint32, 0, 3*tris)
+		cache     []uint32
+		nextTri   = 0 // 缓存中没有候选时, 从这里开始线性查找
+		bestTri   = -1
+		bestScore float32
+	)
+
+	for _, v := range d.Indices {
+		valence[v]++
+	}
+	for v := 0; v < nv; v++ {
+		adjStart[v+1] = adjStart[v] + valence[v]
+	}
+	fill := append([]int(nil), adjStart[:nv]...)
+	for t := 0; t < tris; t++ {
+		for k := 0; k < 3; k++ {
+			v := d.Indices[3*t+k]
+			adj[fill[v]] = t
+			fill[v]++
+		}
+	}
+
+	for v := range cachePos {
+		cachePos[v] = -1
+		vscore[v] = forsythScore(-1, valence[v])
+	}
+	for t := 0; t < tris; t++ {
+		tscore[t] = vscore[d.Indices[3*t]] + vscore[d.Indices[3*t+1]] + vscore[d.Indices[3*t+2]]
+	}
+
+	for range tris {
+		if bestTri < 0 {
+			// 线性查找下一个未输出的三角形中分数最高的
+			for added[nextTri] {
+				nextTri++
+			}
+			bestTri, bestScore = nextTri, tscore[nextTri]
+			for t := nextTri + 1; t < tris; t++ {
+				if !added[t] && tscore[t] > bestScore {
+					bestTri, bestScore = t, tscore[t]
+				}
+			}
+		}
+
+		t := bestTri
+		added[t] = true
+		tri := d.Indices[3*t : 3*t+3]
+		out = append(out, tri...)
+
+		// 从邻接表中移除该三角形
+		for _, v := range tri {
+			list := adj[adjStart[v] : adjStart[v]+valence[v]]
+			for i, at := range list {
+				if at == t {
+					list[i] = list[len(list)-1]
+					break
+				}
+			}
+			valence[v]--
+		}
+
+		// 三个顶点移到缓存最前面, 超出容量的顶点被挤出
+		next := append(make([]uint32, 0, VertexCacheSize+3), tri...)
+		for _, v := range cache {
+			if v != tri[0] && v != tri[1] && v != tri[2] {
+				next = append(next, v)
+			}
+		}
+		for i, v := range next {
+			if i >= VertexCacheSize {
+				i = -1
+			}
+			cachePos[v] = i
+			vscore[v] = forsythScore(i, valence[v])
+		}
+
+		// 只在缓存相关的三角形中寻找下一个最佳, 找不到时回到线性查找
+		bestTri, bestScore = -1, -1
+		for _, v := range next {
+			for _, at := range adj[adjStart[v] : adjStart[v]+valence[v]] {
+				s := vscore[d.Indices[3*at]] + vscore[d.Indices[3*at+1]] + vscore[d.Indices[3*at+2]]
+				tscore[at] = s
+				if s > bestScore {
+					bestTri, bestScore = at, s
+				}
+			}
+		}
+		cache = next[:min(len(next), VertexCacheSize)]
+	}
+
+	d.Indices = out
+}
+
+// OptimizeOverdraw 在顶点缓存优化的基础上按簇重排三角形, 朝外的簇先绘制以减少 overdraw,
+// threshold 为大致允许的 ACMR 放大倍数, 例如 1.05
+func (d *MeshData) OptimizeOverdraw(threshold float32) {
+	tris := d.TriangleCount()
+	if tris == 0 {
+		return
+	}
+	if len(d.Indices) == 0 {
+		d.Indices = sequence(uint32(len(d.Positions)))
+	}
+
+	// 硬边界: 三个顶点都未命中缓存的三角形开始一个新簇
+	var (
+		cache  = make([]uint32, 0, VertexCacheSize)
+		misses = make([]int, tris)
+		total  = 0
+	)
+	for t := 0; t < tris; t++ {
+		a, b, c := d.Triangle(t)
+		for _, v := range [3]uint32{a, b, c} {
+			if !fifoContains(cache, v) {
+				misses[t]++
+				if len(cache) == VertexCacheSize {
+					cache = cache[1:]
+				}
+				cache = append(cache, v)
+			}
+		}
+		total += misses[t]
+	}
+	limit := float32(total) / float32(tris) * threshold
+
+	// 软边界: 簇单独绘制(缓存从空开始)时的 ACMR 不超过 limit 时可以提前切分
+	starts := []int{0}
+	cache = cache[:0]
+	clusterMisses := 0
+	for t := 0; t < tris; t++ {
+		size := t - starts[len(starts)-1]
+		if t > 0 && (misses[t] == 3 || size >= 16 && float32(clusterMisses)/float32(size) <= limit) {
+			starts = append(starts, t)
+			cache = cache[:0]
+			clusterMisses = 0
+		}
+
+		a, b, c := d.Triangle(t)
+		for _, v := range [3]uint32{a, b, c} {
+			if !fifoContains(cache, v) {
+				clusterMisses++
+				if len(cache) == VertexCacheSize {
+					cache = cache[1:]
+				}
+				cache = append(cache, v)
+			}
+		}
+	}
+	starts = append(starts, tris)
+
+	var meshCenter mgl32.Vec3
+	for _, p := range d.Positions {
+		meshCenter = meshCenter.Add(p)
+	}
+	meshCenter = meshCenter.Mul(1 / float32(max(len(d.Positions), 1)))
+
+	type cluster struct {
+		start, end int
+		sort       float32
+	}
+	clusters := make([]cluster, 0, len(starts)-1)
+	for i := 0; i+1 < len(starts); i++ {
+		c := cluster{start: starts[i], end: starts[i+1]}
+		var center, normal mgl32.Vec3
+		var area float32
+		for t := c.start; t < c.end; t++ {
+			a, b, cc := d.Triangle(t)
+			pa, pb, pc := d.Positions[a], d.Positions[b], d.Positions[cc]
+			n := pb.Sub(pa).Cross(pc.Sub(pa)) // 长度为面积的两倍
+			w := n.Len()
+			center = center.Add(pa.Add(pb).Add(pc).Mul(w / 3))
+			normal = normal.Add(n)
+			area += w
+		}
+		if area > 0 {
+			center = center.Mul(1 / area)
+		}
+		if normal.Len() > 0 {
+			normal = normal.Normalize()
+		}
+		c.sort = center.Sub(meshCenter).Dot(normal)
+		clusters = append(clusters, c)
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return clusters[i].sort > clusters[j].sort
+	})
+
+	out := make([]uint32, 0, len(d.Indices))
+	for _, c := range clusters {
+		out = append(out, d.Indices[3*c.start:3*c.end]...)
+	}
+	d.Indices = out
+}
+
+// OptimizeVertexFetch 按索引中首次出现的顺序重排顶点, 提高顶点读取的局部性, 同时去掉未使用的顶点
+func (d *MeshData) OptimizeVertexFetch() {
+	if len(d.Indices) == 0 {
+		return
+	}
+
+	remap := make([]int64, len(d.Positions))
+	for i := range remap {
+		remap[i] = -1
+	}
+
+	var order []uint32
+	for i, v := range d.Indices {
+		if remap[v] < 0 {
+			remap[v] = int64(len(order))
+			order = append(order, v)
+		}
+		d.Indices[i] = uint32(remap[v])
+	}
+
+	d.gatherVertices(order)
+}
@@ -0,0 +1,236 @@
+package common
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// positionsOnly 只保留位置的立方体, 焊接后共 8 个顶点
+func positionsOnly(d *MeshData) *MeshData {
+	d.Normals, d.UVs, d.Tangents = nil, nil, nil
+	return d
+}
+
+// sortedTriangles 与顺序无关的三角形集合, 用于检查重排后图元不变
+func sortedTriangles(d *MeshData) [][3]mgl32.Vec3 {
+	less := func(a, b mgl32.Vec3) int {
+		for i := range a {
+			if a[i] != b[i] {
+				if a[i] < b[i] {
+					return -1
+				}
+				return 1
+			}
+		}
+		return 0
+	}
+
+	var tris [][3]mgl32.Vec3
+	for i := 0; i < d.TriangleCount(); i++ {
+		a, b, c := d.Triangle(i)
+		t := [3]mgl32.Vec3{d.Positions[a], d.Positions[b], d.Positions[c]}
+		// 旋转到最小的顶点在前, 保留环绕方向
+		for t[0] != slices.MinFunc(t[:], less) {
+			t = [3]mgl32.Vec3{t[1], t[2], t[0]}
+		}
+		tris = append(tris, t)
+	}
+	slices.SortFunc(tris, func(a, b [3]mgl32.Vec3) int {
+		for i := range a {
+			if c := less(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	return tris
+}
+
+func TestWeld(t *testing.T) {
+	cube := GenCube(1, 1)
+	cube.Unweld()
+	if cube.VertexCount() != 36 {
+		t.Fatalf("unwelded cube has %d vertices", cube.VertexCount())
+	}
+	want := sortedTriangles(cube)
+
+	// 法线和 UV 不同的角不会合并
+	cube.Weld(0)
+	if cube.VertexCount() != 24 || len(cube.Indices) != 36 {
+		t.Errorf("welded cube: %d vertices, %d indices, want 24 and 36", cube.VertexCount(), len(cube.Indices))
+	}
+	if !slices.Equal(sortedTriangles(cube), want) {
+		t.Error("welding changed the triangles")
+	}
+
+	positionsOnly(cube).Unweld()
+	cube.Weld(0)
+	if cube.VertexCount() != 8 {
+		t.Errorf("position-only cube: %d vertices, want 8", cube.VertexCount())
+	}
+
+	// 量化后相同的顶点合并
+	jittered := positionsOnly(GenCube(1, 1))
+	jittered.Unweld()
+	for i := range jittered.Positions {
+		jittered.Positions[i][0] += float32(i%3) * 1e-6
+	}
+	jittered.Weld(1e-4)
+	if jittered.VertexCount() != 8 {
+		t.Errorf("jittered cube: %d vertices, want 8", jittered.VertexCount())
+	}
+}
+
+func TestWeldArray(t *testing.T) {
+	// 三个三角形共 9 个顶点, 第三个三角形中的 -0 与 +0 视为相同
+	negZero := float32(math.Copysign(0, -1))
+	data := []float32{
+		0, 0, 1, 0, 0, 1,
+		0, 1, 1, 0, 1, 1,
+		negZero, 0, 1, 1, 0, 1,
+	}
+	vertices, indices := WeldArray(data, 2)
+	if want := []uint32{0, 1, 2, 2, 1, 3, 0, 3, 2}; !slices.Equal(indices, want) {
+		t.Errorf("indices %v, want %v", indices, want)
+	}
+	if len(vertices) != 4*2 {
+		t.Errorf("%d floats, want 8", len(vertices))
+	}
+	for i, idx := range indices {
+		if v := vertices[2*idx : 2*idx+2]; v[0] != data[2*i] || v[1] != data[2*i+1] {
+			t.Errorf("vertex %d = %v, want %v", i, v, data[2*i:2*i+2])
+		}
+	}
+}
+
+func TestSmoothNormals(t *testing.T) {
+	tests := []struct {
+		name     string
+		crease   float32
+		vertices int
+		check    func(n mgl32.Vec3) bool
+	}{
+		// 立方体相邻面的夹角为 90 度, 小于该角度时保留硬边, 法线沿坐标轴
+		{"crease", 60, 24, func(n mgl32.Vec3) bool {
+			return mgl32.FloatEqual(abs(n[0])+abs(n[1])+abs(n[2]), 1)
+		}},
+		// 完全平滑时每个角的法线沿对角线方向
+		{"smooth", 180, 8, func(n mgl32.Vec3) bool {
+			d := float32(1 / math.Sqrt(3))
+			return mgl32.FloatEqualThreshold(abs(n[0]), d, 1e-5) &&
+				mgl32.FloatEqualThreshold(abs(n[1]), d, 1e-5) &&
+				mgl32.FloatEqualThreshold(abs(n[2]), d, 1e-5)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cube := positionsOnly(GenCube(2, 1))
+			cube.SmoothNormals(tt.crease)
+
+			if cube.VertexCount() != tt.vertices {
+				t.Errorf("%d vertices, want %d", cube.VertexCount(), tt.vertices)
+			}
+			for i, n := range cube.Normals {
+				if !tt.check(n) {
+					t.Errorf("normal %d = %v", i, n)
+				}
+				// 法线朝外
+				if n.Dot(cube.Positions[i]) <= 0 {
+					t.Errorf("normal %d = %v points inwards at %v", i, n, cube.Positions[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateTangents(t *testing.T) {
+	tests := []struct {
+		name string
+		mesh *MeshData
+	}{
+		{"plane", GenPlane(2, 2, 2, 2)},
+		{"cube", GenCube(1, 2)},
+		{"sphere", GenUVSphere(1, 16, 8)},
+		{"torus", GenTorus(1, 0.3, 16, 8)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := tt.mesh
+			d.Tangents = nil
+			d.GenerateTangents()
+			if len(d.Tangents) != d.VertexCount() {
+				t.Fatalf("%d tangents for %d vertices", len(d.Tangents), d.VertexCount())
+			}
+
+			// 切线单位长度且与法线正交, 并且沿 U 增大的方向
+			for i := 0; i < d.TriangleCount(); i++ {
+				a, b, c := d.Triangle(i)
+				dpdu, _, ok := triangleTangent(d.Positions[a], d.Positions[b], d.Positions[c], d.UVs[a], d.UVs[b], d.UVs[c])
+				// 极点处的三角形 U 方向退化
+				if !ok || dpdu.Len() < 1e-3 {
+					continue
+				}
+				for _, v := range [3]uint32{a, b, c} {
+					tan, n := d.Tangents[v].Vec3(), d.Normals[v]
+					if !mgl32.FloatEqualThreshold(tan.Len(), 1, 1e-4) || abs(tan.Dot(n)) > 1e-4 {
+						t.Fatalf("vertex %d: tangent %v, normal %v", v, d.Tangents[v], n)
+					}
+					if w := d.Tangents[v][3]; w != 1 && w != -1 {
+						t.Fatalf("vertex %d: handedness %v", v, w)
+					}
+					if tan.Dot(dpdu.Normalize()) < 0.5 {
+						t.Fatalf("vertex %d: tangent %v points away from dP/du %v", v, tan, dpdu)
+					}
+				}
+			}
+		})
+	}
+
+	// 平面的 U 沿 +X, V 沿 -Z, 副切线 cross(N, T) = -Z 与 dP/dv 同向
+	plane := GenPlane(2, 2, 1, 1)
+	plane.GenerateTangents()
+	for i, tan := range plane.Tangents {
+		if !tan.ApproxEqualThreshold(mgl32.Vec4{1, 0, 0, 1}, 1e-5) {
+			t.Errorf("plane tangent %d = %v, want (1, 0, 0, 1)", i, tan)
+		}
+	}
+}
+
+func TestOptimizeVertexCache(t *testing.T) {
+	grid := GenPlane(1, 1, 64, 64)
+
+	// 打乱三角形顺序, 缓存命中率很低
+	rng := rand.New(rand.NewSource(1))
+	tris := grid.TriangleCount()
+	perm := rng.Perm(tris)
+	shuffled := make([]uint32, 0, len(grid.Indices))
+	for _, i := range perm {
+		shuffled = append(shuffled, grid.Indices[3*i:3*i+3]...)
+	}
+	grid.Indices = shuffled
+	want := sortedTriangles(grid)
+
+	before := grid.ACMR(VertexCacheSize)
+	grid.OptimizeVertexCache()
+	after := grid.ACMR(VertexCacheSize)
+
+	if !slices.Equal(sortedTriangles(grid), want) {
+		t.Fatal("optimization changed the triangles")
+	}
+	// 网格的理想 ACMR 约为 0.5, 打乱后接近 3
+	if before < 2 || after > 0.8 {
+		t.Errorf("ACMR %v -> %v, want a drop from about 3 to below 0.8", before, after)
+	}
+
+	// 已经优化过的顺序不会变差
+	grid.OptimizeVertexCache()
+	if again := grid.ACMR(VertexCacheSize); again > after+0.01 {
+		t.Errorf("second pass ACMR %v > %v", again, after)
+	}
+}
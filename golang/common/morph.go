@@ -0,0 +1,219 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// MorphTarget 变形目标, 保存每个顶点相对基础网格的偏移, 为空的属性没有偏移
+type MorphTarget struct {
+	Name      string
+	Positions []mgl32.Vec3
+	Normals   []mgl32.Vec3
+	Tangents  []mgl32.Vec3 // 只偏移切线方向, 副切线方向 w 不变
+}
+
+// MorphMesh CPU 变形, 返回按 weights 混合后的副本, 多出或缺少的权重被忽略
+func MorphMesh(d *MeshData, weights []float32) *MeshData {
+	out := *d
+	out.Positions = append([]mgl32.Vec3(nil), d.Positions...)
+	out.Normals = append([]mgl32.Vec3(nil), d.Normals...)
+	out.Tangents = append([]mgl32.Vec4(nil), d.Tangents...)
+
+	for t, target := range d.Targets {
+		if t >= len(weights) || weights[t] == 0 {
+			continue
+		}
+
+		w := weights[t]
+		for i := range min(len(out.Positions), len(target.Positions)) {
+			out.Positions[i] = out.Positions[i].Add(target.Positions[i].Mul(w))
+		}
+		for i := range min(len(out.Normals), len(target.Normals)) {
+			out.Normals[i] = out.Normals[i].Add(target.Normals[i].Mul(w))
+		}
+		for i := range min(len(out.Tangents), len(target.Tangents)) {
+			tan := out.Tangents[i]
+			out.Tangents[i] = tan.Vec3().Add(target.Tangents[i].Mul(w)).Vec4(tan[3])
+		}
+	}
+
+	for i, n := range out.Normals {
+		out.Normals[i] = normalizeSafe(n)
+	}
+	for i, t := range out.Tangents {
+		out.Tangents[i] = normalizeSafe(t.Vec3()).Vec4(t[3])
+	}
+	return &out
+}
+
+// MorphBuffer 保存在纹理缓冲中的变形目标, 由顶点着色器根据 gl_VertexID 读取并混合,
+// 因此目标数不受顶点属性数量的限制
+//
+// 偏移按 [目标][属性][顶点] 排列, 属性依次为位置、法线(如果有)、切线(如果有)
+type MorphBuffer struct {
+	Targets  int
+	Vertices int
+	Attribs  int32    // 每个目标的属性数
+	Slots    [2]int32 // 法线和切线在每个目标中的属性下标, -1 表示没有
+
+	deltaVBO, deltaTex   uint32
+	weightVBO, weightTex uint32
+	weights              []float32
+}
+
+// NewMorphBuffer 上传 d 的所有变形目标, 任意一个目标有法线(切线)偏移时所有目标都保存法线(切线)
+func NewMorphBuffer(d *MeshData) (*MorphBuffer, error) {
+	if len(d.Targets) == 0 {
+		return nil, errors.New("NewMorphBuffer: mesh has no morph targets")
+	}
+
+	b := &MorphBuffer{
+		Targets:  len(d.Targets),
+		Vertices: len(d.Positions),
+		Attribs:  1,
+		Slots:    [2]int32{-1, -1},
+		weights:  make([]float32, len(d.Targets)),
+	}
+	var normals, tangents bool
+	for _, t := range d.Targets {
+		if len(t.Positions) > b.Vertices || len(t.Normals) > b.Vertices || len(t.Tangents) > b.Vertices {
+			return nil, fmt.Errorf("NewMorphBuffer: target %q has more vertices than the mesh", t.Name)
+		}
+		normals = normals || len(t.Normals) > 0
+		tangents = tangents || len(t.Tangents) > 0
+	}
+	if normals {
+		b.Slots[0] = b.Attribs
+		b.Attribs++
+	}
+	if tangents {
+		b.Slots[1] = b.Attribs
+		b.Attribs++
+	}
+
+	texels := b.Targets * int(b.Attribs) * b.Vertices
+	var maxTexels int32
+	gl.GetIntegerv(gl.MAX_TEXTURE_BUFFER_SIZE, &maxTexels)
+	if texels > int(maxTexels) {
+		return nil, fmt.Errorf("NewMorphBuffer: %d texels exceed GL_MAX_TEXTURE_BUFFER_SIZE %d", texels, maxTexels)
+	}
+
+	deltas := make([]mgl32.Vec3, texels)
+	for t, target := range d.Targets {
+		base := t * int(b.Attribs) * b.Vertices
+		copy(deltas[base:], target.Positions)
+		if b.Slots[0] >= 0 {
+			copy(deltas[base+int(b.Slots[0])*b.Vertices:], target.Normals)
+		}
+		if b.Slots[1] >= 0 {
+			copy(deltas[base+int(b.Slots[1])*b.Vertices:], target.Tangents)
+		}
+	}
+
+	b.deltaVBO, b.deltaTex = newTextureBuffer(gl.RGB32F, deltas, gl.STATIC_DRAW)
+	b.weightVBO, b.weightTex = newTextureBuffer(gl.R32F, b.weights, gl.DYNAMIC_DRAW)
+	return b, nil
+}
+
+func newTextureBuffer[T any](format uint32, data []T, usage uint32) (uint32, uint32) {
+	var vbo, tex uint32
+	gl.GenBuffers(1, &vbo)
+	gl.BindBuffer(gl.TEXTURE_BUFFER, vbo)
+	gl.BufferData(gl.TEXTURE_BUFFER, len(data)*int(unsafe.Sizeof(*new(T))), unsafe.Pointer(unsafe.SliceData(data)), usage)
+	gl.BindBuffer(gl.TEXTURE_BUFFER, 0)
+
+	gl.GenTextures(1, &tex)
+	gl.BindTexture(gl.TEXTURE_BUFFER, tex)
+	gl.TexBuffer(gl.TEXTURE_BUFFER, format, vbo)
+	gl.BindTexture(gl.TEXTURE_BUFFER, 0)
+	return vbo, tex
+}
+
+// SetWeights 更新每个目标的权重, 多出的权重被忽略, 缺少的视为 0
+func (b *MorphBuffer) SetWeights(weights []float32) {
+	n := copy(b.weights, weights)
+	clear(b.weights[n:])
+
+	gl.BindBuffer(gl.TEXTURE_BUFFER, b.weightVBO)
+	gl.BufferSubData(gl.TEXTURE_BUFFER, 0, len(b.weights)*4, gl.Ptr(b.weights))
+	gl.BindBuffer(gl.TEXTURE_BUFFER, 0)
+}
+
+// Bind 将偏移和权重绑定到纹理单元 unit 和 unit+1, 并设置 MorphGLSL 中的 uniform,
+// 调用前需要先 Use 着色器
+func (b *MorphBuffer) Bind(s *Shader, unit uint32) {
+	gl.ActiveTexture(gl.TEXTURE0 + unit)
+	gl.BindTexture(gl.TEXTURE_BUFFER, b.deltaTex)
+	gl.ActiveTexture(gl.TEXTURE0 + unit + 1)
+	gl.BindTexture(gl.TEXTURE_BUFFER, b.weightTex)
+
+	// 着色器可能只用到其中一部分, 被优化掉的 uniform 位置为 -1, 设置时会被忽略
+	loc := func(name string) int32 {
+		return gl.GetUniformLocation(s.ID, gl.Str(name+CNull))
+	}
+	gl.Uniform1i(loc("morphDeltas"), int32(unit))
+	gl.Uniform1i(loc("morphWeights"), int32(unit+1))
+	gl.Uniform1i(loc("morphCount"), int32(b.Targets))
+	gl.Uniform1i(loc("morphVertices"), int32(b.Vertices))
+	gl.Uniform1i(loc("morphAttribs"), b.Attribs)
+	gl.Uniform2i(loc("morphSlots"), b.Slots[0], b.Slots[1])
+}
+
+// UnbindMorph 关闭变形, 让共用着色器的其他网格不受影响
+func UnbindMorph(s *Shader) {
+	gl.Uniform1i(gl.GetUniformLocation(s.ID, gl.Str("morphCount"+CNull)), 0)
+}
+
+func (b *MorphBuffer) Delete() {
+	gl.DeleteTextures(1, &b.deltaTex)
+	gl.DeleteTextures(1, &b.weightTex)
+	gl.DeleteBuffers(1, &b.deltaVBO)
+	gl.DeleteBuffers(1, &b.weightVBO)
+}
+
+// MorphGLSL 顶点着色器中的 GPU 变形代码, 放在 #version 之后使用, 先变形再蒙皮:
+//
+//	vec3 pos = morphPosition(aPos);
+//	vec3 normal = morphNormal(aNormal);
+//
+// morphCount 为 0 时不做任何变形
+const MorphGLSL = `
+uniform samplerBuffer morphDeltas;
+uniform samplerBuffer morphWeights;
+uniform int morphCount;
+uniform int morphVertices;
+uniform int morphAttribs;
+uniform ivec2 morphSlots; // 法线和切线的属性下标, -1 表示没有
+
+vec3 morphDelta(int slot)
+{
+	vec3 d = vec3(0.0);
+	for (int i = 0; i < morphCount; i++) {
+		float w = texelFetch(morphWeights, i).r;
+		if (w != 0.0) {
+			d += w * texelFetch(morphDeltas, (i * morphAttribs + slot) * morphVertices + gl_VertexID).xyz;
+		}
+	}
+	return d;
+}
+
+vec3 morphPosition(vec3 p)
+{
+	return p + morphDelta(0);
+}
+
+vec3 morphNormal(vec3 n)
+{
+	return morphSlots.x < 0 ? n : normalize(n + morphDelta(morphSlots.x));
+}
+
+vec3 morphTangent(vec3 t)
+{
+	return morphSlots.y < 0 ? t : normalize(t + morphDelta(morphSlots.y));
+}
+`
@@ -0,0 +1,762 @@
+package common
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Material 材质参数, 贴图字段为相对模型文件解析后的路径
+type Material struct {
+	Name      string
+	Ambient   mgl32.Vec3
+	Diffuse   mgl32.Vec3
+	Specular  mgl32.Vec3
+	Emissive  mgl32.Vec3
+	Shininess float32
+	Opacity   float32
+
+	AmbientMap  string
+	DiffuseMap  string
+	SpecularMap string
+	EmissiveMap string
+	NormalMap   string
+	OpacityMap  string
+}
+
+func NewMaterial(name string) *Material {
+	return &Material{
+		Name:      name,
+		Ambient:   mgl32.Vec3{1, 1, 1},
+		Diffuse:   mgl32.Vec3{1, 1, 1},
+		Specular:  mgl32.Vec3{0, 0, 0},
+		Shininess: 32,
+		Opacity:   1,
+	}
+}
+
+// OBJGroup 按 (对象, 组, 材质) 划分的一块网格
+type OBJGroup struct {
+	Object   string
+	Group    string
+	Material string
+	Mesh     *MeshData
+}
+
+type OBJModel struct {
+	Groups    []*OBJGroup
+	Materials map[string]*Material
+}
+
+// LoadOBJ 读取 obj 文件, mtllib 和贴图路径相对 obj 所在目录解析
+func LoadOBJ(name string) (*OBJModel, error) {
+	fr, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer fr.Close()
+
+	dir := filepath.Dir(name)
+	model, err := ParseOBJ(fr, osDir(dir))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	for _, m := range model.Materials {
+		for _, p := range []*string{&m.AmbientMap, &m.DiffuseMap, &m.SpecularMap, &m.EmissiveMap, &m.NormalMap, &m.OpacityMap} {
+			if *p != "" && !filepath.IsAbs(*p) {
+				*p = filepath.Join(dir, filepath.FromSlash(*p))
+			}
+		}
+	}
+
+	return model, nil
+}
+
+// osDir 与 os.DirFS 类似, 但允许 mtllib 使用 ../ 开头的相对路径和绝对路径
+type osDir string
+
+func (d osDir) Open(name string) (fs.File, error) {
+	name = filepath.FromSlash(name)
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(string(d), name)
+	}
+	return os.Open(name)
+}
+
+// objVertex 面中的一个顶点引用,索引从 0 开始, -1 表示缺失
+type objVertex struct {
+	v, vt, vn int
+}
+
+type objBuilder struct {
+	positions []mgl32.Vec3
+	uvs       []mgl32.Vec2
+	normals   []mgl32.Vec3
+
+	object   string
+	group    string
+	material string
+	smooth   int
+	face     int
+
+	model  *OBJModel
+	chunks map[[3]string]*objChunk
+}
+
+// objChunk 正在构建的网格块, 顶点按 (v,vt,vn,平滑组) 去重
+type objChunk struct {
+	group   *OBJGroup
+	vertex  map[[4]int]uint32
+	smooth  []int // 缺少法线的顶点所属的平滑组键, 其余为 0
+	pos     []int // 每个输出顶点对应的 v 索引
+	missing bool
+}
+
+// ParseOBJ 流式解析 obj, fsys 用于读取 mtllib, 为 nil 时忽略材质库
+// 找不到或无法读取的材质库会被跳过, 引用的材质缺失时使用 NewMaterial 的默认值
+func ParseOBJ(r io.Reader, fsys fs.FS) (*OBJModel, error) {
+	b := &objBuilder{
+		model:  &OBJModel{Materials: make(map[string]*Material)},
+		chunks: make(map[[3]string]*objChunk),
+	}
+
+	err := scanLines(r, func(line int, fields []string) error {
+		err := b.handle(fields, fsys)
+		if err != nil {
+			return fmt.Errorf("obj line %d: %w", line, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	groups := b.model.Groups[:0]
+	for _, g := range b.model.Groups {
+		if len(g.Mesh.Indices) == 0 {
+			continue
+		}
+		b.finish(b.chunks[[3]string{g.Object, g.Group, g.Material}])
+		groups = append(groups, g)
+
+		if _, ok := b.model.Materials[g.Material]; !ok && g.Material != "" {
+			b.model.Materials[g.Material] = NewMaterial(g.Material)
+		}
+	}
+	b.model.Groups = groups
+
+	return b.model, nil
+}
+
+// scanLines 逐行读取, 去掉注释并处理行尾 '\' 续行
+// 只有行首或空白之后的 '#' 开始注释, 文件名中的 '#'(如 map_Kd tex#1.png)保留
+func scanLines(r io.Reader, fn func(line int, fields []string) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var (
+		line, start int
+		buf         strings.Builder
+	)
+	for sc.Scan() {
+		line++
+		text := stripComment(sc.Text())
+
+		if buf.Len() == 0 {
+			start = line
+		}
+		if strings.HasSuffix(text, "\\") {
+			buf.WriteString(text[:len(text)-1])
+			buf.WriteByte(' ')
+			continue
+		}
+		buf.WriteString(text)
+
+		fields := strings.Fields(buf.String())
+		buf.Reset()
+		if len(fields) == 0 {
+			continue
+		}
+		err := fn(start, fields)
+		if err != nil {
+			return err
+		}
+	}
+
+	return sc.Err()
+}
+
+func stripComment(text string) string {
+	for i := 0; i < len(text); i++ {
+		if text[i] == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t') {
+			return strings.TrimRight(text[:i], " \t")
+		}
+	}
+	return text
+}
+
+func parseFloats(fields []string, n int) ([]float32, error) {
+	if len(fields) < n {
+		return nil, fmt.Errorf("want %d values, got %d", n, len(fields))
+	}
+
+	v := make([]float32, n)
+	for i := range v {
+		f, err := strconv.ParseFloat(fields[i], 32)
+		if err != nil {
+			return nil, err
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
+
+func (b *objBuilder) handle(fields []string, fsys fs.FS) error {
+	args := fields[1:]
+	switch fields[0] {
+	case "v":
+		v, err := parseFloats(args, 3)
+		if err != nil {
+			return err
+		}
+		b.positions = append(b.positions, mgl32.Vec3{v[0], v[1], v[2]})
+	case "vt":
+		v, err := parseFloats(args, 1)
+		if err != nil {
+			return err
+		}
+		uv := mgl32.Vec2{v[0], 0}
+		if len(args) > 1 {
+			f, err := strconv.ParseFloat(args[1], 32)
+			if err != nil {
+				return err
+			}
+			uv[1] = float32(f)
+		}
+		b.uvs = append(b.uvs, uv)
+	case "vn":
+		v, err := parseFloats(args, 3)
+		if err != nil {
+			return err
+		}
+		b.normals = append(b.normals, mgl32.Vec3{v[0], v[1], v[2]}.Normalize())
+	case "f":
+		return b.addFace(args)
+	case "o":
+		b.object = strings.Join(args, " ")
+	case "g":
+		b.group = strings.Join(args, " ")
+	case "usemtl":
+		b.material = strings.Join(args, " ")
+	case "s":
+		// "s on" 等同于 "s 1"
+		b.smooth = 0
+		switch {
+		case len(args) == 0 || args[0] == "off":
+		case args[0] == "on":
+			b.smooth = 1
+		default:
+			s, err := strconv.Atoi(args[0])
+			if err != nil {
+				return err
+			}
+			b.smooth = s
+		}
+	case "mtllib":
+		if fsys == nil {
+			return nil
+		}
+		for _, name := range args {
+			// 材质库缺失时仍然可以显示几何体
+			_ = b.loadMTL(fsys, name)
+		}
+	}
+
+	return nil
+}
+
+func (b *objBuilder) loadMTL(fsys fs.FS, name string) error {
+	name = path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	fr, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer fr.Close()
+
+	materials, err := ParseMTL(fr, path.Dir(name))
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	for k, v := range materials {
+		b.model.Materials[k] = v
+	}
+	return nil
+}
+
+// resolveIndex 将 obj 中从 1 开始或为负数的相对索引转为从 0 开始的索引
+func resolveIndex(s string, count int) (int, error) {
+	if s == "" {
+		return -1, nil
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case i > 0 && i <= count:
+		return i - 1, nil
+	case i < 0 && -i <= count:
+		return count + i, nil
+	default:
+		return 0, fmt.Errorf("index %d out of range [1,%d]", i, count)
+	}
+}
+
+func (b *objBuilder) parseVertex(s string) (objVertex, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) > 3 {
+		return objVertex{}, fmt.Errorf("bad face vertex %q", s)
+	}
+
+	var (
+		v   = objVertex{vt: -1, vn: -1}
+		err error
+	)
+	v.v, err = resolveIndex(parts[0], len(b.positions))
+	if err != nil {
+		return v, err
+	}
+	if v.v < 0 {
+		return v, fmt.Errorf("face vertex %q without position", s)
+	}
+	if len(parts) > 1 {
+		v.vt, err = resolveIndex(parts[1], len(b.uvs))
+		if err != nil {
+			return v, err
+		}
+	}
+	if len(parts) > 2 {
+		v.vn, err = resolveIndex(parts[2], len(b.normals))
+		if err != nil {
+			return v, err
+		}
+	}
+
+	return v, nil
+}
+
+func (b *objBuilder) chunk() *objChunk {
+	key := [3]string{b.object, b.group, b.material}
+	if c, ok := b.chunks[key]; ok {
+		return c
+	}
+
+	c := &objChunk{
+		group: &OBJGroup{
+			Object:   b.object,
+			Group:    b.group,
+			Material: b.material,
+			Mesh:     &MeshData{},
+		},
+		vertex: make(map[[4]int]uint32),
+	}
+	b.chunks[key] = c
+	b.model.Groups = append(b.model.Groups, c.group)
+	return c
+}
+
+func (b *objBuilder) addFace(args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("face needs at least 3 vertices, got %d", len(args))
+	}
+
+	face := make([]objVertex, len(args))
+	for i, s := range args {
+		v, err := b.parseVertex(s)
+		if err != nil {
+			return err
+		}
+		face[i] = v
+	}
+
+	polygon := make([]mgl32.Vec3, len(face))
+	for i, v := range face {
+		polygon[i] = b.positions[v.v]
+	}
+
+	b.face++
+	c := b.chunk()
+	d := c.group.Mesh
+	for _, i := range Triangulate(polygon) {
+		v := face[i]
+
+		// 没有法线时需要按平滑组生成,平滑组 0 表示平面着色,每个面独立
+		smooth := 0
+		if v.vn < 0 {
+			smooth = b.smooth
+			if smooth == 0 {
+				smooth = -b.face
+			}
+		}
+
+		key := [4]int{v.v, v.vt, v.vn, smooth}
+		idx, ok := c.vertex[key]
+		if !ok {
+			idx = uint32(len(d.Positions))
+			c.vertex[key] = idx
+			c.pos = append(c.pos, v.v)
+			c.smooth = append(c.smooth, smooth)
+
+			d.Positions = append(d.Positions, b.positions[v.v])
+			var (
+				uv mgl32.Vec2
+				n  mgl32.Vec3
+			)
+			if v.vt >= 0 {
+				uv = b.uvs[v.vt]
+			}
+			if v.vn >= 0 {
+				n = b.normals[v.vn]
+			} else {
+				c.missing = true
+			}
+			d.UVs = append(d.UVs, uv)
+			d.Normals = append(d.Normals, n)
+		}
+		d.Indices = append(d.Indices, idx)
+	}
+
+	return nil
+}
+
+// finish 生成缺失的法线: 同一平滑组中共享位置的顶点使用面积加权的平均法线
+func (b *objBuilder) finish(c *objChunk) {
+	d := c.group.Mesh
+	if c.missing {
+		type key struct{ v, smooth int }
+		sum := make(map[key]mgl32.Vec3)
+		for i := 0; i < d.TriangleCount(); i++ {
+			i0, i1, i2 := d.Triangle(i)
+			p0 := d.Positions[i0]
+			n := d.Positions[i1].Sub(p0).Cross(d.Positions[i2].Sub(p0))
+			for _, v := range [3]uint32{i0, i1, i2} {
+				if c.smooth[v] != 0 {
+					k := key{c.pos[v], c.smooth[v]}
+					sum[k] = sum[k].Add(n)
+				}
+			}
+		}
+		for i, s := range c.smooth {
+			if s != 0 {
+				if n := sum[key{c.pos[i], s}]; n.Len() > 0 {
+					d.Normals[i] = n.Normalize()
+				}
+			}
+		}
+	}
+
+	d.computeTangents()
+}
+
+// ParseMTL 解析材质库, dir 为贴图路径的基准目录
+func ParseMTL(r io.Reader, dir string) (map[string]*Material, error) {
+	var (
+		materials = make(map[string]*Material)
+		cur       *Material
+	)
+
+	err := scanLines(r, func(line int, fields []string) error {
+		args := fields[1:]
+		if fields[0] == "newmtl" {
+			cur = NewMaterial(strings.Join(args, " "))
+			materials[cur.Name] = cur
+			return nil
+		}
+		if cur == nil {
+			return nil // newmtl 之前的内容忽略
+		}
+
+		var err error
+		color := func(dst *mgl32.Vec3) {
+			var v []float32
+			if v, err = parseFloats(args, 3); err == nil {
+				*dst = mgl32.Vec3{v[0], v[1], v[2]}
+			}
+		}
+		scalar := func(dst *float32) {
+			var v []float32
+			if v, err = parseFloats(args, 1); err == nil {
+				*dst = v[0]
+			}
+		}
+		texture := func(dst *string) {
+			*dst = mtlTexture(args, dir)
+		}
+
+		switch strings.ToLower(fields[0]) {
+		case "ka":
+			color(&cur.Ambient)
+		case "kd":
+			color(&cur.Diffuse)
+		case "ks":
+			color(&cur.Specular)
+		case "ke":
+			color(&cur.Emissive)
+		case "ns":
+			scalar(&cur.Shininess)
+		case "d":
+			scalar(&cur.Opacity)
+		case "tr":
+			scalar(&cur.Opacity)
+			cur.Opacity = 1 - cur.Opacity
+		case "map_ka":
+			texture(&cur.AmbientMap)
+		case "map_kd":
+			texture(&cur.DiffuseMap)
+		case "map_ks":
+			texture(&cur.SpecularMap)
+		case "map_ke":
+			texture(&cur.EmissiveMap)
+		case "map_bump", "bump", "norm", "map_kn":
+			texture(&cur.NormalMap)
+		case "map_d":
+			texture(&cur.OpacityMap)
+		}
+		if err != nil {
+			return fmt.Errorf("mtl line %d: %w", line, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return materials, nil
+}
+
+// mtlOptionArgs 贴图选项及其参数个数
+var mtlOptionArgs = map[string]int{
+	"-blendu": 1, "-blendv": 1, "-boost": 1, "-mm": 2, "-o": 3, "-s": 3, "-t": 3,
+	"-texres": 1, "-clamp": 1, "-bm": 1, "-imfchan": 1, "-type": 1, "-cc": 1,
+}
+
+// mtlTexture 跳过贴图选项,剩余部分为文件名(可能包含空格)
+func mtlTexture(args []string, dir string) string {
+	i := 0
+	for i < len(args) {
+		n, ok := mtlOptionArgs[strings.ToLower(args[i])]
+		if !ok {
+			break
+		}
+		// -o/-s/-t 的后两个参数可以省略
+		i++
+		for j := 0; j < n && i < len(args); j++ {
+			if _, err := strconv.ParseFloat(args[i], 32); err != nil && j > 0 {
+				break
+			}
+			i++
+		}
+	}
+	if i >= len(args) {
+		return ""
+	}
+
+	name := strings.ReplaceAll(strings.Join(args[i:], " "), "\\", "/")
+	if path.IsAbs(name) || filepath.IsAbs(name) {
+		return name
+	}
+	return path.Join(dir, name)
+}
+
+// SaveOBJ 写入 obj 文件, 有材质时在同一目录写入同名的 mtl 文件, 贴图路径改为相对 obj 所在目录
+func SaveOBJ(name string, model *OBJModel) error {
+	mtllib := ""
+	if len(model.Materials) > 0 {
+		mtllib = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)) + ".mtl"
+		err := writeFile(filepath.Join(filepath.Dir(name), mtllib), func(w io.Writer) error {
+			return WriteMTL(w, model.Materials, filepath.Dir(name))
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeFile(name, func(w io.Writer) error {
+		return WriteOBJ(w, model, mtllib)
+	})
+}
+
+// writeFile 创建文件并写入, 写入失败时删除不完整的文件
+func writeFile(name string, write func(w io.Writer) error) error {
+	fw, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(fw)
+	err = write(bw)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if cerr := fw.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(name)
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+func formatFloat(v float32) string {
+	return strconv.FormatFloat(float64(v), 'g', -1, 32)
+}
+
+// WriteOBJ 按 ParseOBJ 能还原的形式写入所有组, 只支持三角形网格, mtllib 为空时不引用材质库
+// ParseOBJ 会合并对象名、组名和材质都相同的面, 所以重复的组在对象名后加 ".1"、".2" 等后缀
+func WriteOBJ(w io.Writer, model *OBJModel, mtllib string) error {
+	bw := bufio.NewWriter(w)
+	if mtllib != "" {
+		fmt.Fprintf(bw, "mtllib %s\n", mtllib)
+	}
+
+	var (
+		object, group, material string
+		base                    [3]int // 已写入的 v/vt/vn 数量
+		used                    = make(map[[3]string]bool)
+	)
+	for i, g := range model.Groups {
+		d := g.Mesh
+		if d.Topology != Triangles {
+			return fmt.Errorf("WriteOBJ: group %d is not a triangle mesh", i)
+		}
+
+		key := [3]string{g.Object, g.Group, g.Material}
+		for n := 1; used[key]; n++ {
+			name := g.Object
+			if name == "" {
+				name = "object"
+			}
+			key[0] = fmt.Sprintf("%s.%d", name, n)
+		}
+		used[key] = true
+
+		// 名字与上一组相同时不重复写, 第一组的空名字也不用写
+		if key[0] != object {
+			object = key[0]
+			fmt.Fprintf(bw, "o %s\n", object)
+		}
+		if g.Group != group {
+			group = g.Group
+			fmt.Fprintf(bw, "g %s\n", group)
+		}
+		if g.Material != material {
+			material = g.Material
+			fmt.Fprintf(bw, "usemtl %s\n", material)
+		}
+
+		for _, p := range d.Positions {
+			fmt.Fprintf(bw, "v %s %s %s\n", formatFloat(p[0]), formatFloat(p[1]), formatFloat(p[2]))
+		}
+		for _, uv := range d.UVs {
+			fmt.Fprintf(bw, "vt %s %s\n", formatFloat(uv[0]), formatFloat(uv[1]))
+		}
+		for _, n := range d.Normals {
+			fmt.Fprintf(bw, "vn %s %s %s\n", formatFloat(n[0]), formatFloat(n[1]), formatFloat(n[2]))
+		}
+
+		vertex := func(v uint32) string {
+			s := strconv.Itoa(base[0] + int(v) + 1)
+			switch {
+			case len(d.UVs) > 0 && len(d.Normals) > 0:
+				return fmt.Sprintf("%s/%d/%d", s, base[1]+int(v)+1, base[2]+int(v)+1)
+			case len(d.UVs) > 0:
+				return fmt.Sprintf("%s/%d", s, base[1]+int(v)+1)
+			case len(d.Normals) > 0:
+				return fmt.Sprintf("%s//%d", s, base[2]+int(v)+1)
+			default:
+				return s
+			}
+		}
+		for t := 0; t < d.TriangleCount(); t++ {
+			a, b, c := d.Triangle(t)
+			fmt.Fprintf(bw, "f %s %s %s\n", vertex(a), vertex(b), vertex(c))
+		}
+
+		base[0] += len(d.Positions)
+		base[1] += len(d.UVs)
+		base[2] += len(d.Normals)
+	}
+
+	return bw.Flush()
+}
+
+// WriteMTL 按名字顺序写入材质, 贴图路径尽量改为相对 dir 的路径
+func WriteMTL(w io.Writer, materials map[string]*Material, dir string) error {
+	bw := bufio.NewWriter(w)
+	color := func(key string, c mgl32.Vec3) {
+		fmt.Fprintf(bw, "%s %s %s %s\n", key, formatFloat(c[0]), formatFloat(c[1]), formatFloat(c[2]))
+	}
+	texture := func(key, p string) {
+		if p == "" {
+			return
+		}
+		if rel, err := filepath.Rel(dir, p); err == nil && filepath.IsAbs(p) == filepath.IsAbs(dir) {
+			p = rel
+		}
+		fmt.Fprintf(bw, "%s %s\n", key, filepath.ToSlash(p))
+	}
+
+	names := make([]string, 0, len(materials))
+	for name := range materials {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for i, name := range names {
+		m := materials[name]
+		if i > 0 {
+			fmt.Fprintln(bw)
+		}
+		fmt.Fprintf(bw, "newmtl %s\n", name)
+		color("Ka", m.Ambient)
+		color("Kd", m.Diffuse)
+		color("Ks", m.Specular)
+		color("Ke", m.Emissive)
+		fmt.Fprintf(bw, "Ns %s\n", formatFloat(m.Shininess))
+		fmt.Fprintf(bw, "d %s\n", formatFloat(m.Opacity))
+		texture("map_Ka", m.AmbientMap)
+		texture("map_Kd", m.DiffuseMap)
+		texture("map_Ks", m.SpecularMap)
+		texture("map_Ke", m.EmissiveMap)
+		texture("norm", m.NormalMap)
+		texture("map_d", m.OpacityMap)
+	}
+
+	return bw.Flush()
+}
+
+// Mesh 把所有组合并为一个网格, 组的拓扑需要一致
+func (m *OBJModel) Mesh() *MeshData {
+	d := &MeshData{}
+	for i, g := range m.Groups {
+		if i == 0 {
+			d.Topology = g.Mesh.Topology
+		}
+		d.Append(g.Mesh)
+	}
+	return d
+}
@@ -0,0 +1,244 @@
+package common
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testOBJ = `mtllib cube.mtl
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+s on
+usemtl red
+f 1/1 2/2 3/3 4
+`
+
+func TestLoadOBJMaterialLibrary(t *testing.T) {
+	const mtl = "newmtl red\nKd 1 0 0\nmap_Kd red.png\n"
+
+	tests := []struct {
+		name    string
+		mtllib  string                   // obj 中的 mtllib 参数
+		write   func(root string) string // 写入材质库, 返回材质库所在目录
+		diffuse string                   // 为空时期望默认材质
+	}{
+		{"same dir", "cube.mtl", func(root string) string { return filepath.Join(root, "obj") }, "red.png"},
+		{"parent dir", "../materials/cube.mtl", func(root string) string { return filepath.Join(root, "materials") }, "red.png"},
+		{"absolute", "", func(root string) string { return filepath.Join(root, "abs") }, "red.png"},
+		{"missing", "missing.mtl", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			objDir := filepath.Join(root, "obj")
+			err := os.MkdirAll(objDir, 0o755)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mtllib := tt.mtllib
+			var mtlDir string
+			if tt.write != nil {
+				mtlDir = tt.write(root)
+				err = os.MkdirAll(mtlDir, 0o755)
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = os.WriteFile(filepath.Join(mtlDir, "cube.mtl"), []byte(mtl), 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if mtllib == "" {
+					mtllib = filepath.ToSlash(filepath.Join(mtlDir, "cube.mtl"))
+				}
+			}
+
+			name := filepath.Join(objDir, "cube.obj")
+			err = os.WriteFile(name, []byte(strings.Replace(testOBJ, "cube.mtl", mtllib, 1)), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			model, err := LoadOBJ(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := model.Materials["red"]
+			if m == nil {
+				t.Fatal("material red missing")
+			}
+			if tt.diffuse == "" {
+				if *m != *NewMaterial("red") {
+					t.Errorf("material %+v, want default", m)
+				}
+				return
+			}
+			if want := filepath.Join(mtlDir, tt.diffuse); filepath.Clean(m.DiffuseMap) != want {
+				t.Errorf("diffuse map %q, want %q", m.DiffuseMap, want)
+			}
+			if m.Diffuse[1] != 0 {
+				t.Errorf("diffuse %v, want red", m.Diffuse)
+			}
+		})
+	}
+}
+
+func TestParseOBJSmoothOn(t *testing.T) {
+	model, err := ParseOBJ(strings.NewReader(testOBJ), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Groups) != 1 {
+		t.Fatalf("%d groups, want 1", len(model.Groups))
+	}
+	// 同一平滑组中共享位置的顶点不会被拆开
+	mesh := model.Groups[0].Mesh
+	if mesh.VertexCount() != 4 || mesh.TriangleCount() != 2 {
+		t.Errorf("%d vertices, %d triangles, want 4 and 2", mesh.VertexCount(), mesh.TriangleCount())
+	}
+	for i, n := range mesh.Normals {
+		if n[2] < 0.99 {
+			t.Errorf("normal %d = %v, want +z", i, n)
+		}
+	}
+}
+
+func TestScanLinesComment(t *testing.T) {
+	const mtl = "# exported\nnewmtl red#2 # second red\nKd 1 0 0 #comment\nmap_Kd tex#1.png # diffuse\nmap_Ks\tspec #1.png\n"
+	materials, err := ParseMTL(strings.NewReader(mtl), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := materials["red#2"]
+	if m == nil {
+		t.Fatalf("materials %v, want red#2", materials)
+	}
+	if m.DiffuseMap != "tex#1.png" || m.SpecularMap != "spec" || m.Diffuse[0] != 1 {
+		t.Errorf("diffuse %v map %q, specular map %q", m.Diffuse, m.DiffuseMap, m.SpecularMap)
+	}
+
+	// 续行的下一行可以是注释, 续行中的注释只去掉所在行的剩余部分
+	const obj = "v 0 0 0 # origin\nv 1 0 0\nv 0 1 0 \\\n# not continued\no part#1\nf 1 2 \\\n3 # face\n"
+	model, err := ParseOBJ(strings.NewReader(obj), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Groups) != 1 || model.Groups[0].Object != "part#1" || model.Groups[0].Mesh.TriangleCount() != 1 {
+		t.Errorf("groups %+v", model.Groups)
+	}
+}
+
+func FuzzParseOBJ(f *testing.F) {
+	f.Add(testOBJ)
+	f.Add("v 0 0 0\nv 1 0 0\nv 0 1 0\nvn 0 0 1\nf 1//1 2//1 3//1\n")
+	f.Add("v 0 0 0\nv 1 0 0\nv 0 1 0\nf -3 -2 -1\ng a b\no c\ns off\nf 1 2 3 \\\n 1\n")
+	f.Add("v 1e40 nan inf\nvt 0\nf 1/1/1 1 1\nl 1 2\n")
+
+	f.Fuzz(func(t *testing.T, src string) {
+		model, err := ParseOBJ(strings.NewReader(src), nil)
+		if err != nil {
+			return
+		}
+		for _, g := range model.Groups {
+			d := g.Mesh
+			for _, i := range d.Indices {
+				if int(i) >= d.VertexCount() {
+					t.Fatalf("index %d out of range %d", i, d.VertexCount())
+				}
+			}
+			if len(d.Normals) != d.VertexCount() || len(d.UVs) != d.VertexCount() {
+				t.Fatalf("%d normals, %d uvs for %d vertices", len(d.Normals), len(d.UVs), d.VertexCount())
+			}
+		}
+	})
+}
+
+func FuzzParseMTL(f *testing.F) {
+	f.Add("newmtl a\nKa 1 1 1\nKd 0.5 0.5 0.5\nNs 10\nd 0.5\nmap_Kd -o 1 1 tex.png\n")
+	f.Add("Kd 1 1 1\nnewmtl b\nTr 0.2\nbump -bm 2 normal map.png\nmap_Ks -s 1\n")
+	f.Add("newmtl c\nKd x\n")
+
+	f.Fuzz(func(t *testing.T, src string) {
+		materials, err := ParseMTL(strings.NewReader(src), "dir")
+		if err != nil {
+			return
+		}
+		for name, m := range materials {
+			if m == nil || m.Name != name {
+				t.Fatalf("material %q: %+v", name, m)
+			}
+		}
+	})
+}
+
+// sameTriangles 比较两个网格逐个三角形的顶点属性, 不要求顶点顺序相同
+func sameTriangles(t *testing.T, got, want *MeshData, eps float32) {
+	t.Helper()
+	if got.TriangleCount() != want.TriangleCount() {
+		t.Fatalf("%d triangles, want %d", got.TriangleCount(), want.TriangleCount())
+	}
+	for i := 0; i < want.TriangleCount(); i++ {
+		g0, g1, g2 := got.Triangle(i)
+		w0, w1, w2 := want.Triangle(i)
+		for k, v := range [3][2]uint32{{g0, w0}, {g1, w1}, {g2, w2}} {
+			g, w := v[0], v[1]
+			if got.Positions[g].Sub(want.Positions[w]).Len() > eps {
+				t.Fatalf("triangle %d corner %d: position %v, want %v", i, k, got.Positions[g], want.Positions[w])
+			}
+			if len(want.Normals) > 0 && got.Normals[g].Sub(want.Normals[w]).Len() > eps {
+				t.Fatalf("triangle %d corner %d: normal %v, want %v", i, k, got.Normals[g], want.Normals[w])
+			}
+			if len(want.UVs) > 0 && got.UVs[g].Sub(want.UVs[w]).Len() > eps {
+				t.Fatalf("triangle %d corner %d: uv %v, want %v", i, k, got.UVs[g], want.UVs[w])
+			}
+		}
+	}
+}
+
+func TestWriteOBJRoundTrip(t *testing.T) {
+	// 未命名的相邻组使用相同的材质, 写出后不能被合并
+	model := &OBJModel{Groups: []*OBJGroup{
+		{Material: "red", Mesh: GenCube(1, 1)},
+		{Material: "red", Mesh: GenPlane(2, 2, 2, 2)},
+		{Object: "torus", Group: "body", Material: "blue", Mesh: GenTorus(1, 0.3, 12, 8)},
+		{Material: "red", Mesh: GenUVSphere(0.5, 8, 6)},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteOBJ(&buf, model, ""); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseOBJ(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Groups) != len(model.Groups) {
+		t.Fatalf("%d groups read back, want %d", len(got.Groups), len(model.Groups))
+	}
+
+	names := make(map[[3]string]bool)
+	for i, g := range got.Groups {
+		want := model.Groups[i]
+		if g.Group != want.Group || g.Material != want.Material {
+			t.Errorf("group %d: %q/%q, want %q/%q", i, g.Group, g.Material, want.Group, want.Material)
+		}
+		key := [3]string{g.Object, g.Group, g.Material}
+		if names[key] {
+			t.Errorf("group %d: duplicate name %v", i, key)
+		}
+		names[key] = true
+		sameTriangles(t, g.Mesh, want.Mesh, 1e-6)
+	}
+	if got.Groups[2].Object != "torus" {
+		t.Errorf("object %q, want torus", got.Groups[2].Object)
+	}
+}
@@ -0,0 +1,397 @@
+package common
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// 数据可视化用的几何: 函数曲面、参数曲面和曲线、Bezier 曲面片、沿曲线的管道以及颜色映射
+
+// GenParametricSurface 在 [0,1]x[0,1] 上采样参数曲面 f(u, v), UV 即参数
+// 法线为 ∂f/∂u × ∂f/∂v 的方向(差分估计), 正面朝向法线; f 返回 NaN 的顶点所在的三角形会被丢掉
+func GenParametricSurface(f func(u, v float32) mgl32.Vec3, segU, segV int) *MeshData {
+	segU, segV = max(segU, 1), max(segV, 1)
+	d := &MeshData{}
+
+	for j := 0; j <= segV; j++ {
+		v := float32(j) / float32(segV)
+		for i := 0; i <= segU; i++ {
+			u := float32(i) / float32(segU)
+			d.Positions = append(d.Positions, f(u, v))
+			d.Normals = append(d.Normals, surfaceNormal(f, u, v))
+			d.UVs = append(d.UVs, mgl32.Vec2{u, v})
+		}
+	}
+	gridIndices(d, segU, segV)
+
+	d.computeTangents()
+	return d
+}
+
+// GenFunctionSurface 函数 z=f(x, y) 的图像, (x, y) 取 [min, max] 范围
+// 绘图坐标 (x, y, z) 对应 OpenGL 的 (x, z, -y), 即函数值朝 +Y, 与右手系一致
+func GenFunctionSurface(f func(x, y float32) float32, min, max mgl32.Vec2, segX, segY int) *MeshData {
+	size := max.Sub(min)
+	return GenParametricSurface(func(u, v float32) mgl32.Vec3 {
+		x, y := min[0]+size[0]*u, min[1]+size[1]*v
+		return mgl32.Vec3{x, f(x, y), -y}
+	}, segX, segY)
+}
+
+// GenBezierSurface 任意阶的张量积 Bezier 曲面, control[j][i] 中 i 沿 u 方向, j 沿 v 方向
+// 每行的控制点数需要相同, 4x4 时即常见的双三次曲面片; 没有控制点或行长度不一致时返回错误
+func GenBezierSurface(control [][]mgl32.Vec3, segU, segV int) (*MeshData, error) {
+	if len(control) == 0 || len(control[0]) == 0 {
+		return nil, fmt.Errorf("GenBezierSurface: empty control grid")
+	}
+	for j, row := range control {
+		if len(row) != len(control[0]) {
+			return nil, fmt.Errorf("GenBezierSurface: row %d has %d control points, want %d", j, len(row), len(control[0]))
+		}
+	}
+
+	return GenParametricSurface(func(u, v float32) mgl32.Vec3 {
+		column := make([]mgl32.Vec3, len(control))
+		for j, row := range control {
+			column[j] = bezierPoint(row, u)
+		}
+		return bezierPoint(column, v)
+	}, segU, segV), nil
+}
+
+// GenParametricCurve 在 [0,1] 上采样曲线 f(t), 拓扑为 Lines, UV 的 u 为参数
+func GenParametricCurve(f func(t float32) mgl32.Vec3, segments int) *MeshData {
+	segments = max(segments, 1)
+	d := &MeshData{Topology: Lines}
+
+	for i := 0; i <= segments; i++ {
+		t := float32(i) / float32(segments)
+		d.Positions = append(d.Positions, f(t))
+		d.UVs = append(d.UVs, mgl32.Vec2{t, 0})
+	}
+	for i := uint32(0); i < uint32(segments); i++ {
+		d.Indices = append(d.Indices, i, i+1)
+	}
+	return d
+}
+
+// GenTube 沿折线 path 的圆管, 用平行移动标架避免扭曲, 开放的管道两端不封口
+// UV 的 u 绕管一周, v 为弧长比例; closed 时首尾相接, 并把累计的扭转均摊到每一圈
+func GenTube(path []mgl32.Vec3, radius float32, radialSegments int, closed bool) *MeshData {
+	radialSegments = max(radialSegments, 3)
+	n := len(path)
+	if n < 2 {
+		return &MeshData{}
+	}
+
+	// 重复的点切线为 0, 沿用前一个非零切线, 开头的几个点使用第一个非零切线
+	tangents := make([]mgl32.Vec3, n)
+	first := -1
+	for i := range path {
+		prev, next := max(i-1, 0), min(i+1, n-1)
+		if closed {
+			prev, next = (i+n-1)%n, (i+1)%n
+		}
+		tangents[i] = path[next].Sub(path[prev])
+		switch {
+		case tangents[i].Len() >= 1e-12:
+			tangents[i] = tangents[i].Normalize()
+			if first < 0 {
+				first = i
+			}
+		case first >= 0:
+			tangents[i] = tangents[i-1]
+		}
+	}
+	if first < 0 {
+		return &MeshData{} // 所有点重合
+	}
+	for i := 0; i < first; i++ {
+		tangents[i] = tangents[first]
+	}
+
+	normals := make([]mgl32.Vec3, n)
+	normals[0] = perpendicular(tangents[0])
+	for i := 1; i < n; i++ {
+		normals[i] = transport(normals[i-1], tangents[i])
+	}
+
+	rings := n
+	if closed {
+		// 绕一圈回到起点后标架会有一个扭转角, 逐圈旋转抵消
+		wrap := transport(normals[n-1], tangents[0])
+		twist := float32(math.Atan2(float64(tangents[0].Dot(wrap.Cross(normals[0]))), float64(wrap.Dot(normals[0]))))
+		for i := range normals {
+			normals[i] = rotateAround(normals[i], tangents[i], twist*float32(i)/float32(n))
+		}
+		rings = n + 1
+	}
+
+	lengths := make([]float32, rings)
+	for i := 1; i < rings; i++ {
+		lengths[i] = lengths[i-1] + path[i%n].Sub(path[i-1]).Len()
+	}
+	total := max(lengths[rings-1], 1e-12)
+
+	d := &MeshData{}
+	for i := 0; i < rings; i++ {
+		p, t, nrm := path[i%n], tangents[i%n], normals[i%n]
+		b := t.Cross(nrm)
+		for k := 0; k <= radialSegments; k++ {
+			u := float32(k) / float32(radialSegments)
+			theta := float64(u) * 2 * math.Pi
+			dir := nrm.Mul(float32(math.Cos(theta))).Add(b.Mul(float32(math.Sin(theta))))
+			d.Positions = append(d.Positions, p.Add(dir.Mul(radius)))
+			d.Normals = append(d.Normals, dir)
+			d.UVs = append(d.UVs, mgl32.Vec2{u, lengths[i] / total})
+		}
+	}
+	gridIndices(d, radialSegments, rings-1)
+
+	d.computeTangents()
+	return d
+}
+
+// CatmullRom 向心 Catmull-Rom 样条经过所有控制点, 每段采样 samples 次, 不会出现尖点和自交
+// 开放曲线包含两个端点, closed 时最后一段回到起点但不重复起点
+func CatmullRom(points []mgl32.Vec3, samples int, closed bool) []mgl32.Vec3 {
+	samples = max(samples, 1)
+	n := len(points)
+	if n < 2 {
+		return append([]mgl32.Vec3(nil), points...)
+	}
+
+	// 开放曲线的两端外插一个虚拟控制点
+	at := func(i int) mgl32.Vec3 {
+		switch {
+		case closed:
+			return points[(i+n)%n]
+		case i < 0:
+			return points[0].Mul(2).Sub(points[1])
+		case i >= n:
+			return points[n-1].Mul(2).Sub(points[n-2])
+		}
+		return points[i]
+	}
+
+	segments := n - 1
+	if closed {
+		segments = n
+	}
+	out := make([]mgl32.Vec3, 0, segments*samples+1)
+	for s := 0; s < segments; s++ {
+		p0, p1, p2, p3 := at(s-1), at(s), at(s+1), at(s+2)
+		for k := 0; k < samples; k++ {
+			out = append(out, catmullRomPoint(p0, p1, p2, p3, float32(k)/float32(samples)))
+		}
+	}
+	if !closed {
+		out = append(out, points[n-1])
+	}
+	return out
+}
+
+// catmullRomPoint Barry-Goldman 金字塔形式, 节点间隔为弦长的平方根
+func catmullRomPoint(p0, p1, p2, p3 mgl32.Vec3, u float32) mgl32.Vec3 {
+	knot := func(a, b mgl32.Vec3) float32 {
+		return max(float32(math.Sqrt(float64(b.Sub(a).Len()))), 1e-6)
+	}
+	t0 := float32(0)
+	t1 := t0 + knot(p0, p1)
+	t2 := t1 + knot(p1, p2)
+	t3 := t2 + knot(p2, p3)
+	t := t1 + (t2-t1)*u
+
+	lerp := func(a, b mgl32.Vec3, ta, tb float32) mgl32.Vec3 {
+		return a.Mul((tb - t) / (tb - ta)).Add(b.Mul((t - ta) / (tb - ta)))
+	}
+	a1 := lerp(p0, p1, t0, t1)
+	a2 := lerp(p1, p2, t1, t2)
+	a3 := lerp(p2, p3, t2, t3)
+	b1 := lerp(a1, a2, t0, t2)
+	b2 := lerp(a2, a3, t1, t3)
+	return lerp(b1, b2, t1, t2)
+}
+
+// bezierPoint de Casteljau 算法
+func bezierPoint(points []mgl32.Vec3, t float32) mgl32.Vec3 {
+	tmp := append([]mgl32.Vec3(nil), points...)
+	for n := len(tmp) - 1; n > 0; n-- {
+		for i := 0; i < n; i++ {
+			tmp[i] = tmp[i].Mul(1 - t).Add(tmp[i+1].Mul(t))
+		}
+	}
+	return tmp[0]
+}
+
+// gridIndices 为 (segU+1)*(segV+1) 的顶点网格生成三角形, 跳过有非有限坐标的三角形并去掉多余的顶点
+func gridIndices(d *MeshData, segU, segV int) {
+	valid := func(i uint32) bool {
+		return finite(d.Positions[i])
+	}
+
+	row := uint32(segU + 1)
+	dropped := false
+	for j := uint32(0); j < uint32(segV); j++ {
+		for i := uint32(0); i < uint32(segU); i++ {
+			a := j*row + i
+			b := a + 1
+			c := a + row
+			e := c + 1
+			for _, tri := range [2][3]uint32{{a, b, e}, {a, e, c}} {
+				if valid(tri[0]) && valid(tri[1]) && valid(tri[2]) {
+					d.Indices = append(d.Indices, tri[:]...)
+				} else {
+					dropped = true
+				}
+			}
+		}
+	}
+
+	switch {
+	case !dropped:
+	case len(d.Indices) == 0:
+		*d = MeshData{}
+	default:
+		d.OptimizeVertexFetch()
+	}
+}
+
+func finite(v mgl32.Vec3) bool {
+	for _, x := range v {
+		if math.IsNaN(float64(x)) || math.IsInf(float64(x), 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// partial 参数 t 处的差分, 中心差分遇到定义域外的 NaN 时改用单侧差分, 只关心方向
+func partial(f func(t float32) mgl32.Vec3, t float32) mgl32.Vec3 {
+	const h = 1e-3
+	lo, hi := max(t-h, 0), min(t+h, 1)
+	c := f(t)
+	for _, d := range [...]mgl32.Vec3{f(hi).Sub(f(lo)), f(hi).Sub(c), c.Sub(f(lo))} {
+		if finite(d) {
+			return d
+		}
+	}
+	return mgl32.Vec3{}
+}
+
+// surfaceNormal 极点等退化处偏导数平行, 向旁边偏一点重新求
+func surfaceNormal(f func(u, v float32) mgl32.Vec3, u, v float32) mgl32.Vec3 {
+	for _, o := range [...]mgl32.Vec2{{0, 0}, {0, 0.01}, {0, -0.01}, {0.01, 0}, {-0.01, 0}} {
+		uu, vv := mgl32.Clamp(u+o[0], 0, 1), mgl32.Clamp(v+o[1], 0, 1)
+		du := partial(func(t float32) mgl32.Vec3 { return f(t, vv) }, uu)
+		dv := partial(func(t float32) mgl32.Vec3 { return f(uu, t) }, vv)
+
+		n := du.Cross(dv)
+		if l := n.Len(); l > 1e-4*du.Len()*dv.Len() {
+			return n.Mul(1 / l)
+		}
+	}
+	return mgl32.Vec3{}
+}
+
+// perpendicular 任取一个与 v 垂直的单位向量
+func perpendicular(v mgl32.Vec3) mgl32.Vec3 {
+	axis := mgl32.Vec3{1, 0, 0}
+	if abs(v[0]) > 0.9 {
+		axis = mgl32.Vec3{0, 1, 0}
+	}
+	return v.Cross(axis).Normalize()
+}
+
+// transport 把上一圈的法线投影到与新切线垂直的平面上
+func transport(n, t mgl32.Vec3) mgl32.Vec3 {
+	p := n.Sub(t.Mul(t.Dot(n)))
+	if p.Len() < 1e-6 {
+		return perpendicular(t)
+	}
+	return p.Normalize()
+}
+
+// rotateAround Rodrigues 公式, v 与单位轴 axis 垂直
+func rotateAround(v, axis mgl32.Vec3, angle float32) mgl32.Vec3 {
+	sin, cos := float32(math.Sin(float64(angle))), float32(math.Cos(float64(angle)))
+	return v.Mul(cos).Add(axis.Cross(v).Mul(sin))
+}
+
+// Colormap 均匀分布的颜色节点, 相邻节点之间线性插值
+type Colormap []mgl32.Vec3
+
+// hexColormap 用 0xRRGGBB 形式的颜色构造
+func hexColormap(colors ...uint32) Colormap {
+	c := make(Colormap, len(colors))
+	for i, x := range colors {
+		c[i] = mgl32.Vec3{float32(x>>16&0xff) / 255, float32(x>>8&0xff) / 255, float32(x&0xff) / 255}
+	}
+	return c
+}
+
+// 常用的颜色映射, viridis 和 inferno 取自 matplotlib
+var (
+	ColormapViridis = hexColormap(
+		0x440154, 0x482475, 0x414487, 0x355f8d, 0x2a788e, 0x21918c,
+		0x22a884, 0x44bf70, 0x7ad151, 0xbddf26, 0xfde725,
+	)
+	ColormapInferno = hexColormap(
+		0x000004, 0x160b39, 0x420a68, 0x6a176e, 0x932667, 0xbc3754,
+		0xdd513a, 0xf37819, 0xfca50a, 0xf6d746, 0xfcffa4,
+	)
+	ColormapJet = Colormap{
+		{0, 0, 0.5}, {0, 0, 1}, {0, 0.5, 1}, {0, 1, 1}, {0.5, 1, 0.5},
+		{1, 1, 0}, {1, 0.5, 0}, {1, 0, 0}, {0.5, 0, 0},
+	}
+	ColormapCoolWarm = hexColormap(0x3b4cc0, 0xdddddd, 0xb40426)
+	ColormapGray     = Colormap{{0, 0, 0}, {1, 1, 1}}
+)
+
+// At t 取 [0,1], 超出范围时截断, NaN 按 0 处理
+func (c Colormap) At(t float32) mgl32.Vec4 {
+	if len(c) == 0 {
+		return mgl32.Vec4{1, 1, 1, 1}
+	}
+	if t != t {
+		t = 0
+	}
+
+	x := mgl32.Clamp(t, 0, 1) * float32(len(c)-1)
+	i := min(int(x), len(c)-2)
+	if i < 0 {
+		return c[0].Vec4(1)
+	}
+	f := x - float32(i)
+	return c[i].Mul(1 - f).Add(c[i+1].Mul(f)).Vec4(1)
+}
+
+// ApplyColormap 按每个顶点的标量值设置顶点颜色, values 与 Positions 一一对应, 个数不同时返回错误
+// lo >= hi 时使用 values 的最小值和最大值
+func (d *MeshData) ApplyColormap(c Colormap, values []float32, lo, hi float32) error {
+	if len(values) != len(d.Positions) {
+		return fmt.Errorf("ApplyColormap: %d values for %d vertices", len(values), len(d.Positions))
+	}
+
+	if lo >= hi {
+		lo, hi = float32(math.Inf(1)), float32(math.Inf(-1))
+		for _, v := range values {
+			if v != v {
+				continue
+			}
+			lo, hi = min(lo, v), max(hi, v)
+		}
+	}
+	scale := float32(0)
+	if hi > lo {
+		scale = 1 / (hi - lo)
+	}
+
+	d.Colors = make([]mgl32.Vec4, len(d.Positions))
+	for i := range d.Colors {
+		d.Colors[i] = c.At((values[i] - lo) * scale)
+	}
+	return nil
+}
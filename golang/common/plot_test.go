@@ -0,0 +1,142 @@
+package common
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestGenBezierSurfaceInvalid(t *testing.T) {
+	for name, control := range map[string][][]mgl32.Vec3{
+		"nil":    nil,
+		"empty":  {{}},
+		"ragged": {{{0, 0, 0}, {1, 0, 0}}, {{0, 0, 1}}},
+	} {
+		if _, err := GenBezierSurface(control, 4, 4); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+
+	d, err := GenBezierSurface([][]mgl32.Vec3{{{0, 0, 0}, {1, 0, 0}}, {{0, 0, -1}, {1, 0, -1}}}, 4, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := d.TriangleCount(); n != 32 {
+		t.Errorf("%d triangles, want 32", n)
+	}
+}
+
+func TestApplyColormapLength(t *testing.T) {
+	d := GenPlane(1, 1, 1, 1)
+	if err := d.ApplyColormap(ColormapGray, []float32{0, 1}, 0, 0); err == nil {
+		t.Error("short values accepted")
+	}
+	if err := d.ApplyColormap(ColormapGray, []float32{0, 1, 0, 1}, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if d.Colors[1] != (mgl32.Vec4{1, 1, 1, 1}) {
+		t.Errorf("color %v, want white", d.Colors[1])
+	}
+}
+
+// checkTube 每一圈的顶点都在距路径点 radius 处, 法线为单位向量并指向外侧
+func checkTube(t *testing.T, d *MeshData, path []mgl32.Vec3, radius float32, segments, rings int) {
+	t.Helper()
+	if got, want := len(d.Positions), rings*(segments+1); got != want {
+		t.Fatalf("%d vertices, want %d", got, want)
+	}
+	if got, want := d.TriangleCount(), (rings-1)*segments*2; got != want {
+		t.Fatalf("%d triangles, want %d", got, want)
+	}
+	for i, p := range d.Positions {
+		center := path[i/(segments+1)%len(path)]
+		n := d.Normals[i]
+		if !finite(p) || !finite(n) {
+			t.Fatalf("vertex %d: position %v normal %v", i, p, n)
+		}
+		if e := abs(p.Sub(center).Len() - radius); e > 1e-5 {
+			t.Errorf("vertex %d: distance to path off by %v", i, e)
+		}
+		if e := p.Sub(center.Add(n.Mul(radius))).Len(); e > 1e-5 {
+			t.Errorf("vertex %d: normal %v does not point away from the path", i, n)
+		}
+	}
+}
+
+func TestGenTube(t *testing.T) {
+	path := []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {2, 1, 0}, {2, 2, 1}}
+	checkTube(t, GenTube(path, 0.25, 8, false), path, 0.25, 8, len(path))
+	checkTube(t, GenTube(path, 0.25, 8, true), path, 0.25, 8, len(path)+1)
+}
+
+func TestGenTubeDuplicatePoints(t *testing.T) {
+	for _, path := range [][]mgl32.Vec3{
+		{{0, 0, 0}, {0, 0, 0}, {1, 0, 0}, {2, 1, 0}},
+		{{0, 0, 0}, {1, 0, 0}, {1, 0, 0}, {2, 1, 0}},
+		{{0, 0, 0}, {1, 0, 0}, {2, 1, 0}, {2, 1, 0}},
+	} {
+		checkTube(t, GenTube(path, 0.1, 6, false), path, 0.1, 6, len(path))
+	}
+
+	if d := GenTube([]mgl32.Vec3{{1, 2, 3}, {1, 2, 3}}, 0.1, 6, false); len(d.Positions) != 0 {
+		t.Errorf("%d vertices for a single repeated point", len(d.Positions))
+	}
+}
+
+func TestGenFunctionSurface(t *testing.T) {
+	d := GenFunctionSurface(func(x, y float32) float32 {
+		return x * y
+	}, mgl32.Vec2{-1, -1}, mgl32.Vec2{1, 1}, 4, 4)
+	if len(d.Positions) != 25 || d.TriangleCount() != 32 {
+		t.Fatalf("%d vertices %d triangles, want 25 and 32", len(d.Positions), d.TriangleCount())
+	}
+	for i, p := range d.Positions {
+		x, y := p[0], -p[2]
+		if abs(p[1]-x*y) > 1e-6 {
+			t.Errorf("vertex %d: %v, want height %v", i, p, x*y)
+		}
+		if d.Normals[i][1] <= 0 {
+			t.Errorf("vertex %d: normal %v points down", i, d.Normals[i])
+		}
+	}
+
+	// NaN 的区域没有三角形, 也不留下顶点
+	hole := GenFunctionSurface(func(x, y float32) float32 {
+		if x > 0 {
+			return float32(math.NaN())
+		}
+		return 0
+	}, mgl32.Vec2{-1, -1}, mgl32.Vec2{1, 1}, 4, 4)
+	if hole.TriangleCount() != 16 {
+		t.Errorf("%d triangles, want 16", hole.TriangleCount())
+	}
+	for i, p := range hole.Positions {
+		if !finite(p) {
+			t.Errorf("vertex %d: %v", i, p)
+		}
+	}
+}
+
+func TestCatmullRom(t *testing.T) {
+	points := []mgl32.Vec3{{0, 0, 0}, {1, 2, 0}, {3, 1, 1}, {4, 0, 0}}
+	open := CatmullRom(points, 5, false)
+	if len(open) != 3*5+1 {
+		t.Fatalf("%d samples, want 16", len(open))
+	}
+	for i, p := range points {
+		if e := open[i*5].Sub(p).Len(); e > 1e-5 {
+			t.Errorf("open curve misses control point %d by %v", i, e)
+		}
+	}
+
+	closed := CatmullRom(points, 5, true)
+	if len(closed) != 4*5 {
+		t.Fatalf("%d samples, want 20", len(closed))
+	}
+	for i, p := range points {
+		if e := closed[i*5].Sub(p).Len(); e > 1e-5 {
+			t.Errorf("closed curve misses control point %d by %v", i, e)
+		}
+	}
+}
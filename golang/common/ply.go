@@ -0,0 +1,548 @@
+package common
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// PLYModel ply 文件的内容, 没有面时 Mesh 的拓扑为 Points
+// Properties 保存 vertex 元素中除位置、法线、颜色、纹理坐标以外的标量属性
+type PLYModel struct {
+	Mesh       *MeshData
+	Properties map[string][]float32
+	Comments   []string
+}
+
+// LoadPLY 读取 ascii 或二进制(大端/小端)格式的 ply 文件
+func LoadPLY(name string) (*PLYModel, error) {
+	fr, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer fr.Close()
+
+	m, err := ParsePLY(fr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return m, nil
+}
+
+type plyFormat int
+
+const (
+	plyASCII plyFormat = iota
+	plyBinaryLE
+	plyBinaryBE
+)
+
+type plyProperty struct {
+	Name      string
+	Type      string
+	CountType string // 非空表示 list 属性
+}
+
+type plyElement struct {
+	Name       string
+	Count      int
+	Properties []plyProperty
+}
+
+type plyHeader struct {
+	Format   plyFormat
+	Elements []plyElement
+	Comments []string
+}
+
+func plyTypeSize(t string) int {
+	switch t {
+	case "char", "int8", "uchar", "uint8":
+		return 1
+	case "short", "int16", "ushort", "uint16":
+		return 2
+	case "int", "int32", "uint", "uint32", "float", "float32":
+		return 4
+	case "double", "float64":
+		return 8
+	default:
+		return 0
+	}
+}
+
+// plyNormalize 整数颜色分量按类型的最大值映射到 [0,1](有符号类型为 [-1,1]), 浮点数不变
+func plyNormalize(t string, v float64) float32 {
+	switch t {
+	case "uchar", "uint8":
+		return float32(v / 255)
+	case "ushort", "uint16":
+		return float32(v / 65535)
+	case "char", "int8":
+		return float32(v / 127)
+	case "short", "int16":
+		return float32(v / 32767)
+	case "uint", "uint32":
+		return float32(v / math.MaxUint32)
+	case "int", "int32":
+		return float32(v / math.MaxInt32)
+	default:
+		return float32(v)
+	}
+}
+
+func parsePLYHeader(r *bufio.Reader) (*plyHeader, error) {
+	h := &plyHeader{}
+
+	line, err := r.ReadString('\n')
+	if err != nil || strings.TrimSpace(line) != "ply" {
+		return nil, errors.New("ply: bad magic")
+	}
+
+	hasFormat := false
+	for {
+		line, err = r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("ply header: %w", err)
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "format":
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("ply: bad format line %q", line)
+			}
+			switch fields[1] {
+			case "ascii":
+				h.Format = plyASCII
+			case "binary_little_endian":
+				h.Format = plyBinaryLE
+			case "binary_big_endian":
+				h.Format = plyBinaryBE
+			default:
+				return nil, fmt.Errorf("ply: unknown format %q", fields[1])
+			}
+			hasFormat = true
+		case "comment", "obj_info":
+			h.Comments = append(h.Comments, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), fields[0])))
+		case "element":
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("ply: bad element line %q", line)
+			}
+			n, err := strconv.Atoi(fields[2])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("ply: bad element count %q", fields[2])
+			}
+			h.Elements = append(h.Elements, plyElement{Name: fields[1], Count: n})
+		case "property":
+			if len(h.Elements) == 0 {
+				return nil, errors.New("ply: property before element")
+			}
+			var p plyProperty
+			switch {
+			case len(fields) == 5 && fields[1] == "list":
+				p = plyProperty{Name: fields[4], Type: fields[3], CountType: fields[2]}
+				if plyTypeSize(p.CountType) == 0 {
+					return nil, fmt.Errorf("ply: bad list count type %q", p.CountType)
+				}
+			case len(fields) == 3:
+				p = plyProperty{Name: fields[2], Type: fields[1]}
+			default:
+				return nil, fmt.Errorf("ply: bad property line %q", line)
+			}
+			if plyTypeSize(p.Type) == 0 {
+				return nil, fmt.Errorf("ply: bad property type %q", p.Type)
+			}
+			e := &h.Elements[len(h.Elements)-1]
+			e.Properties = append(e.Properties, p)
+		case "end_header":
+			if !hasFormat {
+				return nil, errors.New("ply: missing format")
+			}
+			return h, nil
+		default:
+			return nil, fmt.Errorf("ply: unknown header line %q", line)
+		}
+	}
+}
+
+// plyReader 按格式读取单个数值
+type plyReader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder // nil 表示 ascii
+	buf   [8]byte
+}
+
+func (p *plyReader) read(t string) (float64, error) {
+	if p.order == nil {
+		return p.readASCII()
+	}
+
+	b := p.buf[:plyTypeSize(t)]
+	if _, err := io.ReadFull(p.r, b); err != nil {
+		return 0, err
+	}
+
+	switch t {
+	case "char", "int8":
+		return float64(int8(b[0])), nil
+	case "uchar", "uint8":
+		return float64(b[0]), nil
+	case "short", "int16":
+		return float64(int16(p.order.Uint16(b))), nil
+	case "ushort", "uint16":
+		return float64(p.order.Uint16(b)), nil
+	case "int", "int32":
+		return float64(int32(p.order.Uint32(b))), nil
+	case "uint", "uint32":
+		return float64(p.order.Uint32(b)), nil
+	case "float", "float32":
+		return float64(math.Float32frombits(p.order.Uint32(b))), nil
+	default:
+		return math.Float64frombits(p.order.Uint64(b)), nil
+	}
+}
+
+// readASCII 读取下一个以空白分隔的数值
+func (p *plyReader) readASCII() (float64, error) {
+	var sb strings.Builder
+	for {
+		c, err := p.r.ReadByte()
+		if err != nil {
+			if err == io.EOF && sb.Len() > 0 {
+				break
+			}
+			return 0, err
+		}
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			if sb.Len() > 0 {
+				break
+			}
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return strconv.ParseFloat(sb.String(), 64)
+}
+
+// ParsePLY 解析 ply, vertex 元素的 x/y/z、nx/ny/nz、red/green/blue/alpha、u/v(s/t) 映射到网格属性,
+// face 元素的多边形会被三角化, 其他元素读取后忽略
+func ParsePLY(r io.Reader) (*PLYModel, error) {
+	br := bufio.NewReader(r)
+	h, err := parsePLYHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	pr := &plyReader{r: br}
+	switch h.Format {
+	case plyBinaryLE:
+		pr.order = binary.LittleEndian
+	case plyBinaryBE:
+		pr.order = binary.BigEndian
+	}
+
+	m := &PLYModel{
+		Mesh:       &MeshData{Topology: Points},
+		Properties: make(map[string][]float32),
+		Comments:   h.Comments,
+	}
+
+	for _, e := range h.Elements {
+		switch e.Name {
+		case "vertex":
+			err = m.readVertices(pr, e)
+		case "face":
+			err = m.readFaces(pr, e)
+		default:
+			err = skipPLYElement(pr, e)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ply element %s: %w", e.Name, err)
+		}
+	}
+
+	d := m.Mesh
+	for _, i := range d.Indices {
+		if int(i) >= len(d.Positions) {
+			return nil, fmt.Errorf("ply: face index %d out of range", i)
+		}
+	}
+	if d.Topology == Triangles && len(d.UVs) > 0 && len(d.Normals) > 0 {
+		d.computeTangents()
+	}
+
+	return m, nil
+}
+
+func (m *PLYModel) readVertices(pr *plyReader, e plyElement) error {
+	var (
+		d      = m.Mesh
+		n      = e.Count
+		pos    = make([]mgl32.Vec3, 0, min(n, 1<<20))
+		values = make([]float64, len(e.Properties))
+	)
+
+	has := make(map[string]bool, len(e.Properties))
+	for _, p := range e.Properties {
+		has[p.Name] = p.CountType == ""
+	}
+
+	for i := 0; i < n; i++ {
+		for j, p := range e.Properties {
+			if p.CountType != "" {
+				// vertex 上的 list 属性没有对应的网格属性, 读取后丢弃
+				if err := skipPLYList(pr, p); err != nil {
+					return err
+				}
+				continue
+			}
+			v, err := pr.read(p.Type)
+			if err != nil {
+				return err
+			}
+			values[j] = v
+		}
+
+		var (
+			p     mgl32.Vec3
+			nrm   mgl32.Vec3
+			uv    mgl32.Vec2
+			color = mgl32.Vec4{1, 1, 1, 1}
+		)
+		for j, prop := range e.Properties {
+			if prop.CountType != "" {
+				continue
+			}
+			v := values[j]
+			switch prop.Name {
+			case "x":
+				p[0] = float32(v)
+			case "y":
+				p[1] = float32(v)
+			case "z":
+				p[2] = float32(v)
+			case "nx":
+				nrm[0] = float32(v)
+			case "ny":
+				nrm[1] = float32(v)
+			case "nz":
+				nrm[2] = float32(v)
+			case "red", "r", "diffuse_red":
+				color[0] = plyNormalize(prop.Type, v)
+			case "green", "g", "diffuse_green":
+				color[1] = plyNormalize(prop.Type, v)
+			case "blue", "b", "diffuse_blue":
+				color[2] = plyNormalize(prop.Type, v)
+			case "alpha", "a", "diffuse_alpha":
+				color[3] = plyNormalize(prop.Type, v)
+			case "u", "s", "texture_u", "texture_s":
+				uv[0] = float32(v)
+			case "v", "t", "texture_v", "texture_t":
+				uv[1] = float32(v)
+			default:
+				m.Properties[prop.Name] = append(m.Properties[prop.Name], float32(v))
+			}
+		}
+
+		pos = append(pos, p)
+		if has["nx"] || has["ny"] || has["nz"] {
+			d.Normals = append(d.Normals, nrm)
+		}
+		if has["u"] || has["s"] || has["texture_u"] || has["texture_s"] {
+			d.UVs = append(d.UVs, uv)
+		}
+		if has["red"] || has["r"] || has["diffuse_red"] {
+			d.Colors = append(d.Colors, color)
+		}
+	}
+
+	d.Positions = pos
+	return nil
+}
+
+func (m *PLYModel) readFaces(pr *plyReader, e plyElement) error {
+	d := m.Mesh
+	var polygon []uint32
+	for i := 0; i < e.Count; i++ {
+		for _, p := range e.Properties {
+			if p.CountType == "" {
+				if _, err := pr.read(p.Type); err != nil {
+					return err
+				}
+				continue
+			}
+
+			cnt, err := pr.read(p.CountType)
+			if err != nil {
+				return err
+			}
+			if cnt < 0 || cnt > 1<<16 {
+				return fmt.Errorf("bad list length %v", cnt)
+			}
+
+			polygon = polygon[:0]
+			for k := 0; k < int(cnt); k++ {
+				v, err := pr.read(p.Type)
+				if err != nil {
+					return err
+				}
+				if v < 0 {
+					return fmt.Errorf("negative vertex index %v", v)
+				}
+				polygon = append(polygon, uint32(v))
+			}
+			if p.Name != "vertex_indices" && p.Name != "vertex_index" {
+				continue
+			}
+
+			d.Topology = Triangles
+			if len(polygon) == 3 {
+				d.Indices = append(d.Indices, polygon...)
+				continue
+			}
+			d.Indices = append(d.Indices, m.triangulate(polygon)...)
+		}
+	}
+	return nil
+}
+
+// triangulate 多边形面三角化, 顶点尚未读取(元素顺序颠倒)时退化为扇形
+func (m *PLYModel) triangulate(polygon []uint32) []uint32 {
+	var out []uint32
+	pos := m.Mesh.Positions
+
+	points := make([]mgl32.Vec3, 0, len(polygon))
+	for _, i := range polygon {
+		if int(i) >= len(pos) {
+			for k := 1; k+1 < len(polygon); k++ {
+				out = append(out, polygon[0], polygon[k], polygon[k+1])
+			}
+			return out
+		}
+		points = append(points, pos[i])
+	}
+
+	for _, k := range Triangulate(points) {
+		out = append(out, polygon[k])
+	}
+	return out
+}
+
+func skipPLYList(pr *plyReader, p plyProperty) error {
+	cnt, err := pr.read(p.CountType)
+	if err != nil {
+		return err
+	}
+	if cnt < 0 || cnt > 1<<16 {
+		return fmt.Errorf("bad list length %v", cnt)
+	}
+	for k := 0; k < int(cnt); k++ {
+		if _, err := pr.read(p.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func skipPLYElement(pr *plyReader, e plyElement) error {
+	for i := 0; i < e.Count; i++ {
+		for _, p := range e.Properties {
+			var err error
+			if p.CountType != "" {
+				err = skipPLYList(pr, p)
+			} else {
+				_, err = pr.read(p.Type)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SavePLY 以二进制小端格式写入 ply 文件
+func SavePLY(name string, d *MeshData, comments ...string) error {
+	return writeFile(name, func(w io.Writer) error {
+		return WritePLY(w, d, comments...)
+	})
+}
+
+// WritePLY 以二进制小端格式写入位置、法线、纹理坐标和颜色(转换为 8 位), 三角形网格写入 face 元素,
+// 点云只写 vertex 元素, 不支持线段
+func WritePLY(w io.Writer, d *MeshData, comments ...string) error {
+	if d.Topology == Lines {
+		return errors.New("WritePLY: lines are not supported")
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprint(bw, "ply\nformat binary_little_endian 1.0\n")
+	for _, c := range comments {
+		fmt.Fprintf(bw, "comment %s\n", c)
+	}
+	fmt.Fprintf(bw, "element vertex %d\n", len(d.Positions))
+	fmt.Fprint(bw, "property float x\nproperty float y\nproperty float z\n")
+	if len(d.Normals) > 0 {
+		fmt.Fprint(bw, "property float nx\nproperty float ny\nproperty float nz\n")
+	}
+	if len(d.UVs) > 0 {
+		fmt.Fprint(bw, "property float u\nproperty float v\n")
+	}
+	if len(d.Colors) > 0 {
+		fmt.Fprint(bw, "property uchar red\nproperty uchar green\nproperty uchar blue\nproperty uchar alpha\n")
+	}
+	if d.Topology == Triangles {
+		fmt.Fprintf(bw, "element face %d\n", d.TriangleCount())
+		fmt.Fprint(bw, "property list uchar uint vertex_indices\n")
+	}
+	fmt.Fprint(bw, "end_header\n")
+
+	var buf []byte
+	float := func(v ...float32) {
+		for _, f := range v {
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(f))
+		}
+	}
+	for i, p := range d.Positions {
+		buf = buf[:0]
+		float(p[:]...)
+		if len(d.Normals) > 0 {
+			float(d.Normals[i][:]...)
+		}
+		if len(d.UVs) > 0 {
+			float(d.UVs[i][:]...)
+		}
+		if len(d.Colors) > 0 {
+			for _, c := range d.Colors[i] {
+				buf = append(buf, uint8(math.Round(float64(mgl32.Clamp(c, 0, 1)*255))))
+			}
+		}
+		bw.Write(buf)
+	}
+
+	if d.Topology == Triangles {
+		for t := 0; t < d.TriangleCount(); t++ {
+			a, b, c := d.Triangle(t)
+			buf = append(buf[:0], 3)
+			for _, v := range [3]uint32{a, b, c} {
+				buf = binary.LittleEndian.AppendUint32(buf, v)
+			}
+			bw.Write(buf)
+		}
+	}
+
+	return bw.Flush()
+}
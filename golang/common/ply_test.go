@@ -0,0 +1,259 @@
+package common
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestWritePLYRoundTrip(t *testing.T) {
+	d := GenTorus(1, 0.3, 12, 8)
+	d.Colors = make([]mgl32.Vec4, len(d.Positions))
+	for i := range d.Colors {
+		d.Colors[i] = mgl32.Vec4{float32(i%3) / 2, float32(i % 2), 0, 1}
+	}
+
+	var buf bytes.Buffer
+	if err := WritePLY(&buf, d, "round trip"); err != nil {
+		t.Fatal(err)
+	}
+	m, err := ParsePLY(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Comments) != 1 || m.Comments[0] != "round trip" {
+		t.Errorf("comments %q", m.Comments)
+	}
+
+	got := m.Mesh
+	if got.Topology != Triangles || len(got.Positions) != len(d.Positions) {
+		t.Fatalf("topology %v, %d vertices, want triangles and %d", got.Topology, len(got.Positions), len(d.Positions))
+	}
+	sameTriangles(t, got, d, 0)
+	for i, c := range got.Colors {
+		// 颜色按 8 位保存
+		if c.Sub(d.Colors[i]).Len() > 1.0/255 {
+			t.Fatalf("color %d: %v, want %v", i, c, d.Colors[i])
+		}
+	}
+
+	// 点云只有 vertex 元素
+	points := &MeshData{Topology: Points, Positions: []mgl32.Vec3{{1, 2, 3}, {-1, 0, 0.5}}}
+	buf.Reset()
+	if err := WritePLY(&buf, points); err != nil {
+		t.Fatal(err)
+	}
+	m, err = ParsePLY(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Mesh.Topology != Points || len(m.Mesh.Positions) != 2 || m.Mesh.Positions[1] != points.Positions[1] {
+		t.Errorf("point cloud read back as %v %v", m.Mesh.Topology, m.Mesh.Positions)
+	}
+
+	if err := WritePLY(&buf, GenGrid(1, 2)); err == nil {
+		t.Error("lines accepted")
+	}
+}
+
+// plyBinary 按 ply 类型把数值依次编码
+func plyBinary(order binary.AppendByteOrder, values ...any) []byte {
+	var buf []byte
+	for _, v := range values {
+		switch v := v.(type) {
+		case uint8:
+			buf = append(buf, v)
+		case int8:
+			buf = append(buf, uint8(v))
+		case int16:
+			buf = order.AppendUint16(buf, uint16(v))
+		case uint16:
+			buf = order.AppendUint16(buf, v)
+		case int32:
+			buf = order.AppendUint32(buf, uint32(v))
+		case uint32:
+			buf = order.AppendUint32(buf, v)
+		case float32:
+			buf = order.AppendUint32(buf, math.Float32bits(v))
+		case float64:
+			buf = order.AppendUint64(buf, math.Float64bits(v))
+		}
+	}
+	return buf
+}
+
+const plyBinaryHeader = `ply
+format %s 1.0
+comment made by hand
+element vertex 4
+property float x
+property float y
+property double z
+property int red
+property uint green
+property short blue
+property float confidence
+property list uchar int extra
+element edge 1
+property int a
+property int b
+element face 1
+property uchar flags
+property list uchar uint vertex_indices
+end_header
+`
+
+func plyBinaryFile(format string, order binary.AppendByteOrder) string {
+	body := plyBinary(order,
+		float32(0), float32(0), float64(0), int32(math.MaxInt32), uint32(0), int16(0), float32(0.5), uint8(1), int32(7),
+		float32(1), float32(0), float64(0), int32(0), uint32(math.MaxUint32), int16(0), float32(0.25), uint8(0),
+		float32(1), float32(1), float64(0), int32(-math.MaxInt32), uint32(0), int16(32767), float32(1), uint8(2), int32(1), int32(2),
+		float32(0), float32(1), float64(0), int32(0), uint32(0), int16(0), float32(0), uint8(0),
+		int32(0), int32(1),
+		uint8(9), uint8(4), uint32(0), uint32(1), uint32(2), uint32(3),
+	)
+	return strings.Replace(plyBinaryHeader, "%s", format, 1) + string(body)
+}
+
+func TestParsePLY(t *testing.T) {
+	quad := []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}}
+	binaryColors := []mgl32.Vec4{{1, 0, 0, 1}, {0, 1, 0, 1}, {-1, 0, 1, 1}, {0, 0, 0, 1}}
+
+	tests := []struct {
+		name      string
+		data      string
+		positions []mgl32.Vec3
+		triangles int
+		normals   bool
+		uvs       bool
+		colors    []mgl32.Vec4
+		extra     []float32 // confidence 属性
+	}{
+		{
+			name: "ascii",
+			data: `ply
+format ascii 1.0
+comment made by hand
+element vertex 4
+property float x
+property float y
+property float z
+property float nx
+property float ny
+property float nz
+property float s
+property float t
+property uchar red
+property uchar green
+property uchar blue
+property float confidence
+element face 1
+property list uchar int vertex_index
+end_header
+0 0 0 0 0 1 0 0 255 0 0 0.5
+1 0 0 0 0 1 1 0 0 255 0 0.25
+1 1 0 0 0 1 1 1 0 0 255 1
+0 1 0 0 0 1 0 1 51 51 51 0
+4 0 1 2 3
+`,
+			positions: quad,
+			triangles: 2,
+			normals:   true,
+			uvs:       true,
+			colors:    []mgl32.Vec4{{1, 0, 0, 1}, {0, 1, 0, 1}, {0, 0, 1, 1}, {0.2, 0.2, 0.2, 1}},
+			extra:     []float32{0.5, 0.25, 1, 0},
+		},
+		{
+			name:      "binary little endian",
+			data:      plyBinaryFile("binary_little_endian", binary.LittleEndian),
+			positions: quad,
+			triangles: 2,
+			colors:    binaryColors,
+			extra:     []float32{0.5, 0.25, 1, 0},
+		},
+		{
+			name:      "binary big endian",
+			data:      plyBinaryFile("binary_big_endian", binary.BigEndian),
+			positions: quad,
+			triangles: 2,
+			colors:    binaryColors,
+			extra:     []float32{0.5, 0.25, 1, 0},
+		},
+		{
+			name: "point cloud",
+			data: `ply
+format ascii 1.0
+comment made by hand
+element vertex 2
+property double x
+property double y
+property double z
+end_header
+1.5 -2 3
+4 5 6
+`,
+			positions: []mgl32.Vec3{{1.5, -2, 3}, {4, 5, 6}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParsePLY(strings.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := m.Mesh
+			if len(m.Comments) != 1 || m.Comments[0] != "made by hand" {
+				t.Errorf("comments %q", m.Comments)
+			}
+			if len(d.Positions) != len(tt.positions) {
+				t.Fatalf("%d vertices, want %d", len(d.Positions), len(tt.positions))
+			}
+			for i, p := range tt.positions {
+				if d.Positions[i] != p {
+					t.Errorf("position %d: %v, want %v", i, d.Positions[i], p)
+				}
+			}
+			wantTopology := Points
+			if tt.triangles > 0 {
+				wantTopology = Triangles
+			}
+			if d.Topology != wantTopology || d.TriangleCount() != tt.triangles {
+				t.Errorf("topology %v with %d triangles, want %v with %d", d.Topology, d.TriangleCount(), wantTopology, tt.triangles)
+			}
+			if (len(d.Normals) > 0) != tt.normals || (len(d.UVs) > 0) != tt.uvs {
+				t.Errorf("%d normals %d uvs", len(d.Normals), len(d.UVs))
+			}
+			if len(d.Colors) != len(tt.colors) {
+				t.Fatalf("%d colors, want %d", len(d.Colors), len(tt.colors))
+			}
+			for i, c := range tt.colors {
+				if d.Colors[i].Sub(c).Len() > 1e-6 {
+					t.Errorf("color %d: %v, want %v", i, d.Colors[i], c)
+				}
+			}
+			if got := m.Properties["confidence"]; !slices.Equal(got, tt.extra) {
+				t.Errorf("confidence %v, want %v", got, tt.extra)
+			}
+		})
+	}
+}
+
+func TestParsePLYErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"magic":      "plx\nformat ascii 1.0\nend_header\n",
+		"format":     "ply\nelement vertex 0\nend_header\n",
+		"type":       "ply\nformat ascii 1.0\nelement vertex 1\nproperty float128 x\nend_header\n0\n",
+		"truncated":  "ply\nformat binary_little_endian 1.0\nelement vertex 2\nproperty float x\nend_header\n\x00\x00",
+		"face index": "ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\nelement face 1\nproperty list uchar int vertex_indices\nend_header\n0\n3 0 0 1\n",
+	} {
+		if _, err := ParsePLY(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
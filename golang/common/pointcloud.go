@@ -0,0 +1,136 @@
+package common
+
+import (
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const pointVertexShader = `#version 440 core
+layout (location = 0) in vec3 aPos;
+layout (location = 4) in vec4 aColor;
+
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+uniform float pointSize;
+uniform float viewportHeight;
+uniform bool worldSize;
+
+out vec4 color;
+
+void main()
+{
+    vec4 viewPos = view * model * vec4(aPos, 1.0);
+    gl_Position = projection * viewPos;
+    color = aColor;
+
+    if (worldSize) {
+        // pointSize 为世界空间的直径, 按透视投影换算成像素
+        gl_PointSize = max(pointSize * projection[1][1] * viewportHeight * 0.5 / max(-viewPos.z, 1e-4), 1.0);
+    } else {
+        gl_PointSize = pointSize;
+    }
+}`
+
+const pointFragmentShader = `#version 440 core
+in vec4 color;
+
+uniform bool roundPoint;
+
+out vec4 FragColor;
+
+void main()
+{
+    if (roundPoint) {
+        vec2 p = gl_PointCoord * 2.0 - 1.0;
+        float r2 = dot(p, p);
+        if (r2 > 1.0)
+            discard;
+        // 模拟球面的简单明暗
+        FragColor = vec4(color.rgb * (0.6 + 0.4 * sqrt(1.0 - r2)), color.a);
+    } else {
+        FragColor = color;
+    }
+}`
+
+type PointCloudOption func(*PointCloud)
+
+// WithPointSize 点的大小, worldSize 为 true 时单位为世界空间长度(近大远小), 否则为像素
+func WithPointSize(size float32, worldSize bool) PointCloudOption {
+	return func(p *PointCloud) {
+		p.PointSize = size
+		p.WorldSize = worldSize
+	}
+}
+
+// WithSquarePoints 使用方形点精灵, 默认为圆形
+func WithSquarePoints() PointCloudOption {
+	return func(p *PointCloud) {
+		p.Round = false
+	}
+}
+
+// PointCloud 点云渲染, 每个顶点绘制为一个点精灵, 没有顶点颜色时为白色
+type PointCloud struct {
+	Mesh      *Mesh
+	Shader    *Shader
+	PointSize float32
+	WorldSize bool
+	Round     bool
+}
+
+// NewPointCloud 上传网格的顶点为点集, 忽略索引和原来的拓扑
+func NewPointCloud(d *MeshData, opts ...PointCloudOption) (*PointCloud, error) {
+	p := &PointCloud{
+		PointSize: 3,
+		Round:     true,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	var err error
+	p.Mesh, err = NewMeshOf(d.Vertices(), nil)
+	if err != nil {
+		return nil, err
+	}
+	p.Mesh.Mode = gl.POINTS
+
+	p.Shader, err = NewShader(pointVertexShader, pointFragmentShader)
+	if err != nil {
+		p.Mesh.Delete()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Draw 绘制点云, viewportHeight 为视口高度(像素), 用于换算世界空间的点大小
+func (p *PointCloud) Draw(model, view, projection mgl32.Mat4, viewportHeight int32) {
+	gl.Enable(gl.PROGRAM_POINT_SIZE)
+
+	p.Shader.Use()
+	p.Shader.SetMat("model", 4, &model[0])
+	p.Shader.SetMat("view", 4, &view[0])
+	p.Shader.SetMat("projection", 4, &projection[0])
+	p.Shader.SetFloat("pointSize", p.PointSize)
+	p.Shader.SetFloat("viewportHeight", float32(viewportHeight))
+	p.Shader.SetInt("worldSize", boolInt(p.WorldSize))
+	p.Shader.SetInt("roundPoint", boolInt(p.Round))
+
+	p.Mesh.Draw()
+
+	gl.Disable(gl.PROGRAM_POINT_SIZE)
+}
+
+func (p *PointCloud) Delete() {
+	p.Mesh.Delete()
+	p.Shader.Del()
+}
+
+func boolInt(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,393 @@
+package common
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// 程序化生成的基本几何体,都以原点为中心,包含法线、切线、UV 和索引
+
+// gridFace 生成一个 (segU+1)*(segV+1) 的网格面,origin 为 UV(0,0) 处的角点
+func gridFace(d *MeshData, origin, du, dv mgl32.Vec3, segU, segV int) {
+	base := uint32(len(d.Positions))
+	n := du.Cross(dv).Normalize()
+
+	for j := 0; j <= segV; j++ {
+		v := float32(j) / float32(segV)
+		for i := 0; i <= segU; i++ {
+			u := float32(i) / float32(segU)
+			d.Positions = append(d.Positions, origin.Add(du.Mul(u)).Add(dv.Mul(v)))
+			d.Normals = append(d.Normals, n)
+			d.UVs = append(d.UVs, mgl32.Vec2{u, v})
+		}
+	}
+
+	row := uint32(segU + 1)
+	for j := uint32(0); j < uint32(segV); j++ {
+		for i := uint32(0); i < uint32(segU); i++ {
+			a := base + j*row + i
+			b := a + 1
+			c := a + row
+			e := c + 1
+			d.Indices = append(d.Indices, a, b, e, a, e, c)
+		}
+	}
+}
+
+// GenCube 边长为 size 的立方体,每个面细分为 segments*segments 个格子
+func GenCube(size float32, segments int) *MeshData {
+	segments = max(segments, 1)
+	h := size / 2
+	d := &MeshData{}
+
+	faces := []struct{ origin, du, dv mgl32.Vec3 }{
+		{mgl32.Vec3{-h, -h, h}, mgl32.Vec3{size, 0, 0}, mgl32.Vec3{0, size, 0}},  // +Z
+		{mgl32.Vec3{h, -h, -h}, mgl32.Vec3{-size, 0, 0}, mgl32.Vec3{0, size, 0}}, // -Z
+		{mgl32.Vec3{h, -h, h}, mgl32.Vec3{0, 0, -size}, mgl32.Vec3{0, size, 0}},  // +X
+		{mgl32.Vec3{-h, -h, -h}, mgl32.Vec3{0, 0, size}, mgl32.Vec3{0, size, 0}}, // -X
+		{mgl32.Vec3{-h, h, h}, mgl32.Vec3{size, 0, 0}, mgl32.Vec3{0, 0, -size}},  // +Y
+		{mgl32.Vec3{-h, -h, -h}, mgl32.Vec3{size, 0, 0}, mgl32.Vec3{0, 0, size}}, // -Y
+	}
+	for _, f := range faces {
+		gridFace(d, f.origin, f.du, f.dv, segments, segments)
+	}
+
+	d.computeTangents()
+	return d
+}
+
+// GenPlane 位于 XZ 平面、法线朝 +Y 的平面
+func GenPlane(width, depth float32, segX, segZ int) *MeshData {
+	d := &MeshData{}
+	gridFace(d,
+		mgl32.Vec3{-width / 2, 0, depth / 2},
+		mgl32.Vec3{width, 0, 0},
+		mgl32.Vec3{0, 0, -depth},
+		max(segX, 1), max(segZ, 1),
+	)
+
+	d.computeTangents()
+	return d
+}
+
+// GenGrid XZ 平面上的参考网格线,拓扑为 Lines
+func GenGrid(size float32, divisions int) *MeshData {
+	divisions = max(divisions, 1)
+	h := size / 2
+	d := &MeshData{Topology: Lines}
+
+	for i := 0; i <= divisions; i++ {
+		v := -h + size*float32(i)/float32(divisions)
+		d.Positions = append(d.Positions,
+			mgl32.Vec3{v, 0, -h}, mgl32.Vec3{v, 0, h},
+			mgl32.Vec3{-h, 0, v}, mgl32.Vec3{h, 0, v},
+		)
+	}
+	for range d.Positions {
+		d.Normals = append(d.Normals, mgl32.Vec3{0, 1, 0})
+	}
+
+	return d
+}
+
+// profilePoint 旋转体轮廓上的一点, R 为到 Y 轴的距离, N 为 (径向, 竖直) 法线
+type profilePoint struct {
+	R, Y float32
+	N    mgl32.Vec2
+	V    float32
+}
+
+// lathe 将轮廓绕 Y 轴旋转一周,接缝处顶点重复以保证 UV 连续
+func lathe(d *MeshData, profile []profilePoint, segments int) {
+	base := uint32(len(d.Positions))
+
+	for _, p := range profile {
+		for i := 0; i <= segments; i++ {
+			u := float32(i) / float32(segments)
+			// 最后一列与第一列位置完全相同, 只有 UV 不同
+			theta := float64(i%segments) / float64(segments) * 2 * math.Pi
+			sin, cos := float32(math.Sin(theta)), float32(math.Cos(theta))
+
+			d.Positions = append(d.Positions, mgl32.Vec3{p.R * sin, p.Y, p.R * cos})
+			d.Normals = append(d.Normals, mgl32.Vec3{p.N[0] * sin, p.N[1], p.N[0] * cos}.Normalize())
+			d.UVs = append(d.UVs, mgl32.Vec2{u, p.V})
+		}
+	}
+
+	row := uint32(segments + 1)
+	for j := uint32(0); j+1 < uint32(len(profile)); j++ {
+		for i := uint32(0); i < uint32(segments); i++ {
+			a := base + j*row + i
+			b := a + 1
+			c := a + row
+			e := c + 1
+			// 轮廓从下往上,保证逆时针为正面
+			d.Indices = append(d.Indices, a, b, e, a, e, c)
+		}
+	}
+}
+
+// disc 圆盘,用于圆柱和圆锥的底面
+func disc(d *MeshData, y, radius float32, segments int, up bool) {
+	base := uint32(len(d.Positions))
+	n := mgl32.Vec3{0, -1, 0}
+	if up {
+		n = mgl32.Vec3{0, 1, 0}
+	}
+
+	d.Positions = append(d.Positions, mgl32.Vec3{0, y, 0})
+	d.Normals = append(d.Normals, n)
+	d.UVs = append(d.UVs, mgl32.Vec2{0.5, 0.5})
+
+	for i := 0; i <= segments; i++ {
+		theta := float64(i) / float64(segments) * 2 * math.Pi
+		sin, cos := float32(math.Sin(theta)), float32(math.Cos(theta))
+		d.Positions = append(d.Positions, mgl32.Vec3{radius * sin, y, radius * cos})
+		d.Normals = append(d.Normals, n)
+		d.UVs = append(d.UVs, mgl32.Vec2{0.5 + sin/2, 0.5 + cos/2})
+	}
+
+	for i := uint32(1); i <= uint32(segments); i++ {
+		if up {
+			d.Indices = append(d.Indices, base, base+i, base+i+1)
+		} else {
+			d.Indices = append(d.Indices, base, base+i+1, base+i)
+		}
+	}
+}
+
+// hemisphere 半球轮廓,从 from 纬度到 to 纬度(弧度, -π/2 为南极)
+func hemisphere(profile []profilePoint, radius, centerY, from, to float32, rings int, v0, v1 float32) []profilePoint {
+	for j := 0; j <= rings; j++ {
+		t := float32(j) / float32(rings)
+		phi := float64(from + (to-from)*t)
+		sin, cos := float32(math.Sin(phi)), float32(math.Cos(phi))
+		if abs(cos) < 1e-6 {
+			cos = 0 // 极点的一圈顶点重合在轴上
+		}
+		profile = append(profile, profilePoint{
+			R: radius * cos,
+			Y: centerY + radius*sin,
+			N: mgl32.Vec2{cos, sin},
+			V: v0 + (v1-v0)*t,
+		})
+	}
+	return profile
+}
+
+// GenUVSphere 经纬度球体
+func GenUVSphere(radius float32, segments, rings int) *MeshData {
+	segments, rings = max(segments, 3), max(rings, 2)
+	d := &MeshData{}
+
+	lathe(d, hemisphere(nil, radius, 0, -math.Pi/2, math.Pi/2, rings, 0, 1), segments)
+
+	d.computeTangents()
+	return d
+}
+
+// GenIcosphere 正二十面体细分得到的球体,三角形分布比经纬度球均匀
+// UV 使用球面映射,-Z 方向的接缝和两极的顶点按三角形复制,避免纹理被拉伸
+func GenIcosphere(radius float32, subdivisions int) *MeshData {
+	t := float32((1 + math.Sqrt(5)) / 2)
+	positions := []mgl32.Vec3{
+		{-1, t, 0}, {1, t, 0}, {-1, -t, 0}, {1, -t, 0},
+		{0, -1, t}, {0, 1, t}, {0, -1, -t}, {0, 1, -t},
+		{t, 0, -1}, {t, 0, 1}, {-t, 0, -1}, {-t, 0, 1},
+	}
+	for i, p := range positions {
+		positions[i] = p.Normalize()
+	}
+	indices := []uint32{
+		0, 11, 5, 0, 5, 1, 0, 1, 7, 0, 7, 10, 0, 10, 11,
+		1, 5, 9, 5, 11, 4, 11, 10, 2, 10, 7, 6, 7, 1, 8,
+		3, 9, 4, 3, 4, 2, 3, 2, 6, 3, 6, 8, 3, 8, 9,
+		4, 9, 5, 2, 4, 11, 6, 2, 10, 8, 6, 7, 9, 8, 1,
+	}
+
+	for s := 0; s < subdivisions; s++ {
+		cache := make(map[[2]uint32]uint32)
+		midpoint := func(a, b uint32) uint32 {
+			key := [2]uint32{min(a, b), max(a, b)}
+			if i, ok := cache[key]; ok {
+				return i
+			}
+			positions = append(positions, positions[a].Add(positions[b]).Normalize())
+			i := uint32(len(positions) - 1)
+			cache[key] = i
+			return i
+		}
+
+		next := make([]uint32, 0, len(indices)*4)
+		for i := 0; i < len(indices); i += 3 {
+			a, b, c := indices[i], indices[i+1], indices[i+2]
+			ab, bc, ca := midpoint(a, b), midpoint(b, c), midpoint(c, a)
+			next = append(next, a, ab, ca, b, bc, ab, c, ca, bc, ab, bc, ca)
+		}
+		indices = next
+	}
+
+	d := &MeshData{Indices: indices}
+	for _, p := range positions {
+		d.Positions = append(d.Positions, p.Mul(radius))
+		d.Normals = append(d.Normals, p)
+		d.UVs = append(d.UVs, mgl32.Vec2{
+			0.5 + float32(math.Atan2(float64(p[0]), float64(p[2])))/(2*math.Pi),
+			0.5 + float32(math.Asin(float64(p[1])))/math.Pi,
+		})
+	}
+	icosphereSeams(d)
+
+	d.computeTangents()
+	return d
+}
+
+// icosphereSeams 跨过接缝的三角形中 u 较小的顶点复制一份并把 u 加 1, 与 lathe 一样在接缝处重复顶点;
+// 极点的 u 没有意义, 每个三角形复制一份极点, u 取另外两个顶点的平均值
+func icosphereSeams(d *MeshData) {
+	duplicate := func(i uint32, uv mgl32.Vec2) uint32 {
+		d.Positions = append(d.Positions, d.Positions[i])
+		d.Normals = append(d.Normals, d.Normals[i])
+		d.UVs = append(d.UVs, uv)
+		return uint32(len(d.Positions) - 1)
+	}
+
+	wrapped := make(map[uint32]uint32)
+	for t := 0; t+2 < len(d.Indices); t += 3 {
+		tri := d.Indices[t : t+3]
+		lo, hi := float32(1), float32(0)
+		for _, i := range tri {
+			lo, hi = min(lo, d.UVs[i][0]), max(hi, d.UVs[i][0])
+		}
+		if hi-lo <= 0.5 {
+			continue
+		}
+		for k, i := range tri {
+			if d.UVs[i][0] >= 0.5 {
+				continue
+			}
+			w, ok := wrapped[i]
+			if !ok {
+				w = duplicate(i, mgl32.Vec2{d.UVs[i][0] + 1, d.UVs[i][1]})
+				wrapped[i] = w
+			}
+			tri[k] = w
+		}
+	}
+
+	poles := make(map[uint32]bool) // 已经被某个三角形使用的极点, 第一个三角形直接修改原顶点
+	for t := 0; t+2 < len(d.Indices); t += 3 {
+		tri := d.Indices[t : t+3]
+		for k, i := range tri {
+			if abs(d.Normals[i][1]) < 1-1e-6 {
+				continue
+			}
+			a, b := tri[(k+1)%3], tri[(k+2)%3]
+			uv := mgl32.Vec2{(d.UVs[a][0] + d.UVs[b][0]) / 2, d.UVs[i][1]}
+			if poles[i] {
+				tri[k] = duplicate(i, uv)
+			} else {
+				d.UVs[i] = uv
+				poles[i] = true
+			}
+		}
+	}
+}
+
+// GenCylinder 高为 height 的圆柱,带上下底面
+func GenCylinder(radius, height float32, segments, heightSegments int) *MeshData {
+	segments, heightSegments = max(segments, 3), max(heightSegments, 1)
+	h := height / 2
+	d := &MeshData{}
+
+	profile := make([]profilePoint, 0, heightSegments+1)
+	for j := 0; j <= heightSegments; j++ {
+		v := float32(j) / float32(heightSegments)
+		profile = append(profile, profilePoint{R: radius, Y: -h + height*v, N: mgl32.Vec2{1, 0}, V: v})
+	}
+	lathe(d, profile, segments)
+	disc(d, h, radius, segments, true)
+	disc(d, -h, radius, segments, false)
+
+	d.computeTangents()
+	return d
+}
+
+// GenCone 底面半径 radius、高 height 的圆锥,顶点朝 +Y
+func GenCone(radius, height float32, segments, heightSegments int) *MeshData {
+	segments, heightSegments = max(segments, 3), max(heightSegments, 1)
+	h := height / 2
+	d := &MeshData{}
+
+	// 侧面法线与母线垂直
+	n := mgl32.Vec2{height, radius}.Normalize()
+	profile := make([]profilePoint, 0, heightSegments+1)
+	for j := 0; j <= heightSegments; j++ {
+		v := float32(j) / float32(heightSegments)
+		profile = append(profile, profilePoint{R: radius * (1 - v), Y: -h + height*v, N: n, V: v})
+	}
+	lathe(d, profile, segments)
+	disc(d, -h, radius, segments, false)
+
+	d.computeTangents()
+	return d
+}
+
+// GenTorus 圆环, radius 为圆环中心到管中心的距离, tube 为管的半径
+func GenTorus(radius, tube float32, radialSegments, tubularSegments int) *MeshData {
+	radialSegments, tubularSegments = max(radialSegments, 3), max(tubularSegments, 3)
+	d := &MeshData{}
+
+	for j := 0; j <= radialSegments; j++ {
+		v := float32(j) / float32(radialSegments)
+		phi := float64(v) * 2 * math.Pi
+		for i := 0; i <= tubularSegments; i++ {
+			u := float32(i) / float32(tubularSegments)
+			theta := float64(u) * 2 * math.Pi
+
+			center := mgl32.Vec3{radius * float32(math.Cos(theta)), 0, -radius * float32(math.Sin(theta))}
+			n := mgl32.Vec3{
+				float32(math.Cos(phi) * math.Cos(theta)),
+				float32(math.Sin(phi)),
+				float32(-math.Cos(phi) * math.Sin(theta)),
+			}
+			d.Positions = append(d.Positions, center.Add(n.Mul(tube)))
+			d.Normals = append(d.Normals, n)
+			d.UVs = append(d.UVs, mgl32.Vec2{u, v})
+		}
+	}
+
+	row := uint32(tubularSegments + 1)
+	for j := uint32(0); j < uint32(radialSegments); j++ {
+		for i := uint32(0); i < uint32(tubularSegments); i++ {
+			a := j*row + i
+			b := a + 1
+			c := a + row
+			e := c + 1
+			d.Indices = append(d.Indices, a, b, e, a, e, c)
+		}
+	}
+
+	d.computeTangents()
+	return d
+}
+
+// GenCapsule 胶囊体, height 为中间圆柱部分的高度,总高度为 height+2*radius
+func GenCapsule(radius, height float32, segments, rings int) *MeshData {
+	segments, rings = max(segments, 3), max(rings, 1)
+	h := height / 2
+	d := &MeshData{}
+
+	// V 坐标按弧长比例分配, 每个半球的弧长为 πr/2
+	arc := radius * math.Pi / 2
+	total := height + 2*arc
+	vBottom := arc / total
+	vTop := (arc + height) / total
+	profile := hemisphere(nil, radius, -h, -math.Pi/2, 0, rings, 0, vBottom)
+	profile = hemisphere(profile, radius, h, 0, math.Pi/2, rings, vTop, 1)
+	lathe(d, profile, segments)
+
+	d.computeTangents()
+	return d
+}
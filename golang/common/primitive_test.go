@@ -0,0 +1,90 @@
+package common
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestLatheSeam(t *testing.T) {
+	for name, d := range map[string]*MeshData{
+		"sphere":  GenUVSphere(1, 16, 8),
+		"capsule": GenCapsule(0.5, 1, 12, 4),
+	} {
+		// 接缝两侧的顶点位置完全相同, 极点的一圈顶点也重合
+		first := make(map[float32]mgl32.Vec3)
+		for i, uv := range d.UVs {
+			if uv[0] == 0 {
+				first[uv[1]] = d.Positions[i]
+			}
+		}
+		for i, uv := range d.UVs {
+			p := d.Positions[i]
+			if uv[0] == 1 && p != first[uv[1]] {
+				t.Errorf("%s: seam vertex %v at v=%v, other side %v", name, p, uv[1], first[uv[1]])
+			}
+			if (uv[1] == 0 || uv[1] == 1) && (p[0] != 0 || p[2] != 0) {
+				t.Errorf("%s: pole vertex %v off the axis", name, p)
+			}
+		}
+	}
+}
+
+func TestGenCapsuleUV(t *testing.T) {
+	const radius, height = 0.5, 1.0
+	d := GenCapsule(radius, height, 8, 4)
+
+	arc := radius * math.Pi / 2
+	total := float32(height + 2*arc)
+	for i, p := range d.Positions {
+		// V 与从底部沿表面量到该点的弧长成正比
+		var s float64
+		switch y := float64(p[1]); {
+		case y < -height/2:
+			s = radius * math.Asin(math.Min((-height/2-y)/radius, 1))
+			s = arc - s
+		case y > height/2:
+			s = arc + height + radius*math.Asin(math.Min((y-height/2)/radius, 1))
+		default:
+			s = arc + y + height/2
+		}
+		if v := d.UVs[i][1]; abs(v-float32(s)/total) > 1e-5 {
+			t.Errorf("vertex %v: v %v, want %v", p, v, float32(s)/total)
+		}
+	}
+}
+
+func TestGenIcosphereUV(t *testing.T) {
+	d := GenIcosphere(2, 3)
+	for i, p := range d.Positions {
+		if abs(p.Len()-2) > 1e-5 {
+			t.Fatalf("vertex %d: radius %v", i, p.Len())
+		}
+	}
+
+	seam := 0
+	for i := 0; i < d.TriangleCount(); i++ {
+		a, b, c := d.Triangle(i)
+		lo, hi := float32(2), float32(-1)
+		for _, v := range [3]uint32{a, b, c} {
+			lo, hi = min(lo, d.UVs[v][0]), max(hi, d.UVs[v][0])
+		}
+		// 3 次细分后每个三角形在经度方向不超过 1/8 圈(极点附近除外)
+		if hi-lo > 0.2 {
+			t.Errorf("triangle %d stretched over u in [%v, %v]", i, lo, hi)
+		}
+		if hi > 1 {
+			seam++
+		}
+
+		// UV 与位置的朝向一致(从外侧看都是逆时针)
+		ua, ub, uc := d.UVs[a], d.UVs[b], d.UVs[c]
+		if ub.Sub(ua)[0]*uc.Sub(ua)[1]-ub.Sub(ua)[1]*uc.Sub(ua)[0] <= 0 {
+			t.Errorf("triangle %d has flipped or degenerate UVs %v %v %v", i, ua, ub, uc)
+		}
+	}
+	if seam == 0 {
+		t.Error("no triangle uses the duplicated seam vertices")
+	}
+}
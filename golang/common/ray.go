@@ -0,0 +1,229 @@
+package common
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const rayEpsilon = 1e-6
+
+// Ray 射线: Origin + t*Direction, t >= 0
+type Ray struct {
+	Origin    mgl32.Vec3
+	Direction mgl32.Vec3
+}
+
+// RayHit 射线命中结果,Distance 为射线参数 t,
+// Index 为命中的三角形序号(仅 IntersectMesh 有意义)
+type RayHit struct {
+	Distance float32
+	Point    mgl32.Vec3
+	Normal   mgl32.Vec3
+	Index    int
+}
+
+// NewRayFromCursor 将窗口光标位置转换为世界空间射线
+// x,y: 窗口坐标(原点在左上), width,height: 窗口尺寸
+func NewRayFromCursor(x, y float64, width, height int, view, projection mgl32.Mat4) Ray {
+	// 窗口坐标 -> 标准化设备坐标(NDC),注意 y 轴方向相反
+	ndcX := float32(2*x/float64(width) - 1)
+	ndcY := float32(1 - 2*y/float64(height))
+
+	inv := projection.Mul4(view).Inv()
+	near := mgl32.TransformCoordinate(mgl32.Vec3{ndcX, ndcY, -1}, inv)
+	far := mgl32.TransformCoordinate(mgl32.Vec3{ndcX, ndcY, 1}, inv)
+
+	return Ray{Origin: near, Direction: far.Sub(near).Normalize()}
+}
+
+// CursorRay 使用相机的视图矩阵生成光标射线
+func (c *Camera) CursorRay(x, y float64, width, height int, projection mgl32.Mat4) Ray {
+	return NewRayFromCursor(x, y, width, height, c.GetViewMatrix(), projection)
+}
+
+func (r Ray) At(t float32) mgl32.Vec3 {
+	return r.Origin.Add(r.Direction.Mul(t))
+}
+
+// Transform 变换射线,方向不做归一化,这样变换后求得的 t 与变换前一致
+// 常用于将世界射线变换到模型空间: r.Transform(model.Inv())
+func (r Ray) Transform(m mgl32.Mat4) Ray {
+	return Ray{
+		Origin:    mgl32.TransformCoordinate(r.Origin, m),
+		Direction: mgl32.TransformNormal(r.Direction, m),
+	}
+}
+
+func (r Ray) IntersectPlane(p Plane) (RayHit, bool) {
+	denom := p.Normal.Dot(r.Direction)
+	if float32(math.Abs(float64(denom))) < rayEpsilon {
+		return RayHit{}, false // 射线与平面平行
+	}
+
+	t := -p.Distance(r.Origin) / denom
+	if t < 0 {
+		return RayHit{}, false
+	}
+
+	n := p.Normal
+	if denom > 0 {
+		n = n.Mul(-1) // 法线朝向射线来的一侧
+	}
+	return RayHit{Distance: t, Point: r.At(t), Normal: n}, true
+}
+
+func (r Ray) IntersectSphere(s Sphere) (RayHit, bool) {
+	oc := r.Origin.Sub(s.Center)
+	a := r.Direction.Dot(r.Direction)
+	b := oc.Dot(r.Direction)
+	c := oc.Dot(oc) - s.Radius*s.Radius
+
+	disc := b*b - a*c
+	if disc < 0 {
+		return RayHit{}, false
+	}
+
+	sq := float32(math.Sqrt(float64(disc)))
+	t := (-b - sq) / a
+	if t < 0 {
+		t = (-b + sq) / a // 射线起点在球内
+		if t < 0 {
+			return RayHit{}, false
+		}
+	}
+
+	p := r.At(t)
+	return RayHit{Distance: t, Point: p, Normal: p.Sub(s.Center).Normalize()}, true
+}
+
+// IntersectAABB slab 算法
+func (r Ray) IntersectAABB(b AABB) (RayHit, bool) {
+	var (
+		tMin    = float32(math.Inf(-1))
+		tMax    = float32(math.Inf(1))
+		minAxis = 0
+		maxAxis = 0
+	)
+
+	for i := 0; i < 3; i++ {
+		if float32(math.Abs(float64(r.Direction[i]))) < rayEpsilon {
+			if r.Origin[i] < b.Min[i] || r.Origin[i] > b.Max[i] {
+				return RayHit{}, false
+			}
+			continue
+		}
+
+		t1 := (b.Min[i] - r.Origin[i]) / r.Direction[i]
+		t2 := (b.Max[i] - r.Origin[i]) / r.Direction[i]
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		if t1 > tMin {
+			tMin, minAxis = t1, i
+		}
+		if t2 < tMax {
+			tMax, maxAxis = t2, i
+		}
+		if tMin > tMax {
+			return RayHit{}, false
+		}
+	}
+
+	if tMax < 0 {
+		return RayHit{}, false
+	}
+
+	// 射线起点在盒子内部时取出射点
+	t, axis := tMin, minAxis
+	if t < 0 {
+		t, axis = tMax, maxAxis
+	}
+
+	// 法线朝向射线来的一侧
+	var n mgl32.Vec3
+	if r.Direction[axis] > 0 {
+		n[axis] = -1
+	} else {
+		n[axis] = 1
+	}
+	return RayHit{Distance: t, Point: r.At(t), Normal: n}, true
+}
+
+// IntersectTriangle Möller–Trumbore 算法,双面检测
+func (r Ray) IntersectTriangle(a, b, c mgl32.Vec3) (RayHit, bool) {
+	e1 := b.Sub(a)
+	e2 := c.Sub(a)
+	p := r.Direction.Cross(e2)
+
+	det := e1.Dot(p)
+	if float32(math.Abs(float64(det))) < rayEpsilon {
+		return RayHit{}, false
+	}
+	invDet := 1 / det
+
+	s := r.Origin.Sub(a)
+	u := s.Dot(p) * invDet
+	if u < 0 || u > 1 {
+		return RayHit{}, false
+	}
+
+	q := s.Cross(e1)
+	v := r.Direction.Dot(q) * invDet
+	if v < 0 || u+v > 1 {
+		return RayHit{}, false
+	}
+
+	t := e2.Dot(q) * invDet
+	if t < 0 {
+		return RayHit{}, false
+	}
+
+	n := e1.Cross(e2).Normalize()
+	if n.Dot(r.Direction) > 0 {
+		n = n.Mul(-1)
+	}
+	return RayHit{Distance: t, Point: r.At(t), Normal: n}, true
+}
+
+// IntersectMesh 返回与三角形网格最近的命中
+// indices 为空时 positions 每 3 个顶点组成一个三角形
+func (r Ray) IntersectMesh(positions []mgl32.Vec3, indices []uint32) (RayHit, bool) {
+	var (
+		best  RayHit
+		found bool
+	)
+
+	test := func(i int, a, b, c mgl32.Vec3) {
+		hit, ok := r.IntersectTriangle(a, b, c)
+		if ok && (!found || hit.Distance < best.Distance) {
+			hit.Index = i
+			best, found = hit, true
+		}
+	}
+
+	if len(indices) == 0 {
+		for i := 0; i+2 < len(positions); i += 3 {
+			test(i/3, positions[i], positions[i+1], positions[i+2])
+		}
+	} else {
+		for i := 0; i+2 < len(indices); i += 3 {
+			test(i/3, positions[indices[i]], positions[indices[i+1]], positions[indices[i+2]])
+		}
+	}
+
+	return best, found
+}
+
+// IntersectModel 先将射线变换到模型空间再求交,
+// 返回的命中点和法线都在世界空间
+func (r Ray) IntersectModel(model mgl32.Mat4, intersect func(local Ray) (RayHit, bool)) (RayHit, bool) {
+	hit, ok := intersect(r.Transform(model.Inv()))
+	if !ok {
+		return RayHit{}, false
+	}
+
+	hit.Point = r.At(hit.Distance)
+	hit.Normal = mgl32.TransformNormal(hit.Normal, model.Inv().Transpose()).Normalize()
+	return hit, true
+}
@@ -0,0 +1,499 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Transform 平移、旋转、缩放, 对应的矩阵为 T*R*S
+type Transform struct {
+	Translation mgl32.Vec3
+	Rotation    mgl32.Quat
+	Scale       mgl32.Vec3
+}
+
+func NewTransform() Transform {
+	return Transform{Rotation: mgl32.QuatIdent(), Scale: mgl32.Vec3{1, 1, 1}}
+}
+
+// TransformFromMatrix 分解不含切变的仿射矩阵
+func TransformFromMatrix(m mgl32.Mat4) Transform {
+	t, r, s := DecomposeMatrix(m)
+	return Transform{Translation: t, Rotation: r, Scale: s}
+}
+
+func (t Transform) Matrix() mgl32.Mat4 {
+	return mgl32.Translate3D(t.Translation[0], t.Translation[1], t.Translation[2]).
+		Mul4(t.Rotation.Mat4()).
+		Mul4(mgl32.Scale3D(t.Scale[0], t.Scale[1], t.Scale[2]))
+}
+
+type NodeOption func(*Node)
+
+func WithMesh(mesh *Mesh) NodeOption {
+	return func(n *Node) {
+		n.Mesh = mesh
+	}
+}
+
+// WithMeshData 网格在 CPU 端的数据, 用于 FlattenScene 导出
+func WithMeshData(d *MeshData) NodeOption {
+	return func(n *Node) {
+		n.Data = d
+	}
+}
+
+// WithMaterial 节点的材质, 由 Renderer 自行解释
+func WithMaterial(material any) NodeOption {
+	return func(n *Node) {
+		n.Material = material
+	}
+}
+
+// WithBounds 网格在局部空间的包围盒, 用于 SceneBVH
+func WithBounds(b AABB) NodeOption {
+	return func(n *Node) {
+		n.Bounds = b
+	}
+}
+
+func WithTransform(t Transform) NodeOption {
+	return func(n *Node) {
+		n.local = t
+	}
+}
+
+// Node 场景图的节点, 世界矩阵在局部变换或祖先变化后的第一次访问时重新计算
+type Node struct {
+	Name     string
+	Mesh     *Mesh     // 可以为 nil, 只作为变换节点
+	Data     *MeshData // Mesh 在 CPU 端的数据, 可以为 nil
+	Material any
+	Visible  bool // 为 false 时整棵子树都不渲染
+	Bounds   AABB // 局部空间的包围盒, 为空时不参与 SceneBVH 的查询
+
+	local    Transform
+	world    mgl32.Mat4
+	dirty    bool
+	parent   *Node
+	children []*Node
+}
+
+func NewNode(name string, opts ...NodeOption) *Node {
+	n := &Node{
+		Name:    name,
+		Visible: true,
+		Bounds:  EmptyAABB(),
+		local:   NewTransform(),
+		dirty:   true,
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	return n
+}
+
+func (n *Node) Parent() *Node {
+	return n.parent
+}
+
+func (n *Node) Children() []*Node {
+	return n.children
+}
+
+func (n *Node) Local() Transform {
+	return n.local
+}
+
+func (n *Node) SetLocal(t Transform) {
+	n.local = t
+	n.invalidate()
+}
+
+func (n *Node) SetTranslation(v mgl32.Vec3) {
+	n.local.Translation = v
+	n.invalidate()
+}
+
+func (n *Node) SetRotation(q mgl32.Quat) {
+	n.local.Rotation = q
+	n.invalidate()
+}
+
+func (n *Node) SetScale(v mgl32.Vec3) {
+	n.local.Scale = v
+	n.invalidate()
+}
+
+// Translate 在父节点空间中平移
+func (n *Node) Translate(v mgl32.Vec3) {
+	n.SetTranslation(n.local.Translation.Add(v))
+}
+
+// Rotate 在当前旋转的基础上绕局部轴旋转
+func (n *Node) Rotate(q mgl32.Quat) {
+	n.SetRotation(n.local.Rotation.Mul(q).Normalize())
+}
+
+// invalidate 标记自己和所有子孙的世界矩阵失效
+// 节点失效时其子孙一定已经失效, 因此遇到失效的节点可以提前结束
+func (n *Node) invalidate() {
+	if n.dirty {
+		return
+	}
+
+	n.dirty = true
+	for _, c := range n.children {
+		c.invalidate()
+	}
+}
+
+// World 世界矩阵, 需要时才重新计算
+func (n *Node) World() mgl32.Mat4 {
+	if n.dirty {
+		n.world = n.local.Matrix()
+		if n.parent != nil {
+			n.world = n.parent.World().Mul4(n.world)
+		}
+		n.dirty = false
+	}
+
+	return n.world
+}
+
+// WorldPosition 节点原点在世界空间中的位置
+func (n *Node) WorldPosition() mgl32.Vec3 {
+	return n.World().Col(3).Vec3()
+}
+
+// SetWorld 设置世界矩阵, 转换为相对父节点的局部变换
+func (n *Node) SetWorld(m mgl32.Mat4) {
+	if n.parent != nil {
+		m = n.parent.World().Inv().Mul4(m)
+	}
+	n.SetLocal(TransformFromMatrix(m))
+}
+
+// IsAncestorOf n 是否为 o 的祖先(不包括 o 自己)
+func (n *Node) IsAncestorOf(o *Node) bool {
+	for p := o.parent; p != nil; p = p.parent {
+		if p == n {
+			return true
+		}
+	}
+	return false
+}
+
+// AddChild 添加子节点, 子节点保持局部变换, 原来有父节点时先从中移除
+func (n *Node) AddChild(c *Node) error {
+	if c == n || c.IsAncestorOf(n) {
+		return fmt.Errorf("AddChild: %q cannot be a child of its descendant %q", c.Name, n.Name)
+	}
+
+	c.Detach()
+	c.parent = n
+	n.children = append(n.children, c)
+	c.invalidate()
+	return nil
+}
+
+// Reparent 移动到新的父节点下并保持世界变换不变, parent 为 nil 时成为根节点
+func (n *Node) Reparent(parent *Node) error {
+	world := n.World()
+	if parent == nil {
+		n.Detach()
+		n.SetWorld(world)
+		return nil
+	}
+
+	err := parent.AddChild(n)
+	if err != nil {
+		return err
+	}
+	n.SetWorld(world)
+	return nil
+}
+
+// Detach 从父节点中移除, 局部变换不变
+func (n *Node) Detach() {
+	p := n.parent
+	if p == nil {
+		return
+	}
+
+	for i, c := range p.children {
+		if c == n {
+			p.children = append(p.children[:i], p.children[i+1:]...)
+			break
+		}
+	}
+	n.parent = nil
+	n.invalidate()
+}
+
+// Walk 深度优先遍历, fn 返回 false 时跳过该节点的子树
+func (n *Node) Walk(fn func(*Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, c := range n.children {
+		c.Walk(fn)
+	}
+}
+
+// Find 按名字查找子树中的第一个节点
+func (n *Node) Find(name string) *Node {
+	var found *Node
+	n.Walk(func(c *Node) bool {
+		if found == nil && c.Name == name {
+			found = c
+		}
+		return found == nil
+	})
+	return found
+}
+
+// Renderer 接收场景遍历时提交的网格
+type Renderer interface {
+	Submit(node *Node, world mgl32.Mat4)
+}
+
+// RenderFunc 将函数适配为 Renderer
+type RenderFunc func(node *Node, world mgl32.Mat4)
+
+func (f RenderFunc) Submit(node *Node, world mgl32.Mat4) {
+	f(node, world)
+}
+
+// ShaderRenderer 最简单的渲染器, 设置 model 矩阵后直接绘制
+type ShaderRenderer struct {
+	Shader *Shader
+	Model  string // model 矩阵的 uniform 名字, 默认为 "model"
+}
+
+func (r ShaderRenderer) Submit(node *Node, world mgl32.Mat4) {
+	name := r.Model
+	if name == "" {
+		name = "model"
+	}
+
+	r.Shader.SetMat(name, 4, &world[0])
+	node.Mesh.Draw()
+}
+
+// Render 遍历可见的节点, 将带网格的节点连同世界矩阵提交给 r
+func (n *Node) Render(r Renderer) {
+	n.Walk(func(c *Node) bool {
+		if !c.Visible {
+			return false
+		}
+		if c.Mesh != nil {
+			r.Submit(c, c.World())
+		}
+		return true
+	})
+}
+
+// NewSceneFromGLTF 上传 glTF 场景中的网格并生成节点树, scene 小于 0 时使用默认场景
+// 包含多个图元的网格拆分为多个子节点, 节点的 Material 为对应的 *PBRMaterial, 没有材质时为 nil,
+// Data 直接引用 model 中的图元数据
+func NewSceneFromGLTF(model *GLTFModel, scene int) (*Node, error) {
+	if scene < 0 {
+		scene = model.Scene
+	}
+
+	root := NewNode("")
+	var roots []int
+	switch {
+	case scene < len(model.Scenes):
+		root.Name = model.Scenes[scene].Name
+		roots = model.Scenes[scene].Nodes
+	case len(model.Scenes) == 0:
+		// 没有场景时把所有根节点放进来
+		child := make([]bool, len(model.Nodes))
+		for _, n := range model.Nodes {
+			for _, c := range n.Children {
+				child[c] = true
+			}
+		}
+		for i := range model.Nodes {
+			if !child[i] {
+				roots = append(roots, i)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("NewSceneFromGLTF: scene %d out of range", scene)
+	}
+
+	meshes := make([][]*Mesh, len(model.Meshes))
+	for i, m := range model.Meshes {
+		for _, p := range m.Primitives {
+			mesh, err := p.Mesh.Upload()
+			if err != nil {
+				deleteMeshes(meshes)
+				return nil, err
+			}
+			meshes[i] = append(meshes[i], mesh)
+		}
+	}
+
+	// glTF 要求节点树不能有环, 这里同时防止节点被多次引用
+	visited := make([]bool, len(model.Nodes))
+	var build func(i int) (*Node, error)
+	build = func(i int) (*Node, error) {
+		if visited[i] {
+			return nil, fmt.Errorf("NewSceneFromGLTF: node %d referenced twice", i)
+		}
+		visited[i] = true
+
+		src := model.Nodes[i]
+		n := NewNode(src.Name, WithTransform(Transform{
+			Translation: src.Translation,
+			Rotation:    src.Rotation,
+			Scale:       src.Scale,
+		}))
+
+		if src.Mesh >= 0 {
+			for j, p := range model.Meshes[src.Mesh].Primitives {
+				target := n
+				if len(model.Meshes[src.Mesh].Primitives) > 1 {
+					target = NewNode(fmt.Sprintf("%s#%d", src.Name, j))
+					_ = n.AddChild(target)
+				}
+				target.Mesh = meshes[src.Mesh][j]
+				target.Data = p.Mesh
+				target.Bounds = p.Mesh.Bounds()
+				if p.Material >= 0 {
+					target.Material = &model.Materials[p.Material]
+				}
+			}
+		}
+
+		for _, c := range src.Children {
+			child, err := build(c)
+			if err != nil {
+				return nil, err
+			}
+			_ = n.AddChild(child)
+		}
+		return n, nil
+	}
+
+	for _, i := range roots {
+		n, err := build(i)
+		if err != nil {
+			deleteMeshes(meshes)
+			return nil, err
+		}
+		_ = root.AddChild(n)
+	}
+
+	return root, nil
+}
+
+func deleteMeshes(meshes [][]*Mesh) {
+	for _, list := range meshes {
+		for _, m := range list {
+			m.Delete()
+		}
+	}
+}
+
+// DeleteMeshes 释放子树中所有节点的网格, 共享的网格只释放一次
+func (n *Node) DeleteMeshes() {
+	seen := make(map[*Mesh]bool)
+	n.Walk(func(c *Node) bool {
+		if c.Mesh != nil && !seen[c.Mesh] {
+			seen[c.Mesh] = true
+			c.Mesh.Delete()
+		}
+		return true
+	})
+}
+
+// FlattenScene 把子树中可见且带 Data 的三角形网格变换到世界空间, 每个节点一组, 用于 SaveOBJ 导出,
+// 合并为一个网格可以调用 OBJModel.Mesh; 节点的 Material 为 *Material 或 *PBRMaterial 时一并导出
+func FlattenScene(root *Node) *OBJModel {
+	model := &OBJModel{Materials: make(map[string]*Material)}
+	names := make(map[any]string)
+
+	material := func(m any) string {
+		if name, ok := names[m]; ok {
+			return name
+		}
+
+		var mtl *Material
+		switch m := m.(type) {
+		case *Material:
+			mtl = m
+		case *PBRMaterial:
+			mtl = pbrToMaterial(m)
+		default:
+			return ""
+		}
+
+		// 材质名可能为空或重复
+		base := mtl.Name
+		if base == "" {
+			base = "material"
+		}
+		name := base
+		for i := 1; model.Materials[name] != nil; i++ {
+			name = fmt.Sprintf("%s.%d", base, i)
+		}
+		c := *mtl
+		c.Name = name
+		model.Materials[name] = &c
+		names[m] = name
+		return name
+	}
+
+	root.Walk(func(n *Node) bool {
+		if !n.Visible {
+			return false
+		}
+		if n.Data == nil || n.Data.Topology != Triangles {
+			return true
+		}
+
+		world := n.World()
+		d := n.Data.Clone()
+		d.Transform(world)
+		if world.Det() < 0 {
+			// 镜像变换会翻转三角形的朝向
+			if len(d.Indices) == 0 {
+				d.Indices = sequence(uint32(len(d.Positions)))
+			}
+			for i := 0; i+2 < len(d.Indices); i += 3 {
+				d.Indices[i+1], d.Indices[i+2] = d.Indices[i+2], d.Indices[i+1]
+			}
+		}
+
+		model.Groups = append(model.Groups, &OBJGroup{
+			Object:   n.Name,
+			Material: material(n.Material),
+			Mesh:     d,
+		})
+		return true
+	})
+
+	return model
+}
+
+// pbrToMaterial 近似转换为 Phong 材质, 贴图是解码后的图片, 不导出
+func pbrToMaterial(p *PBRMaterial) *Material {
+	m := NewMaterial(p.Name)
+	base := p.BaseColorFactor.Vec3()
+	m.Ambient = base
+	m.Diffuse = base.Mul(1 - p.MetallicFactor)
+	m.Specular = mgl32.Vec3{0.04, 0.04, 0.04}.Mul(1 - p.MetallicFactor).Add(base.Mul(p.MetallicFactor))
+	m.Emissive = p.EmissiveFactor
+	m.Opacity = p.BaseColorFactor[3]
+
+	// 常用的粗糙度到高光指数的换算
+	r := max(p.RoughnessFactor, 0.05)
+	m.Shininess = min(2/(r*r*r*r)-2, 1000)
+	return m
+}
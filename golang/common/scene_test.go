@@ -0,0 +1,203 @@
+package common
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// uncachedWorld 不使用缓存, 沿父节点链重新计算世界矩阵
+func uncachedWorld(n *Node) mgl32.Mat4 {
+	m := n.Local().Matrix()
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		m = p.Local().Matrix().Mul4(m)
+	}
+	return m
+}
+
+func TestNodeWorldCache(t *testing.T) {
+	root := NewNode("root", WithTransform(Transform{Translation: mgl32.Vec3{1, 0, 0}, Rotation: mgl32.QuatIdent(), Scale: mgl32.Vec3{1, 1, 1}}))
+	child := NewNode("child")
+	grandchild := NewNode("grandchild")
+	if err := root.AddChild(child); err != nil {
+		t.Fatal(err)
+	}
+	if err := child.AddChild(grandchild); err != nil {
+		t.Fatal(err)
+	}
+	child.SetTranslation(mgl32.Vec3{0, 2, 0})
+	grandchild.SetScale(mgl32.Vec3{2, 2, 2})
+
+	if p := grandchild.WorldPosition(); p != (mgl32.Vec3{1, 2, 0}) {
+		t.Errorf("grandchild at %v, want [1 2 0]", p)
+	}
+
+	// 修改父节点后, 已经缓存的子孙也要更新
+	root.SetTranslation(mgl32.Vec3{5, 0, 0})
+	if p := grandchild.WorldPosition(); p != (mgl32.Vec3{5, 2, 0}) {
+		t.Errorf("after moving root: grandchild at %v, want [5 2 0]", p)
+	}
+	root.Rotate(mgl32.QuatRotate(mgl32.DegToRad(90), mgl32.Vec3{0, 0, 1}))
+	if p := grandchild.WorldPosition(); p.Sub(mgl32.Vec3{3, 0, 0}).Len() > 1e-5 {
+		t.Errorf("after rotating root: grandchild at %v, want [3 0 0]", p)
+	}
+
+	// 随机修改和访问, 缓存的结果始终与重新计算一致
+	rng := rand.New(rand.NewSource(1))
+	nodes := []*Node{root, child, grandchild}
+	for i := 0; i < 20; i++ {
+		n := NewNode("")
+		if err := nodes[rng.Intn(len(nodes))].AddChild(n); err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, n)
+	}
+	for i := 0; i < 1000; i++ {
+		n := nodes[rng.Intn(len(nodes))]
+		switch rng.Intn(4) {
+		case 0:
+			n.Translate(mgl32.Vec3{rng.Float32() - 0.5, rng.Float32() - 0.5, rng.Float32() - 0.5})
+		case 1:
+			n.Rotate(mgl32.QuatRotate(rng.Float32(), mgl32.Vec3{rng.Float32(), 1, rng.Float32()}.Normalize()))
+		case 2:
+			n.SetScale(mgl32.Vec3{0.5 + rng.Float32(), 0.5 + rng.Float32(), 0.5 + rng.Float32()})
+		default:
+			if got, want := n.World(), uncachedWorld(n); !got.ApproxEqualThreshold(want, 1e-3) {
+				t.Fatalf("step %d: cached world %v, want %v", i, got, want)
+			}
+		}
+	}
+	for i, n := range nodes {
+		if got, want := n.World(), uncachedWorld(n); !got.ApproxEqualThreshold(want, 1e-3) {
+			t.Errorf("node %d: cached world %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestNodeReparent(t *testing.T) {
+	a := NewNode("a")
+	b := NewNode("b")
+	c := NewNode("c")
+	a.SetTranslation(mgl32.Vec3{1, 0, 0})
+	b.SetTranslation(mgl32.Vec3{0, 0, 3})
+	b.SetScale(mgl32.Vec3{2, 2, 2})
+	c.SetTranslation(mgl32.Vec3{0, 1, 0})
+	if err := a.AddChild(c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reparent 保持世界变换
+	world := c.World()
+	if err := c.Reparent(b); err != nil {
+		t.Fatal(err)
+	}
+	if c.Parent() != b || len(a.Children()) != 0 || len(b.Children()) != 1 {
+		t.Fatalf("parent %v, a has %d children, b has %d", c.Parent().Name, len(a.Children()), len(b.Children()))
+	}
+	if !c.World().ApproxEqualThreshold(world, 1e-5) {
+		t.Errorf("world after Reparent %v, want %v", c.World(), world)
+	}
+	if want := (mgl32.Vec3{0.5, 0.5, -1.5}); !c.Local().Translation.ApproxEqualThreshold(want, 1e-5) {
+		t.Errorf("local translation %v, want %v", c.Local().Translation, want)
+	}
+	if err := c.Reparent(nil); err != nil {
+		t.Fatal(err)
+	}
+	if c.Parent() != nil || !c.World().ApproxEqualThreshold(world, 1e-5) {
+		t.Errorf("Reparent(nil): parent %v, world %v, want %v", c.Parent(), c.World(), world)
+	}
+
+	// AddChild 和 Detach 保持局部变换
+	if err := b.AddChild(c); err != nil {
+		t.Fatal(err)
+	}
+	local := c.Local()
+	c.Detach()
+	if c.Parent() != nil || len(b.Children()) != 0 || c.Local() != local {
+		t.Errorf("Detach: parent %v, %d children left, local %v", c.Parent(), len(b.Children()), c.Local())
+	}
+	if !c.World().ApproxEqualThreshold(local.Matrix(), 1e-6) {
+		t.Errorf("detached world %v, want local matrix", c.World())
+	}
+	c.Detach()
+
+	// 不能成为自己子孙的子节点, 失败时树不变
+	if err := a.AddChild(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Reparent(b); err == nil {
+		t.Error("node became its own parent")
+	}
+	if err := a.Reparent(b); err == nil {
+		t.Error("node became a child of its descendant")
+	}
+	if a.Parent() != nil || b.Parent() != a || len(a.Children()) != 1 {
+		t.Errorf("tree changed after failed Reparent")
+	}
+}
+
+func TestFlattenScene(t *testing.T) {
+	red := NewMaterial("paint")
+	blue := NewMaterial("paint")
+	pbr := &PBRMaterial{Name: "metal", BaseColorFactor: mgl32.Vec4{1, 0, 0, 1}, MetallicFactor: 1, RoughnessFactor: 0.5}
+
+	root := NewNode("root")
+	root.SetTranslation(mgl32.Vec3{0, 10, 0})
+	cube := GenCube(1, 1)
+	lines := &MeshData{Topology: Lines, Positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}}}
+	nodes := []*Node{
+		NewNode("a", WithMeshData(cube), WithMaterial(red)),
+		NewNode("b", WithMeshData(cube), WithMaterial(blue), WithTransform(Transform{Rotation: mgl32.QuatIdent(), Scale: mgl32.Vec3{-1, 1, 1}})),
+		NewNode("c", WithMeshData(cube), WithMaterial(red)),
+		NewNode("d", WithMeshData(cube), WithMaterial(pbr)),
+		NewNode("lines", WithMeshData(lines)),
+		NewNode("empty"),
+	}
+	for _, n := range nodes {
+		if err := root.AddChild(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hidden := NewNode("hidden")
+	hidden.Visible = false
+	if err := hidden.AddChild(NewNode("under hidden", WithMeshData(cube))); err != nil {
+		t.Fatal(err)
+	}
+	if err := root.AddChild(hidden); err != nil {
+		t.Fatal(err)
+	}
+
+	model := FlattenScene(root)
+	var objects []string
+	for _, g := range model.Groups {
+		objects = append(objects, g.Object)
+	}
+	if len(objects) != 4 || objects[0] != "a" || objects[1] != "b" || objects[2] != "c" || objects[3] != "d" {
+		t.Fatalf("groups %v, want [a b c d]", objects)
+	}
+
+	// 同一个材质只导出一次, 同名的不同材质重命名
+	g := model.Groups
+	if g[0].Material != "paint" || g[1].Material != "paint.1" || g[2].Material != "paint" || g[3].Material != "metal" {
+		t.Errorf("materials %q %q %q %q", g[0].Material, g[1].Material, g[2].Material, g[3].Material)
+	}
+	if len(model.Materials) != 3 || model.Materials["metal"] == nil || model.Materials["metal"].Diffuse != (mgl32.Vec3{}) {
+		t.Errorf("exported materials %v", model.Materials)
+	}
+
+	// 顶点变换到世界空间, 原数据不变; 镜像后三角形仍然朝外
+	for _, g := range model.Groups {
+		b := g.Mesh.Bounds()
+		if !b.Center().ApproxEqualThreshold(mgl32.Vec3{0, 10, 0}, 1e-5) {
+			t.Errorf("%s: center %v, want [0 10 0]", g.Object, b.Center())
+		}
+		d := g.Mesh
+		checkFacing(t, d, func(a, b, c uint32) mgl32.Vec3 {
+			return d.Normals[a].Add(d.Normals[b]).Add(d.Normals[c])
+		})
+	}
+	if c := cube.Bounds().Center(); c != (mgl32.Vec3{}) {
+		t.Errorf("source mesh moved to %v", c)
+	}
+}
@@ -0,0 +1,488 @@
+package common
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// quadric 误差二次型, 对称 4x4 矩阵的 10 个元素, w 为累计的权重
+type quadric struct {
+	a00, a01, a02, a03 float64
+	a11, a12, a13      float64
+	a22, a23           float64
+	a33                float64
+	w                  float64
+}
+
+// planeQuadric 到平面 n·x + d = 0 的距离平方, n 为单位向量
+func planeQuadric(n mgl32.Vec3, d, weight float32) quadric {
+	a, b, c, dd, w := float64(n[0]), float64(n[1]), float64(n[2]), float64(d), float64(weight)
+	return quadric{
+		a00: w * a * a, a01: w * a * b, a02: w * a * c, a03: w * a * dd,
+		a11: w * b * b, a12: w * b * c, a13: w * b * dd,
+		a22: w * c * c, a23: w * c * dd,
+		a33: w * dd * dd,
+		w:   w,
+	}
+}
+
+func (q *quadric) add(o quadric) {
+	q.a00 += o.a00
+	q.a01 += o.a01
+	q.a02 += o.a02
+	q.a03 += o.a03
+	q.a11 += o.a11
+	q.a12 += o.a12
+	q.a13 += o.a13
+	q.a22 += o.a22
+	q.a23 += o.a23
+	q.a33 += o.a33
+	q.w += o.w
+}
+
+// eval 按权重平均的距离平方
+func (q *quadric) eval(p mgl32.Vec3) float64 {
+	if q.w <= 0 {
+		return 0
+	}
+	x, y, z := float64(p[0]), float64(p[1]), float64(p[2])
+	e := q.a00*x*x + 2*q.a01*x*y + 2*q.a02*x*z + 2*q.a03*x +
+		q.a11*y*y + 2*q.a12*y*z + 2*q.a13*y +
+		q.a22*z*z + 2*q.a23*z +
+		q.a33
+	return max(e/q.w, 0)
+}
+
+// 顶点的类型, 决定它可以沿哪些边折叠
+const (
+	vertexInterior = iota
+	vertexBorder   // 在开放边界上, 只能沿边界折叠
+	vertexSeam     // 在 UV/法线接缝上, 只能沿接缝折叠
+	vertexLocked   // 角点或非流形, 不能移动
+)
+
+// borderWeight 边界和接缝约束平面的权重, 越大越不容易偏离
+const borderWeight = 10
+
+// Simplify 使用二次误差度量(QEM)折叠边, 直到三角形数不超过 targetTriangles,
+// 或下一次折叠的误差超过 maxError(模型空间的距离, 为 0 时不限制), 返回简化后的误差
+//
+// 顶点只会被折叠到相邻顶点上, 属性不做插值; 开放边界和 UV/法线接缝只沿自身折叠, 因此形状和纹理不会撕开
+// 位置完全相同的顶点视为同一个点, 简化前可以先 Weld 合并属性也相同的顶点
+func (d *MeshData) Simplify(targetTriangles int, maxError float32) float32 {
+	if d.Topology != Triangles || len(d.Positions) == 0 {
+		return 0
+	}
+	if len(d.Indices) == 0 {
+		d.Indices = sequence(uint32(len(d.Positions)))
+	}
+
+	s := newSimplifier(d)
+	limit := math.Inf(1)
+	if maxError > 0 {
+		limit = float64(maxError) * float64(maxError)
+	}
+
+	var result float64
+	for s.triangles() > targetTriangles {
+		e, n := s.pass(targetTriangles, limit)
+		result = max(result, e)
+		if n == 0 {
+			break
+		}
+	}
+
+	d.Indices = s.indices
+	d.OptimizeVertexFetch()
+	return float32(math.Sqrt(result))
+}
+
+type simplifier struct {
+	d        *MeshData
+	indices  []uint32
+	pos      []uint32 // 每个顶点对应的位置代表顶点, 位置相同的顶点共享一个代表
+	quadrics []quadric
+}
+
+func newSimplifier(d *MeshData) *simplifier {
+	s := &simplifier{
+		d:        d,
+		indices:  slices.Clone(d.Indices),
+		pos:      make([]uint32, len(d.Positions)),
+		quadrics: make([]quadric, len(d.Positions)),
+	}
+
+	first := make(map[mgl32.Vec3]uint32, len(d.Positions))
+	for i, p := range d.Positions {
+		if p[0] == 0 {
+			p[0] = 0 // -0 按 +0 处理
+		}
+		if p[1] == 0 {
+			p[1] = 0
+		}
+		if p[2] == 0 {
+			p[2] = 0
+		}
+		r, ok := first[p]
+		if !ok {
+			r = uint32(i)
+			first[p] = r
+		}
+		s.pos[i] = r
+	}
+
+	// 面的二次型按面积加权
+	for t := 0; t+2 < len(s.indices); t += 3 {
+		a, b, c := s.corners(t)
+		pa, pb, pc := d.Positions[a], d.Positions[b], d.Positions[c]
+		n := pb.Sub(pa).Cross(pc.Sub(pa))
+		area := n.Len() / 2
+		if area <= 0 {
+			continue
+		}
+		n = n.Normalize()
+		q := planeQuadric(n, -n.Dot(pa), area)
+		s.quadrics[a].add(q)
+		s.quadrics[b].add(q)
+		s.quadrics[c].add(q)
+	}
+
+	// 边界和接缝加上垂直于面、经过该边的约束平面
+	edges := s.edges()
+	for t := 0; t+2 < len(s.indices); t += 3 {
+		a, b, c := s.corners(t)
+		n := faceNormal(d.Positions[a], d.Positions[b], d.Positions[c])
+		for _, e := range [3][2]uint32{{a, b}, {b, c}, {c, a}} {
+			info := edges[edgeKey(e[0], e[1])]
+			if info.count != 1 && !info.seam {
+				continue
+			}
+
+			p0, p1 := d.Positions[e[0]], d.Positions[e[1]]
+			dir := p1.Sub(p0)
+			length := dir.Len()
+			if length <= 0 || n == (mgl32.Vec3{}) {
+				continue
+			}
+			side := dir.Cross(n).Normalize()
+			q := planeQuadric(side, -side.Dot(p0), length*length*borderWeight)
+			s.quadrics[e[0]].add(q)
+			s.quadrics[e[1]].add(q)
+		}
+	}
+	return s
+}
+
+func (s *simplifier) triangles() int {
+	return len(s.indices) / 3
+}
+
+// corners 三角形 t(索引下标)三个角的位置代表顶点
+func (s *simplifier) corners(t int) (uint32, uint32, uint32) {
+	return s.pos[s.indices[t]], s.pos[s.indices[t+1]], s.pos[s.indices[t+2]]
+}
+
+type edgeInfo struct {
+	count  int
+	wa, wb uint32 // 第一次出现时两个端点的实际顶点
+	seam   bool
+}
+
+// edgeKey 无向边, 端点为位置代表顶点
+func edgeKey(a, b uint32) uint64 {
+	if a > b {
+		a, b = b, a
+	}
+	return uint64(a)<<32 | uint64(b)
+}
+
+// edges 统计每条边被几个三角形使用, 两侧实际顶点不同的边为接缝
+func (s *simplifier) edges() map[uint64]edgeInfo {
+	edges := make(map[uint64]edgeInfo, len(s.indices))
+	for t := 0; t+2 < len(s.indices); t += 3 {
+		for k := 0; k < 3; k++ {
+			va, vb := s.indices[t+k], s.indices[t+(k+1)%3]
+			pa, pb := s.pos[va], s.pos[vb]
+			if pa > pb {
+				va, vb = vb, va
+			}
+
+			key := edgeKey(pa, pb)
+			info, ok := edges[key]
+			if !ok {
+				info.wa, info.wb = va, vb
+			} else if info.wa != va || info.wb != vb {
+				info.seam = true
+			}
+			info.count++
+			edges[key] = info
+		}
+	}
+	return edges
+}
+
+// classify 根据边界边和接缝边的数量确定每个位置的类型
+func (s *simplifier) classify(edges map[uint64]edgeInfo) []uint8 {
+	var (
+		kind   = make([]uint8, len(s.pos))
+		border = make([]uint8, len(s.pos))
+		seam   = make([]uint8, len(s.pos))
+	)
+	inc := func(c []uint8, i uint32) {
+		if c[i] < 255 {
+			c[i]++
+		}
+	}
+
+	for key, info := range edges {
+		a, b := uint32(key>>32), uint32(key)
+		switch {
+		case info.count > 2:
+			kind[a], kind[b] = vertexLocked, vertexLocked
+		case info.count == 1:
+			inc(border, a)
+			inc(border, b)
+		case info.seam:
+			inc(seam, a)
+			inc(seam, b)
+		}
+	}
+
+	for i := range kind {
+		switch {
+		case kind[i] == vertexLocked:
+		case border[i] > 0 && seam[i] > 0:
+			kind[i] = vertexLocked
+		case border[i] == 2:
+			kind[i] = vertexBorder
+		case seam[i] == 2:
+			kind[i] = vertexSeam
+		case border[i] > 0 || seam[i] > 0:
+			kind[i] = vertexLocked // 边界或接缝的端点、分叉点
+		}
+	}
+	return kind
+}
+
+type collapse struct {
+	from, to uint32 // 位置代表顶点
+	err      float64
+}
+
+// pass 计算所有边的折叠代价, 按代价从小到大执行互不影响的折叠, 返回最大误差和折叠次数
+func (s *simplifier) pass(target int, limit float64) (float64, int) {
+	var (
+		d     = s.d
+		edges = s.edges()
+		kind  = s.classify(edges)
+	)
+
+	// 每个位置相邻的三角形
+	adjStart := make([]int32, len(s.pos)+1)
+	for t := 0; t+2 < len(s.indices); t += 3 {
+		a, b, c := s.corners(t)
+		adjStart[a+1]++
+		adjStart[b+1]++
+		adjStart[c+1]++
+	}
+	for i := 1; i < len(adjStart); i++ {
+		adjStart[i] += adjStart[i-1]
+	}
+	adj := make([]int32, adjStart[len(adjStart)-1])
+	fill := slices.Clone(adjStart[:len(s.pos)])
+	for t := 0; t+2 < len(s.indices); t += 3 {
+		a, b, c := s.corners(t)
+		for _, v := range [3]uint32{a, b, c} {
+			adj[fill[v]] = int32(t)
+			fill[v]++
+		}
+	}
+	triangles := func(v uint32) []int32 {
+		return adj[adjStart[v]:adjStart[v+1]]
+	}
+
+	var candidates []collapse
+	for key, info := range edges {
+		a, b := uint32(key>>32), uint32(key)
+		for _, c := range [2][2]uint32{{a, b}, {b, a}} {
+			from, to := c[0], c[1]
+			switch kind[from] {
+			case vertexLocked:
+				continue
+			case vertexBorder:
+				if info.count != 1 {
+					continue
+				}
+			case vertexSeam:
+				if !info.seam || info.count != 2 {
+					continue
+				}
+			}
+
+			q := s.quadrics[from]
+			q.add(s.quadrics[to])
+			e := q.eval(d.Positions[to])
+			if e <= limit {
+				candidates = append(candidates, collapse{from: from, to: to, err: e})
+			}
+		}
+	}
+	// 误差相同时按顶点排序, 保证结果稳定
+	slices.SortFunc(candidates, func(x, y collapse) int {
+		switch {
+		case x.err != y.err:
+			return cmp.Compare(x.err, y.err)
+		case x.from != y.from:
+			return cmp.Compare(x.from, y.from)
+		}
+		return cmp.Compare(x.to, y.to)
+	})
+
+	var (
+		remap     = make([]uint32, len(d.Positions))
+		touched   = make([]bool, len(s.pos))
+		remaining = s.triangles()
+		maxErr    float64
+		count     int
+	)
+	for i := range remap {
+		remap[i] = uint32(i)
+	}
+
+	for _, c := range candidates {
+		if remaining <= target {
+			break
+		}
+		if touched[c.from] || touched[c.to] {
+			continue
+		}
+
+		wedges, ok := s.wedgeTargets(c, triangles(c.from))
+		if !ok || s.flips(c, triangles(c.from)) || !s.linkCondition(c, triangles(c.from), triangles(c.to)) {
+			continue
+		}
+
+		for w, t := range wedges {
+			remap[w] = t
+		}
+		s.quadrics[c.to].add(s.quadrics[c.from])
+
+		// 一环邻域内的三角形都已改变, 本轮不再参与折叠
+		for _, t := range triangles(c.from) {
+			a, b, cc := s.corners(int(t))
+			touched[a], touched[b], touched[cc] = true, true, true
+			if a == c.to || b == c.to || cc == c.to {
+				remaining--
+			}
+		}
+		maxErr = max(maxErr, c.err)
+		count++
+	}
+
+	// 重写索引, 去掉退化的三角形
+	out := s.indices[:0]
+	for t := 0; t+2 < len(s.indices); t += 3 {
+		a, b, c := remap[s.indices[t]], remap[s.indices[t+1]], remap[s.indices[t+2]]
+		if s.pos[a] == s.pos[b] || s.pos[b] == s.pos[c] || s.pos[c] == s.pos[a] {
+			continue
+		}
+		out = append(out, a, b, c)
+	}
+	s.indices = out
+
+	return maxErr, count
+}
+
+// wedgeTargets 折叠 from 时它的每个实际顶点要合并到 to 的哪个实际顶点,
+// 取共用三角形中 to 的实际顶点, 有歧义时(接缝的两侧不一致)放弃这次折叠
+func (s *simplifier) wedgeTargets(c collapse, tris []int32) (map[uint32]uint32, bool) {
+	wedges := make(map[uint32]uint32, 2)
+	for _, t := range tris {
+		var wf, wt uint32
+		hasFrom, hasTo := false, false
+		for k := 0; k < 3; k++ {
+			v := s.indices[int(t)+k]
+			switch s.pos[v] {
+			case c.from:
+				wf, hasFrom = v, true
+			case c.to:
+				wt, hasTo = v, true
+			}
+		}
+		if !hasFrom || !hasTo {
+			continue
+		}
+
+		if prev, ok := wedges[wf]; ok && prev != wt {
+			return nil, false
+		}
+		wedges[wf] = wt
+	}
+
+	// from 的所有实际顶点都必须有去处
+	for _, t := range tris {
+		for k := 0; k < 3; k++ {
+			v := s.indices[int(t)+k]
+			if s.pos[v] != c.from {
+				continue
+			}
+			if _, ok := wedges[v]; !ok {
+				return nil, false
+			}
+		}
+	}
+	return wedges, true
+}
+
+// linkCondition 两个端点共同的邻居只能是该边所在三角形的第三个顶点, 否则折叠后会产生非流形
+func (s *simplifier) linkCondition(c collapse, fromTris, toTris []int32) bool {
+	neighbors := make(map[uint32]bool, 8)
+	for _, t := range fromTris {
+		a, b, cc := s.corners(int(t))
+		neighbors[a], neighbors[b], neighbors[cc] = true, true, true
+	}
+
+	var shared, opposite int
+	seen := make(map[uint32]bool, 8)
+	for _, t := range toTris {
+		a, b, cc := s.corners(int(t))
+		if a == c.from || b == c.from || cc == c.from {
+			opposite++
+		}
+		for _, v := range [3]uint32{a, b, cc} {
+			if v != c.from && v != c.to && neighbors[v] && !seen[v] {
+				seen[v] = true
+				shared++
+			}
+		}
+	}
+	return shared == opposite
+}
+
+// flips 折叠后 from 周围剩下的三角形是否会翻转
+func (s *simplifier) flips(c collapse, tris []int32) bool {
+	p := s.d.Positions
+	for _, t := range tris {
+		a, b, cc := s.corners(int(t))
+		if a == c.to || b == c.to || cc == c.to {
+			continue // 会被删除
+		}
+
+		before := p[b].Sub(p[a]).Cross(p[cc].Sub(p[a]))
+		move := func(v uint32) mgl32.Vec3 {
+			if v == c.from {
+				return p[c.to]
+			}
+			return p[v]
+		}
+		pa, pb, pc := move(a), move(b), move(cc)
+		after := pb.Sub(pa).Cross(pc.Sub(pa))
+		if before.Dot(after) <= 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// meshArea 所有三角形的面积之和
+func meshArea(d *MeshData) float32 {
+	var area float32
+	for t := 0; t < d.TriangleCount(); t++ {
+		a, b, c := d.Triangle(t)
+		p := d.Positions
+		area += p[b].Sub(p[a]).Cross(p[c].Sub(p[a])).Len() / 2
+	}
+	return area
+}
+
+// checkFacing 每个三角形的几何法线都与 want 返回的方向一致, 即没有翻转的三角形
+// UV 球极点处的一圈顶点重合, 原网格在那里就有面积为 0 的三角形, 跳过
+func checkFacing(t *testing.T, d *MeshData, want func(a, b, c uint32) mgl32.Vec3) {
+	t.Helper()
+	for i := 0; i < d.TriangleCount(); i++ {
+		a, b, c := d.Triangle(i)
+		p := d.Positions
+		n := p[b].Sub(p[a]).Cross(p[c].Sub(p[a]))
+		if n.Len() < 1e-6 {
+			continue
+		}
+		if n.Normalize().Dot(want(a, b, c)) <= 0 {
+			t.Fatalf("triangle %d (%v %v %v) flipped: normal %v", i, p[a], p[b], p[c], n)
+		}
+	}
+}
+
+// positionEdges 按位置统计每条无向边被几个三角形使用
+func positionEdges(d *MeshData) map[[2]mgl32.Vec3]int {
+	edges := make(map[[2]mgl32.Vec3]int)
+	for i := 0; i < d.TriangleCount(); i++ {
+		a, b, c := d.Triangle(i)
+		for _, e := range [3][2]uint32{{a, b}, {b, c}, {c, a}} {
+			p, q := d.Positions[e[0]], d.Positions[e[1]]
+			if q[0] < p[0] || q[0] == p[0] && (q[1] < p[1] || q[1] == p[1] && q[2] < p[2]) {
+				p, q = q, p
+			}
+			edges[[2]mgl32.Vec3{p, q}]++
+		}
+	}
+	return edges
+}
+
+func TestSimplifyPlaneBorder(t *testing.T) {
+	d := GenPlane(2, 2, 16, 16)
+	e := d.Simplify(2, 1e-4)
+	if e > 1e-4 {
+		t.Errorf("error %v, want <= 1e-4", e)
+	}
+	if n := d.TriangleCount(); n != 2 {
+		t.Errorf("%d triangles, want 2", n)
+	}
+	checkFacing(t, d, func(a, b, c uint32) mgl32.Vec3 { return mgl32.Vec3{0, 1, 0} })
+
+	// 边界仍然是原来的正方形
+	if area := meshArea(d); abs(area-4) > 1e-4 {
+		t.Errorf("area %v, want 4", area)
+	}
+	var border float32
+	for e, n := range positionEdges(d) {
+		if n != 1 {
+			continue
+		}
+		p, q := e[0], e[1]
+		if !(p[0] == q[0] && abs(p[0]) == 1) && !(p[2] == q[2] && abs(p[2]) == 1) {
+			t.Errorf("border edge %v-%v is not on the square", p, q)
+		}
+		border += p.Sub(q).Len()
+	}
+	if abs(border-8) > 1e-4 {
+		t.Errorf("border length %v, want 8", border)
+	}
+}
+
+func TestSimplifyCubeSeams(t *testing.T) {
+	d := GenCube(1, 8)
+	e := d.Simplify(12, 1e-4)
+	if e > 1e-4 {
+		t.Errorf("error %v, want <= 1e-4", e)
+	}
+	if n := d.TriangleCount(); n != 12 {
+		t.Errorf("%d triangles, want 12", n)
+	}
+
+	// 法线接缝不会被折叠穿过: 每个三角形的三个顶点仍然属于同一个面
+	checkFacing(t, d, func(a, b, c uint32) mgl32.Vec3 {
+		n := d.Normals[a]
+		if d.Normals[b] != n || d.Normals[c] != n {
+			t.Fatalf("triangle %d %d %d spans faces %v %v %v", a, b, c, n, d.Normals[b], d.Normals[c])
+		}
+		return n
+	})
+	if area := meshArea(d); abs(area-6) > 1e-4 {
+		t.Errorf("area %v, want 6", area)
+	}
+	for e, n := range positionEdges(d) {
+		if n != 2 {
+			t.Errorf("edge %v used by %d triangles, want a closed surface", e, n)
+		}
+	}
+}
+
+func TestSimplifySphere(t *testing.T) {
+	src := GenUVSphere(1, 32, 16)
+	target := src.TriangleCount() / 4
+
+	d := src.Clone()
+	d.Simplify(target, 0)
+	if n := d.TriangleCount(); n > target {
+		t.Errorf("%d triangles, want <= %d", n, target)
+	}
+	outward := func(a, b, c uint32) mgl32.Vec3 {
+		return d.Positions[a].Add(d.Positions[b]).Add(d.Positions[c])
+	}
+	checkFacing(t, d, outward)
+
+	// UV 接缝(经度 0 处的子午线)的两侧使用相同的位置, 纹理不会撕开
+	side := [2]map[mgl32.Vec3]bool{{}, {}}
+	for i := 0; i < d.TriangleCount(); i++ {
+		a, b, c := d.Triangle(i)
+		var lo, hi float32 = 1, 0
+		for _, v := range [3]uint32{a, b, c} {
+			lo, hi = min(lo, d.UVs[v][0]), max(hi, d.UVs[v][0])
+		}
+		if hi-lo > 0.5 {
+			t.Fatalf("triangle %d wraps around the UV seam: u in [%v, %v]", i, lo, hi)
+		}
+		for _, v := range [3]uint32{a, b, c} {
+			p := d.Positions[v]
+			if abs(p[1]) > 0.999 {
+				continue // 极点
+			}
+			switch d.UVs[v][0] {
+			case 0:
+				side[0][p] = true
+			case 1:
+				side[1][p] = true
+			}
+		}
+	}
+	if len(side[0]) == 0 || len(side[0]) != len(side[1]) {
+		t.Fatalf("seam has %d vertices on one side and %d on the other", len(side[0]), len(side[1]))
+	}
+	for p := range side[0] {
+		if !side[1][p] {
+			t.Errorf("seam vertex %v only on one side", p)
+		}
+		if abs(p[0]) > 1e-6 || p[2] < 0 {
+			t.Errorf("seam vertex %v moved off the meridian", p)
+		}
+	}
+
+	// 给定误差上限时返回的误差不超过上限, 表面也不会偏离太多
+	const maxError = 0.02
+	d = src.Clone()
+	e := d.Simplify(0, maxError)
+	if e > maxError || e <= 0 {
+		t.Errorf("error %v, want in (0, %v]", e, maxError)
+	}
+	if n := d.TriangleCount(); n >= src.TriangleCount() || n <= 0 {
+		t.Errorf("%d triangles from %d", n, src.TriangleCount())
+	}
+	checkFacing(t, d, outward)
+	for i := 0; i < d.TriangleCount(); i++ {
+		a, b, c := d.Triangle(i)
+		center := d.Positions[a].Add(d.Positions[b]).Add(d.Positions[c]).Mul(1.0 / 3)
+		if dev := 1 - center.Len(); dev > 0.1 {
+			t.Errorf("triangle %d is %v inside the sphere", i, dev)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package common
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type StereoMode int
+
+const (
+	StereoNone       StereoMode = 0 // 普通单目渲染
+	StereoSideBySide StereoMode = 1 // 左右并排
+	StereoTopBottom  StereoMode = 2 // 上下排列,左眼在上
+	StereoAnaglyph   StereoMode = 3 // 红青立体,左眼红色右眼青色
+
+	stereoModeCount = 4
+)
+
+func (m StereoMode) Next() StereoMode {
+	return (m + 1) % stereoModeCount
+}
+
+type Eye int
+
+const (
+	EyeCenter Eye = 0
+	EyeLeft   Eye = 1
+	EyeRight  Eye = 2
+)
+
+const (
+	IPD         float32 = 0.064 // 默认瞳距,单位与场景一致(米)
+	Convergence float32 = 3.0   // 默认汇聚距离,此深度处左右眼视差为 0
+)
+
+// StereoRig 由一个相机派生出左右两个眼睛,
+// 使用平行的视线加非对称(off-axis)视锥,避免 toe-in 带来的垂直视差
+type StereoRig struct {
+	Camera      *Camera
+	IPD         float32
+	Convergence float32
+	Near        float32
+	Far         float32
+	Mode        StereoMode
+}
+
+type StereoOption func(*StereoRig)
+
+func WithIPD(ipd float32) StereoOption {
+	return func(s *StereoRig) {
+		s.IPD = ipd
+	}
+}
+
+func WithConvergence(distance float32) StereoOption {
+	return func(s *StereoRig) {
+		s.Convergence = distance
+	}
+}
+
+func WithClipPlanes(near, far float32) StereoOption {
+	return func(s *StereoRig) {
+		s.Near = near
+		s.Far = far
+	}
+}
+
+func WithStereoMode(mode StereoMode) StereoOption {
+	return func(s *StereoRig) {
+		s.Mode = mode
+	}
+}
+
+func NewStereoRig(camera *Camera, opts ...StereoOption) *StereoRig {
+	s := &StereoRig{
+		Camera:      camera,
+		IPD:         IPD,
+		Convergence: Convergence,
+		Near:        0.1,
+		Far:         100.0,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *StereoRig) eyeOffset(eye Eye) float32 {
+	switch eye {
+	case EyeLeft:
+		return -s.IPD / 2
+	case EyeRight:
+		return s.IPD / 2
+	default:
+		return 0
+	}
+}
+
+// EyePosition 眼睛沿相机 Right 方向偏移半个瞳距
+func (s *StereoRig) EyePosition(eye Eye) mgl32.Vec3 {
+	return s.Camera.Position.Add(s.Camera.Right.Mul(s.eyeOffset(eye)))
+}
+
+func (s *StereoRig) EyeView(eye Eye) mgl32.Mat4 {
+	p := s.EyePosition(eye)
+	return mgl32.LookAtV(p, p.Add(s.Camera.Front), s.Camera.Up)
+}
+
+// EyeProjection 非对称视锥,视锥在近平面上向相反方向平移 offset*near/convergence,
+// 使两只眼睛的视锥在汇聚距离处重合
+func (s *StereoRig) EyeProjection(eye Eye, aspect float32) mgl32.Mat4 {
+	top := s.Near * float32(math.Tan(float64(mgl32.DegToRad(s.Camera.Zoom))/2))
+	right := top * aspect
+
+	var shift float32
+	if s.Convergence > 0 {
+		shift = -s.eyeOffset(eye) * s.Near / s.Convergence
+	}
+
+	return mgl32.Frustum(-right+shift, right+shift, -top, top, s.Near, s.Far)
+}
+
+// Render 按当前模式渲染每只眼睛,draw 中只需要使用传入的 view 和 projection 绘制场景
+// 调用前由调用者清空颜色和深度缓冲, width,height 为帧缓冲尺寸
+func (s *StereoRig) Render(width, height int32, draw func(eye Eye, view, projection mgl32.Mat4)) {
+	switch s.Mode {
+	case StereoSideBySide:
+		half := width / 2
+		aspect := float32(half) / float32(height)
+
+		gl.Viewport(0, 0, half, height)
+		draw(EyeLeft, s.EyeView(EyeLeft), s.EyeProjection(EyeLeft, aspect))
+		gl.Viewport(half, 0, width-half, height)
+		draw(EyeRight, s.EyeView(EyeRight), s.EyeProjection(EyeRight, aspect))
+	case StereoTopBottom:
+		half := height / 2
+		aspect := float32(width) / float32(half)
+
+		gl.Viewport(0, height-half, width, half)
+		draw(EyeLeft, s.EyeView(EyeLeft), s.EyeProjection(EyeLeft, aspect))
+		gl.Viewport(0, 0, width, height-half)
+		draw(EyeRight, s.EyeView(EyeRight), s.EyeProjection(EyeRight, aspect))
+	case StereoAnaglyph:
+		aspect := float32(width) / float32(height)
+
+		gl.Viewport(0, 0, width, height)
+		gl.ColorMask(true, false, false, true)
+		draw(EyeLeft, s.EyeView(EyeLeft), s.EyeProjection(EyeLeft, aspect))
+		// 两只眼睛画在同一区域,需要清空深度缓冲
+		gl.Clear(gl.DEPTH_BUFFER_BIT)
+		gl.ColorMask(false, true, true, true)
+		draw(EyeRight, s.EyeView(EyeRight), s.EyeProjection(EyeRight, aspect))
+		gl.ColorMask(true, true, true, true)
+	default:
+		gl.Viewport(0, 0, width, height)
+		draw(EyeCenter, s.EyeView(EyeCenter), s.EyeProjection(EyeCenter, float32(width)/float32(height)))
+		return
+	}
+
+	gl.Viewport(0, 0, width, height)
+}
@@ -0,0 +1,144 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// LoadSTL 读取二进制或 ASCII 格式的 stl 文件
+func LoadSTL(name string) (*MeshData, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := ParseSTL(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return d, nil
+}
+
+// ParseSTL 解析 stl, 每个三角面独立 3 个顶点, 法线取面法线
+func ParseSTL(data []byte) (*MeshData, error) {
+	// 有些二进制文件的 80 字节头部也以 "solid" 开头, 因此先按文件长度判断
+	if len(data) >= 84 {
+		n := binary.LittleEndian.Uint32(data[80:])
+		if uint64(len(data)) == 84+50*uint64(n) {
+			return parseBinarySTL(data[84:], int(n)), nil
+		}
+	}
+
+	if bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("solid")) {
+		return parseASCIISTL(data)
+	}
+	return nil, errors.New("stl: unrecognized format")
+}
+
+func parseBinarySTL(data []byte, n int) *MeshData {
+	d := &MeshData{
+		Positions: make([]mgl32.Vec3, 0, 3*n),
+		Normals:   make([]mgl32.Vec3, 0, 3*n),
+	}
+
+	vec := func(b []byte) mgl32.Vec3 {
+		le := binary.LittleEndian
+		return mgl32.Vec3{
+			math.Float32frombits(le.Uint32(b)),
+			math.Float32frombits(le.Uint32(b[4:])),
+			math.Float32frombits(le.Uint32(b[8:])),
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		b := data[50*i:]
+		d.addFacet(vec(b), vec(b[12:]), vec(b[24:]), vec(b[36:]))
+	}
+	return d
+}
+
+func parseASCIISTL(data []byte) (*MeshData, error) {
+	var (
+		d      = &MeshData{}
+		normal mgl32.Vec3
+		facet  []mgl32.Vec3
+		line   int
+	)
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line++
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "facet":
+			if len(fields) != 5 || fields[1] != "normal" {
+				return nil, fmt.Errorf("stl line %d: bad facet", line)
+			}
+			v, err := parseSTLVec(fields[2:])
+			if err != nil {
+				return nil, fmt.Errorf("stl line %d: %w", line, err)
+			}
+			normal, facet = v, facet[:0]
+		case "vertex":
+			if len(fields) != 4 {
+				return nil, fmt.Errorf("stl line %d: bad vertex", line)
+			}
+			v, err := parseSTLVec(fields[1:])
+			if err != nil {
+				return nil, fmt.Errorf("stl line %d: %w", line, err)
+			}
+			facet = append(facet, v)
+		case "endloop":
+			// 个别导出器会写出多边形, 按扇形拆分
+			for i := 1; i+1 < len(facet); i++ {
+				d.addFacet(normal, facet[0], facet[i], facet[i+1])
+			}
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func parseSTLVec(fields []string) (mgl32.Vec3, error) {
+	var v mgl32.Vec3
+	for i, f := range fields {
+		x, err := strconv.ParseFloat(f, 32)
+		if err != nil {
+			return v, err
+		}
+		v[i] = float32(x)
+	}
+	return v, nil
+}
+
+// addFacet 添加一个三角面, 文件中的法线为零或无效时根据顶点重新计算
+func (d *MeshData) addFacet(n, a, b, c mgl32.Vec3) {
+	if l := n.Len(); l < 1e-6 || l != l {
+		n = b.Sub(a).Cross(c.Sub(a))
+		if n.Len() > 0 {
+			n = n.Normalize()
+		}
+	} else {
+		n = n.Mul(1 / l)
+	}
+
+	d.Positions = append(d.Positions, a, b, c)
+	d.Normals = append(d.Normals, n, n, n)
+}
@@ -0,0 +1,121 @@
+package common
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// binarySTL 每个三角形写入法线和三个顶点
+func binarySTL(header string, facets ...[4]mgl32.Vec3) []byte {
+	buf := make([]byte, 80, 84+50*len(facets))
+	copy(buf, header)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(facets)))
+	for _, f := range facets {
+		for _, v := range f {
+			for _, c := range v {
+				buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(c))
+			}
+		}
+		buf = append(buf, 0, 0) // attribute byte count
+	}
+	return buf
+}
+
+func TestParseSTL(t *testing.T) {
+	up := mgl32.Vec3{0, 0, 1}
+	tri := [3]mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}
+
+	tests := []struct {
+		name      string
+		data      []byte
+		positions []mgl32.Vec3
+		normals   []mgl32.Vec3 // 每个三角形一个
+	}{
+		{
+			name: "ascii",
+			data: []byte(`solid test
+  facet normal 0 0 2
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 0 1 0
+    endloop
+  endfacet
+  facet normal 0 0 0
+    outer loop
+      vertex 0 0 0
+      vertex 0 1 0
+      vertex 1 0 0
+    endloop
+  endfacet
+endsolid test
+`),
+			positions: []mgl32.Vec3{tri[0], tri[1], tri[2], tri[0], tri[2], tri[1]},
+			normals:   []mgl32.Vec3{up, {0, 0, -1}}, // 第一个法线归一化, 第二个为零时按顶点计算
+		},
+		{
+			name: "ascii polygon",
+			data: []byte(`solid quad
+facet normal 0 0 1
+outer loop
+vertex 0 0 0
+vertex 1 0 0
+vertex 1 1 0
+vertex 0 1 0
+endloop
+endfacet
+endsolid
+`),
+			positions: []mgl32.Vec3{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 0, 0}, {1, 1, 0}, {0, 1, 0}},
+			normals:   []mgl32.Vec3{up, up},
+		},
+		{
+			name:      "binary",
+			data:      binarySTL("binary", [4]mgl32.Vec3{up, tri[0], tri[1], tri[2]}),
+			positions: tri[:],
+			normals:   []mgl32.Vec3{up},
+		},
+		{
+			// 头部以 solid 开头的二进制文件按长度识别
+			name:      "binary solid header",
+			data:      binarySTL("solid exported", [4]mgl32.Vec3{{}, tri[0], tri[2], tri[1]}, [4]mgl32.Vec3{up, tri[0], tri[1], tri[2]}),
+			positions: []mgl32.Vec3{tri[0], tri[2], tri[1], tri[0], tri[1], tri[2]},
+			normals:   []mgl32.Vec3{{0, 0, -1}, up},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseSTL(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(d.Positions) != len(tt.positions) || len(d.Normals) != len(tt.positions) {
+				t.Fatalf("%d positions %d normals, want %d", len(d.Positions), len(d.Normals), len(tt.positions))
+			}
+			for i, p := range tt.positions {
+				if d.Positions[i] != p {
+					t.Errorf("position %d: %v, want %v", i, d.Positions[i], p)
+				}
+				if n := tt.normals[i/3]; d.Normals[i].Sub(n).Len() > 1e-6 {
+					t.Errorf("normal %d: %v, want %v", i, d.Normals[i], n)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSTLErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown": "not an stl file",
+		"facet":   "solid x\nfacet 0 0 1\nendsolid\n",
+		"vertex":  "solid x\nfacet normal 0 0 1\nouter loop\nvertex 0 zero 0\nendloop\nendfacet\nendsolid\n",
+	} {
+		if _, err := ParseSTL([]byte(data)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
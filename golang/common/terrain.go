@@ -0,0 +1,330 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Heightmap 高度图, 高度范围 0~1, 第 0 行对应图片底部(与 LoadImgRGB 一致)
+type Heightmap struct {
+	Width   int
+	Depth   int
+	Heights []float32
+}
+
+// NewHeightmap 把灰度图转换为高度图, 彩色图片取各通道的平均值, 忽略 alpha
+func NewHeightmap(img *ImageData) (*Heightmap, error) {
+	c := img.Channels()
+	if img.Width < 2 || img.Height < 2 || c == 0 {
+		return nil, fmt.Errorf("NewHeightmap: image too small (%dx%d)", img.Width, img.Height)
+	}
+
+	gray := min(c, 3)
+	h := &Heightmap{Width: img.Width, Depth: img.Height, Heights: make([]float32, img.Width*img.Height)}
+	for i := range h.Heights {
+		var sum int
+		for _, v := range img.Pixels[i*c : i*c+gray] {
+			sum += int(v)
+		}
+		h.Heights[i] = float32(sum) / float32(gray*255)
+	}
+	return h, nil
+}
+
+func LoadHeightmap(path string) (*Heightmap, error) {
+	img, err := LoadImgRGB(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewHeightmap(img)
+}
+
+// At 取格点的高度, 超出范围时取边界
+func (h *Heightmap) At(x, z int) float32 {
+	x = min(max(x, 0), h.Width-1)
+	z = min(max(z, 0), h.Depth-1)
+	return h.Heights[z*h.Width+x]
+}
+
+// TerrainChunk 地形的一块, Bounds 为世界空间的包围盒
+type TerrainChunk struct {
+	Mesh   *Mesh
+	Bounds AABB
+}
+
+// Terrain 由高度图生成的分块网格, 中心在原点, 图片的上方朝向 -z
+type Terrain struct {
+	Heightmap  *Heightmap
+	Size       mgl32.Vec3 // x/z 为地形的宽和深, y 为高度 1 对应的世界高度
+	ChunkCells int        // 每块边长的格子数
+	Tiling     float32    // 各层纹理在整个地形上重复的次数
+	Chunks     []TerrainChunk
+
+	splat  *Texture
+	layers []*Texture
+}
+
+type TerrainOption func(*Terrain)
+
+func WithTerrainSize(width, height, depth float32) TerrainOption {
+	return func(t *Terrain) {
+		t.Size = mgl32.Vec3{width, height, depth}
+	}
+}
+
+func WithChunkCells(cells int) TerrainOption {
+	return func(t *Terrain) {
+		t.ChunkCells = cells
+	}
+}
+
+func WithLayerTiling(tiling float32) TerrainOption {
+	return func(t *Terrain) {
+		t.Tiling = tiling
+	}
+}
+
+// NewTerrain 生成所有块并上传, 默认每个格子边长为 1
+func NewTerrain(h *Heightmap, opts ...TerrainOption) (*Terrain, error) {
+	w, d := float32(h.Width-1), float32(h.Depth-1)
+	t := &Terrain{
+		Heightmap:  h,
+		Size:       mgl32.Vec3{w, max(w, d) / 8, d},
+		ChunkCells: 64,
+		Tiling:     max(w, d) / 8,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.ChunkCells < 1 {
+		return nil, fmt.Errorf("NewTerrain: invalid chunk cells %d", t.ChunkCells)
+	}
+
+	cx, cz := t.ChunkCount()
+	for z := 0; z < cz; z++ {
+		for x := 0; x < cx; x++ {
+			data := t.ChunkData(x, z)
+			m, err := data.Upload()
+			if err != nil {
+				t.Delete()
+				return nil, err
+			}
+			t.Chunks = append(t.Chunks, TerrainChunk{Mesh: m, Bounds: data.Bounds()})
+		}
+	}
+	return t, nil
+}
+
+// ChunkCount x 和 z 方向的块数, Chunks 按行排列
+func (t *Terrain) ChunkCount() (int, int) {
+	n := t.ChunkCells
+	return (t.Heightmap.Width - 2 + n) / n, (t.Heightmap.Depth - 2 + n) / n
+}
+
+func (t *Terrain) cellSize() (float32, float32) {
+	return t.Size[0] / float32(t.Heightmap.Width-1), t.Size[2] / float32(t.Heightmap.Depth-1)
+}
+
+// vertex 格点 (x, z) 的世界坐标
+func (t *Terrain) vertex(x, z int) mgl32.Vec3 {
+	h := t.Heightmap
+	return mgl32.Vec3{
+		(float32(x)/float32(h.Width-1) - 0.5) * t.Size[0],
+		h.At(x, z) * t.Size[1],
+		(0.5 - float32(z)/float32(h.Depth-1)) * t.Size[2],
+	}
+}
+
+// vertexNormal 用中心差分求格点的法线, 相邻块共用的格点法线一致, 接缝处不会出现光照断层
+func (t *Terrain) vertexNormal(x, z int) mgl32.Vec3 {
+	h := t.Heightmap
+	sx, sz := t.cellSize()
+	x0, x1 := max(x-1, 0), min(x+1, h.Width-1)
+	z0, z1 := max(z-1, 0), min(z+1, h.Depth-1)
+
+	// 格点 z 增大时世界坐标 z 减小
+	dx := (h.At(x1, z) - h.At(x0, z)) * t.Size[1] / (float32(x1-x0) * sx)
+	dz := -(h.At(x, z1) - h.At(x, z0)) * t.Size[1] / (float32(z1-z0) * sz)
+	return mgl32.Vec3{-dx, 1, -dz}.Normalize()
+}
+
+// ChunkData 生成第 (cx, cz) 块的网格, UV 覆盖整个地形的 0~1, 用于采样 splat 贴图
+func (t *Terrain) ChunkData(cx, cz int) *MeshData {
+	h := t.Heightmap
+	x0, z0 := cx*t.ChunkCells, cz*t.ChunkCells
+	x1, z1 := min(x0+t.ChunkCells, h.Width-1), min(z0+t.ChunkCells, h.Depth-1)
+	w := x1 - x0 + 1
+
+	d := &MeshData{}
+	for z := z0; z <= z1; z++ {
+		for x := x0; x <= x1; x++ {
+			d.Positions = append(d.Positions, t.vertex(x, z))
+			d.Normals = append(d.Normals, t.vertexNormal(x, z))
+			d.UVs = append(d.UVs, mgl32.Vec2{float32(x) / float32(h.Width-1), float32(z) / float32(h.Depth-1)})
+		}
+	}
+
+	// 每个格子沿 (x+1, z)-(x, z+1) 对角线切成两个三角形, 与 HeightAt 的插值一致
+	for z := 0; z < z1-z0; z++ {
+		for x := 0; x < x1-x0; x++ {
+			a := uint32(z*w + x)
+			b, c := a+1, a+uint32(w)
+			d.Indices = append(d.Indices, a, b, c, b, c+1, c)
+		}
+	}
+	return d
+}
+
+// gridCoord 世界坐标转换为格点坐标
+func (t *Terrain) gridCoord(x, z float32) (float32, float32) {
+	h := t.Heightmap
+	gx := mgl32.Clamp((x/t.Size[0]+0.5)*float32(h.Width-1), 0, float32(h.Width-1))
+	gz := mgl32.Clamp((0.5-z/t.Size[2])*float32(h.Depth-1), 0, float32(h.Depth-1))
+	return gx, gz
+}
+
+// cell 格点坐标所在的格子和格子内的偏移
+func (t *Terrain) cell(gx, gz float32) (int, int, float32, float32) {
+	x := min(int(gx), t.Heightmap.Width-2)
+	z := min(int(gz), t.Heightmap.Depth-2)
+	return x, z, gx - float32(x), gz - float32(z)
+}
+
+// HeightAt 世界坐标 (x, z) 处地形表面的高度, 与渲染的三角形完全一致, 超出地形时取边界
+func (t *Terrain) HeightAt(x, z float32) float32 {
+	h := t.Heightmap
+	cx, cz, fx, fz := t.cell(t.gridCoord(x, z))
+	ha, hb := h.At(cx, cz), h.At(cx+1, cz)
+	hc, hd := h.At(cx, cz+1), h.At(cx+1, cz+1)
+
+	var v float32
+	if fx+fz <= 1 {
+		v = ha + (hb-ha)*fx + (hc-ha)*fz
+	} else {
+		v = hd + (hc-hd)*(1-fx) + (hb-hd)*(1-fz)
+	}
+	return v * t.Size[1]
+}
+
+// NormalAt 世界坐标 (x, z) 处平滑插值后的法线, 与顶点法线一致
+func (t *Terrain) NormalAt(x, z float32) mgl32.Vec3 {
+	cx, cz, fx, fz := t.cell(t.gridCoord(x, z))
+	n0 := t.vertexNormal(cx, cz).Mul(1 - fx).Add(t.vertexNormal(cx+1, cz).Mul(fx))
+	n1 := t.vertexNormal(cx, cz+1).Mul(1 - fx).Add(t.vertexNormal(cx+1, cz+1).Mul(fx))
+	return n0.Mul(1 - fz).Add(n1.Mul(fz)).Normalize()
+}
+
+func (t *Terrain) Bounds() AABB {
+	b := EmptyAABB()
+	for _, c := range t.Chunks {
+		b = b.Union(c.Bounds)
+	}
+	return b
+}
+
+// SetSplat 设置最多 4 层纹理, splat 的 r/g/b/a 通道依次为各层的权重,
+// splat 为 nil 时只显示第一层; 加载 4 层的 splat 贴图时需要保留 alpha 通道
+func (t *Terrain) SetSplat(splat *ImageData, layers ...*ImageData) error {
+	if len(layers) == 0 || len(layers) > 4 {
+		return fmt.Errorf("SetSplat: %d layers, 1 to 4 supported", len(layers))
+	}
+	if splat != nil && splat.Channels() < len(layers) {
+		return errors.New("SetSplat: splat map has fewer channels than layers")
+	}
+	if splat == nil {
+		splat = &ImageData{Width: 1, Height: 1, Pixels: []uint8{255, 0, 0, 0}}
+	}
+
+	t.deleteTextures()
+	t.splat = NewTexture(splat, Sampler{
+		MagFilter: gl.LINEAR,
+		MinFilter: gl.LINEAR,
+		WrapS:     gl.CLAMP_TO_EDGE,
+		WrapT:     gl.CLAMP_TO_EDGE,
+	})
+	for _, l := range layers {
+		t.layers = append(t.layers, NewTexture(l, DefaultSampler()))
+	}
+	return nil
+}
+
+// Bind 绑定 splat 贴图和各层纹理, 占用纹理单元 unit ~ unit+层数
+func (t *Terrain) Bind(s *Shader, unit uint32) {
+	if t.splat == nil {
+		return
+	}
+
+	t.splat.Bind(unit)
+	var units [4]int32
+	for i := range units {
+		// 没有用到的层也指向第一层, 权重为 0 不影响结果
+		units[i] = int32(unit + 1)
+		if i < len(t.layers) {
+			t.layers[i].Bind(unit + 1 + uint32(i))
+			units[i] += int32(i)
+		}
+	}
+
+	loc := func(name string) int32 {
+		return gl.GetUniformLocation(s.ID, gl.Str(name+CNull))
+	}
+	gl.Uniform1i(loc("terrainSplat"), int32(unit))
+	gl.Uniform1iv(loc("terrainLayers"), int32(len(units)), &units[0])
+	gl.Uniform1i(loc("terrainLayerCount"), int32(len(t.layers)))
+	gl.Uniform1f(loc("terrainTiling"), t.Tiling)
+}
+
+// Draw 只绘制与视锥体相交的块, 返回绘制的块数
+func (t *Terrain) Draw(f Frustum) int {
+	n := 0
+	for _, c := range t.Chunks {
+		if f.IntersectsAABB(c.Bounds) {
+			c.Mesh.Draw()
+			n++
+		}
+	}
+	return n
+}
+
+func (t *Terrain) deleteTextures() {
+	if t.splat != nil {
+		t.splat.Delete()
+		t.splat = nil
+	}
+	for _, l := range t.layers {
+		l.Delete()
+	}
+	t.layers = nil
+}
+
+func (t *Terrain) Delete() {
+	for _, c := range t.Chunks {
+		c.Mesh.Delete()
+	}
+	t.Chunks = nil
+	t.deleteTextures()
+}
+
+// TerrainGLSL 片段着色器中按 splat 贴图混合各层纹理, uv 为地形网格的 UV
+const TerrainGLSL = `
+uniform sampler2D terrainSplat;
+uniform sampler2D terrainLayers[4];
+uniform int terrainLayerCount;
+uniform float terrainTiling;
+
+vec3 terrainColor(vec2 uv)
+{
+	vec4 w = texture(terrainSplat, uv) * vec4(lessThan(ivec4(0, 1, 2, 3), ivec4(terrainLayerCount)));
+	float sum = w.r + w.g + w.b + w.a;
+	w = sum > 0.0 ? w / sum : vec4(1.0, 0.0, 0.0, 0.0);
+
+	vec2 st = uv * terrainTiling;
+	return texture(terrainLayers[0], st).rgb * w.r +
+		texture(terrainLayers[1], st).rgb * w.g +
+		texture(terrainLayers[2], st).rgb * w.b +
+		texture(terrainLayers[3], st).rgb * w.a;
+}
+`
@@ -0,0 +1,179 @@
+package common
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// testTerrain 不上传网格的地形, 只用于测试采样和分块
+func testTerrain(h *Heightmap, size mgl32.Vec3, cells int) *Terrain {
+	return &Terrain{Heightmap: h, Size: size, ChunkCells: cells}
+}
+
+func randomHeightmap(rng *rand.Rand, w, d int) *Heightmap {
+	h := &Heightmap{Width: w, Depth: d, Heights: make([]float32, w*d)}
+	for i := range h.Heights {
+		h.Heights[i] = rng.Float32()
+	}
+	return h
+}
+
+// triangleHeight 在所有块的三角形中找到包含 (x, z) 的一个, 返回插值的高度
+func triangleHeight(t *Terrain, x, z float32) (float32, bool) {
+	cx, cz := t.ChunkCount()
+	for j := 0; j < cz; j++ {
+		for i := 0; i < cx; i++ {
+			d := t.ChunkData(i, j)
+			for k := 0; k < len(d.Indices); k += 3 {
+				a, b, c := d.Positions[d.Indices[k]], d.Positions[d.Indices[k+1]], d.Positions[d.Indices[k+2]]
+				det := (b.X()-a.X())*(c.Z()-a.Z()) - (c.X()-a.X())*(b.Z()-a.Z())
+				if det == 0 {
+					continue
+				}
+				u := ((x-a.X())*(c.Z()-a.Z()) - (c.X()-a.X())*(z-a.Z())) / det
+				v := ((b.X()-a.X())*(z-a.Z()) - (x-a.X())*(b.Z()-a.Z())) / det
+				const eps = 1e-5
+				if u < -eps || v < -eps || u+v > 1+eps {
+					continue
+				}
+				return a.Y() + (b.Y()-a.Y())*u + (c.Y()-a.Y())*v, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func TestHeightAtMatchesChunks(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	ter := testTerrain(randomHeightmap(rng, 11, 8), mgl32.Vec3{20, 5, 14}, 4)
+
+	for i := 0; i < 500; i++ {
+		x := (rng.Float32() - 0.5) * ter.Size[0]
+		z := (rng.Float32() - 0.5) * ter.Size[2]
+		want, ok := triangleHeight(ter, x, z)
+		if !ok {
+			t.Fatalf("(%v, %v) not covered by any chunk", x, z)
+		}
+		if got := ter.HeightAt(x, z); math.Abs(float64(got-want)) > 1e-4 {
+			t.Errorf("HeightAt(%v, %v) = %v, triangles give %v", x, z, got, want)
+		}
+	}
+
+	// 格点上与高度图一致, 超出地形时取边界
+	h := ter.Heightmap
+	for z := 0; z < h.Depth; z++ {
+		for x := 0; x < h.Width; x++ {
+			p := ter.vertex(x, z)
+			if got := ter.HeightAt(p.X(), p.Z()); math.Abs(float64(got-p.Y())) > 1e-4 {
+				t.Errorf("grid (%d, %d): HeightAt %v, want %v", x, z, got, p.Y())
+			}
+		}
+	}
+	if got, want := ter.HeightAt(-100, 100), h.At(0, 0)*ter.Size[1]; got != want {
+		t.Errorf("outside corner: %v, want %v", got, want)
+	}
+}
+
+func TestChunkCount(t *testing.T) {
+	for _, c := range []struct {
+		w, d, cells int
+		cx, cz      int
+	}{
+		{2, 2, 64, 1, 1},
+		{5, 5, 4, 1, 1},
+		{6, 5, 4, 2, 1},
+		{10, 7, 4, 3, 2},
+		{65, 65, 64, 1, 1},
+		{66, 130, 64, 2, 3},
+		{9, 9, 1, 8, 8},
+	} {
+		h := &Heightmap{Width: c.w, Depth: c.d, Heights: make([]float32, c.w*c.d)}
+		ter := testTerrain(h, mgl32.Vec3{float32(c.w - 1), 1, float32(c.d - 1)}, c.cells)
+		cx, cz := ter.ChunkCount()
+		if cx != c.cx || cz != c.cz {
+			t.Errorf("%dx%d cells %d: %dx%d chunks, want %dx%d", c.w, c.d, c.cells, cx, cz, c.cx, c.cz)
+			continue
+		}
+
+		// 所有块正好覆盖整个地形, 没有空块
+		var tris int
+		bounds := EmptyAABB()
+		for z := 0; z < cz; z++ {
+			for x := 0; x < cx; x++ {
+				d := ter.ChunkData(x, z)
+				if d.TriangleCount() == 0 {
+					t.Errorf("%dx%d cells %d: chunk (%d, %d) is empty", c.w, c.d, c.cells, x, z)
+				}
+				tris += d.TriangleCount()
+				bounds = bounds.Union(d.Bounds())
+			}
+		}
+		if want := 2 * (c.w - 1) * (c.d - 1); tris != want {
+			t.Errorf("%dx%d cells %d: %d triangles, want %d", c.w, c.d, c.cells, tris, want)
+		}
+		half := mgl32.Vec3{ter.Size[0] / 2, 0, ter.Size[2] / 2}
+		if !bounds.Min.ApproxEqual(half.Mul(-1)) || !bounds.Max.ApproxEqual(half) {
+			t.Errorf("%dx%d cells %d: bounds %v, want ±%v", c.w, c.d, c.cells, bounds, half)
+		}
+	}
+}
+
+func TestNormalAt(t *testing.T) {
+	// 斜面 h = a*x + b*z 的法线处处相同, 边界上的单侧差分也是精确的
+	const w, d = 9, 6
+	a, b := float32(0.05), float32(0.1)
+	h := &Heightmap{Width: w, Depth: d, Heights: make([]float32, w*d)}
+	for z := 0; z < d; z++ {
+		for x := 0; x < w; x++ {
+			h.Heights[z*w+x] = a*float32(x) + b*float32(z)
+		}
+	}
+	ter := testTerrain(h, mgl32.Vec3{16, 4, 10}, 4)
+	sx, sz := ter.cellSize()
+	// 格点 z 增大时世界坐标 z 减小, 所以高度沿世界 -z 方向升高
+	want := mgl32.Vec3{-a * ter.Size[1] / sx, 1, b * ter.Size[1] / sz}.Normalize()
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		x := (rng.Float32() - 0.5) * ter.Size[0]
+		z := (rng.Float32() - 0.5) * ter.Size[2]
+		if n := ter.NormalAt(x, z); !n.ApproxEqualThreshold(want, 1e-5) {
+			t.Errorf("NormalAt(%v, %v) = %v, want %v", x, z, n, want)
+		}
+	}
+
+	// 随机地形的格点上与顶点法线一致
+	ter = testTerrain(randomHeightmap(rng, 7, 7), mgl32.Vec3{6, 2, 6}, 4)
+	for z := 0; z < 7; z++ {
+		for x := 0; x < 7; x++ {
+			p := ter.vertex(x, z)
+			if n, want := ter.NormalAt(p.X(), p.Z()), ter.vertexNormal(x, z); !n.ApproxEqualThreshold(want, 1e-5) {
+				t.Errorf("grid (%d, %d): %v, want %v", x, z, n, want)
+			}
+		}
+	}
+}
+
+func TestNewHeightmap(t *testing.T) {
+	img := &ImageData{Width: 2, Height: 2, Pixels: []uint8{
+		0, 0, 0, 255, 255, 255, 255, 0,
+		255, 0, 0, 255, 30, 60, 90, 255,
+	}}
+	h, err := NewHeightmap(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float32{0, 1, 1.0 / 3, 60.0 / 255}
+	for i, v := range h.Heights {
+		if math.Abs(float64(v-want[i])) > 1e-6 {
+			t.Errorf("height %d = %v, want %v", i, v, want[i])
+		}
+	}
+
+	if _, err := NewHeightmap(&ImageData{Width: 1, Height: 4, Pixels: make([]uint8, 4)}); err == nil {
+		t.Error("1 pixel wide image accepted")
+	}
+}
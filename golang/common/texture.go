@@ -0,0 +1,157 @@
+package common
+
+import (
+	"errors"
+
+	"github.com/go-gl/gl/v4.4-core/gl"
+)
+
+// Sampler 纹理采样参数,取值为 gl 枚举,与 glTF 中的定义一致
+type Sampler struct {
+	MagFilter int32
+	MinFilter int32
+	WrapS     int32
+	WrapT     int32
+}
+
+func DefaultSampler() Sampler {
+	return Sampler{
+		MagFilter: gl.LINEAR,
+		MinFilter: gl.LINEAR_MIPMAP_LINEAR,
+		WrapS:     gl.REPEAT,
+		WrapT:     gl.REPEAT,
+	}
+}
+
+func (s Sampler) mipmap() bool {
+	switch s.MinFilter {
+	case gl.NEAREST_MIPMAP_NEAREST, gl.LINEAR_MIPMAP_NEAREST,
+		gl.NEAREST_MIPMAP_LINEAR, gl.LINEAR_MIPMAP_LINEAR:
+		return true
+	default:
+		return false
+	}
+}
+
+type Texture struct {
+	ID     uint32
+	Target uint32
+}
+
+// NewTexture 上传二维纹理,像素格式由通道数决定
+func NewTexture(img *ImageData, s Sampler) *Texture {
+	t := &Texture{Target: gl.TEXTURE_2D}
+	gl.GenTextures(1, &t.ID)
+	gl.BindTexture(gl.TEXTURE_2D, t.ID)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, s.WrapS)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, s.WrapT)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, s.MinFilter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, s.MagFilter)
+
+	var format uint32
+	switch img.Channels() {
+	case 1:
+		format = gl.RED
+	case 2:
+		format = gl.RG
+	case 3:
+		format = gl.RGB
+	default:
+		format = gl.RGBA
+	}
+
+	// RGB 图片每行字节数不一定是 4 的倍数
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.TexImage2D(
+		gl.TEXTURE_2D,
+		0,
+		int32(format),
+		int32(img.Width),
+		int32(img.Height),
+		0,
+		format,
+		gl.UNSIGNED_BYTE,
+		gl.Ptr(img.Pixels),
+	)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+
+	if s.mipmap() {
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+	}
+
+	return t
+}
+
+// NewTextureArray 上传二维纹理数组, 每张图片为一层, 尺寸和通道数需要一致, 着色器中为 sampler2DArray
+func NewTextureArray(layers []*ImageData, s Sampler) (*Texture, error) {
+	if len(layers) == 0 {
+		return nil, errors.New("NewTextureArray: no layers")
+	}
+	first := layers[0]
+	for _, img := range layers[1:] {
+		if img.Width != first.Width || img.Height != first.Height || img.Channels() != first.Channels() {
+			return nil, errors.New("NewTextureArray: layers differ in size or channels")
+		}
+	}
+
+	var format, internal uint32
+	switch first.Channels() {
+	case 1:
+		format, internal = gl.RED, gl.R8
+	case 2:
+		format, internal = gl.RG, gl.RG8
+	case 3:
+		format, internal = gl.RGB, gl.RGB8
+	default:
+		format, internal = gl.RGBA, gl.RGBA8
+	}
+
+	t := &Texture{Target: gl.TEXTURE_2D_ARRAY}
+	gl.GenTextures(1, &t.ID)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, t.ID)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_S, s.WrapS)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_T, s.WrapT)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MIN_FILTER, s.MinFilter)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MAG_FILTER, s.MagFilter)
+
+	// 先分配所有层, 再逐层上传
+	gl.TexImage3D(
+		gl.TEXTURE_2D_ARRAY,
+		0,
+		int32(internal),
+		int32(first.Width), int32(first.Height), int32(len(layers)),
+		0,
+		format,
+		gl.UNSIGNED_BYTE,
+		nil,
+	)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	for i, img := range layers {
+		gl.TexSubImage3D(
+			gl.TEXTURE_2D_ARRAY,
+			0,
+			0, 0, int32(i),
+			int32(img.Width), int32(img.Height), 1,
+			format,
+			gl.UNSIGNED_BYTE,
+			gl.Ptr(img.Pixels),
+		)
+	}
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+
+	if s.mipmap() {
+		gl.GenerateMipmap(gl.TEXTURE_2D_ARRAY)
+	}
+
+	return t, nil
+}
+
+// Bind 绑定到纹理单元 unit(从 0 开始)
+func (t *Texture) Bind(unit uint32) {
+	gl.ActiveTexture(gl.TEXTURE0 + unit)
+	gl.BindTexture(t.Target, t.ID)
+}
+
+func (t *Texture) Delete() {
+	gl.DeleteTextures(1, &t.ID)
+}
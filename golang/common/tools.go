@@ -79,6 +79,11 @@ func LoadImgRGB(path string, rgba ...bool) (*ImageData, error) {
 		return nil, err
 	}
 
+	return NewImageData(img, rgba...), nil
+}
+
+// NewImageData 将解码后的图片转换为 OpenGL 可直接使用的像素数据
+func NewImageData(img image.Image, rgba ...bool) *ImageData {
 	var (
 		i      = 0
 		rect   = img.Bounds()
@@ -118,5 +123,13 @@ func LoadImgRGB(path string, rgba ...bool) (*ImageData, error) {
 		Width:  width,
 		Height: height,
 		Pixels: pixels,
-	}, nil
+	}
+}
+
+// Channels 每个像素的通道数, RGB 为 3, RGBA 为 4
+func (d *ImageData) Channels() int {
+	if d.Width == 0 || d.Height == 0 {
+		return 0
+	}
+	return len(d.Pixels) / (d.Width * d.Height)
 }
@@ -0,0 +1,465 @@
+package common
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ChunkSize 每个体素块在三个方向上的方块数
+const ChunkSize = 16
+
+// Block 方块类型, 0 为空气, 其余为 VoxelWorld.Blocks 的下标
+type Block uint8
+
+// BlockType 方块每个面在纹理数组中的层, 顺序为 +X -X +Y -Y +Z -Z(与立方体贴图一致)
+type BlockType struct {
+	Name  string
+	Faces [6]int32
+}
+
+// UniformBlock 六个面使用同一层纹理的方块
+func UniformBlock(name string, layer int32) BlockType {
+	return BlockType{Name: name, Faces: [6]int32{layer, layer, layer, layer, layer, layer}}
+}
+
+// VoxelVertex 体素网格的顶点, AO 占用 Vertex.Color 的 location, Layer 与 Instance.Layer 一致:
+//
+//	layout (location = 4) in float aAO;
+//	layout (location = 10) in float aLayer;
+type VoxelVertex struct {
+	Position mgl32.Vec3 `gl:"0"`
+	Normal   mgl32.Vec3 `gl:"1"`
+	UV       mgl32.Vec2 `gl:"2"` // 以方块为单位, 纹理需要使用 REPEAT 环绕
+	AO       float32    `gl:"4"` // 环境光遮蔽, 0 最暗, 1 没有遮挡
+	Layer    float32    `gl:"10"`
+}
+
+// VoxelChunk 一个体素块, Mesh 和 Bounds 在 Remesh 后更新
+type VoxelChunk struct {
+	Coord  [3]int
+	Mesh   *Mesh // 没有可见的面时为 nil
+	Bounds AABB  // 网格在世界空间的包围盒
+
+	blocks [ChunkSize * ChunkSize * ChunkSize]Block
+	count  int // 非空气方块的个数
+	dirty  bool
+}
+
+func chunkIndex(x, y, z int) int {
+	return (y*ChunkSize+z)*ChunkSize + x
+}
+
+// Origin 块的最小角在世界空间的坐标
+func (c *VoxelChunk) Origin() mgl32.Vec3 {
+	return mgl32.Vec3{float32(c.Coord[0] * ChunkSize), float32(c.Coord[1] * ChunkSize), float32(c.Coord[2] * ChunkSize)}
+}
+
+// VoxelWorld 按块稀疏存储的方块世界, 方块 (x, y, z) 占据 [x, x+1]*[y, y+1]*[z, z+1]
+type VoxelWorld struct {
+	Blocks []BlockType // 下标 0 对应空气, 不会被使用
+
+	chunks map[[3]int]*VoxelChunk
+}
+
+func NewVoxelWorld(blocks []BlockType) *VoxelWorld {
+	return &VoxelWorld{Blocks: blocks, chunks: make(map[[3]int]*VoxelChunk)}
+}
+
+// floorDiv 向下取整的除法, 负坐标也能得到正确的块
+func floorDiv(a, b int) (int, int) {
+	q, r := a/b, a%b
+	if r < 0 {
+		q--
+		r += b
+	}
+	return q, r
+}
+
+func (w *VoxelWorld) locate(x, y, z int) ([3]int, int) {
+	cx, lx := floorDiv(x, ChunkSize)
+	cy, ly := floorDiv(y, ChunkSize)
+	cz, lz := floorDiv(z, ChunkSize)
+	return [3]int{cx, cy, cz}, chunkIndex(lx, ly, lz)
+}
+
+// Chunk 返回块坐标为 coord 的块, 不存在时为 nil
+func (w *VoxelWorld) Chunk(coord [3]int) *VoxelChunk {
+	return w.chunks[coord]
+}
+
+func (w *VoxelWorld) Get(x, y, z int) Block {
+	coord, i := w.locate(x, y, z)
+	if c := w.chunks[coord]; c != nil {
+		return c.blocks[i]
+	}
+	return 0
+}
+
+// Set 修改方块, 所在的块和共享边界的相邻块(面剔除和 AO 会受影响)会在下次 Remesh 时重建
+// b 不是 Blocks 中的方块类型时返回错误, 世界不会被修改
+func (w *VoxelWorld) Set(x, y, z int, b Block) error {
+	if b != 0 && int(b) >= len(w.Blocks) {
+		return fmt.Errorf("VoxelWorld.Set: unknown block type %d", b)
+	}
+
+	coord, i := w.locate(x, y, z)
+	c := w.chunks[coord]
+	if c == nil {
+		if b == 0 {
+			return nil
+		}
+		c = &VoxelChunk{Coord: coord, Bounds: EmptyAABB()}
+		w.chunks[coord] = c
+	}
+	if c.blocks[i] == b {
+		return nil
+	}
+
+	switch {
+	case c.blocks[i] == 0:
+		c.count++
+	case b == 0:
+		c.count--
+	}
+	c.blocks[i] = b
+
+	for dz := -1; dz <= 1; dz++ {
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				n, _ := w.locate(x+dx, y+dy, z+dz)
+				if c := w.chunks[n]; c != nil {
+					c.dirty = true
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Remesh 重建修改过的块, limit 大于 0 时最多重建 limit 个, 用于把工作量分摊到多帧; 返回重建的块数
+func (w *VoxelWorld) Remesh(limit int) (int, error) {
+	n := 0
+	for coord, c := range w.chunks {
+		if !c.dirty {
+			continue
+		}
+		if limit > 0 && n >= limit {
+			break
+		}
+
+		if c.Mesh != nil {
+			c.Mesh.Delete()
+			c.Mesh = nil
+		}
+		c.Bounds = EmptyAABB()
+		c.dirty = false
+		n++
+
+		if c.count == 0 {
+			delete(w.chunks, coord)
+			continue
+		}
+
+		vertices, indices := w.ChunkMesh(coord)
+		if len(indices) == 0 {
+			continue
+		}
+		m, err := NewMeshOf(vertices, indices)
+		if err != nil {
+			return n, err
+		}
+		c.Mesh = m
+		for _, v := range vertices {
+			c.Bounds = c.Bounds.Extend(v.Position)
+		}
+	}
+	return n, nil
+}
+
+// voxelFaceUV 每个面的 u/v 对应的世界坐标轴和方向, 从面外看 u 向右, 侧面的 v 向上
+var voxelFaceUV = [6][2]struct {
+	axis int
+	sign float32
+}{
+	{{2, -1}, {1, 1}}, // +X
+	{{2, 1}, {1, 1}},  // -X
+	{{0, 1}, {2, -1}}, // +Y
+	{{0, 1}, {2, 1}},  // -Y
+	{{0, 1}, {1, 1}},  // +Z
+	{{0, -1}, {1, 1}}, // -Z
+}
+
+// ChunkMesh 用贪心算法合并同一层纹理且 AO 相同的相邻面, 生成世界空间的顶点
+func (w *VoxelWorld) ChunkMesh(coord [3]int) ([]VoxelVertex, []uint32) {
+	c := w.chunks[coord]
+	if c == nil || c.count == 0 {
+		return nil, nil
+	}
+
+	// 把块和周围一圈方块复制出来, 避免查询邻居时反复查表
+	const P = ChunkSize + 2
+	var padded [P * P * P]Block
+	ox, oy, oz := coord[0]*ChunkSize, coord[1]*ChunkSize, coord[2]*ChunkSize
+	for y := 0; y < P; y++ {
+		for z := 0; z < P; z++ {
+			for x := 0; x < P; x++ {
+				lx, ly, lz := x-1, y-1, z-1
+				if lx >= 0 && lx < ChunkSize && ly >= 0 && ly < ChunkSize && lz >= 0 && lz < ChunkSize {
+					padded[(y*P+z)*P+x] = c.blocks[chunkIndex(lx, ly, lz)]
+				} else {
+					padded[(y*P+z)*P+x] = w.Get(ox+lx, oy+ly, oz+lz)
+				}
+			}
+		}
+	}
+	at := func(p [3]int) Block {
+		return padded[((p[1]+1)*P+p[2]+1)*P+p[0]+1]
+	}
+	solid := func(p [3]int) int {
+		if at(p) != 0 {
+			return 1
+		}
+		return 0
+	}
+
+	var (
+		vertices []VoxelVertex
+		indices  []uint32
+		mask     [ChunkSize * ChunkSize]uint64
+		origin   = c.Origin()
+		corners  = [4][2]int{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}}
+	)
+	for face := 0; face < 6; face++ {
+		d, dir := face/2, 1-face%2*2
+		u, v := (d+1)%3, (d+2)%3 // u × v = d
+
+		var normal mgl32.Vec3
+		normal[d] = float32(dir)
+
+		for s := 0; s < ChunkSize; s++ {
+			// 可见的面: 方块非空气且法线方向的邻居是空气, 键为纹理层和 4 个角的 AO
+			for j := 0; j < ChunkSize; j++ {
+				for i := 0; i < ChunkSize; i++ {
+					var p [3]int
+					p[d], p[u], p[v] = s, i, j
+					b := at(p)
+					n := p
+					n[d] += dir
+					if b == 0 || at(n) != 0 {
+						mask[j*ChunkSize+i] = 0
+						continue
+					}
+
+					key := uint64(w.Blocks[b].Faces[face]+1) << 8
+					for k, corner := range corners {
+						s1, s2, cc := n, n, n
+						s1[u] += corner[0]
+						s2[v] += corner[1]
+						cc[u] += corner[0]
+						cc[v] += corner[1]
+						ao := 3 - (solid(s1) + solid(s2) + solid(cc))
+						if solid(s1) == 1 && solid(s2) == 1 {
+							ao = 0
+						}
+						key |= uint64(ao) << (k * 2)
+					}
+					mask[j*ChunkSize+i] = key
+				}
+			}
+
+			// 贪心合并: 先沿 u 扩展, 再整行沿 v 扩展
+			for j := 0; j < ChunkSize; j++ {
+				for i := 0; i < ChunkSize; {
+					key := mask[j*ChunkSize+i]
+					if key == 0 {
+						i++
+						continue
+					}
+
+					width := 1
+					for i+width < ChunkSize && mask[j*ChunkSize+i+width] == key {
+						width++
+					}
+					height := 1
+				grow:
+					for j+height < ChunkSize {
+						for x := i; x < i+width; x++ {
+							if mask[(j+height)*ChunkSize+x] != key {
+								break grow
+							}
+						}
+						height++
+					}
+					for y := j; y < j+height; y++ {
+						for x := i; x < i+width; x++ {
+							mask[y*ChunkSize+x] = 0
+						}
+					}
+
+					vertices, indices = appendVoxelQuad(vertices, indices, voxelQuad{
+						origin: origin, normal: normal, face: face, d: d, u: u, v: v,
+						plane: s + max(dir, 0), i: i, j: j, width: width, height: height,
+						layer: float32(key>>8) - 1, ao: uint8(key),
+					})
+					i += width
+				}
+			}
+		}
+	}
+	return vertices, indices
+}
+
+type voxelQuad struct {
+	origin, normal             mgl32.Vec3
+	face, d, u, v              int
+	plane, i, j, width, height int
+	layer                      float32
+	ao                         uint8 // 4 个角各 2 位
+}
+
+func appendVoxelQuad(vertices []VoxelVertex, indices []uint32, q voxelQuad) ([]VoxelVertex, []uint32) {
+	base := uint32(len(vertices))
+	corners := [4][2]int{{q.i, q.j}, {q.i + q.width, q.j}, {q.i + q.width, q.j + q.height}, {q.i, q.j + q.height}}
+
+	var ao [4]int
+	for k, corner := range corners {
+		var p mgl32.Vec3
+		p[q.d] = float32(q.plane)
+		p[q.u] = float32(corner[0])
+		p[q.v] = float32(corner[1])
+		p = p.Add(q.origin)
+
+		ao[k] = int(q.ao>>(k*2)) & 3
+		uv := voxelFaceUV[q.face]
+		vertices = append(vertices, VoxelVertex{
+			Position: p,
+			Normal:   q.normal,
+			UV:       mgl32.Vec2{p[uv[0].axis] * uv[0].sign, p[uv[1].axis] * uv[1].sign},
+			AO:       float32(ao[k]) / 3,
+			Layer:    q.layer,
+		})
+	}
+
+	// 沿较亮的对角线切分, 避免 AO 插值出现各向异性的条纹
+	tri := [6]uint32{0, 1, 2, 0, 2, 3}
+	if ao[0]+ao[2] < ao[1]+ao[3] {
+		tri = [6]uint32{0, 1, 3, 1, 2, 3}
+	}
+	if q.normal[q.d] < 0 {
+		tri[1], tri[2] = tri[2], tri[1]
+		tri[4], tri[5] = tri[5], tri[4]
+	}
+	for _, t := range tri {
+		indices = append(indices, base+t)
+	}
+	return vertices, indices
+}
+
+// Draw 绘制与视锥体相交的块, 返回绘制的块数
+func (w *VoxelWorld) Draw(f Frustum) int {
+	n := 0
+	for _, c := range w.chunks {
+		if c.Mesh != nil && f.IntersectsAABB(c.Bounds) {
+			c.Mesh.Draw()
+			n++
+		}
+	}
+	return n
+}
+
+func (w *VoxelWorld) Delete() {
+	for _, c := range w.chunks {
+		if c.Mesh != nil {
+			c.Mesh.Delete()
+			c.Mesh = nil
+		}
+		c.dirty = true
+	}
+}
+
+// VoxelHit 射线命中的方块, Face 为进入方块时穿过的面的法线, 在 Block+Face 处放置方块即贴着该面;
+// 射线起点在方块内部时 Face 为 0
+type VoxelHit struct {
+	RayHit
+	Block [3]int
+	Face  [3]int
+}
+
+// Raycast 用 DDA 逐个遍历射线经过的方块, 返回 maxDist(世界空间距离)内第一个非空气方块
+// 射线离开已有块的范围后不可能再命中, 所以 maxDist 可以为 +Inf
+func (w *VoxelWorld) Raycast(r Ray, maxDist float32) (VoxelHit, bool) {
+	length := r.Direction.Len()
+	if length == 0 || len(w.chunks) == 0 {
+		return VoxelHit{}, false
+	}
+
+	// 已有块覆盖的方块坐标范围
+	lo := [3]int{math.MaxInt, math.MaxInt, math.MaxInt}
+	hi := [3]int{math.MinInt, math.MinInt, math.MinInt}
+	for coord := range w.chunks {
+		for i := 0; i < 3; i++ {
+			lo[i] = min(lo[i], coord[i]*ChunkSize)
+			hi[i] = max(hi[i], coord[i]*ChunkSize+ChunkSize-1)
+		}
+	}
+	dir := r.Direction.Mul(1 / length)
+
+	var (
+		p      [3]int
+		step   [3]int
+		tMax   mgl32.Vec3
+		tDelta mgl32.Vec3
+		face   [3]int
+		t      float32
+	)
+	inf := float32(math.Inf(1))
+	for i := 0; i < 3; i++ {
+		p[i] = int(math.Floor(float64(r.Origin[i])))
+		switch {
+		case dir[i] > 0:
+			step[i] = 1
+			tDelta[i] = 1 / dir[i]
+			tMax[i] = (float32(p[i]+1) - r.Origin[i]) / dir[i]
+		case dir[i] < 0:
+			step[i] = -1
+			tDelta[i] = -1 / dir[i]
+			tMax[i] = (float32(p[i]) - r.Origin[i]) / dir[i]
+		default:
+			tDelta[i], tMax[i] = inf, inf
+		}
+	}
+
+	for t <= maxDist {
+		// 在某个轴上已经越过范围并且还在远离
+		for i := 0; i < 3; i++ {
+			if (p[i] < lo[i] && step[i] <= 0) || (p[i] > hi[i] && step[i] >= 0) {
+				return VoxelHit{}, false
+			}
+		}
+
+		if w.Get(p[0], p[1], p[2]) != 0 {
+			hit := VoxelHit{Block: p, Face: face}
+			hit.Distance = t / length
+			hit.Point = r.At(hit.Distance)
+			hit.Normal = mgl32.Vec3{float32(face[0]), float32(face[1]), float32(face[2])}
+			return hit, true
+		}
+
+		// 前进到最近的方块边界
+		axis := 0
+		if tMax[1] < tMax[axis] {
+			axis = 1
+		}
+		if tMax[2] < tMax[axis] {
+			axis = 2
+		}
+		t = tMax[axis]
+		tMax[axis] += tDelta[axis]
+		p[axis] += step[axis]
+		face = [3]int{}
+		face[axis] = -step[axis]
+	}
+	return VoxelHit{}, false
+}
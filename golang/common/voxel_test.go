@@ -0,0 +1,224 @@
+package common
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestVoxelWorldSet(t *testing.T) {
+	w := NewVoxelWorld([]BlockType{{}, UniformBlock("stone", 0)})
+	if err := w.Set(0, 0, 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Set(1, 0, 0, 2); err == nil {
+		t.Error("unknown block type accepted")
+	}
+	if b := w.Get(1, 0, 0); b != 0 {
+		t.Errorf("block after failed Set = %d, want 0", b)
+	}
+}
+
+func TestVoxelWorldRaycastInfinite(t *testing.T) {
+	inf := float32(math.Inf(1))
+	w := NewVoxelWorld([]BlockType{{}, UniformBlock("stone", 0)})
+	if _, ok := w.Raycast(Ray{Direction: mgl32.Vec3{1, 0, 0}}, inf); ok {
+		t.Error("hit in an empty world")
+	}
+
+	if err := w.Set(-3, 5, 2, 1); err != nil {
+		t.Fatal(err)
+	}
+	hit, ok := w.Raycast(Ray{Origin: mgl32.Vec3{-1.5, 5.5, 40}, Direction: mgl32.Vec3{0, 0, -2}}, inf)
+	if ok {
+		t.Errorf("hit block %v, want miss", hit.Block)
+	}
+	hit, ok = w.Raycast(Ray{Origin: mgl32.Vec3{20.5, 5.5, 2.5}, Direction: mgl32.Vec3{-2, 0, 0}}, inf)
+	if !ok || hit.Block != [3]int{-3, 5, 2} || hit.Face != [3]int{1, 0, 0} {
+		t.Fatalf("hit %v face %v (%v), want [-3 5 2] face [1 0 0]", hit.Block, hit.Face, ok)
+	}
+	if !mgl32.FloatEqualThreshold(hit.Distance, 11.25, 1e-5) {
+		t.Errorf("distance %v, want 11.25", hit.Distance)
+	}
+
+	// 射向远离所有块的方向
+	if _, ok := w.Raycast(Ray{Origin: mgl32.Vec3{0.5, 5.5, 2.5}, Direction: mgl32.Vec3{0, 1, 0}}, inf); ok {
+		t.Error("hit while moving away")
+	}
+}
+
+// voxelFaces 检查每个三角形从法线方向看是逆时针, 纹理层与面对应, 返回每个面(顺序同 BlockType.Faces)的四边形数
+func voxelFaces(t *testing.T, name string, vertices []VoxelVertex, indices []uint32, layers [6]float32) [6]int {
+	t.Helper()
+	var quads [6]int
+	if len(vertices)%4 != 0 || len(indices) != len(vertices)/4*6 {
+		t.Fatalf("%s: %d vertices, %d indices", name, len(vertices), len(indices))
+	}
+	for k := 0; k < len(indices); k += 3 {
+		a, b, c := vertices[indices[k]], vertices[indices[k+1]], vertices[indices[k+2]]
+		n := b.Position.Sub(a.Position).Cross(c.Position.Sub(a.Position))
+		if n.Dot(a.Normal) <= 0 {
+			t.Errorf("%s: triangle %d facing %v, normal %v", name, k/3, n, a.Normal)
+		}
+	}
+	for q := 0; q < len(vertices); q += 4 {
+		n := vertices[q].Normal
+		face := 0
+		for d := 0; d < 3; d++ {
+			if n[d] != 0 {
+				face = d * 2
+				if n[d] < 0 {
+					face++
+				}
+			}
+		}
+		quads[face]++
+		for _, v := range vertices[q : q+4] {
+			if v.Normal != n || v.Layer != layers[face] {
+				t.Errorf("%s: face %d vertex normal %v layer %v, want %v layer %v", name, face, v.Normal, v.Layer, n, layers[face])
+			}
+		}
+	}
+	return quads
+}
+
+func TestChunkMesh(t *testing.T) {
+	// 六个面使用不同的层, 便于检查层和面的对应
+	dice := BlockType{Name: "dice", Faces: [6]int32{0, 1, 2, 3, 4, 5}}
+	layers := [6]float32{0, 1, 2, 3, 4, 5}
+	stone := [6]float32{6, 6, 6, 6, 6, 6}
+
+	w := NewVoxelWorld([]BlockType{{}, dice, UniformBlock("stone", 6)})
+	if err := w.Set(3, 4, 5, 1); err != nil {
+		t.Fatal(err)
+	}
+	vertices, indices := w.ChunkMesh([3]int{})
+	if quads := voxelFaces(t, "single", vertices, indices, layers); quads != [6]int{1, 1, 1, 1, 1, 1} {
+		t.Errorf("single block: quads %v, want one per face", quads)
+	}
+	for _, v := range vertices {
+		if v.AO != 1 {
+			t.Errorf("single block: AO %v at %v, want 1", v.AO, v.Position)
+		}
+	}
+
+	// 整层合并为 6 个四边形, 每个面的面积不变
+	w = NewVoxelWorld([]BlockType{{}, UniformBlock("stone", 6)})
+	for z := 0; z < ChunkSize; z++ {
+		for x := 0; x < ChunkSize; x++ {
+			if err := w.Set(x, 0, z, 1); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	vertices, indices = w.ChunkMesh([3]int{})
+	if quads := voxelFaces(t, "slab", vertices, indices, stone); quads != [6]int{1, 1, 1, 1, 1, 1} {
+		t.Errorf("slab: quads %v, want one per face", quads)
+	}
+	var area float32
+	for k := 0; k < len(indices); k += 3 {
+		a, b, c := vertices[indices[k]].Position, vertices[indices[k+1]].Position, vertices[indices[k+2]].Position
+		area += b.Sub(a).Cross(c.Sub(a)).Len() / 2
+	}
+	if want := float32(2*ChunkSize*ChunkSize + 4*ChunkSize); area != want {
+		t.Errorf("slab: area %v, want %v", area, want)
+	}
+}
+
+func TestChunkMeshNeighbour(t *testing.T) {
+	w := NewVoxelWorld([]BlockType{{}, UniformBlock("stone", 6)})
+	stone := [6]float32{6, 6, 6, 6, 6, 6}
+	for _, x := range []int{ChunkSize - 1, ChunkSize} {
+		if err := w.Set(x, 0, 0, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 相邻块的方块遮住共享的面
+	vertices, indices := w.ChunkMesh([3]int{})
+	if quads := voxelFaces(t, "left", vertices, indices, stone); quads != [6]int{0, 1, 1, 1, 1, 1} {
+		t.Errorf("left chunk: quads %v, want no +X face", quads)
+	}
+	vertices, indices = w.ChunkMesh([3]int{1, 0, 0})
+	if quads := voxelFaces(t, "right", vertices, indices, stone); quads != [6]int{1, 0, 1, 1, 1, 1} {
+		t.Errorf("right chunk: quads %v, want no -X face", quads)
+	}
+
+	// 删除后露出的面重新出现
+	if err := w.Set(ChunkSize, 0, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	vertices, indices = w.ChunkMesh([3]int{})
+	if quads := voxelFaces(t, "removed", vertices, indices, stone); quads != [6]int{1, 1, 1, 1, 1, 1} {
+		t.Errorf("after removal: quads %v, want one per face", quads)
+	}
+}
+
+func TestChunkMeshMerge(t *testing.T) {
+	// 纹理层不同的相邻面不合并
+	w := NewVoxelWorld([]BlockType{{}, UniformBlock("stone", 6), UniformBlock("dirt", 7)})
+	for x, b := range []Block{1, 1, 2, 2} {
+		if err := w.Set(x, 0, 0, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	vertices, _ := w.ChunkMesh([3]int{})
+	top := map[float32]int{}
+	for q := 0; q < len(vertices); q += 4 {
+		if vertices[q].Normal == (mgl32.Vec3{0, 1, 0}) {
+			top[vertices[q].Layer]++
+		}
+	}
+	if top[6] != 1 || top[7] != 1 || len(top) != 2 {
+		t.Errorf("top quads by layer %v, want one stone and one dirt", top)
+	}
+}
+
+func TestChunkMeshAO(t *testing.T) {
+	w := NewVoxelWorld([]BlockType{{}, UniformBlock("stone", 0)})
+	// 方块上方沿 +X 和 +Z 各有一个邻居, 顶面的 (1, 1, 1) 角两侧都被挡住
+	for _, p := range [][3]int{{0, 0, 0}, {1, 1, 0}, {0, 1, 1}} {
+		if err := w.Set(p[0], p[1], p[2], 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	vertices, indices := w.ChunkMesh([3]int{})
+	want := map[mgl32.Vec3]float32{
+		{0, 1, 0}: 1,
+		{1, 1, 0}: 2.0 / 3,
+		{0, 1, 1}: 2.0 / 3,
+		{1, 1, 1}: 0,
+	}
+	found := 0
+	for q := 0; q < len(vertices); q += 4 {
+		if vertices[q].Normal != (mgl32.Vec3{0, 1, 0}) || vertices[q].Position.Y() != 1 {
+			continue
+		}
+		found++
+		for _, v := range vertices[q : q+4] {
+			if ao, ok := want[v.Position]; !ok || !mgl32.FloatEqual(v.AO, ao) {
+				t.Errorf("top face corner %v: AO %v, want %v", v.Position, v.AO, ao)
+			}
+		}
+
+		// 沿较亮的对角线 (1,1,0)-(0,1,1) 切分, 没有三角形同时包含最暗和最亮的角
+		base := uint32(q)
+		for k := 0; k < len(indices); k += 3 {
+			if indices[k] < base || indices[k] >= base+4 {
+				continue
+			}
+			var hasLight, hasDark bool
+			for _, i := range indices[k : k+3] {
+				hasLight = hasLight || vertices[i].AO == 1
+				hasDark = hasDark || vertices[i].AO == 0
+			}
+			if hasLight && hasDark {
+				t.Errorf("top face triangle %v spans the dark diagonal", indices[k:k+3])
+			}
+		}
+	}
+	if found != 1 {
+		t.Errorf("%d top faces at y=1, want 1", found)
+	}
+}